@@ -0,0 +1,90 @@
+\connect auth;
+
+-- Таблицы и последовательности этой и следующих миграций получают права автоматически,
+-- поэтому GRANT в миграциях не повторяется
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE, TRUNCATE ON TABLES TO auth;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO auth;
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "Role" CASCADE;
+CREATE TABLE "Role"
+(
+    id              UUID            DEFAULT uuid_generate_v4() PRIMARY KEY,
+    name            VARCHAR(127)    NOT NULL UNIQUE,
+    description     VARCHAR(255)    NOT NULL DEFAULT '',
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at      TIMESTAMP       NULL
+);
+--
+COMMENT ON TABLE "Role" is 'Таблица ролей';
+COMMENT ON COLUMN "Role".name is 'Уникальное имя роли';
+COMMENT ON COLUMN "Role".description is 'Описание роли';
+COMMENT ON COLUMN "Role".created_at is 'Создание записи по UTC';
+COMMENT ON COLUMN "Role".updated_at is 'Время последнего обновления';
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "Permission" CASCADE;
+CREATE TABLE "Permission"
+(
+    id              UUID            DEFAULT uuid_generate_v4() PRIMARY KEY,
+    code            VARCHAR(127)    NOT NULL UNIQUE,
+    description     VARCHAR(255)    NOT NULL DEFAULT '',
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+--
+COMMENT ON TABLE "Permission" is 'Таблица разрешений';
+COMMENT ON COLUMN "Permission".code is 'Код разрешения, попадает в claim permissions';
+COMMENT ON COLUMN "Permission".description is 'Описание разрешения';
+COMMENT ON COLUMN "Permission".created_at is 'Создание записи по UTC';
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "RolePermission";
+CREATE TABLE "RolePermission"
+(
+    role_id         UUID            NOT NULL REFERENCES "Role" (id) ON DELETE CASCADE,
+    permission_id   UUID            NOT NULL REFERENCES "Permission" (id) ON DELETE CASCADE,
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (role_id, permission_id)
+);
+--
+COMMENT ON TABLE "RolePermission" is 'Разрешения, выданные роли';
+COMMENT ON COLUMN "RolePermission".role_id is 'ID роли';
+COMMENT ON COLUMN "RolePermission".permission_id is 'ID разрешения';
+COMMENT ON COLUMN "RolePermission".created_at is 'Создание записи по UTC';
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "AccountRole";
+CREATE TABLE "AccountRole"
+(
+    account_id      UUID            NOT NULL REFERENCES "Account" (id) ON DELETE CASCADE,
+    role_id         UUID            NOT NULL REFERENCES "Role" (id) ON DELETE CASCADE,
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (account_id, role_id)
+);
+--
+CREATE INDEX ON "AccountRole" (role_id);
+--
+COMMENT ON TABLE "AccountRole" is 'Роли, назначенные аккаунту';
+COMMENT ON COLUMN "AccountRole".account_id is 'ID аккаунта';
+COMMENT ON COLUMN "AccountRole".role_id is 'ID роли';
+COMMENT ON COLUMN "AccountRole".created_at is 'Создание записи по UTC';
+
+-- --------------------------------
+
+INSERT INTO "Permission" (code, description) VALUES
+    ('rbac:read', 'Просмотр ролей и разрешений'),
+    ('rbac:manage', 'Управление ролями, разрешениями и их назначением');
+
+INSERT INTO "Role" (name, description) VALUES
+    ('admin', 'Администратор сервиса'),
+    ('user', 'Пользователь');
+
+INSERT INTO "RolePermission" (role_id, permission_id)
+SELECT r.id, p.id
+FROM "Role" r
+CROSS JOIN "Permission" p
+WHERE r.name = 'admin';
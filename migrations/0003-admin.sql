@@ -0,0 +1,50 @@
+\connect auth;
+
+-- --------------------------------
+
+ALTER TABLE "Account"
+    ADD COLUMN status VARCHAR(31) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'blocked'));
+--
+CREATE INDEX ON "Account" (created_at);
+CREATE INDEX ON "Account" (status);
+--
+COMMENT ON COLUMN "Account".status is 'Статус аккаунта: active или blocked';
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "PasswordReset";
+CREATE TABLE "PasswordReset"
+(
+    id              UUID            DEFAULT uuid_generate_v4() PRIMARY KEY,
+    account_id      UUID            NOT NULL REFERENCES "Account" (id) ON DELETE CASCADE,
+    code            VARCHAR(255)    NOT NULL,
+    initiated_by    UUID            NULL,
+    expires_at      TIMESTAMP       NOT NULL,
+    used_at         TIMESTAMP       NULL,
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+--
+CREATE INDEX ON "PasswordReset" (account_id);
+--
+COMMENT ON TABLE "PasswordReset" is 'Запросы на сброс пароля';
+COMMENT ON COLUMN "PasswordReset".account_id is 'ID аккаунта, пароль которого сбрасывается';
+COMMENT ON COLUMN "PasswordReset".code is 'Код подтверждения сброса';
+COMMENT ON COLUMN "PasswordReset".initiated_by is 'ID администратора, запустившего сброс, NULL если сброс запросил пользователь';
+COMMENT ON COLUMN "PasswordReset".expires_at is 'Время истечения кода';
+COMMENT ON COLUMN "PasswordReset".used_at is 'Время использования кода';
+COMMENT ON COLUMN "PasswordReset".created_at is 'Создание записи по UTC';
+
+-- --------------------------------
+
+INSERT INTO "Permission" (code, description) VALUES
+    ('accounts:read', 'Поиск и просмотр аккаунтов и регистраций'),
+    ('accounts:manage', 'Принудительный выход, подтверждение регистрации, сброс пароля и блокировка аккаунтов');
+
+INSERT INTO "RolePermission" (role_id, permission_id)
+SELECT r.id, p.id
+FROM "Role" r
+CROSS JOIN "Permission" p
+WHERE r.name = 'admin'
+    AND p.code IN ('accounts:read', 'accounts:manage')
+ON CONFLICT DO NOTHING;
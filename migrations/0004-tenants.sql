@@ -0,0 +1,163 @@
+\connect auth;
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "Tenant" CASCADE;
+CREATE TABLE "Tenant"
+(
+    id              UUID            DEFAULT uuid_generate_v4() PRIMARY KEY,
+    slug            VARCHAR(63)     NOT NULL UNIQUE,
+    name            VARCHAR(255)    NOT NULL,
+    host            VARCHAR(255)    NULL UNIQUE,
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at      TIMESTAMP       NULL
+);
+--
+COMMENT ON TABLE "Tenant" is 'Тенанты (продукты), между которыми изолированы аккаунты';
+COMMENT ON COLUMN "Tenant".slug is 'Уникальный код тенанта, передается в заголовке X-Tenant-ID';
+COMMENT ON COLUMN "Tenant".name is 'Название тенанта';
+COMMENT ON COLUMN "Tenant".host is 'Хост, по которому определяется тенант, если заголовок не передан';
+COMMENT ON COLUMN "Tenant".created_at is 'Создание записи по UTC';
+COMMENT ON COLUMN "Tenant".updated_at is 'Время последнего обновления';
+
+INSERT INTO "Tenant" (id, slug, name) VALUES
+    ('00000000-0000-0000-0000-000000000001', 'default', 'Тенант по умолчанию');
+
+-- --------------------------------
+
+ALTER TABLE "SignupEmail"
+    ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES "Tenant" (id);
+ALTER TABLE "SignupEmail" ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE "SignupEmail" DROP CONSTRAINT IF EXISTS "SignupEmail_email_key";
+ALTER TABLE "SignupEmail" ADD CONSTRAINT "SignupEmail_tenant_email_key" UNIQUE (tenant_id, email);
+--
+COMMENT ON COLUMN "SignupEmail".tenant_id is 'ID тенанта регистрации';
+
+ALTER TABLE "Account"
+    ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES "Tenant" (id);
+ALTER TABLE "Account" ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE "Account" DROP CONSTRAINT IF EXISTS "Account_email_key";
+ALTER TABLE "Account" ADD CONSTRAINT "Account_tenant_email_key" UNIQUE (tenant_id, email);
+--
+COMMENT ON COLUMN "Account".tenant_id is 'ID тенанта аккаунта, email уникален в пределах тенанта';
+
+ALTER TABLE "RefreshToken"
+    ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES "Tenant" (id);
+ALTER TABLE "RefreshToken" ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX ON "RefreshToken" (tenant_id, account_id);
+--
+COMMENT ON COLUMN "RefreshToken".tenant_id is 'ID тенанта, в котором выпущен токен';
+
+ALTER TABLE "PasswordReset"
+    ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES "Tenant" (id);
+ALTER TABLE "PasswordReset" ALTER COLUMN tenant_id DROP DEFAULT;
+--
+COMMENT ON COLUMN "PasswordReset".tenant_id is 'ID тенанта аккаунта';
+
+ALTER TABLE "Role"
+    ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES "Tenant" (id) ON DELETE CASCADE,
+    ADD COLUMN platform BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE "Role" ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE "Role" DROP CONSTRAINT IF EXISTS "Role_name_key";
+ALTER TABLE "Role" ADD CONSTRAINT "Role_tenant_name_key" UNIQUE (tenant_id, name);
+--
+COMMENT ON COLUMN "Role".tenant_id is 'ID тенанта роли, роль назначается только аккаунтам этого тенанта';
+COMMENT ON COLUMN "Role".platform is 'Роль операторов платформы: назначается и меняется только миграциями, не через API';
+
+ALTER TABLE "Permission"
+    ADD COLUMN tenant_id UUID NULL REFERENCES "Tenant" (id) ON DELETE CASCADE,
+    ADD COLUMN platform BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE "Permission" DROP CONSTRAINT IF EXISTS "Permission_code_key";
+ALTER TABLE "Permission" ADD CONSTRAINT "Permission_tenant_code_key" UNIQUE (tenant_id, code);
+CREATE UNIQUE INDEX "Permission_builtin_code_key" ON "Permission" (code) WHERE tenant_id IS NULL;
+--
+COMMENT ON COLUMN "Permission".tenant_id is 'ID тенанта, создавшего разрешение; NULL - встроенное разрешение, доступное всем тенантам';
+COMMENT ON COLUMN "Permission".platform is 'Разрешение операторов платформы: выдается только ролям platform, не через API';
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "Organization" CASCADE;
+CREATE TABLE "Organization"
+(
+    id              UUID            DEFAULT uuid_generate_v4() PRIMARY KEY,
+    tenant_id       UUID            NOT NULL REFERENCES "Tenant" (id) ON DELETE CASCADE,
+    name            VARCHAR(255)    NOT NULL,
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at      TIMESTAMP       NULL
+);
+--
+CREATE INDEX ON "Organization" (tenant_id);
+--
+COMMENT ON TABLE "Organization" is 'Организации внутри тенанта';
+COMMENT ON COLUMN "Organization".tenant_id is 'ID тенанта организации';
+COMMENT ON COLUMN "Organization".name is 'Название организации';
+COMMENT ON COLUMN "Organization".created_at is 'Создание записи по UTC';
+COMMENT ON COLUMN "Organization".updated_at is 'Время последнего обновления';
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "OrganizationMember";
+CREATE TABLE "OrganizationMember"
+(
+    org_id          UUID            NOT NULL REFERENCES "Organization" (id) ON DELETE CASCADE,
+    account_id      UUID            NOT NULL REFERENCES "Account" (id) ON DELETE CASCADE,
+    role            VARCHAR(31)     NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at      TIMESTAMP       NULL,
+    PRIMARY KEY (org_id, account_id)
+);
+--
+CREATE INDEX ON "OrganizationMember" (account_id);
+--
+COMMENT ON TABLE "OrganizationMember" is 'Участники организаций';
+COMMENT ON COLUMN "OrganizationMember".org_id is 'ID организации';
+COMMENT ON COLUMN "OrganizationMember".account_id is 'ID аккаунта того же тенанта';
+COMMENT ON COLUMN "OrganizationMember".role is 'Роль в организации: owner, admin или member';
+COMMENT ON COLUMN "OrganizationMember".created_at is 'Создание записи по UTC';
+COMMENT ON COLUMN "OrganizationMember".updated_at is 'Время последнего обновления';
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "OrganizationInvite";
+CREATE TABLE "OrganizationInvite"
+(
+    id              UUID            DEFAULT uuid_generate_v4() PRIMARY KEY,
+    org_id          UUID            NOT NULL REFERENCES "Organization" (id) ON DELETE CASCADE,
+    email           VARCHAR(255)    NOT NULL,
+    role            VARCHAR(31)     NOT NULL CHECK (role IN ('admin', 'member')),
+    code            VARCHAR(255)    NOT NULL,
+    invited_by      UUID            NOT NULL,
+    expires_at      TIMESTAMP       NOT NULL,
+    accepted_at     TIMESTAMP       NULL,
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+--
+CREATE INDEX ON "OrganizationInvite" (org_id);
+--
+COMMENT ON TABLE "OrganizationInvite" is 'Приглашения в организации';
+COMMENT ON COLUMN "OrganizationInvite".org_id is 'ID организации';
+COMMENT ON COLUMN "OrganizationInvite".email is 'Емейл приглашенного';
+COMMENT ON COLUMN "OrganizationInvite".role is 'Роль, которую получит приглашенный';
+COMMENT ON COLUMN "OrganizationInvite".code is 'Код приглашения';
+COMMENT ON COLUMN "OrganizationInvite".invited_by is 'ID пригласившего аккаунта';
+COMMENT ON COLUMN "OrganizationInvite".expires_at is 'Время истечения приглашения';
+COMMENT ON COLUMN "OrganizationInvite".accepted_at is 'Время принятия приглашения';
+COMMENT ON COLUMN "OrganizationInvite".created_at is 'Создание записи по UTC';
+
+-- --------------------------------
+
+-- Создание тенантов доступно только операторам платформы, а не администраторам тенантов
+INSERT INTO "Permission" (code, description, platform) VALUES
+    ('tenants:manage', 'Создание тенантов', TRUE);
+
+INSERT INTO "Role" (tenant_id, name, description, platform) VALUES
+    ('00000000-0000-0000-0000-000000000001', 'platform_operator', 'Оператор платформы', TRUE);
+
+INSERT INTO "RolePermission" (role_id, permission_id)
+SELECT r.id, p.id
+FROM "Role" r
+CROSS JOIN "Permission" p
+WHERE r.name = 'platform_operator'
+    AND r.platform
+    AND p.code = 'tenants:manage'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,43 @@
+\connect auth;
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "Impersonation";
+CREATE TABLE "Impersonation"
+(
+    id              UUID            DEFAULT uuid_generate_v4() PRIMARY KEY,
+    tenant_id       UUID            NOT NULL REFERENCES "Tenant" (id),
+    admin_id        UUID            NOT NULL,
+    account_id      UUID            NOT NULL REFERENCES "Account" (id) ON DELETE CASCADE,
+    reason          TEXT            NOT NULL,
+    expires_at      TIMESTAMP       NOT NULL,
+    ended_at        TIMESTAMP       NULL,
+    ended_by        UUID            NULL,
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+--
+CREATE INDEX ON "Impersonation" (tenant_id, account_id);
+CREATE INDEX ON "Impersonation" (tenant_id, admin_id);
+--
+COMMENT ON TABLE "Impersonation" is 'Журнал входов администраторов под аккаунтами пользователей';
+COMMENT ON COLUMN "Impersonation".tenant_id is 'ID тенанта';
+COMMENT ON COLUMN "Impersonation".admin_id is 'ID администратора (claim act.sub токена)';
+COMMENT ON COLUMN "Impersonation".account_id is 'ID аккаунта, под которым выполнен вход';
+COMMENT ON COLUMN "Impersonation".reason is 'Причина входа под пользователем';
+COMMENT ON COLUMN "Impersonation".expires_at is 'Время истечения выданного access токена';
+COMMENT ON COLUMN "Impersonation".ended_at is 'Время досрочного завершения сессии, NULL - не завершалась';
+COMMENT ON COLUMN "Impersonation".ended_by is 'ID аккаунта, завершившего сессию';
+COMMENT ON COLUMN "Impersonation".created_at is 'Начало сессии по UTC';
+
+-- --------------------------------
+
+INSERT INTO "Permission" (code, description) VALUES
+    ('accounts:impersonate', 'Вход под аккаунтом пользователя');
+
+INSERT INTO "RolePermission" (role_id, permission_id)
+SELECT r.id, p.id
+FROM "Role" r
+CROSS JOIN "Permission" p
+WHERE r.name = 'admin'
+    AND p.code = 'accounts:impersonate'
+ON CONFLICT DO NOTHING;
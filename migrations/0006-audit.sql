@@ -0,0 +1,57 @@
+\connect auth;
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "AuditLog";
+CREATE TABLE "AuditLog"
+(
+    seq             BIGSERIAL       PRIMARY KEY,
+    tenant_id       UUID            NOT NULL REFERENCES "Tenant" (id),
+    event           VARCHAR(63)     NOT NULL,
+    outcome         VARCHAR(31)     NOT NULL CHECK (outcome IN ('success', 'failure')),
+    account_id      UUID            NULL,
+    actor_id        UUID            NULL,
+    email           VARCHAR(255)    NULL,
+    ip_address      VARCHAR(255)    NULL,
+    user_agent      TEXT            NULL,
+    details         JSONB           NOT NULL DEFAULT '{}',
+    prev_hash       CHAR(64)        NOT NULL,
+    hash            CHAR(64)        NOT NULL,
+    created_at      TIMESTAMP       NOT NULL
+);
+--
+CREATE INDEX ON "AuditLog" (tenant_id, seq);
+CREATE INDEX ON "AuditLog" (tenant_id, account_id, created_at);
+CREATE INDEX ON "AuditLog" (tenant_id, event, created_at);
+--
+COMMENT ON TABLE "AuditLog" is 'Журнал событий безопасности, записи тенанта связаны в цепочку хешей';
+COMMENT ON COLUMN "AuditLog".seq is 'Порядковый номер записи, задает порядок цепочки и входит в хеш';
+COMMENT ON COLUMN "AuditLog".tenant_id is 'ID тенанта, у каждого тенанта своя цепочка';
+COMMENT ON COLUMN "AuditLog".event is 'Тип события, например login или admin.account.status';
+COMMENT ON COLUMN "AuditLog".outcome is 'Результат: success или failure';
+COMMENT ON COLUMN "AuditLog".account_id is 'ID аккаунта, к которому относится событие';
+COMMENT ON COLUMN "AuditLog".actor_id is 'ID аккаунта, выполнившего действие (администратор для admin-событий)';
+COMMENT ON COLUMN "AuditLog".email is 'Емейл из запроса, если аккаунт не определен';
+COMMENT ON COLUMN "AuditLog".ip_address is 'IP адрес клиента';
+COMMENT ON COLUMN "AuditLog".user_agent is 'User-Agent клиента';
+COMMENT ON COLUMN "AuditLog".details is 'Дополнительные строковые поля события';
+COMMENT ON COLUMN "AuditLog".prev_hash is 'Хеш предыдущей записи тенанта (нули для первой)';
+COMMENT ON COLUMN "AuditLog".hash is 'SHA-256 от prev_hash и полей записи';
+COMMENT ON COLUMN "AuditLog".created_at is 'Время события по UTC';
+
+-- --------------------------------
+
+INSERT INTO "Permission" (code, description) VALUES
+    ('audit:read', 'Просмотр и проверка журнала событий безопасности');
+
+INSERT INTO "RolePermission" (role_id, permission_id)
+SELECT r.id, p.id
+FROM "Role" r
+CROSS JOIN "Permission" p
+WHERE r.name = 'admin'
+    AND p.code = 'audit:read'
+ON CONFLICT DO NOTHING;
+
+-- Журнал только дополняется. Права на новые таблицы выдаются через default privileges из 0002:
+-- GRANT ON ALL TABLES в следующих миграциях вернул бы права на изменение журнала
+REVOKE UPDATE, DELETE, TRUNCATE ON "AuditLog" FROM auth;
@@ -0,0 +1,53 @@
+\connect auth;
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "Job";
+CREATE TABLE "Job"
+(
+    id              BIGSERIAL       PRIMARY KEY,
+    tenant_id       UUID            NOT NULL REFERENCES "Tenant" (id),
+    kind            VARCHAR(63)     NOT NULL,
+    payload         JSONB           NOT NULL DEFAULT '{}',
+    status          VARCHAR(31)     NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'dead')),
+    attempts        INTEGER         NOT NULL DEFAULT 0,
+    max_attempts    INTEGER         NOT NULL,
+    run_at          TIMESTAMP       NOT NULL DEFAULT NOW(),
+    locked_by       VARCHAR(255)    NULL,
+    locked_at       TIMESTAMP       NULL,
+    last_error      TEXT            NULL,
+    finished_at     TIMESTAMP       NULL,
+    created_at      TIMESTAMP       NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP       NOT NULL DEFAULT NOW()
+);
+--
+CREATE INDEX ON "Job" (run_at, id) WHERE status = 'pending';
+CREATE INDEX ON "Job" (locked_at) WHERE status = 'running';
+CREATE INDEX ON "Job" (tenant_id, status, kind);
+--
+COMMENT ON TABLE "Job" is 'Очередь фоновых задач';
+COMMENT ON COLUMN "Job".tenant_id is 'ID тенанта, в котором поставлена задача';
+COMMENT ON COLUMN "Job".kind is 'Тип задачи, по нему выбирается обработчик, например mail.send';
+COMMENT ON COLUMN "Job".payload is 'Данные задачи для обработчика';
+COMMENT ON COLUMN "Job".status is 'pending - ждет выполнения, running - выполняется, done - выполнена, dead - попытки исчерпаны';
+COMMENT ON COLUMN "Job".attempts is 'Количество начатых попыток';
+COMMENT ON COLUMN "Job".max_attempts is 'Количество попыток, после которого задача уходит в dead';
+COMMENT ON COLUMN "Job".run_at is 'Время, не раньше которого задачу можно брать в работу';
+COMMENT ON COLUMN "Job".locked_by is 'Идентификатор воркера, выполняющего задачу';
+COMMENT ON COLUMN "Job".locked_at is 'Время взятия в работу; зависшую задачу забирает другой воркер';
+COMMENT ON COLUMN "Job".last_error is 'Ошибка последней неудачной попытки';
+COMMENT ON COLUMN "Job".finished_at is 'Время перехода в done или dead';
+
+-- --------------------------------
+
+INSERT INTO "Permission" (code, description) VALUES
+    ('jobs:read', 'Просмотр очереди фоновых задач'),
+    ('jobs:manage', 'Повторный запуск задач из dead');
+
+INSERT INTO "RolePermission" (role_id, permission_id)
+SELECT r.id, p.id
+FROM "Role" r
+CROSS JOIN "Permission" p
+WHERE r.name = 'admin'
+    AND p.code IN ('jobs:read', 'jobs:manage')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,34 @@
+\connect auth;
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "Outbox";
+CREATE TABLE "Outbox"
+(
+    id              BIGSERIAL       PRIMARY KEY,
+    tenant_id       UUID            NOT NULL REFERENCES "Tenant" (id),
+    type            VARCHAR(63)     NOT NULL,
+    account_id      UUID            NOT NULL,
+    payload         JSONB           NOT NULL DEFAULT '{}',
+    attempts        INTEGER         NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP       NOT NULL DEFAULT NOW(),
+    last_error      TEXT            NULL,
+    published_at    TIMESTAMP       NULL,
+    created_at      TIMESTAMP       NOT NULL DEFAULT NOW()
+);
+--
+CREATE INDEX ON "Outbox" (id) WHERE published_at IS NULL;
+CREATE INDEX ON "Outbox" (account_id, id) WHERE published_at IS NULL;
+CREATE INDEX ON "Outbox" (published_at) WHERE published_at IS NOT NULL;
+--
+COMMENT ON TABLE "Outbox" is 'Доменные события, записанные в транзакции изменения состояния и ожидающие публикации';
+COMMENT ON COLUMN "Outbox".tenant_id is 'ID тенанта, в котором произошло событие';
+COMMENT ON COLUMN "Outbox".type is 'Тип события, например account.created';
+COMMENT ON COLUMN "Outbox".account_id is 'ID аккаунта; события одного аккаунта публикуются в порядке id. Без внешнего ключа, чтобы событие пережило удаление аккаунта';
+COMMENT ON COLUMN "Outbox".payload is 'Данные события';
+COMMENT ON COLUMN "Outbox".attempts is 'Количество попыток публикации';
+COMMENT ON COLUMN "Outbox".next_attempt_at is 'Время, не раньше которого событие можно публиковать повторно после ошибки';
+COMMENT ON COLUMN "Outbox".last_error is 'Ошибка последней неудачной публикации';
+COMMENT ON COLUMN "Outbox".published_at is 'Время подтверждения брокером; NULL - еще не опубликовано';
+
+-- --------------------------------
@@ -0,0 +1,82 @@
+\connect auth;
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "WebhookDelivery";
+DROP TABLE IF EXISTS "Webhook";
+CREATE TABLE "Webhook"
+(
+    id                   UUID            DEFAULT uuid_generate_v4() PRIMARY KEY,
+    tenant_id            UUID            NOT NULL REFERENCES "Tenant" (id),
+    url                  VARCHAR(2048)   NOT NULL,
+    events               VARCHAR(63)[]   NOT NULL,
+    secret               VARCHAR(255)    NOT NULL,
+    description          VARCHAR(255)    NOT NULL DEFAULT '',
+    enabled              BOOLEAN         NOT NULL DEFAULT TRUE,
+    consecutive_failures INTEGER         NOT NULL DEFAULT 0,
+    disabled_at          TIMESTAMP       NULL,
+    disabled_reason      TEXT            NULL,
+    created_at           TIMESTAMP       NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMP       NOT NULL DEFAULT NOW()
+);
+--
+CREATE INDEX ON "Webhook" (tenant_id) WHERE enabled;
+--
+COMMENT ON TABLE "Webhook" is 'Подписки тенантов на HTTP уведомления о событиях авторизации';
+COMMENT ON COLUMN "Webhook".tenant_id is 'ID тенанта, события которого отправляются';
+COMMENT ON COLUMN "Webhook".url is 'Адрес, на который отправляется POST с событием';
+COMMENT ON COLUMN "Webhook".events is 'Типы событий подписки, * - все события';
+COMMENT ON COLUMN "Webhook".secret is 'Ключ HMAC подписи. Хранится открыто: он нужен для подписи каждого запроса';
+COMMENT ON COLUMN "Webhook".enabled is 'FALSE - подписка отключена вручную или после серии неудачных доставок';
+COMMENT ON COLUMN "Webhook".consecutive_failures is 'Неудачных попыток доставки подряд, сбрасывается после успешной';
+COMMENT ON COLUMN "Webhook".disabled_at is 'Время отключения подписки';
+COMMENT ON COLUMN "Webhook".disabled_reason is 'Причина отключения подписки';
+
+-- --------------------------------
+
+CREATE TABLE "WebhookDelivery"
+(
+    id              UUID            DEFAULT uuid_generate_v4() PRIMARY KEY,
+    tenant_id       UUID            NOT NULL REFERENCES "Tenant" (id),
+    webhook_id      UUID            NOT NULL REFERENCES "Webhook" (id) ON DELETE CASCADE,
+    event_id        UUID            NOT NULL,
+    event           VARCHAR(63)     NOT NULL,
+    payload         JSONB           NOT NULL,
+    status          VARCHAR(31)     NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'success', 'retrying', 'failed')),
+    attempts        INTEGER         NOT NULL DEFAULT 0,
+    response_status INTEGER         NULL,
+    response_body   TEXT            NULL,
+    last_error      TEXT            NULL,
+    duration_ms     INTEGER         NULL,
+    delivered_at    TIMESTAMP       NULL,
+    created_at      TIMESTAMP       NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP       NOT NULL DEFAULT NOW()
+);
+--
+CREATE INDEX ON "WebhookDelivery" (webhook_id, created_at DESC);
+--
+COMMENT ON TABLE "WebhookDelivery" is 'Журнал доставки событий по подпискам';
+COMMENT ON COLUMN "WebhookDelivery".event_id is 'ID события, общий для всех подписок; передается в заголовке Webhook-Id для дедупликации';
+COMMENT ON COLUMN "WebhookDelivery".event is 'Тип события';
+COMMENT ON COLUMN "WebhookDelivery".payload is 'Тело запроса';
+COMMENT ON COLUMN "WebhookDelivery".status is 'pending - ждет отправки, success - доставлено, retrying - ждет повтора, failed - попытки исчерпаны или подписка отключена';
+COMMENT ON COLUMN "WebhookDelivery".attempts is 'Количество попыток доставки';
+COMMENT ON COLUMN "WebhookDelivery".response_status is 'HTTP статус последнего ответа';
+COMMENT ON COLUMN "WebhookDelivery".response_body is 'Начало тела последнего ответа';
+COMMENT ON COLUMN "WebhookDelivery".last_error is 'Ошибка последней попытки';
+COMMENT ON COLUMN "WebhookDelivery".duration_ms is 'Длительность последней попытки в миллисекундах';
+COMMENT ON COLUMN "WebhookDelivery".delivered_at is 'Время успешной доставки';
+
+-- --------------------------------
+
+INSERT INTO "Permission" (code, description) VALUES
+    ('webhooks:read', 'Просмотр подписок на события и журнала доставки'),
+    ('webhooks:manage', 'Создание, изменение и удаление подписок на события');
+
+INSERT INTO "RolePermission" (role_id, permission_id)
+SELECT r.id, p.id
+FROM "Role" r
+CROSS JOIN "Permission" p
+WHERE r.name = 'admin'
+    AND p.code IN ('webhooks:read', 'webhooks:manage')
+ON CONFLICT DO NOTHING;
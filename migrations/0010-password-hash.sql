@@ -0,0 +1,12 @@
+\connect auth;
+
+-- --------------------------------
+
+-- Новые хеши хранят соль внутри строки PHC, колонка salt нужна только устаревшим SHA-256 хешам
+ALTER TABLE "SignupEmail" ALTER COLUMN salt SET DEFAULT '';
+ALTER TABLE "Account" ALTER COLUMN salt SET DEFAULT '';
+
+COMMENT ON COLUMN "SignupEmail".passwd_hash is 'Хеш пароля в формате PHC ($argon2id$... или $2a$... для bcrypt); устаревшие хеши - hex SHA-256 от пароля с солью, пересчитываются при входе';
+COMMENT ON COLUMN "SignupEmail".salt is 'Соль устаревшего SHA-256 хеша; пустая для хешей в формате PHC';
+COMMENT ON COLUMN "Account".passwd_hash is 'Хеш пароля в формате PHC ($argon2id$... или $2a$... для bcrypt); устаревшие хеши - hex SHA-256 от пароля с солью, пересчитываются при входе';
+COMMENT ON COLUMN "Account".salt is 'Соль устаревшего SHA-256 хеша; пустая для хешей в формате PHC';
@@ -0,0 +1,21 @@
+\connect auth;
+
+-- --------------------------------
+
+-- Refresh токены больше не хранятся в открытом виде: только идентификатор поиска и SHA-256 хеш.
+-- У выпущенных ранее JWT токенов идентификатором поиска служит сам хеш, они принимаются до истечения
+ALTER TABLE "RefreshToken" ADD COLUMN lookup_id VARCHAR(64) NULL;
+ALTER TABLE "RefreshToken" ADD COLUMN token_hash VARCHAR(64) NULL;
+
+UPDATE "RefreshToken"
+SET lookup_id = encode(sha256(convert_to(token, 'UTF8')), 'hex'),
+    token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex');
+
+ALTER TABLE "RefreshToken" ALTER COLUMN lookup_id SET NOT NULL;
+ALTER TABLE "RefreshToken" ALTER COLUMN token_hash SET NOT NULL;
+ALTER TABLE "RefreshToken" DROP COLUMN token;
+--
+CREATE UNIQUE INDEX ON "RefreshToken" (tenant_id, lookup_id);
+--
+COMMENT ON COLUMN "RefreshToken".lookup_id is 'Идентификатор поиска: hex первых 12 байт токена; для устаревших JWT токенов совпадает с token_hash';
+COMMENT ON COLUMN "RefreshToken".token_hash is 'Hex SHA-256 от refresh токена, сам токен не хранится';
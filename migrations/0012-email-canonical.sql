@@ -0,0 +1,55 @@
+\connect auth;
+
+-- --------------------------------
+
+-- Каноническая форма email для уникальности без учета регистра. Приложение вычисляет ее при записи
+-- (домен в punycode, все в нижнем регистре); для существующих записей берется lower(email), что совпадает
+-- с канонической формой для всех ASCII адресов. Миграцию можно применять повторно: колонки добавляются,
+-- только если их нет, а заполняются только пустые значения
+ALTER TABLE "SignupEmail" ADD COLUMN IF NOT EXISTS email_canonical VARCHAR(255) NULL;
+ALTER TABLE "Account" ADD COLUMN IF NOT EXISTS email_canonical VARCHAR(255) NULL;
+
+UPDATE "SignupEmail" SET email_canonical = lower(trim(email)) WHERE email_canonical IS NULL;
+UPDATE "Account" SET email_canonical = lower(trim(email)) WHERE email_canonical IS NULL;
+
+ALTER TABLE "SignupEmail" ALTER COLUMN email_canonical SET NOT NULL;
+ALTER TABLE "Account" ALTER COLUMN email_canonical SET NOT NULL;
+
+COMMENT ON COLUMN "SignupEmail".email_canonical is 'Каноническая форма email: нижний регистр, домен в punycode; уникальна в тенанте';
+COMMENT ON COLUMN "Account".email_canonical is 'Каноническая форма email: нижний регистр, домен в punycode; уникальна в тенанте';
+
+-- --------------------------------
+
+-- Дубликаты, которые различаются только регистром или пробелами
+DROP VIEW IF EXISTS "EmailDuplicate";
+CREATE VIEW "EmailDuplicate" AS
+SELECT 'Account' AS source, tenant_id, email_canonical, array_agg(id ORDER BY created_at) AS ids, array_agg(email ORDER BY created_at) AS emails
+FROM "Account"
+GROUP BY tenant_id, email_canonical
+HAVING COUNT(*) > 1
+UNION ALL
+SELECT 'SignupEmail' AS source, tenant_id, email_canonical, array_agg(id ORDER BY created_at), array_agg(email ORDER BY created_at)
+FROM "SignupEmail"
+GROUP BY tenant_id, email_canonical
+HAVING COUNT(*) > 1;
+
+COMMENT ON VIEW "EmailDuplicate" is 'Записи с одинаковой канонической формой email, мешающие уникальному индексу';
+
+-- Дубликаты аккаунтов и неподтвержденных регистраций разбираются вручную, список в "EmailDuplicate":
+-- регистрация может ждать подтверждения, поэтому миграция ничего не удаляет сама
+DO $$
+DECLARE
+    duplicates INT;
+BEGIN
+    SELECT COUNT(*) INTO duplicates FROM "EmailDuplicate";
+    IF duplicates > 0 THEN
+        RAISE EXCEPTION 'Найдено % email с несколькими аккаунтами или регистрациями в одном тенанте', duplicates
+            USING HINT = 'Объедините, переименуйте или удалите записи из SELECT * FROM "EmailDuplicate", поменяв email и email_canonical, и примените миграцию повторно';
+    END IF;
+END
+$$;
+
+ALTER TABLE "SignupEmail" DROP CONSTRAINT IF EXISTS "SignupEmail_tenant_email_canonical_key";
+ALTER TABLE "SignupEmail" ADD CONSTRAINT "SignupEmail_tenant_email_canonical_key" UNIQUE (tenant_id, email_canonical);
+ALTER TABLE "Account" DROP CONSTRAINT IF EXISTS "Account_tenant_email_canonical_key";
+ALTER TABLE "Account" ADD CONSTRAINT "Account_tenant_email_canonical_key" UNIQUE (tenant_id, email_canonical);
@@ -0,0 +1,16 @@
+\connect auth;
+
+-- --------------------------------
+
+-- Семейство refresh токенов - одна сессия, начатая входом. При каждом рефреше токен заменяется новым
+-- из того же семейства; повторное предъявление замененного токена и политика привязки сессии отзывают
+-- только это семейство, не трогая другие устройства аккаунта
+ALTER TABLE "RefreshToken" ADD COLUMN family_id UUID NULL DEFAULT uuid_generate_v4();
+ALTER TABLE "RefreshToken" ADD COLUMN replaced_by UUID NULL;
+UPDATE "RefreshToken" SET family_id = id;
+ALTER TABLE "RefreshToken" ALTER COLUMN family_id SET NOT NULL;
+--
+CREATE INDEX ON "RefreshToken" (tenant_id, account_id, family_id);
+--
+COMMENT ON COLUMN "RefreshToken".family_id is 'ID семейства токенов: сессии, начатой одним входом';
+COMMENT ON COLUMN "RefreshToken".replaced_by is 'ID токена, выданного взамен при рефреше, NULL - токен не заменялся';
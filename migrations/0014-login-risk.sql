@@ -0,0 +1,44 @@
+\connect auth;
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "LoginEvent";
+CREATE TABLE "LoginEvent"
+(
+    id              UUID            DEFAULT uuid_generate_v4() PRIMARY KEY,
+    tenant_id       UUID            NOT NULL REFERENCES "Tenant" (id),
+    account_id      UUID            NULL,
+    email_canonical VARCHAR(255)    NOT NULL DEFAULT '',
+    kind            VARCHAR(15)     NOT NULL CHECK (kind IN ('login', 'refresh')),
+    outcome         VARCHAR(15)     NOT NULL CHECK (outcome IN ('success', 'failure', 'blocked')),
+    ip_address      VARCHAR(255)    NOT NULL DEFAULT '',
+    device          VARCHAR(255)    NOT NULL DEFAULT '',
+    country         VARCHAR(2)      NOT NULL DEFAULT '',
+    asn             BIGINT          NOT NULL DEFAULT 0,
+    latitude        DOUBLE PRECISION NULL,
+    longitude       DOUBLE PRECISION NULL,
+    score           INTEGER         NOT NULL DEFAULT 0,
+    decision        VARCHAR(15)     NOT NULL DEFAULT '',
+    signals         TEXT[]          NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+--
+CREATE INDEX ON "LoginEvent" (tenant_id, account_id, created_at);
+CREATE INDEX ON "LoginEvent" (tenant_id, ip_address, created_at);
+--
+COMMENT ON TABLE "LoginEvent" is 'Журнал попыток входа и рефреша, по нему оценивается риск следующих входов';
+COMMENT ON COLUMN "LoginEvent".tenant_id is 'ID тенанта';
+COMMENT ON COLUMN "LoginEvent".account_id is 'ID аккаунта, NULL - email не найден';
+COMMENT ON COLUMN "LoginEvent".email_canonical is 'Канонический email попытки, по нему считается перебор паролей по многим аккаунтам';
+COMMENT ON COLUMN "LoginEvent".kind is 'login - вход по паролю, refresh - обновление токенов';
+COMMENT ON COLUMN "LoginEvent".outcome is 'success, failure или blocked - запрещен оценкой риска';
+COMMENT ON COLUMN "LoginEvent".ip_address is 'IP адрес клиента';
+COMMENT ON COLUMN "LoginEvent".device is 'Семейство браузера и ОС';
+COMMENT ON COLUMN "LoginEvent".country is 'Код страны ISO 3166-1 по GeoIP';
+COMMENT ON COLUMN "LoginEvent".asn is 'Номер автономной системы по GeoIP, 0 - неизвестен';
+COMMENT ON COLUMN "LoginEvent".latitude is 'Широта по GeoIP, NULL - неизвестна';
+COMMENT ON COLUMN "LoginEvent".longitude is 'Долгота по GeoIP, NULL - неизвестна';
+COMMENT ON COLUMN "LoginEvent".score is 'Балл риска';
+COMMENT ON COLUMN "LoginEvent".decision is 'Решение: allow, notify или block';
+COMMENT ON COLUMN "LoginEvent".signals is 'Сработавшие сигналы риска';
+COMMENT ON COLUMN "LoginEvent".created_at is 'Время попытки по UTC';
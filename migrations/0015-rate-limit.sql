@@ -0,0 +1,22 @@
+\connect auth;
+
+-- --------------------------------
+
+DROP TABLE IF EXISTS "RateLimit";
+CREATE UNLOGGED TABLE "RateLimit"
+(
+    key             TEXT            PRIMARY KEY,
+    value           DOUBLE PRECISION NOT NULL DEFAULT 0,
+    previous        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    stamp           TIMESTAMP       NULL,
+    expires_at      TIMESTAMP       NOT NULL
+);
+--
+CREATE INDEX ON "RateLimit" (expires_at);
+--
+COMMENT ON TABLE "RateLimit" is 'Счетчики ограничения частоты запросов, общие для всех экземпляров сервиса. Таблица без журнала, при сбое счетчики обнуляются';
+COMMENT ON COLUMN "RateLimit".key is 'Маршрут, правило, тенант и клиент';
+COMMENT ON COLUMN "RateLimit".value is 'Токенов в ведре (token_bucket) или запросов в текущем окне (sliding_window)';
+COMMENT ON COLUMN "RateLimit".previous is 'Запросов в предыдущем окне, только sliding_window';
+COMMENT ON COLUMN "RateLimit".stamp is 'Время последнего пополнения ведра или начало текущего окна по UTC, NULL - счетчик новый';
+COMMENT ON COLUMN "RateLimit".expires_at is 'Время, после которого счетчик можно удалить';
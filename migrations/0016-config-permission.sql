@@ -0,0 +1,14 @@
+\connect auth;
+
+-- --------------------------------
+
+INSERT INTO "Permission" (code, description) VALUES
+    ('config:read', 'Просмотр версии загруженной конфигурации');
+
+INSERT INTO "RolePermission" (role_id, permission_id)
+SELECT r.id, p.id
+FROM "Role" r
+CROSS JOIN "Permission" p
+WHERE r.name = 'admin'
+    AND p.code IN ('config:read')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,89 @@
+package captcha
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// ProviderOff - проверка выключена
+	ProviderOff = "off"
+	// ProviderHCaptcha - hCaptcha
+	ProviderHCaptcha = "hcaptcha"
+	// ProviderReCaptcha - Google reCAPTCHA v2 или v3
+	ProviderReCaptcha = "recaptcha"
+	// ProviderTurnstile - Cloudflare Turnstile
+	ProviderTurnstile = "turnstile"
+	// ProviderPoW - встроенная задача proof-of-work без сторонних сервисов
+	ProviderPoW = "pow"
+)
+
+// verifyURLs - адреса проверки ответа у провайдеров; все три принимают одинаковый запрос siteverify
+var verifyURLs = map[string]string{
+	ProviderHCaptcha:  "https://api.hcaptcha.com/siteverify",
+	ProviderReCaptcha: "https://www.google.com/recaptcha/api/siteverify",
+	ProviderTurnstile: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// Config - провайдер CAPTCHA и где ее требовать
+type Config struct {
+	Provider  string        `yaml:"provider"`   // off, hcaptcha, recaptcha, turnstile или pow
+	SiteKey   string        `yaml:"site_key"`   // публичный ключ виджета, отдается клиенту
+	SecretKey string        `yaml:"secret_key"` // секретный ключ для проверки ответа у провайдера
+	VerifyURL string        `yaml:"verify_url"` // адрес siteverify; пусто - адрес провайдера, заглушка для разработки подменяет его
+	MinScore  float64       `yaml:"min_score"`  // минимальная оценка reCAPTCHA v3, 0 - оценка не проверяется
+	Timeout   time.Duration `yaml:"timeout"`    // ограничение времени запроса к провайдеру
+	// PoW
+	PoWSecret     string        `yaml:"pow_secret"`     // ключ подписи задач; пусто - случайный, тогда задачи действуют только в этом процессе
+	PoWDifficulty int           `yaml:"pow_difficulty"` // число ведущих нулевых бит SHA-256 решения
+	PoWTTL        time.Duration `yaml:"pow_ttl"`        // время жизни задачи
+	// Где требовать
+	OnSignup           bool          `yaml:"on_signup"`            // при регистрации
+	OnReset            bool          `yaml:"on_reset"`             // при запросе сброса пароля
+	LoginAfterFailures int           `yaml:"login_after_failures"` // при входе после стольких неудачных входов с email или IP за FailureWindow, 0 - никогда
+	FailureWindow      time.Duration `yaml:"failure_window"`       // окно подсчета неудачных входов
+	LoginFailOpen      bool          `yaml:"login_fail_open"`      // не требовать CAPTCHA на входе, если неудачные входы не удалось подсчитать; false - требовать
+}
+
+// Challenge - параметры проверки для клиента
+type Challenge struct {
+	Provider   string     // провайдер, клиент выбирает по нему виджет
+	SiteKey    string     // ключ виджета стороннего провайдера
+	Challenge  string     // задача proof-of-work
+	Difficulty int        // число ведущих нулевых бит решения
+	ExpiresAt  *time.Time // срок действия задачи
+}
+
+// Verifier проверяет ответ CAPTCHA, присланный клиентом. Реализации должны быть безопасны для параллельного использования.
+type Verifier interface {
+	// Challenge возвращает параметры проверки для клиента; для proof-of-work - новую задачу.
+	Challenge() (*Challenge, error)
+	// Verify проверяет ответ клиента. Ошибки: core.ErrCaptchaRequired - ответа нет, core.ErrCaptchaInvalid - ответ
+	// неверен, core.ErrCaptchaUnavailable - провайдер не ответил.
+	Verify(ctx context.Context, token string, remote_ip string) error
+}
+
+// NewVerifier создает проверку, указанную в конфигурации.
+//
+// Параметры:
+//   - cfg: настройки CAPTCHA
+//
+// Возвращает:
+//   - Verifier; nil, если проверка выключена
+//   - ошибку, если провайдер неизвестен или не задан ключ
+func NewVerifier(cfg Config) (Verifier, error) {
+	switch cfg.Provider {
+	case "", ProviderOff:
+		return nil, nil
+	case ProviderHCaptcha, ProviderReCaptcha, ProviderTurnstile:
+		if cfg.SecretKey == "" {
+			return nil, fmt.Errorf("captcha %s: secret key is not set", cfg.Provider)
+		}
+		return NewSiteVerify(cfg), nil
+	case ProviderPoW:
+		return NewProofOfWork(cfg)
+	default:
+		return nil, fmt.Errorf("unknown captcha provider: %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,140 @@
+package captcha
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+)
+
+// ProofOfWork - встроенная проверка без сторонних сервисов. Клиент получает подписанную задачу
+// "<payload>.<подпись>" и подбирает число n, при котором SHA-256 строки "<задача>.<n>" начинается
+// с Difficulty нулевых бит; ответ - "<задача>.<n>". Задача одноразовая и действует PoWTTL.
+type ProofOfWork struct {
+	secret     []byte
+	difficulty int
+	ttl        time.Duration
+
+	mu   sync.Mutex
+	used map[string]time.Time // решенные задачи до истечения их срока, защита от повторного ответа
+}
+
+// powNonceLen - длина случайной части задачи
+const powNonceLen = 16
+
+// NewProofOfWork создает проверку proof-of-work.
+//
+// Параметры:
+//   - cfg: ключ подписи, сложность и время жизни задач
+//
+// Возвращает:
+//   - указатель на новый экземпляр ProofOfWork
+//   - ошибку, если сложность вне диапазона 1..32 или не удалось создать случайный ключ
+func NewProofOfWork(cfg Config) (*ProofOfWork, error) {
+	if cfg.PoWDifficulty < 1 || cfg.PoWDifficulty > 32 {
+		return nil, fmt.Errorf("captcha pow: difficulty must be between 1 and 32, got %d", cfg.PoWDifficulty)
+	}
+	secret := []byte(cfg.PoWSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("captcha pow: %w", err)
+		}
+	}
+	return &ProofOfWork{secret: secret, difficulty: cfg.PoWDifficulty, ttl: cfg.PoWTTL, used: map[string]time.Time{}}, nil
+}
+
+// Challenge создает новую задачу: случайная часть, срок действия и сложность, подписанные HMAC-SHA256.
+func (p *ProofOfWork) Challenge() (*Challenge, error) {
+	payload := make([]byte, powNonceLen+9)
+	if _, err := rand.Read(payload[:powNonceLen]); err != nil {
+		return nil, fmt.Errorf("captcha pow: %w", err)
+	}
+	expires_at := time.Now().Add(p.ttl).UTC().Truncate(time.Second)
+	binary.BigEndian.PutUint64(payload[powNonceLen:], uint64(expires_at.Unix()))
+	payload[powNonceLen+8] = byte(p.difficulty)
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return &Challenge{
+		Provider:   ProviderPoW,
+		Challenge:  encoded + "." + p.sign(encoded),
+		Difficulty: p.difficulty,
+		ExpiresAt:  &expires_at,
+	}, nil
+}
+
+// Verify проверяет подпись, срок и решение задачи и отмечает задачу использованной.
+//
+// Параметры:
+//   - ctx: не используется
+//   - token: ответ "<задача>.<n>"
+//   - remote_ip: не используется
+//
+// Возвращает:
+//   - ошибку core.ErrCaptchaRequired или core.ErrCaptchaInvalid
+func (p *ProofOfWork) Verify(_ context.Context, token string, _ string) error {
+	if token == "" {
+		return &core.ErrCaptchaRequired{ErrMessage: ProviderPoW}
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[2] == "" {
+		return &core.ErrCaptchaInvalid{ErrMessage: "malformed solution"}
+	}
+	encoded, sig := parts[0], parts[1]
+	if !hmac.Equal([]byte(sig), []byte(p.sign(encoded))) {
+		return &core.ErrCaptchaInvalid{ErrMessage: "bad signature"}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(payload) != powNonceLen+9 {
+		return &core.ErrCaptchaInvalid{ErrMessage: "malformed challenge"}
+	}
+	expires_at := time.Unix(int64(binary.BigEndian.Uint64(payload[powNonceLen:])), 0)
+	if time.Now().After(expires_at) {
+		return &core.ErrCaptchaInvalid{ErrMessage: "challenge expired"}
+	}
+	if leadingZeroBits(sha256.Sum256([]byte(token))) < int(payload[powNonceLen+8]) {
+		return &core.ErrCaptchaInvalid{ErrMessage: "wrong solution"}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for key, exp := range p.used {
+		if now.After(exp) {
+			delete(p.used, key)
+		}
+	}
+	if _, ok := p.used[encoded]; ok {
+		return &core.ErrCaptchaInvalid{ErrMessage: "challenge already used"}
+	}
+	p.used[encoded] = expires_at
+	return nil
+}
+
+// sign возвращает подпись задачи.
+func (p *ProofOfWork) sign(encoded string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// leadingZeroBits считает ведущие нулевые биты хеша.
+func leadingZeroBits(sum [sha256.Size]byte) int {
+	n := 0
+	for _, b := range sum {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,154 @@
+package captcha
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+)
+
+// solve подбирает ответ на задачу, у которого есть (ok = true) или нет (ok = false) нужного числа нулевых бит.
+func solve(challenge string, difficulty int, ok bool) string {
+	for n := 0; ; n++ {
+		token := challenge + "." + strconv.Itoa(n)
+		if (leadingZeroBits(sha256.Sum256([]byte(token))) >= difficulty) == ok {
+			return token
+		}
+	}
+}
+
+func newTestPoW(t *testing.T, secret string, ttl time.Duration) *ProofOfWork {
+	t.Helper()
+	p, err := NewProofOfWork(Config{Provider: ProviderPoW, PoWSecret: secret, PoWDifficulty: 8, PoWTTL: ttl})
+	if err != nil {
+		t.Fatalf("NewProofOfWork: %s", err)
+	}
+	return p
+}
+
+func TestProofOfWorkVerify(t *testing.T) {
+	p := newTestPoW(t, "secret", time.Minute)
+	other := newTestPoW(t, "other secret", time.Minute)
+	expired := newTestPoW(t, "secret", -time.Minute)
+
+	challenge := func(p *ProofOfWork) string {
+		c, err := p.Challenge()
+		if err != nil {
+			t.Fatalf("Challenge: %s", err)
+		}
+		return c.Challenge
+	}
+	tamper := func(challenge string) string {
+		payload, sig, _ := strings.Cut(challenge, ".")
+		last := payload[len(payload)-1]
+		swap := byte('A')
+		if last == 'A' {
+			swap = 'B'
+		}
+		return payload[:len(payload)-1] + string(swap) + "." + sig
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{"solved", solve(challenge(p), 8, true), ""},
+		{"empty", "", "required"},
+		{"no nonce", challenge(p), "malformed solution"},
+		{"empty nonce", challenge(p) + ".", "malformed solution"},
+		{"extra part", solve(challenge(p), 8, true) + ".1", "malformed solution"},
+		{"wrong solution", solve(challenge(p), 8, false), "wrong solution"},
+		{"other secret", solve(challenge(other), 8, true), "bad signature"},
+		{"tampered payload", solve(tamper(challenge(p)), 8, true), "bad signature"},
+		{"expired", solve(challenge(expired), 8, true), "challenge expired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Verify(context.Background(), tt.token, "203.0.113.7")
+			switch tt.want {
+			case "":
+				if err != nil {
+					t.Errorf("Verify: %s", err)
+				}
+			case "required":
+				var required *core.ErrCaptchaRequired
+				if !errors.As(err, &required) {
+					t.Errorf("Verify error = %v, want ErrCaptchaRequired", err)
+				}
+			default:
+				var invalid *core.ErrCaptchaInvalid
+				if !errors.As(err, &invalid) || invalid.ErrMessage != tt.want {
+					t.Errorf("Verify error = %v, want ErrCaptchaInvalid %q", err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestProofOfWorkReplay(t *testing.T) {
+	p := newTestPoW(t, "", time.Minute)
+	c, err := p.Challenge()
+	if err != nil {
+		t.Fatalf("Challenge: %s", err)
+	}
+	if c.Provider != ProviderPoW || c.Difficulty != 8 || c.ExpiresAt == nil {
+		t.Fatalf("Challenge = %+v", c)
+	}
+
+	token := solve(c.Challenge, 8, true)
+	if err := p.Verify(context.Background(), token, ""); err != nil {
+		t.Fatalf("Verify: %s", err)
+	}
+	var invalid *core.ErrCaptchaInvalid
+	if err := p.Verify(context.Background(), token, ""); !errors.As(err, &invalid) || invalid.ErrMessage != "challenge already used" {
+		t.Errorf("second Verify error = %v, want challenge already used", err)
+	}
+	// Другое решение той же задачи тоже отклоняется
+	other := ""
+	for n := 0; other == ""; n++ {
+		candidate := c.Challenge + "." + strconv.Itoa(n)
+		if candidate != token && leadingZeroBits(sha256.Sum256([]byte(candidate))) >= 8 {
+			other = candidate
+		}
+	}
+	if err := p.Verify(context.Background(), other, ""); !errors.As(err, &invalid) || invalid.ErrMessage != "challenge already used" {
+		t.Errorf("Verify of another solution error = %v, want challenge already used", err)
+	}
+}
+
+func TestNewProofOfWorkDifficulty(t *testing.T) {
+	for _, difficulty := range []int{0, -1, 33} {
+		if _, err := NewProofOfWork(Config{PoWDifficulty: difficulty}); err == nil {
+			t.Errorf("NewProofOfWork(difficulty %d): want error", difficulty)
+		}
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		prefix []byte
+		want   int
+	}{
+		{[]byte{0x80}, 0},
+		{[]byte{0x01}, 7},
+		{[]byte{0x00, 0xff}, 8},
+		{[]byte{0x00, 0x00, 0x10}, 19},
+		{make([]byte, sha256.Size), 256},
+	}
+	for _, tt := range tests {
+		var sum [sha256.Size]byte
+		copy(sum[:], tt.prefix)
+		if len(tt.prefix) < sha256.Size {
+			sum[len(sum)-1] = 1
+		}
+		if got := leadingZeroBits(sum); got != tt.want {
+			t.Errorf("leadingZeroBits(%x) = %d, want %d", tt.prefix, got, tt.want)
+		}
+	}
+}
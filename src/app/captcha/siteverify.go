@@ -0,0 +1,96 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/MedodsTechTask/app/core"
+)
+
+// SiteVerify проверяет ответ через siteverify API hCaptcha, reCAPTCHA или Turnstile: форма с secret, response
+// и remoteip, в ответе JSON с полем success.
+type SiteVerify struct {
+	cfg    Config
+	url    string
+	client *http.Client
+}
+
+// siteVerifyResponse - общие поля ответа siteverify трех провайдеров
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	Score      *float64 `json:"score"` // только reCAPTCHA v3
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// NewSiteVerify создает проверку через siteverify API провайдера.
+//
+// Параметры:
+//   - cfg: провайдер, ключи, адрес и таймаут
+//
+// Возвращает:
+//   - указатель на новый экземпляр SiteVerify
+func NewSiteVerify(cfg Config) *SiteVerify {
+	verify_url := cfg.VerifyURL
+	if verify_url == "" {
+		verify_url = verifyURLs[cfg.Provider]
+	}
+	return &SiteVerify{cfg: cfg, url: verify_url, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+// Challenge возвращает провайдера и ключ виджета.
+func (v *SiteVerify) Challenge() (*Challenge, error) {
+	return &Challenge{Provider: v.cfg.Provider, SiteKey: v.cfg.SiteKey}, nil
+}
+
+// Verify отправляет ответ клиента провайдеру.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - token: ответ виджета
+//   - remote_ip: адрес клиента, пустая строка - не передается
+//
+// Возвращает:
+//   - ошибку core.ErrCaptchaRequired, core.ErrCaptchaInvalid или core.ErrCaptchaUnavailable
+func (v *SiteVerify) Verify(ctx context.Context, token string, remote_ip string) error {
+	if token == "" {
+		return &core.ErrCaptchaRequired{ErrMessage: v.cfg.Provider}
+	}
+
+	form := url.Values{"secret": {v.cfg.SecretKey}, "response": {token}}
+	if remote_ip != "" {
+		form.Set("remoteip", remote_ip)
+	}
+	if v.cfg.Provider == ProviderHCaptcha && v.cfg.SiteKey != "" {
+		form.Set("sitekey", v.cfg.SiteKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return &core.ErrCaptchaUnavailable{ErrMessage: err}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return &core.ErrCaptchaUnavailable{ErrMessage: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &core.ErrCaptchaUnavailable{ErrMessage: fmt.Sprintf("siteverify status %d", resp.StatusCode)}
+	}
+
+	var res siteVerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return &core.ErrCaptchaUnavailable{ErrMessage: err}
+	}
+	if !res.Success {
+		return &core.ErrCaptchaInvalid{ErrMessage: strings.Join(res.ErrorCodes, ",")}
+	}
+	if v.cfg.MinScore > 0 && res.Score != nil && *res.Score < v.cfg.MinScore {
+		return &core.ErrCaptchaInvalid{ErrMessage: fmt.Sprintf("score %.2f is below %.2f", *res.Score, v.cfg.MinScore)}
+	}
+	return nil
+}
@@ -5,6 +5,17 @@ const (
 	BasePath = "/api/v1"
 )
 
+const (
+	// HealthzPath - Проверка живости процесса
+	HealthzPath = "/healthz"
+
+	// ReadyzPath - Проверка готовности принимать запросы
+	ReadyzPath = "/readyz"
+
+	// MetricsPath - Метрики в формате Prometheus
+	MetricsPath = "/metrics"
+)
+
 const (
 	// UserAuthPath - роут auth сервиса
 	UserAuthPath = "/user/auth"
@@ -20,4 +31,114 @@ const (
 
 	// UserAuthRefreshToken - Рефреш токена
 	UserAuthRefreshToken = "/refresh/token"
+
+	// UserAuthResetPassword - Запрос на сброс пароля
+	UserAuthResetPassword = "/reset/password"
+
+	// UserAuthResetPasswordConfirm - Установка нового пароля по коду сброса
+	UserAuthResetPasswordConfirm = "/reset/password/confirm"
+
+	// UserAuthCaptcha - Параметры CAPTCHA и задача proof-of-work
+	UserAuthCaptcha = "/captcha"
+
+	// UserAuthImpersonationEnd - Завершение сессии входа под пользователем из самой сессии
+	UserAuthImpersonationEnd = "/impersonation/end"
+
+	// UserAuthOrgs - Организации аккаунта и создание организации
+	UserAuthOrgs = "/orgs"
+
+	// UserAuthOrgMembers - Участники организации
+	UserAuthOrgMembers = "/orgs/:org_id/members"
+
+	// UserAuthOrgMember - Смена роли и исключение участника
+	UserAuthOrgMember = "/orgs/:org_id/members/:account_id"
+
+	// UserAuthOrgInvites - Приглашение в организацию
+	UserAuthOrgInvites = "/orgs/:org_id/invites"
+
+	// UserAuthInviteAccept - Принятие приглашения
+	UserAuthInviteAccept = "/invites/:invite_id/accept"
+
+	// UserAuthRoles - Список и создание ролей
+	UserAuthRoles = "/roles"
+
+	// UserAuthRolePermissions - Выдача разрешения роли
+	UserAuthRolePermissions = "/roles/:role_id/permissions"
+
+	// UserAuthRolePermission - Отзыв разрешения у роли
+	UserAuthRolePermission = "/roles/:role_id/permissions/:code"
+
+	// UserAuthPermissions - Список и создание разрешений
+	UserAuthPermissions = "/permissions"
+
+	// UserAuthAccountRoles - Роли и разрешения аккаунта, назначение роли
+	UserAuthAccountRoles = "/accounts/:account_id/roles"
+
+	// UserAuthAccountRole - Снятие роли с аккаунта
+	UserAuthAccountRole = "/accounts/:account_id/roles/:role_id"
+)
+
+const (
+	// AdminPath - роут административного api
+	AdminPath = "/admin"
+
+	// AdminAccounts - Поиск аккаунтов
+	AdminAccounts = "/accounts"
+
+	// AdminAccount - Детали аккаунта с активными сессиями
+	AdminAccount = "/accounts/:account_id"
+
+	// AdminAccountLogout - Принудительный выход из всех сессий
+	AdminAccountLogout = "/accounts/:account_id/logout"
+
+	// AdminAccountStatus - Блокировка и разблокировка аккаунта
+	AdminAccountStatus = "/accounts/:account_id/status"
+
+	// AdminAccountPasswordReset - Запуск сброса пароля
+	AdminAccountPasswordReset = "/accounts/:account_id/password/reset"
+
+	// AdminAccountImpersonate - Вход под аккаунтом пользователя
+	AdminAccountImpersonate = "/accounts/:account_id/impersonate"
+
+	// AdminImpersonations - Журнал входов под пользователями
+	AdminImpersonations = "/impersonations"
+
+	// AdminImpersonationEnd - Досрочное завершение входа под пользователем
+	AdminImpersonationEnd = "/impersonations/:impersonation_id/end"
+
+	// AdminSignups - Поиск неподтвержденных регистраций
+	AdminSignups = "/signups"
+
+	// AdminSignupConfirm - Ручное подтверждение регистрации
+	AdminSignupConfirm = "/signups/:signup_id/confirm"
+
+	// AdminAudit - Поиск по журналу событий безопасности
+	AdminAudit = "/audit"
+
+	// AdminAuditVerify - Проверка целостности цепочки журнала
+	AdminAuditVerify = "/audit/verify"
+
+	// AdminJobs - Поиск задач фоновой очереди
+	AdminJobs = "/jobs"
+
+	// AdminJobStats - Количество задач по типу и статусу
+	AdminJobStats = "/jobs/stats"
+
+	// AdminJobRetry - Повторный запуск задачи из dead
+	AdminJobRetry = "/jobs/:job_id/retry"
+
+	// AdminWebhooks - Список и создание подписок на события
+	AdminWebhooks = "/webhooks"
+
+	// AdminWebhook - Изменение и удаление подписки
+	AdminWebhook = "/webhooks/:webhook_id"
+
+	// AdminWebhookDeliveries - Журнал доставки событий подписки
+	AdminWebhookDeliveries = "/webhooks/:webhook_id/deliveries"
+
+	// AdminTenants - Создание тенанта
+	AdminTenants = "/tenants"
+
+	// AdminConfigVersion - Версия загруженной конфигурации
+	AdminConfigVersion = "/config/version"
 )
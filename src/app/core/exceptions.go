@@ -12,7 +12,7 @@ type ZError struct {
 
 // ------------- for UseCase -------------
 
-type ErrInvalidLenPassword struct {
+type ErrPasswordPolicy struct {
 	ErrMessage any
 }
 
@@ -20,16 +20,24 @@ type ErrEmailValidate struct {
 	ErrMessage any
 }
 
+type ErrEmailDisposable struct {
+	ErrMessage any
+}
+
 // ------------- Error Func to uc -------------
 
-func (e *ErrInvalidLenPassword) Error() string {
-	return fmt.Sprintf("неверная длина пароля \nerr: %s", e.ErrMessage)
+func (e *ErrPasswordPolicy) Error() string {
+	return fmt.Sprintf("пароль не соответствует политике \nerr: %v", e.ErrMessage)
 }
 
 func (e *ErrEmailValidate) Error() string {
 	return fmt.Sprintf("не валидная почта \nerr: %s", e.ErrMessage)
 }
 
+func (e *ErrEmailDisposable) Error() string {
+	return fmt.Sprintf("одноразовая почта \nerr: %s", e.ErrMessage)
+}
+
 // ------------- for repo -------------
 
 type ErrPGRepo struct {
@@ -60,6 +68,62 @@ type ErrTokenNotFound struct {
 	ErrMessage any
 }
 
+type ErrTokenReplaced struct {
+	ErrMessage any
+}
+
+type ErrCreateRole struct {
+	ErrMessage any
+}
+
+type ErrRoleNotFound struct {
+	ErrMessage any
+}
+
+type ErrCreatePermission struct {
+	ErrMessage any
+}
+
+type ErrPermissionNotFound struct {
+	ErrMessage any
+}
+
+type ErrPasswordResetNotFound struct {
+	ErrMessage any
+}
+
+type ErrTenantNotFound struct {
+	ErrMessage any
+}
+
+type ErrCreateTenant struct {
+	ErrMessage any
+}
+
+type ErrOrganizationNotFound struct {
+	ErrMessage any
+}
+
+type ErrMemberNotFound struct {
+	ErrMessage any
+}
+
+type ErrInviteNotFound struct {
+	ErrMessage any
+}
+
+type ErrImpersonationNotFound struct {
+	ErrMessage any
+}
+
+type ErrJobNotFound struct {
+	ErrMessage any
+}
+
+type ErrWebhookNotFound struct {
+	ErrMessage any
+}
+
 // ------------- Error Func to repo -------------
 
 func (e *ErrPGRepo) Error() string {
@@ -90,6 +154,62 @@ func (e *ErrTokenNotFound) Error() string {
 	return fmt.Sprintf("токен не найден \nerr: %s", e.ErrMessage)
 }
 
+func (e *ErrTokenReplaced) Error() string {
+	return fmt.Sprintf("токен уже заменен новым \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrCreateRole) Error() string {
+	return fmt.Sprintf("ошибка создания роли \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrRoleNotFound) Error() string {
+	return fmt.Sprintf("роль или аккаунт не найдены \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrCreatePermission) Error() string {
+	return fmt.Sprintf("ошибка создания разрешения \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrPermissionNotFound) Error() string {
+	return fmt.Sprintf("разрешение не найдено \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrPasswordResetNotFound) Error() string {
+	return fmt.Sprintf("запрос на сброс пароля не найден или истек \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrTenantNotFound) Error() string {
+	return fmt.Sprintf("тенант не найден \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrCreateTenant) Error() string {
+	return fmt.Sprintf("ошибка создания тенанта \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrOrganizationNotFound) Error() string {
+	return fmt.Sprintf("организация не найдена \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrMemberNotFound) Error() string {
+	return fmt.Sprintf("участник организации не найден \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrInviteNotFound) Error() string {
+	return fmt.Sprintf("приглашение не найдено или истекло \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrImpersonationNotFound) Error() string {
+	return fmt.Sprintf("сессия входа под пользователем не найдена или завершена \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrJobNotFound) Error() string {
+	return fmt.Sprintf("задача не найдена \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrWebhookNotFound) Error() string {
+	return fmt.Sprintf("подписка на события не найдена \nerr: %s", e.ErrMessage)
+}
+
 // ------------- for security -------------
 
 type ErrPasswordEmpty struct {
@@ -181,3 +301,51 @@ func (e *ErrIncorrectJwt) Error() string {
 func (e *ErrInvalidJwtPayload) Error() string {
 	return fmt.Sprintf("недействительная полезная нагрузка JWT \nerr: %s", e.ErrMessage)
 }
+
+// ------------- for mail -------------
+
+type ErrMailTemplate struct {
+	ErrMessage any
+}
+
+type ErrMailSend struct {
+	ErrMessage any
+}
+
+// ------------- Error Func to mail -------------
+
+func (e *ErrMailTemplate) Error() string {
+	return fmt.Sprintf("ошибка шаблона письма \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrMailSend) Error() string {
+	return fmt.Sprintf("ошибка отправки письма \nerr: %s", e.ErrMessage)
+}
+
+// ------------- for captcha -------------
+
+type ErrCaptchaRequired struct {
+	ErrMessage any
+}
+
+type ErrCaptchaInvalid struct {
+	ErrMessage any
+}
+
+type ErrCaptchaUnavailable struct {
+	ErrMessage any
+}
+
+// ------------- Error Func to captcha -------------
+
+func (e *ErrCaptchaRequired) Error() string {
+	return fmt.Sprintf("требуется проверка captcha \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrCaptchaInvalid) Error() string {
+	return fmt.Sprintf("проверка captcha не пройдена \nerr: %s", e.ErrMessage)
+}
+
+func (e *ErrCaptchaUnavailable) Error() string {
+	return fmt.Sprintf("сервис captcha недоступен \nerr: %s", e.ErrMessage)
+}
@@ -0,0 +1,39 @@
+package core
+
+const (
+	// PermRBACRead - просмотр ролей и разрешений
+	PermRBACRead = "rbac:read"
+
+	// PermRBACManage - управление ролями, разрешениями и их назначением
+	PermRBACManage = "rbac:manage"
+
+	// PermAccountsRead - поиск и просмотр аккаунтов и регистраций
+	PermAccountsRead = "accounts:read"
+
+	// PermAccountsManage - принудительный выход, подтверждение регистрации, сброс пароля и блокировка аккаунтов
+	PermAccountsManage = "accounts:manage"
+
+	// PermTenantsManage - создание тенантов
+	PermTenantsManage = "tenants:manage"
+
+	// PermAccountsImpersonate - вход под аккаунтом пользователя и завершение таких сессий
+	PermAccountsImpersonate = "accounts:impersonate"
+
+	// PermAuditRead - просмотр и проверка целостности журнала событий безопасности
+	PermAuditRead = "audit:read"
+
+	// PermJobsRead - просмотр очереди фоновых задач
+	PermJobsRead = "jobs:read"
+
+	// PermJobsManage - повторный запуск задач, исчерпавших попытки
+	PermJobsManage = "jobs:manage"
+
+	// PermWebhooksRead - просмотр подписок на события и журнала доставки
+	PermWebhooksRead = "webhooks:read"
+
+	// PermWebhooksManage - создание, изменение и удаление подписок на события
+	PermWebhooksManage = "webhooks:manage"
+
+	// PermConfigRead - просмотр версии загруженной конфигурации
+	PermConfigRead = "config:read"
+)
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -15,20 +18,53 @@ var (
 	ErrAuditInsertFailed = errors.New("audit insert failed")
 )
 
+// PgConfig - подключение к PostgreSQL и размер пула соединений
+type PgConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Name     string `yaml:"name"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	SSLMode  string `yaml:"ssl_mode"` // disable, prefer, require, verify-ca или verify-full; пусто - значение pgx по умолчанию
+	// Pool
+	MinConns          int32         `yaml:"min_conns"`           // соединений, которые пул держит открытыми
+	MaxConns          int32         `yaml:"max_conns"`           // максимум соединений пула
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime"`   // после этого времени соединение закрывается и открывается заново
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time"`  // простаивающее дольше соединение сверх MinConns закрывается
+	HealthCheckPeriod time.Duration `yaml:"health_check_period"` // как часто проверяются простаивающие соединения; 0 - значение pgx по умолчанию
+}
+
+// DSN возвращает строку подключения в формате URL, логин и пароль экранируются.
+//
+// Возвращает:
+//   - строку подключения к базе данных
+func (c PgConfig) DSN() string {
+	dsn := url.URL{
+		Scheme: "postgresql",
+		User:   url.UserPassword(c.User, c.Password),
+		Host:   net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:   "/" + c.Name,
+	}
+	if c.SSLMode != "" {
+		dsn.RawQuery = url.Values{"sslmode": {c.SSLMode}}.Encode()
+	}
+	return dsn.String()
+}
+
 type PgRepo struct {
 	pool *pgxpool.Pool
-	dsn  string
+	cfg  PgConfig
 }
 
-// NewPgRepo создает новый экземпляр PgRepo с заданной строкой подключения.
+// NewPgRepo создает новый экземпляр PgRepo с заданными параметрами подключения.
 //
 // Параметры:
-//   - dsn: строка подключения к базе данных
+//   - cfg: параметры подключения к базе данных и пула соединений
 //
 // Возвращает:
 //   - указатель на новый экземпляр PgRepo
-func NewPgRepo(dsn string) *PgRepo {
-	return &PgRepo{dsn: dsn}
+func NewPgRepo(cfg PgConfig) *PgRepo {
+	return &PgRepo{cfg: cfg}
 }
 
 // InitPool инициализирует пул соединений к базе данных PostgreSQL.
@@ -39,14 +75,20 @@ func NewPgRepo(dsn string) *PgRepo {
 // Возвращает:
 //   - ошибку, если инициализация пула завершилась неудачей
 func (r *PgRepo) InitPool(ctx context.Context) error {
-	config, err := pgxpool.ParseConfig(r.dsn)
+	config, err := pgxpool.ParseConfig(r.cfg.DSN())
 	if err != nil {
 		return fmt.Errorf("failed to parse DSN: %w", err)
 	}
 
-	config.MinConns = 1
-	config.MaxConns = 2
-	config.MaxConnLifetime = 500 * time.Second
+	config.MinConns = r.cfg.MinConns
+	config.MaxConns = r.cfg.MaxConns
+	config.MaxConnLifetime = r.cfg.MaxConnLifetime
+	config.MaxConnIdleTime = r.cfg.MaxConnIdleTime
+	if r.cfg.HealthCheckPeriod > 0 {
+		config.HealthCheckPeriod = r.cfg.HealthCheckPeriod
+	}
+
+	config.ConnConfig.Tracer = newPgTracer(r.cfg.Name)
 
 	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
 		conn.TypeMap().RegisterDefaultPgType(json.RawMessage{}, "jsonb")
@@ -77,3 +119,35 @@ func (r *PgRepo) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
 	}
 	return r.pool.Acquire(ctx)
 }
+
+// Ping проверяет, что база данных доступна: берет соединение из пула и выполняет пустой запрос.
+//
+// Параметры:
+//   - ctx: контекст выполнения, ограничивает время проверки
+//
+// Возвращает:
+//   - ошибку, если пул не создан или база данных не ответила
+func (r *PgRepo) Ping(ctx context.Context) error {
+	if r.pool == nil {
+		return errors.New("connection pool is not initialized")
+	}
+	return r.pool.Ping(ctx)
+}
+
+// Stat возвращает статистику пула соединений для метрик.
+//
+// Возвращает:
+//   - указатель на статистику или nil, если пул еще не создан
+func (r *PgRepo) Stat() *pgxpool.Stat {
+	if r.pool == nil {
+		return nil
+	}
+	return r.pool.Stat()
+}
+
+// Close закрывает все соединения пула; ждет, пока выданные соединения вернутся в пул.
+func (r *PgRepo) Close() {
+	if r.pool != nil {
+		r.pool.Close()
+	}
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// pgTracer создает спаны для каждого запроса к базе данных и для ожидания соединения из пула, поэтому
+// в трассе видно, сколько времени ушло на сам запрос, а сколько - на ожидание свободного соединения.
+// Подключается к пулу в InitPool и покрывает все репозитории, очередь задач и хранилища, работающие через PgRepo.
+type pgTracer struct {
+	tracer trace.Tracer
+	dbName string
+}
+
+func newPgTracer(db_name string) *pgTracer {
+	return &pgTracer{
+		tracer: otel.Tracer("github.com/MedodsTechTask/app/core"),
+		dbName: db_name,
+	}
+}
+
+// TraceQueryStart реализует pgx.QueryTracer: начинает спан запроса с SQL операцией в атрибуте db.operation.name.
+func (t *pgTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	op := sqlOperation(data.SQL)
+	ctx, _ = t.tracer.Start(ctx, op, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system.name", "postgresql"),
+		attribute.String("db.namespace", t.dbName),
+		attribute.String("db.operation.name", op),
+		// Запросы параметризованы, значения параметров в текст не попадают
+		attribute.String("db.query.text", data.SQL),
+	))
+	return ctx
+}
+
+// TraceQueryEnd реализует pgx.QueryTracer: завершает спан запроса, ошибку записывает в спан.
+func (t *pgTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	} else {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
+
+// TraceAcquireStart реализует pgxpool.AcquireTracer: начинает спан ожидания соединения из пула.
+func (t *pgTracer) TraceAcquireStart(ctx context.Context, _ *pgxpool.Pool, _ pgxpool.TraceAcquireStartData) context.Context {
+	ctx, _ = t.tracer.Start(ctx, "pool.acquire", trace.WithAttributes(
+		attribute.String("db.system.name", "postgresql"),
+		attribute.String("db.namespace", t.dbName),
+	))
+	return ctx
+}
+
+// TraceAcquireEnd реализует pgxpool.AcquireTracer.
+func (t *pgTracer) TraceAcquireEnd(ctx context.Context, _ *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+}
+
+// sqlOperation возвращает первое ключевое слово запроса в верхнем регистре (SELECT, INSERT, WITH, ...),
+// пропуская пробелы и комментарии.
+//
+// Параметры:
+//   - sql: текст запроса
+//
+// Возвращает:
+//   - SQL операцию или "QUERY", если ее не удалось определить
+func sqlOperation(sql string) string {
+	for {
+		sql = strings.TrimLeftFunc(sql, unicode.IsSpace)
+		switch {
+		case strings.HasPrefix(sql, "--"):
+			_, sql, _ = strings.Cut(sql, "\n")
+		case strings.HasPrefix(sql, "/*"):
+			_, sql, _ = strings.Cut(sql, "*/")
+		default:
+			end := strings.IndexFunc(sql, func(r rune) bool { return !unicode.IsLetter(r) })
+			if end < 0 {
+				end = len(sql)
+			}
+			if end == 0 {
+				return "QUERY"
+			}
+			return strings.ToUpper(sql[:end])
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSqlOperation(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT 1", "SELECT"},
+		{"  \n\tinsert into accounts values ($1)", "INSERT"},
+		{"WITH expired AS (UPDATE jobs SET status = 'dead') SELECT 1", "WITH"},
+		{"-- name: ClaimJobs\nUPDATE jobs SET status = 'running'", "UPDATE"},
+		{"/* tenant */ DELETE FROM sessions", "DELETE"},
+		{"/* a */ -- b\n  select(1)", "SELECT"},
+		{"", "QUERY"},
+		{"(SELECT 1)", "QUERY"},
+		{"-- only a comment", "QUERY"},
+	}
+
+	for _, tt := range tests {
+		if got := sqlOperation(tt.sql); got != tt.want {
+			t.Errorf("sqlOperation(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestPgTracerSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := newPgTracer("auth")
+	tracer.tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "UPDATE sessions SET revoked = true WHERE id = $1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 2")})
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT * FROM accounts"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("connection reset")})
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(spans))
+	}
+	attrs := func(i int) map[attribute.Key]attribute.Value {
+		res := map[attribute.Key]attribute.Value{}
+		for _, kv := range spans[i].Attributes() {
+			res[kv.Key] = kv.Value
+		}
+		return res
+	}
+
+	update := attrs(0)
+	if spans[0].Name() != "UPDATE" || update["db.operation.name"].AsString() != "UPDATE" || update["db.namespace"].AsString() != "auth" {
+		t.Errorf("update span = %s %v", spans[0].Name(), update)
+	}
+	if update["db.rows_affected"].AsInt64() != 2 {
+		t.Errorf("rows affected = %v, want 2", update["db.rows_affected"])
+	}
+	if spans[0].Status().Code != codes.Unset {
+		t.Errorf("update status = %v, want unset", spans[0].Status())
+	}
+
+	if spans[1].Name() != "SELECT" || spans[1].Status().Code != codes.Error || len(spans[1].Events()) != 1 {
+		t.Errorf("failed select span = %s %v, events %d; want error status and one event", spans[1].Name(), spans[1].Status(), len(spans[1].Events()))
+	}
+	if _, ok := attrs(1)["db.rows_affected"]; ok {
+		t.Error("failed query has db.rows_affected")
+	}
+}
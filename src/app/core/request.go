@@ -0,0 +1,81 @@
+package core
+
+import "context"
+
+type clientCtxKey struct{}
+
+type actorCtxKey struct{}
+
+// RequestClient - сетевые данные клиента запроса
+type RequestClient struct {
+	IP        string
+	UserAgent string
+	Locale    string // значение заголовка Accept-Language
+}
+
+// WithClient возвращает контекст, в котором сохранены IP-адрес и User-Agent клиента запроса.
+// Язык клиента, сохраненный ранее, переносится в новый контекст.
+//
+// Параметры:
+//   - ctx: родительский контекст
+//   - ip: IP-адрес клиента
+//   - user_agent: заголовок User-Agent клиента
+//
+// Возвращает:
+//   - дочерний контекст с данными клиента
+func WithClient(ctx context.Context, ip string, user_agent string) context.Context {
+	client := ClientFromContext(ctx)
+	client.IP, client.UserAgent = ip, user_agent
+	return context.WithValue(ctx, clientCtxKey{}, client)
+}
+
+// WithLocale возвращает контекст, в котором сохранен язык клиента для писем и сообщений.
+//
+// Параметры:
+//   - ctx: родительский контекст
+//   - locale: значение заголовка Accept-Language
+//
+// Возвращает:
+//   - дочерний контекст с языком клиента
+func WithLocale(ctx context.Context, locale string) context.Context {
+	client := ClientFromContext(ctx)
+	client.Locale = locale
+	return context.WithValue(ctx, clientCtxKey{}, client)
+}
+
+// ClientFromContext возвращает данные клиента, сохраненные WithClient.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//
+// Возвращает:
+//   - данные клиента; пустые, если они не были сохранены
+func ClientFromContext(ctx context.Context) RequestClient {
+	client, _ := ctx.Value(clientCtxKey{}).(RequestClient)
+	return client
+}
+
+// WithActor возвращает контекст, в котором сохранен идентификатор аккаунта, выполняющего запрос.
+// При входе администратора под пользователем это идентификатор администратора.
+//
+// Параметры:
+//   - ctx: родительский контекст
+//   - actor_id: идентификатор аккаунта
+//
+// Возвращает:
+//   - дочерний контекст с идентификатором аккаунта
+func WithActor(ctx context.Context, actor_id string) context.Context {
+	return context.WithValue(ctx, actorCtxKey{}, actor_id)
+}
+
+// ActorFromContext возвращает идентификатор аккаунта, сохраненный WithActor.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//
+// Возвращает:
+//   - идентификатор аккаунта; пустая строка для неавторизованных запросов
+func ActorFromContext(ctx context.Context) string {
+	actor_id, _ := ctx.Value(actorCtxKey{}).(string)
+	return actor_id
+}
@@ -0,0 +1,40 @@
+package core
+
+import "context"
+
+const (
+	// DefaultTenantID - ID тенанта по умолчанию, создается миграцией 0004-tenants.sql
+	DefaultTenantID = "00000000-0000-0000-0000-000000000001"
+
+	// TenantHeader - заголовок, в котором клиент передает slug или ID тенанта
+	TenantHeader = "X-Tenant-ID"
+)
+
+type tenantCtxKey struct{}
+
+// WithTenant возвращает контекст, в котором сохранен идентификатор тенанта запроса.
+//
+// Параметры:
+//   - ctx: родительский контекст
+//   - tenant_id: идентификатор тенанта
+//
+// Возвращает:
+//   - дочерний контекст с идентификатором тенанта
+func WithTenant(ctx context.Context, tenant_id string) context.Context {
+	return context.WithValue(ctx, tenantCtxKey{}, tenant_id)
+}
+
+// TenantFromContext возвращает идентификатор тенанта, сохраненный WithTenant.
+// Если тенант не задан (например, в однотенантной установке без middleware), возвращает DefaultTenantID.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//
+// Возвращает:
+//   - идентификатор тенанта
+func TenantFromContext(ctx context.Context) string {
+	if tenant_id, ok := ctx.Value(tenantCtxKey{}).(string); ok && tenant_id != "" {
+		return tenant_id
+	}
+	return DefaultTenantID
+}
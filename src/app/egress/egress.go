@@ -0,0 +1,164 @@
+package egress
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbidden - адрес назначения входит во внутренние или служебные сети
+var ErrForbidden = errors.New("destination address is not allowed")
+
+// denied - сети, не покрытые методами netip.Addr: служебные, документационные и трансляции, через которые
+// можно попасть во внутреннюю сеть IPv4
+var denied = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "эта" сеть
+	netip.MustParsePrefix("100.64.0.0/10"),   // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // назначения IETF
+	netip.MustParsePrefix("192.0.2.0/24"),    // TEST-NET-1
+	netip.MustParsePrefix("198.18.0.0/15"),   // тестирование производительности
+	netip.MustParsePrefix("198.51.100.0/24"), // TEST-NET-2
+	netip.MustParsePrefix("203.0.113.0/24"),  // TEST-NET-3
+	netip.MustParsePrefix("240.0.0.0/4"),     // зарезервировано, включая broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // локальный NAT64
+	netip.MustParsePrefix("100::/64"),        // discard
+	netip.MustParsePrefix("2001:db8::/32"),   // документация
+	netip.MustParsePrefix("2002::/16"),       // 6to4
+	netip.MustParsePrefix("fec0::/10"),       // site-local
+}
+
+// Guard не дает исходящим запросам уходить во внутренние сети: loopback, частные, link-local (в том числе
+// адрес метаданных облака 169.254.169.254), CGNAT, multicast и служебные диапазоны. Адрес проверяется
+// в момент подключения, уже после разрешения имени, поэтому смена DNS записи между проверкой URL
+// и запросом (DNS rebinding) не помогает обойти запрет.
+type Guard struct {
+	allowed []netip.Prefix
+}
+
+// NewGuard разбирает список сетей, в которые запросы разрешены несмотря на запрет, например 127.0.0.1
+// для получателя на машине разработчика.
+//
+// Параметры:
+//   - allowed: адреса и подсети (CIDR); пусто - разрешены только публичные адреса
+//
+// Возвращает:
+//   - указатель на новый экземпляр Guard
+//   - ошибку, если адрес или подсеть записаны неверно
+func NewGuard(allowed []string) (*Guard, error) {
+	res := &Guard{}
+	for _, raw := range allowed {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if strings.Contains(raw, "/") {
+			prefix, err := netip.ParsePrefix(raw)
+			if err != nil {
+				return nil, fmt.Errorf("allowed network %q: %w", raw, err)
+			}
+			if prefix.Addr().Is4In6() {
+				prefix = netip.PrefixFrom(prefix.Addr().Unmap(), max(prefix.Bits()-96, 0))
+			}
+			res.allowed = append(res.allowed, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(raw)
+		if err != nil {
+			return nil, fmt.Errorf("allowed network %q: %w", raw, err)
+		}
+		addr = addr.Unmap().WithZone("")
+		res.allowed = append(res.allowed, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return res, nil
+}
+
+// Permitted сообщает, можно ли подключаться к адресу.
+//
+// Параметры:
+//   - addr: адрес назначения
+//
+// Возвращает:
+//   - true, если адрес публичный или входит в разрешенные сети
+func (g *Guard) Permitted(addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range g.allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range denied {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost проверяет хост из URL, если это IP адрес. Имена проверяются только при подключении,
+// когда известен адрес, к которому они разрешились.
+//
+// Параметры:
+//   - host: хост URL без порта
+//
+// Возвращает:
+//   - ErrForbidden, если хост - запрещенный IP адрес
+func (g *Guard) CheckHost(host string) error {
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+	if err != nil {
+		return nil
+	}
+	if !g.Permitted(addr) {
+		return fmt.Errorf("%w: %s", ErrForbidden, addr)
+	}
+	return nil
+}
+
+// Control проверяет адрес перед подключением, подходит для net.Dialer.Control.
+//
+// Параметры:
+//   - network: сеть подключения
+//   - address: адрес с портом, к которому разрешилось имя
+//
+// Возвращает:
+//   - ErrForbidden, если адрес запрещен
+func (g *Guard) Control(network string, address string, _ syscall.RawConn) error {
+	addrport, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbidden, address)
+	}
+	if !g.Permitted(addrport.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbidden, addrport.Addr())
+	}
+	return nil
+}
+
+// Client создает HTTP клиент, который подключается только к разрешенным адресам. Прокси из окружения
+// не используется: через него адрес назначения не проверить. Перенаправления не выполняются, ответ 3xx
+// возвращается как есть.
+//
+// Возвращает:
+//   - указатель на новый экземпляр http.Client
+func (g *Guard) Client() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   g.Control,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
@@ -0,0 +1,172 @@
+package egress
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestPermitted(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"93.184.216.34", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"127.1.2.3", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"198.18.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"2002:a9fe:a9fe::1", false},
+	}
+	g, err := NewGuard(nil)
+	if err != nil {
+		t.Fatalf("NewGuard: %s", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := g.Permitted(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("Permitted(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowedNetworks(t *testing.T) {
+	g, err := NewGuard([]string{" 127.0.0.1", "", "::ffff:10.0.0.0/104"})
+	if err != nil {
+		t.Fatalf("NewGuard: %s", err)
+	}
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"::ffff:127.0.0.1", true},
+		{"127.0.0.2", false},
+		{"10.20.30.40", true},
+		{"169.254.169.254", false},
+		{"8.8.8.8", true},
+	}
+	for _, tt := range tests {
+		if got := g.Permitted(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("Permitted(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"localhost", "10.0.0.0/33", "10.0.0.1/8/8"} {
+		if _, err := NewGuard([]string{bad}); err == nil {
+			t.Errorf("NewGuard(%q) = nil error", bad)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	g, _ := NewGuard(nil)
+	tests := []struct {
+		host      string
+		forbidden bool
+	}{
+		{"example.com", false},
+		{"localhost", false}, // имя проверяется при подключении
+		{"8.8.8.8", false},
+		{"169.254.169.254", true},
+		{"[::1]", true},
+		{"::1", true},
+		{"[2606:4700:4700::1111]", false},
+	}
+	for _, tt := range tests {
+		err := g.CheckHost(tt.host)
+		if tt.forbidden != errors.Is(err, ErrForbidden) {
+			t.Errorf("CheckHost(%q) = %v, want forbidden %v", tt.host, err, tt.forbidden)
+		}
+	}
+}
+
+func TestControl(t *testing.T) {
+	g, _ := NewGuard(nil)
+	tests := []struct {
+		address   string
+		forbidden bool
+	}{
+		{"8.8.8.8:443", false},
+		{"[2606:4700:4700::1111]:443", false},
+		{"127.0.0.1:80", true},
+		{"169.254.169.254:80", true},
+		{"[::1]:8080", true},
+		{"not an address", true},
+	}
+	for _, tt := range tests {
+		err := g.Control("tcp", tt.address, nil)
+		if tt.forbidden != errors.Is(err, ErrForbidden) {
+			t.Errorf("Control(%q) = %v, want forbidden %v", tt.address, err, tt.forbidden)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	port := srv.URL[strings.LastIndex(srv.URL, ":"):]
+
+	denied, _ := NewGuard(nil)
+	allowed, _ := NewGuard([]string{"127.0.0.0/8", "::1"})
+	tests := []struct {
+		name   string
+		guard  *Guard
+		url    string
+		status int
+	}{
+		{"loopback address", denied, srv.URL, 0},
+		// Имя разрешается в loopback уже после проверки URL, запрос отклоняется при подключении
+		{"name resolving to loopback", denied, "http://localhost" + port, 0},
+		{"allowed network", allowed, srv.URL, http.StatusNoContent},
+		{"allowed network by name", allowed, "http://localhost" + port, http.StatusNoContent},
+		{"redirect is not followed", allowed, srv.URL + "/redirect", http.StatusFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.guard.Client().Get(tt.url)
+			if tt.status == 0 {
+				if !errors.Is(err, ErrForbidden) {
+					t.Errorf("Get(%s) error = %v, want ErrForbidden", tt.url, err)
+				}
+				if resp != nil {
+					resp.Body.Close()
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get(%s): %s", tt.url, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("Get(%s) status = %d, want %d", tt.url, resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
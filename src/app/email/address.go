@@ -0,0 +1,102 @@
+package email
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	// maxLocalLen - максимальная длина локальной части по RFC 5321
+	maxLocalLen = 64
+	// maxAddressLen - максимальная длина адреса в команде SMTP по RFC 5321
+	maxAddressLen = 254
+)
+
+var (
+	// ErrInvalid - строка не является адресом email
+	ErrInvalid = errors.New("invalid email address")
+	// ErrDisposable - адрес на домене одноразовой почты
+	ErrDisposable = errors.New("disposable email domain")
+)
+
+// profile - преобразование IDN доменов по правилам IDNA2008 для регистрации, как его выполняют браузеры
+var profile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.ValidateLabels(true),
+	idna.StrictDomainName(true),
+	idna.Transitional(false),
+)
+
+// Address - разобранный адрес email
+type Address struct {
+	Local     string // локальная часть в том виде, в каком ее ввел пользователь
+	Domain    string // домен в ASCII (punycode) в нижнем регистре
+	Canonical string // каноническая форма для проверки уникальности: локальная часть и домен в нижнем регистре, домен в ASCII
+}
+
+// String возвращает адрес для хранения и отправки писем: локальная часть как введена, домен в ASCII.
+func (a *Address) String() string {
+	return a.Local + "@" + a.Domain
+}
+
+// Parse разбирает адрес email по RFC 5322 (addr-spec без отображаемого имени и комментариев;
+// локальная часть в кавычках и домен-литерал [IP] не принимаются),
+// переводит IDN домен в punycode и вычисляет каноническую форму.
+//
+// Параметры:
+//   - raw: адрес, введенный пользователем
+//
+// Возвращает:
+//   - указатель на структуру Address
+//   - ErrInvalid, если строка не является одиночным адресом email
+func Parse(raw string) (*Address, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || utf8.RuneCountInString(raw) > maxAddressLen {
+		return nil, ErrInvalid
+	}
+
+	parsed, err := mail.ParseAddress(raw)
+	if err != nil || parsed.Name != "" || parsed.Address != raw {
+		return nil, ErrInvalid
+	}
+
+	at := strings.LastIndexByte(parsed.Address, '@')
+	local, domain := parsed.Address[:at], parsed.Address[at+1:]
+	if local == "" || len(local) > maxLocalLen || strings.HasPrefix(domain, "[") {
+		return nil, ErrInvalid
+	}
+
+	ascii, err := profile.ToASCII(domain)
+	if err != nil || !strings.Contains(ascii, ".") {
+		return nil, ErrInvalid
+	}
+	ascii = strings.ToLower(strings.TrimSuffix(ascii, "."))
+
+	addr := &Address{Local: local, Domain: ascii}
+	addr.Canonical = strings.ToLower(local) + "@" + ascii
+	if len(addr.String()) > maxAddressLen {
+		return nil, ErrInvalid
+	}
+	return addr, nil
+}
+
+// Canonical возвращает каноническую форму адреса для поиска. Если строка не разбирается как адрес,
+// возвращается она же в нижнем регистре, чтобы поиск просто ничего не нашел.
+//
+// Параметры:
+//   - raw: адрес, введенный пользователем
+//
+// Возвращает:
+//   - каноническую форму адреса
+func Canonical(raw string) string {
+	addr, err := Parse(raw)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(raw))
+	}
+	return addr.Canonical
+}
@@ -0,0 +1,84 @@
+package email
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw       string
+		local     string
+		domain    string
+		canonical string
+	}{
+		{"john@example.com", "john", "example.com", "john@example.com"},
+		{"John.Doe@Example.COM", "John.Doe", "example.com", "john.doe@example.com"},
+		{"  john@example.com\t", "john", "example.com", "john@example.com"},
+		{"john+tag@mail.example.com", "john+tag", "mail.example.com", "john+tag@mail.example.com"},
+		{"o'brien@example.ie", "o'brien", "example.ie", "o'brien@example.ie"},
+		{"user@пример.рф", "user", "xn--e1afmkfd.xn--p1ai", "user@xn--e1afmkfd.xn--p1ai"},
+		{"user@ПРИМЕР.РФ", "user", "xn--e1afmkfd.xn--p1ai", "user@xn--e1afmkfd.xn--p1ai"},
+		{"user@Bücher.de", "user", "xn--bcher-kva.de", "user@xn--bcher-kva.de"},
+		{"user@xn--bcher-kva.de", "user", "xn--bcher-kva.de", "user@xn--bcher-kva.de"},
+		{"Почта@Example.com", "Почта", "example.com", "почта@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			addr, err := Parse(tt.raw)
+			if err != nil {
+				t.Fatalf("Parse(%q): %s", tt.raw, err)
+			}
+			if addr.Local != tt.local || addr.Domain != tt.domain || addr.Canonical != tt.canonical {
+				t.Errorf("Parse(%q) = %+v, want local %q, domain %q, canonical %q", tt.raw, addr, tt.local, tt.domain, tt.canonical)
+			}
+			if got := addr.String(); got != tt.local+"@"+tt.domain {
+				t.Errorf("String() = %q", got)
+			}
+			if got := Canonical(tt.raw); got != tt.canonical {
+				t.Errorf("Canonical(%q) = %q, want %q", tt.raw, got, tt.canonical)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"empty", ""},
+		{"spaces", "   "},
+		{"no at", "john.example.com"},
+		{"no local part", "@example.com"},
+		{"no domain", "john@"},
+		{"two at", "john@doe@example.com"},
+		{"single label domain", "john@localhost"},
+		{"display name", "John <john@example.com>"},
+		{"comment", "john@example.com (John)"},
+		{"list", "john@example.com, jane@example.com"},
+		{"quoted local part", `"john doe"@example.com`},
+		{"needless quotes", `"john"@example.com`},
+		{"domain literal", "john@[127.0.0.1]"},
+		{"leading dot", ".john@example.com"},
+		{"double dot", "john..doe@example.com"},
+		{"underscore in domain", "john@exa_mple.com"},
+		{"hyphen at label start", "john@-example.com"},
+		{"long local part", strings.Repeat("a", 65) + "@example.com"},
+		{"long address", "john@" + strings.Repeat("a", 62) + "." + strings.Repeat("b", 62) + "." + strings.Repeat("c", 62) + "." + strings.Repeat("d", 62) + ".com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if addr, err := Parse(tt.raw); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Parse(%q) = %+v, %v, want ErrInvalid", tt.raw, addr, err)
+			}
+		})
+	}
+}
+
+func TestCanonicalInvalid(t *testing.T) {
+	if got := Canonical("  Not An Email "); got != "not an email" {
+		t.Errorf("Canonical = %q, want %q", got, "not an email")
+	}
+}
@@ -0,0 +1,102 @@
+package email
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Config - настройки проверки адресов email
+type Config struct {
+	BlockDisposable   bool     `yaml:"block_disposable"`   // отклонять адреса на доменах одноразовой почты
+	DisposableDomains []string `yaml:"disposable_domains"` // домены одноразовой почты, поддомены блокируются вместе с доменом
+	// DisposableFile - файл с дополнительными доменами, по одному в строке, строки с # пропускаются.
+	// Пустая строка - только DisposableDomains
+	DisposableFile string `yaml:"disposable_file"`
+}
+
+// Validator разбирает адреса email и проверяет их по списку доменов одноразовой почты.
+type Validator struct {
+	cfg        Config
+	disposable map[string]struct{}
+}
+
+// NewValidator создает валидатор адресов и загружает список доменов одноразовой почты.
+//
+// Параметры:
+//   - cfg: настройки проверки
+//
+// Возвращает:
+//   - указатель на новый экземпляр Validator
+//   - ошибку чтения файла доменов
+func NewValidator(cfg Config) (*Validator, error) {
+	v := &Validator{cfg: cfg, disposable: map[string]struct{}{}}
+	for _, d := range cfg.DisposableDomains {
+		v.addDisposable(d)
+	}
+	if cfg.DisposableFile != "" {
+		f, err := os.Open(cfg.DisposableFile)
+		if err != nil {
+			return nil, fmt.Errorf("open disposable domains list: %w", err)
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			v.addDisposable(line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read disposable domains list: %w", err)
+		}
+	}
+	return v, nil
+}
+
+// Validate разбирает адрес и проверяет, что он не на домене одноразовой почты.
+//
+// Параметры:
+//   - raw: адрес, введенный пользователем
+//
+// Возвращает:
+//   - указатель на структуру Address
+//   - ErrInvalid или ErrDisposable
+func (v *Validator) Validate(raw string) (*Address, error) {
+	addr, err := Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if v.cfg.BlockDisposable && v.isDisposable(addr.Domain) {
+		return nil, ErrDisposable
+	}
+	return addr, nil
+}
+
+// addDisposable добавляет домен в список одноразовых в той же ASCII форме, что и Address.Domain.
+func (v *Validator) addDisposable(domain string) {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if ascii, err := profile.ToASCII(domain); err == nil {
+		domain = strings.ToLower(ascii)
+	}
+	if domain != "" {
+		v.disposable[domain] = struct{}{}
+	}
+}
+
+// isDisposable проверяет домен и все его родительские домены.
+func (v *Validator) isDisposable(domain string) bool {
+	for {
+		if _, ok := v.disposable[domain]; ok {
+			return true
+		}
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			return false
+		}
+		domain = domain[dot+1:]
+	}
+}
@@ -0,0 +1,62 @@
+package email
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidatorDisposable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disposable.txt")
+	if err := os.WriteFile(path, []byte("# список для теста\n\n  Trash.Example \nпочта.рф\n"), 0o600); err != nil {
+		t.Fatalf("write disposable list: %s", err)
+	}
+	v, err := NewValidator(Config{
+		BlockDisposable:   true,
+		DisposableDomains: []string{"mailinator.com", "TempMail.org."},
+		DisposableFile:    path,
+	})
+	if err != nil {
+		t.Fatalf("NewValidator: %s", err)
+	}
+
+	tests := []struct {
+		raw  string
+		want error
+	}{
+		{"john@example.com", nil},
+		{"john@mailinator.com", ErrDisposable},
+		{"john@MAILINATOR.com", ErrDisposable},
+		{"john@eu.mailinator.com", ErrDisposable},
+		{"john@notmailinator.com", nil},
+		{"john@tempmail.org", ErrDisposable},
+		{"john@trash.example", ErrDisposable},
+		{"john@почта.рф", ErrDisposable},
+		{"john@xn--80a1acny.xn--p1ai", ErrDisposable},
+		{"not an email", ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			if _, err := v.Validate(tt.raw); !errors.Is(err, tt.want) {
+				t.Errorf("Validate(%q) error = %v, want %v", tt.raw, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatorDisposableOff(t *testing.T) {
+	v, err := NewValidator(Config{DisposableDomains: []string{"mailinator.com"}})
+	if err != nil {
+		t.Fatalf("NewValidator: %s", err)
+	}
+	if _, err := v.Validate("john@mailinator.com"); err != nil {
+		t.Errorf("Validate with BlockDisposable off: %s", err)
+	}
+}
+
+func TestNewValidatorMissingFile(t *testing.T) {
+	if _, err := NewValidator(Config{DisposableFile: filepath.Join(t.TempDir(), "missing.txt")}); err == nil {
+		t.Errorf("NewValidator with missing file: want error")
+	}
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// BrokerNATS - публикация в NATS JetStream
+	BrokerNATS = "nats"
+	// BrokerMemory - доставка подписчикам внутри процесса, для тестов
+	BrokerMemory = "memory"
+)
+
+// Message - сообщение для брокера
+type Message struct {
+	Subject string // тема, например auth.account.created
+	ID      string // идентификатор события для дедупликации
+	Key     string // ключ упорядочивания, идентификатор аккаунта
+	Data    []byte // Envelope в JSON
+}
+
+// Broker публикует сообщения. Publish возвращает nil только после того, как брокер принял сообщение,
+// иначе relay повторит публикацию.
+type Broker interface {
+	Publish(ctx context.Context, msg *Message) error
+	Close() error
+}
+
+// Config - настройки публикации событий
+type Config struct {
+	Broker string `yaml:"broker"` // nats или memory
+	// NATS
+	NATSURL       string `yaml:"nats_url"`
+	SubjectPrefix string `yaml:"subject_prefix"` // префикс темы, полная тема - <prefix>.<тип события>
+	NATSStream    string `yaml:"nats_stream"`    // поток JetStream; создается с темами <prefix>.>, если его нет
+	// Relay
+	PollInterval time.Duration `yaml:"poll_interval"` // пауза между опросами пустого outbox
+	BatchSize    int           `yaml:"batch_size"`    // сколько событий публикуется за одну транзакцию relay
+	BackoffBase  time.Duration `yaml:"backoff_base"`  // задержка после первой неудачной публикации, дальше удваивается
+	BackoffMax   time.Duration `yaml:"backoff_max"`   // максимальная задержка между попытками
+	Retention    time.Duration `yaml:"retention"`     // сколько хранить опубликованные события; 0 - не удалять
+}
+
+// NewBroker создает брокер, указанный в конфигурации.
+//
+// Параметры:
+//   - cfg: настройки публикации событий
+//
+// Возвращает:
+//   - Broker, готовый к публикации
+//   - ошибку, если брокер неизвестен или не удалось подключиться
+func NewBroker(cfg Config) (Broker, error) {
+	switch cfg.Broker {
+	case BrokerNATS:
+		return NewNATSBroker(cfg.NATSURL, cfg.NATSStream, cfg.SubjectPrefix)
+	case BrokerMemory:
+		return NewMemoryBroker(), nil
+	default:
+		return nil, fmt.Errorf("unknown event broker: %q", cfg.Broker)
+	}
+}
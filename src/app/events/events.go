@@ -0,0 +1,107 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// AccountCreated - аккаунт создан после подтверждения регистрации, данные AccountCreatedData
+	AccountCreated = "account.created"
+	// AccountEmailChanged - email аккаунта изменен, данные EmailChangedData.
+	// Зарезервировано: в сервисе пока нет смены email, тип объявлен, чтобы потребители знали контракт
+	AccountEmailChanged = "account.email_changed"
+	// AccountStatusChanged - администратор изменил статус аккаунта, данные StatusChangedData
+	AccountStatusChanged = "account.status_changed"
+	// AccountPasswordChanged - пароль аккаунта изменен, данные PasswordChangedData
+	AccountPasswordChanged = "account.password_changed"
+	// SessionRevoked - refresh токены аккаунта отозваны, данные SessionRevokedData
+	SessionRevoked = "session.revoked"
+)
+
+// AccountCreatedData - данные события account.created
+type AccountCreatedData struct {
+	Email  string `json:"email"`
+	Status string `json:"status"`
+}
+
+// EmailChangedData - данные события account.email_changed
+type EmailChangedData struct {
+	OldEmail string `json:"old_email"`
+	NewEmail string `json:"new_email"`
+}
+
+// StatusChangedData - данные события account.status_changed
+type StatusChangedData struct {
+	Status string `json:"status"`
+}
+
+// PasswordChangedData - данные события account.password_changed
+type PasswordChangedData struct {
+	Method string `json:"method"` // способ смены, например password_reset
+}
+
+// SessionRevokedData - данные события session.revoked
+type SessionRevokedData struct {
+	Reason   string `json:"reason"`   // причина отзыва, как в журнале безопасности
+	Sessions int    `json:"sessions"` // количество отозванных токенов
+}
+
+// DB - соединение или транзакция pgx. Событие записывается в той же транзакции, что и изменение состояния.
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// NewEvent - событие для записи в outbox
+type NewEvent struct {
+	TenantID  string
+	Type      string // одна из констант событий
+	AccountID string // ключ упорядочивания: события одного аккаунта публикуются в порядке записи
+	Data      any    // сериализуется в JSON
+}
+
+// Envelope - сообщение, которое получают потребители. ID одинаков при повторной доставке
+// одного события, по нему потребители отбрасывают дубликаты.
+type Envelope struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	TenantID   string          `json:"tenant_id"`
+	AccountID  string          `json:"account_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Append записывает событие в outbox через переданное соединение или транзакцию.
+// Если db - транзакция, событие будет опубликовано только после ее коммита.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - db: соединение или транзакция
+//   - ev: событие
+//
+// Возвращает:
+//   - ошибку, если данные не сериализуются или произошла ошибка базы данных
+func Append(ctx context.Context, db DB, ev NewEvent) error {
+	const q = `
+		INSERT INTO "Outbox"
+		(
+			tenant_id
+			, type
+			, account_id
+			, payload
+		)
+		VALUES ($1, $2, $3, $4);
+	`
+
+	payload, err := json.Marshal(ev.Data)
+	if err != nil {
+		return fmt.Errorf("encode payload of %s event: %w", ev.Type, err)
+	}
+
+	_, err = db.Exec(ctx, q, ev.TenantID, ev.Type, ev.AccountID, json.RawMessage(payload))
+	return err
+}
@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// SubscriberFunc получает сообщение внутри процесса. Ошибка подписчика возвращается из Publish,
+// поэтому relay повторит публикацию, как при недоступном брокере.
+type SubscriberFunc func(ctx context.Context, msg *Message) error
+
+// MemoryBroker хранит опубликованные сообщения в памяти и синхронно вызывает подписчиков. Для тестов.
+type MemoryBroker struct {
+	mu          sync.Mutex
+	messages    []Message
+	subscribers []SubscriberFunc
+}
+
+// NewMemoryBroker создает брокер внутри процесса.
+//
+// Возвращает:
+//   - указатель на новый экземпляр MemoryBroker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+// Subscribe добавляет подписчика на все сообщения.
+//
+// Параметры:
+//   - fn: подписчик
+func (b *MemoryBroker) Subscribe(fn SubscriberFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// Publish вызывает подписчиков по порядку и сохраняет сообщение, если все они его приняли.
+func (b *MemoryBroker) Publish(ctx context.Context, msg *Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, fn := range b.subscribers {
+		if err := fn(ctx, msg); err != nil {
+			return err
+		}
+	}
+	b.messages = append(b.messages, *msg)
+	return nil
+}
+
+// Messages возвращает копию опубликованных сообщений в порядке публикации.
+func (b *MemoryBroker) Messages() []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Message(nil), b.messages...)
+}
+
+// Reset удаляет сохраненные сообщения.
+func (b *MemoryBroker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = nil
+}
+
+// Close ничего не делает.
+func (b *MemoryBroker) Close() error {
+	return nil
+}
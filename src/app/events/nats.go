@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSBroker публикует события в NATS JetStream. JetStream подтверждает прием каждого сообщения,
+// а заголовок Nats-Msg-Id позволяет потоку отбросить повторную публикацию в окне дедупликации.
+type NATSBroker struct {
+	conn *nats.Conn
+	js   nats.JetStreamContext
+}
+
+// NewNATSBroker подключается к NATS и проверяет наличие потока. Если сервер недоступен при старте,
+// подключение повторяется в фоне, а публикации до подключения завершаются ошибкой и повторяются relay.
+//
+// Параметры:
+//   - url: адрес сервера, например nats://localhost:4222
+//   - stream: имя потока JetStream; пустое - поток должен быть создан заранее
+//   - prefix: префикс тем событий
+//
+// Возвращает:
+//   - указатель на новый экземпляр NATSBroker
+//   - ошибку, если не удалось подключиться или создать поток
+func NewNATSBroker(url string, stream string, prefix string) (*NATSBroker, error) {
+	conn, err := nats.Connect(url, nats.RetryOnFailedConnect(true), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("nats connect: %w", err)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("nats jetstream: %w", err)
+	}
+
+	if stream != "" && conn.IsConnected() {
+		_, err := js.StreamInfo(stream)
+		if errors.Is(err, nats.ErrStreamNotFound) {
+			_, err = js.AddStream(&nats.StreamConfig{
+				Name:     stream,
+				Subjects: []string{prefix + ".>"},
+				Storage:  nats.FileStorage,
+			})
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("nats stream %s: %w", stream, err)
+		}
+	}
+
+	return &NATSBroker{conn: conn, js: js}, nil
+}
+
+// Publish публикует сообщение и ждет подтверждения JetStream.
+func (b *NATSBroker) Publish(ctx context.Context, msg *Message) error {
+	m := nats.NewMsg(msg.Subject)
+	m.Data = msg.Data
+	m.Header.Set("Account-Id", msg.Key)
+
+	_, err := b.js.PublishMsg(m, nats.MsgId(msg.ID), nats.Context(ctx))
+	return err
+}
+
+// Close дожидается отправки буфера и закрывает соединение.
+func (b *NATSBroker) Close() error {
+	return b.conn.Drain()
+}
@@ -0,0 +1,313 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+)
+
+// relayLockKey - ключ advisory lock, под которым работает relay. Одновременно события публикует
+// только один экземпляр сервиса, иначе события одного аккаунта могли бы уйти не по порядку.
+const relayLockKey = 7_033_001
+
+// cleanupInterval - как часто relay удаляет опубликованные события старше Retention
+const cleanupInterval = time.Minute
+
+// Relay публикует события из outbox в брокер. Доставка at-least-once: событие помечается опубликованным
+// только после подтверждения брокера, поэтому после сбоя между публикацией и коммитом оно уйдет повторно.
+type Relay struct {
+	pgRepo *core.PgRepo
+	broker Broker
+	cfg    Config
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// pending - неопубликованное событие outbox
+type pending struct {
+	id         int64
+	tenantID   string
+	kind       string
+	accountID  string
+	payload    json.RawMessage
+	attempts   int
+	occurredAt time.Time
+}
+
+// NewRelay создает relay поверх пула соединений.
+//
+// Параметры:
+//   - pgRepo: пул соединений с базой данных
+//   - broker: брокер, в который публикуются события
+//   - cfg: настройки публикации событий
+//
+// Возвращает:
+//   - указатель на новый экземпляр Relay
+func NewRelay(pgRepo *core.PgRepo, broker Broker, cfg Config) *Relay {
+	return &Relay{pgRepo: pgRepo, broker: broker, cfg: cfg}
+}
+
+// Start запускает опрос outbox до вызова Shutdown.
+func (r *Relay) Start() {
+	ctx, stop := context.WithCancel(context.Background())
+	r.stop = stop
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.loop(ctx)
+	}()
+}
+
+// Shutdown останавливает опрос и ждет завершения текущей пачки. Неопубликованные события
+// останутся в outbox и будут опубликованы после перезапуска.
+//
+// Параметры:
+//   - ctx: ограничение времени ожидания
+//
+// Возвращает:
+//   - ошибку ctx, если пачка не успела завершиться
+func (r *Relay) Shutdown(ctx context.Context) error {
+	if r.stop == nil {
+		return nil
+	}
+	r.stop()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loop публикует пачки, пока они не пустые, затем ждет PollInterval.
+func (r *Relay) loop(ctx context.Context) {
+	var last_cleanup time.Time
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		n, err := r.relayBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("events: relay: %s", err)
+		}
+		if n > 0 && err == nil {
+			continue
+		}
+
+		if r.cfg.Retention > 0 && time.Since(last_cleanup) > cleanupInterval {
+			if err := r.cleanup(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("events: cleanup: %s", err)
+			}
+			last_cleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.cfg.PollInterval):
+		}
+	}
+}
+
+// relayBatch публикует одну пачку событий в порядке записи. Выбираются только события, перед которыми
+// у того же аккаунта нет отложенного после ошибки события. Если публикация события не удалась,
+// остальные события этого аккаунта в пачке пропускаются до следующей попытки.
+//
+// Возвращает:
+//   - количество опубликованных событий
+//   - ошибку базы данных
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	const qLock = `SELECT pg_try_advisory_xact_lock($1);`
+	const qPending = `
+		SELECT
+			o.id
+			, o.tenant_id
+			, o.type
+			, o.account_id
+			, o.payload
+			, o.attempts
+			, o.created_at
+		FROM "Outbox" o
+		WHERE True
+			AND o.published_at IS NULL
+			AND o.next_attempt_at <= NOW()
+			AND NOT EXISTS (
+				SELECT 1
+				FROM "Outbox" p
+				WHERE True
+					AND p.account_id = o.account_id
+					AND p.published_at IS NULL
+					AND p.id < o.id
+					AND p.next_attempt_at > NOW()
+			)
+		ORDER BY o.id
+		LIMIT $1;
+	`
+	const qPublished = `
+		UPDATE "Outbox"
+		SET published_at = NOW(),
+		attempts = attempts + 1,
+		last_error = NULL
+		WHERE id = ANY($1);
+	`
+	const qFailed = `
+		UPDATE "Outbox"
+		SET attempts = attempts + 1,
+		last_error = $2,
+		next_attempt_at = NOW() + make_interval(secs => $3)
+		WHERE id = $1;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var locked bool
+	if err := tx.QueryRow(ctx, qLock, relayLockKey).Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	rows, err := tx.Query(ctx, qPending, max(r.cfg.BatchSize, 1))
+	if err != nil {
+		return 0, err
+	}
+	batch := []pending{}
+	for rows.Next() {
+		var ev pending
+		if err := rows.Scan(&ev.id, &ev.tenantID, &ev.kind, &ev.accountID, &ev.payload, &ev.attempts, &ev.occurredAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batch = append(batch, ev)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	published, failed := r.publishBatch(ctx, batch)
+	for _, f := range failed {
+		if _, err := tx.Exec(ctx, qFailed, f.ev.id, f.err.Error(), r.backoff(f.ev.attempts+1).Seconds()); err != nil {
+			return 0, err
+		}
+	}
+	if len(published) > 0 {
+		if _, err := tx.Exec(ctx, qPublished, published); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(published), nil
+}
+
+// failure - событие, публикация которого не удалась
+type failure struct {
+	ev  pending
+	err error
+}
+
+// publishBatch публикует события пачки по порядку. После неудачной публикации остальные события того же
+// аккаунта в пачке пропускаются, чтобы потребители не получили их раньше неудавшегося.
+//
+// Возвращает:
+//   - идентификаторы опубликованных событий
+//   - события, публикация которых не удалась
+func (r *Relay) publishBatch(ctx context.Context, batch []pending) ([]int64, []failure) {
+	blocked := map[string]bool{}
+	published := []int64{}
+	failed := []failure{}
+	for _, ev := range batch {
+		if blocked[ev.accountID] {
+			continue
+		}
+		if err := r.publish(ctx, &ev); err != nil {
+			blocked[ev.accountID] = true
+			log.Printf("events: publish %s %d attempt %d: %s", ev.kind, ev.id, ev.attempts+1, err)
+			failed = append(failed, failure{ev: ev, err: err})
+			continue
+		}
+		published = append(published, ev.id)
+	}
+	return published, failed
+}
+
+// publish отправляет событие в брокер в виде Envelope.
+func (r *Relay) publish(ctx context.Context, ev *pending) error {
+	data, err := json.Marshal(Envelope{
+		ID:         ev.id,
+		Type:       ev.kind,
+		TenantID:   ev.tenantID,
+		AccountID:  ev.accountID,
+		OccurredAt: ev.occurredAt,
+		Data:       ev.payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	subject := ev.kind
+	if r.cfg.SubjectPrefix != "" {
+		subject = r.cfg.SubjectPrefix + "." + ev.kind
+	}
+	return r.broker.Publish(ctx, &Message{
+		Subject: subject,
+		ID:      strconv.FormatInt(ev.id, 10),
+		Key:     ev.accountID,
+		Data:    data,
+	})
+}
+
+// cleanup удаляет опубликованные события старше Retention.
+func (r *Relay) cleanup(ctx context.Context) error {
+	const q = `
+		DELETE FROM "Outbox"
+		WHERE published_at < NOW() - make_interval(secs => $1);
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, q, r.cfg.Retention.Seconds())
+	return err
+}
+
+// backoff возвращает задержку перед следующей публикацией: BackoffBase * 2^(attempt-1), не больше BackoffMax.
+// Событие не уходит в dead, как задача очереди: пропуск сломал бы порядок событий аккаунта.
+func (r *Relay) backoff(attempt int) time.Duration {
+	delay := r.cfg.BackoffBase
+	for i := 1; i < attempt && delay < r.cfg.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, r.cfg.BackoffMax)
+}
@@ -0,0 +1,92 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPublishBatchOrdering(t *testing.T) {
+	broker := NewMemoryBroker()
+	down := map[string]bool{"3": true}
+	broker.Subscribe(func(ctx context.Context, msg *Message) error {
+		if down[msg.ID] {
+			return errors.New("nats: timeout")
+		}
+		return nil
+	})
+	r := NewRelay(nil, broker, Config{SubjectPrefix: "auth"})
+
+	batch := []pending{
+		{id: 1, kind: AccountCreated, accountID: "alice", payload: json.RawMessage(`{}`)},
+		{id: 2, kind: AccountCreated, accountID: "bob", payload: json.RawMessage(`{}`)},
+		{id: 3, kind: AccountPasswordChanged, accountID: "alice", payload: json.RawMessage(`{}`)},
+		{id: 4, kind: AccountPasswordChanged, accountID: "bob", payload: json.RawMessage(`{}`)},
+		{id: 5, kind: SessionRevoked, accountID: "alice", payload: json.RawMessage(`{}`)},
+	}
+	published, failed := r.publishBatch(context.Background(), batch)
+
+	// Событие 5 аккаунта alice не обгоняет неопубликованное событие 3
+	if want := []int64{1, 2, 4}; !slices.Equal(published, want) {
+		t.Errorf("published = %v, want %v", published, want)
+	}
+	if len(failed) != 1 || failed[0].ev.id != 3 || failed[0].err == nil {
+		t.Fatalf("failed = %+v, want event 3", failed)
+	}
+
+	ids := []string{}
+	for _, msg := range broker.Messages() {
+		ids = append(ids, msg.ID)
+	}
+	if want := []string{"1", "2", "4"}; !slices.Equal(ids, want) {
+		t.Errorf("broker messages = %v, want %v", ids, want)
+	}
+
+	// Следующая пачка после восстановления брокера публикует события alice по порядку
+	down["3"] = false
+	broker.Reset()
+	published, failed = r.publishBatch(context.Background(), []pending{batch[2], batch[4]})
+	if want := []int64{3, 5}; !slices.Equal(published, want) || len(failed) != 0 {
+		t.Errorf("retry published = %v, failed = %v, want %v", published, failed, want)
+	}
+}
+
+func TestPublishEnvelope(t *testing.T) {
+	broker := NewMemoryBroker()
+	r := NewRelay(nil, broker, Config{SubjectPrefix: "auth"})
+	occurred := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	ev := pending{id: 42, tenantID: "tenant", kind: AccountCreated, accountID: "alice", payload: json.RawMessage(`{"email":"alice@example.com"}`), occurredAt: occurred}
+	if err := r.publish(context.Background(), &ev); err != nil {
+		t.Fatalf("publish: %s", err)
+	}
+	msgs := broker.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("messages = %d, want 1", len(msgs))
+	}
+	msg := msgs[0]
+	if msg.Subject != "auth."+AccountCreated || msg.ID != "42" || msg.Key != "alice" {
+		t.Errorf("message = %s %s %s, want auth.%s 42 alice", msg.Subject, msg.ID, msg.Key, AccountCreated)
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(msg.Data, &env); err != nil {
+		t.Fatalf("Unmarshal: %s", err)
+	}
+	if env.ID != 42 || env.TenantID != "tenant" || env.AccountID != "alice" || !env.OccurredAt.Equal(occurred) || string(env.Data) != string(ev.payload) {
+		t.Errorf("envelope = %+v", env)
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	r := &Relay{cfg: Config{BackoffBase: time.Second, BackoffMax: 5 * time.Second}}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := r.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Config - пути к локальным базам в формате MaxMind DB (.mmdb). Пустой путь отключает соответствующие данные.
+type Config struct {
+	CityDB string `yaml:"city_db"` // GeoLite2-City или GeoIP2-City: страна и координаты; подойдет и GeoLite2-Country, но без координат
+	ASNDB  string `yaml:"asn_db"`  // GeoLite2-ASN: номер и владелец автономной системы
+}
+
+// Location - результат поиска адреса
+type Location struct {
+	Country   string   // ISO 3166-1 alpha-2, пустая строка - неизвестно
+	ASN       uint     // номер автономной системы, 0 - неизвестно
+	ASOrg     string   // владелец автономной системы
+	Latitude  *float64 // координаты, nil - неизвестно
+	Longitude *float64
+}
+
+// cityRecord - поля записи базы City/Country, которые нужны сервису
+type cityRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+// asnRecord - запись базы ASN
+type asnRecord struct {
+	Number uint   `maxminddb:"autonomous_system_number"`
+	Org    string `maxminddb:"autonomous_system_organization"`
+}
+
+// Locator ищет страну, координаты и автономную систему IP адреса в локальных базах MaxMind.
+// Без баз возвращает пустой Location, чтобы сервис работал и без GeoIP.
+type Locator struct {
+	city *maxminddb.Reader
+	asn  *maxminddb.Reader
+}
+
+// Open открывает базы из конфигурации.
+//
+// Параметры:
+//   - cfg: пути к базам
+//
+// Возвращает:
+//   - указатель на новый экземпляр Locator
+//   - ошибку открытия базы
+func Open(cfg Config) (*Locator, error) {
+	l := &Locator{}
+	if cfg.CityDB != "" {
+		db, err := maxminddb.Open(cfg.CityDB)
+		if err != nil {
+			return nil, fmt.Errorf("open GeoIP city database: %w", err)
+		}
+		l.city = db
+	}
+	if cfg.ASNDB != "" {
+		db, err := maxminddb.Open(cfg.ASNDB)
+		if err != nil {
+			l.Close()
+			return nil, fmt.Errorf("open GeoIP ASN database: %w", err)
+		}
+		l.asn = db
+	}
+	return l, nil
+}
+
+// Lookup ищет адрес в базах. Ошибки чтения отдельных записей не прерывают поиск: возвращается то, что найдено.
+//
+// Параметры:
+//   - ip: IP адрес в текстовом виде
+//
+// Возвращает:
+//   - структуру Location
+func (l *Locator) Lookup(ip string) Location {
+	var res Location
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return res
+	}
+	if l.city != nil {
+		var rec cityRecord
+		if err := l.city.Lookup(addr, &rec); err == nil {
+			res.Country = rec.Country.ISOCode
+			res.Latitude, res.Longitude = rec.Location.Latitude, rec.Location.Longitude
+		}
+	}
+	if l.asn != nil {
+		var rec asnRecord
+		if err := l.asn.Lookup(addr, &rec); err == nil {
+			res.ASN, res.ASOrg = rec.Number, rec.Org
+		}
+	}
+	return res
+}
+
+// Close закрывает базы.
+func (l *Locator) Close() {
+	if l.city != nil {
+		l.city.Close()
+	}
+	if l.asn != nil {
+		l.asn.Close()
+	}
+}
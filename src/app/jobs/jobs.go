@@ -0,0 +1,161 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// StatusPending - задача ждет выполнения
+	StatusPending = "pending"
+	// StatusRunning - задача взята воркером
+	StatusRunning = "running"
+	// StatusDone - задача выполнена
+	StatusDone = "done"
+	// StatusDead - попытки исчерпаны или ошибка не допускает повтора
+	StatusDead = "dead"
+)
+
+// DefaultMaxAttempts - количество попыток, если оно не задано при постановке задачи
+const DefaultMaxAttempts = 8
+
+// DB - соединение или транзакция pgx. Позволяет ставить задачу в той же транзакции, что и бизнес-запись.
+type DB interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// NewJob - задача для постановки в очередь
+type NewJob struct {
+	TenantID    string
+	Kind        string
+	Payload     any        // сериализуется в JSON
+	RunAt       *time.Time // nil - как можно скорее
+	MaxAttempts int        // 0 - DefaultMaxAttempts
+}
+
+// Job - задача очереди
+type Job struct {
+	ID          int64
+	TenantID    string
+	Kind        string
+	Payload     json.RawMessage
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LockedBy    *string
+	LockedAt    *time.Time
+	LastError   *string
+	FinishedAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Decode разбирает данные задачи в структуру обработчика.
+//
+// Параметры:
+//   - v: указатель на структуру
+//
+// Возвращает:
+//   - ошибку, не допускающую повтора, если данные не разбираются
+func (j *Job) Decode(v any) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("decode payload of job %d: %w", j.ID, err))
+	}
+	return nil
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку обработчика как не допускающую повтора: задача сразу уходит в dead.
+//
+// Параметры:
+//   - err: ошибка обработчика
+//
+// Возвращает:
+//   - обернутую ошибку
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent сообщает, помечена ли ошибка через Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+const jobColumns = `
+	id
+	, tenant_id
+	, kind
+	, payload
+	, status
+	, attempts
+	, max_attempts
+	, run_at
+	, locked_by
+	, locked_at
+	, last_error
+	, finished_at
+	, created_at
+	, updated_at
+`
+
+// Enqueue ставит задачу в очередь через переданное соединение или транзакцию.
+// Если db - транзакция, задача появится в очереди только вместе с ее коммитом.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - db: соединение или транзакция
+//   - job: задача
+//
+// Возвращает:
+//   - идентификатор задачи
+//   - ошибку, если данные не сериализуются или произошла ошибка базы данных
+func Enqueue(ctx context.Context, db DB, job NewJob) (int64, error) {
+	const q = `
+		INSERT INTO "Job"
+		(
+			tenant_id
+			, kind
+			, payload
+			, max_attempts
+			, run_at
+		)
+		VALUES ($1, $2, $3, $4, COALESCE($5, NOW()))
+		RETURNING id;
+	`
+
+	payload, err := json.Marshal(job.Payload)
+	if err != nil {
+		return 0, fmt.Errorf("encode payload of %s job: %w", job.Kind, err)
+	}
+	max_attempts := job.MaxAttempts
+	if max_attempts <= 0 {
+		max_attempts = DefaultMaxAttempts
+	}
+
+	var id int64
+	err = db.QueryRow(ctx, q, job.TenantID, job.Kind, json.RawMessage(payload), max_attempts, job.RunAt).Scan(&id)
+	return id, err
+}
+
+// scanJob читает задачу из строки с колонками jobColumns.
+func scanJob(row pgx.Row) (*Job, error) {
+	var job Job
+	err := row.Scan(&job.ID, &job.TenantID, &job.Kind, &job.Payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAt, &job.LockedBy, &job.LockedAt, &job.LastError, &job.FinishedAt, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,324 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/jackc/pgx/v5"
+)
+
+// Queue - очередь фоновых задач в таблице "Job"
+type Queue struct {
+	pgRepo *core.PgRepo
+}
+
+// Stat - количество задач одного типа в одном статусе
+type Stat struct {
+	Kind   string
+	Status string
+	Count  int
+}
+
+// Filter - параметры поиска задач
+type Filter struct {
+	TenantID string
+	Kind     string // пустой - любой тип
+	Status   string // пустой - любой статус
+	Limit    int
+	Offset   int
+}
+
+// NewQueue создает очередь поверх пула соединений.
+//
+// Параметры:
+//   - pgRepo: пул соединений с базой данных
+//
+// Возвращает:
+//   - указатель на новый экземпляр Queue
+func NewQueue(pgRepo *core.PgRepo) *Queue {
+	return &Queue{pgRepo: pgRepo}
+}
+
+// Enqueue ставит задачу в очередь отдельным запросом. Чтобы задача появилась только вместе с бизнес-записью,
+// используйте функцию Enqueue с транзакцией.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - job: задача
+//
+// Возвращает:
+//   - идентификатор задачи
+//   - ошибку, если произошла ошибка базы данных
+func (q *Queue) Enqueue(ctx context.Context, job NewJob) (int64, error) {
+	conn, err := q.pgRepo.Acquire(ctx)
+	if err != nil {
+		return 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	id, err := Enqueue(ctx, conn, job)
+	if err != nil {
+		return 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return id, nil
+}
+
+// Stats возвращает количество задач тенанта по типу и статусу.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//
+// Возвращает:
+//   - список счетчиков, отсортированный по типу и статусу
+//   - ошибку, если произошла ошибка базы данных
+func (q *Queue) Stats(ctx context.Context, tenant_id string) ([]Stat, error) {
+	const query = `
+		SELECT kind, status, COUNT(*)
+		FROM "Job"
+		WHERE tenant_id = $1
+		GROUP BY kind, status
+		ORDER BY kind, status;
+	`
+
+	conn, err := q.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, query, tenant_id)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res := []Stat{}
+	for rows.Next() {
+		var stat Stat
+		if err := rows.Scan(&stat.Kind, &stat.Status, &stat.Count); err != nil {
+			return nil, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, stat)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return res, nil
+}
+
+// Search ищет задачи тенанта по типу и статусу.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - filter: тенант, фильтры и параметры пагинации
+//
+// Возвращает:
+//   - страницу задач, отсортированных от новых к старым
+//   - общее количество задач, подходящих под фильтр
+//   - ошибку, если произошла ошибка базы данных
+func (q *Queue) Search(ctx context.Context, filter *Filter) ([]Job, int, error) {
+	const where = `
+		WHERE True
+			AND tenant_id = $1
+			AND ($2 = '' OR kind = $2)
+			AND ($3 = '' OR status = $3)
+	`
+	const qCount = `
+		SELECT COUNT(*)
+		FROM "Job"
+	` + where
+	const query = `
+		SELECT` + jobColumns + `
+		FROM "Job"
+	` + where + `
+		ORDER BY id DESC
+		LIMIT $4 OFFSET $5;
+	`
+
+	conn, err := q.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var total int
+	if err := conn.QueryRow(ctx, qCount, filter.TenantID, filter.Kind, filter.Status).Scan(&total); err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	rows, err := conn.Query(ctx, query, filter.TenantID, filter.Kind, filter.Status, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res := []Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return res, total, nil
+}
+
+// Retry возвращает задачу из dead в очередь с обнуленным счетчиком попыток.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор задачи
+//
+// Возвращает:
+//   - указатель на обновленную задачу
+//   - ошибку core.ErrJobNotFound, если задачи нет в тенанте или она не в статусе dead
+func (q *Queue) Retry(ctx context.Context, tenant_id string, id int64) (*Job, error) {
+	const query = `
+		UPDATE "Job"
+		SET status = 'pending',
+		attempts = 0,
+		run_at = NOW(),
+		finished_at = NULL,
+		updated_at = NOW()
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2
+			AND status = 'dead'
+		RETURNING` + jobColumns + `;`
+
+	conn, err := q.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	job, err := scanJob(conn.QueryRow(ctx, query, tenant_id, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &core.ErrJobNotFound{ErrMessage: err}
+		}
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return job, nil
+}
+
+// claim забирает одну готовую к выполнению задачу известного типа. Параллельные воркеры пропускают
+// строки, заблокированные друг другом (SKIP LOCKED), поэтому одна задача не выдается дважды.
+// Задача, которую воркер держит дольше lease, считается зависшей и выдается снова, а если ее попытки
+// исчерпаны, уходит в dead: иначе задача, роняющая воркер, выполнялась бы бесконечно.
+//
+// Возвращает:
+//   - задачу или nil, если готовых задач нет
+func (q *Queue) claim(ctx context.Context, worker_id string, kinds []string, lease time.Duration) (*Job, error) {
+	const query = `
+		WITH expired AS (
+			UPDATE "Job"
+			SET status = 'dead',
+			locked_by = NULL,
+			locked_at = NULL,
+			last_error = 'lease expired after the last attempt',
+			finished_at = NOW(),
+			updated_at = NOW()
+			WHERE id IN (
+				SELECT id
+				FROM "Job"
+				WHERE True
+					AND kind = ANY($2)
+					AND status = 'running'
+					AND locked_at < NOW() - make_interval(secs => $3)
+					AND attempts >= max_attempts
+				FOR UPDATE SKIP LOCKED
+			)
+		)
+		UPDATE "Job"
+		SET status = 'running',
+		attempts = attempts + 1,
+		locked_by = $1,
+		locked_at = NOW(),
+		updated_at = NOW()
+		WHERE id = (
+			SELECT id
+			FROM "Job"
+			WHERE True
+				AND kind = ANY($2)
+				AND (
+					(status = 'pending' AND run_at <= NOW())
+					OR (status = 'running' AND locked_at < NOW() - make_interval(secs => $3) AND attempts < max_attempts)
+				)
+			ORDER BY run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING` + jobColumns + `;`
+
+	conn, err := q.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	job, err := scanJob(conn.QueryRow(ctx, query, worker_id, kinds, lease.Seconds()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return job, err
+}
+
+// complete отмечает задачу выполненной, если она все еще принадлежит воркеру.
+func (q *Queue) complete(ctx context.Context, worker_id string, id int64) error {
+	const query = `
+		UPDATE "Job"
+		SET status = 'done',
+		locked_by = NULL,
+		locked_at = NULL,
+		last_error = NULL,
+		finished_at = NOW(),
+		updated_at = NOW()
+		WHERE True
+			AND id = $1
+			AND locked_by = $2;
+	`
+
+	conn, err := q.pgRepo.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, query, id, worker_id)
+	return err
+}
+
+// fail записывает ошибку попытки. Задача возвращается в очередь через retry_in
+// или уходит в dead, если попытки исчерпаны или dead = true.
+func (q *Queue) fail(ctx context.Context, worker_id string, id int64, cause string, retry_in time.Duration, dead bool) error {
+	const query = `
+		UPDATE "Job"
+		SET status = CASE WHEN $3 OR attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+		run_at = NOW() + make_interval(secs => $4),
+		locked_by = NULL,
+		locked_at = NULL,
+		last_error = $5,
+		finished_at = CASE WHEN $3 OR attempts >= max_attempts THEN NOW() END,
+		updated_at = NOW()
+		WHERE True
+			AND id = $1
+			AND locked_by = $2;
+	`
+
+	conn, err := q.pgRepo.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, query, id, worker_id, dead, retry_in.Seconds(), cause)
+	return err
+}
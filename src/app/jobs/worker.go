@@ -0,0 +1,194 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+)
+
+// HandlerFunc выполняет задачу. Ошибка возвращает задачу в очередь с задержкой,
+// ошибка, обернутая в Permanent, сразу отправляет ее в dead.
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+// Config - настройки воркеров
+type Config struct {
+	Concurrency  int           `yaml:"concurrency"`   // количество параллельно выполняемых задач
+	PollInterval time.Duration `yaml:"poll_interval"` // пауза между опросами пустой очереди
+	Lease        time.Duration `yaml:"lease"`         // время, после которого задача running считается зависшей
+	BackoffBase  time.Duration `yaml:"backoff_base"`  // задержка после первой неудачной попытки, дальше удваивается
+	BackoffMax   time.Duration `yaml:"backoff_max"`   // максимальная задержка между попытками
+	Timeout      time.Duration `yaml:"timeout"`       // ограничение времени одной попытки
+}
+
+// Worker забирает задачи из очереди и выполняет зарегистрированные обработчики
+type Worker struct {
+	queue    *Queue
+	cfg      Config
+	id       string
+	handlers map[string]HandlerFunc
+
+	// stop останавливает опрос очереди, cancel прерывает выполняемые задачи при истечении Shutdown
+	stop   context.CancelFunc
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWorker создает воркер очереди. Идентификатор воркера строится из хоста и PID процесса.
+//
+// Параметры:
+//   - queue: очередь задач
+//   - cfg: настройки воркеров
+//
+// Возвращает:
+//   - указатель на новый экземпляр Worker
+func NewWorker(queue *Queue, cfg Config) *Worker {
+	host, _ := os.Hostname()
+	return &Worker{
+		queue:    queue,
+		cfg:      cfg,
+		id:       fmt.Sprintf("%s-%d-%04x", host, os.Getpid(), rand.IntN(0x10000)),
+		handlers: map[string]HandlerFunc{},
+	}
+}
+
+// Handle регистрирует обработчик для типа задач. Вызывается до Start.
+//
+// Параметры:
+//   - kind: тип задачи
+//   - fn: обработчик
+func (w *Worker) Handle(kind string, fn HandlerFunc) {
+	w.handlers[kind] = fn
+}
+
+// Start запускает cfg.Concurrency горутин, которые опрашивают очередь до вызова Shutdown.
+// Берутся только задачи, для которых зарегистрирован обработчик.
+func (w *Worker) Start() {
+	kinds := make([]string, 0, len(w.handlers))
+	for kind := range w.handlers {
+		kinds = append(kinds, kind)
+	}
+
+	poll_ctx, stop := context.WithCancel(context.Background())
+	run_ctx, cancel := context.WithCancel(context.Background())
+	w.stop, w.cancel = stop, cancel
+
+	for i := 0; i < max(w.cfg.Concurrency, 1); i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.loop(poll_ctx, run_ctx, kinds)
+		}()
+	}
+}
+
+// Shutdown перестает брать новые задачи и ждет завершения выполняемых. Если ctx истекает раньше,
+// выполняемые задачи прерываются через отмену их контекста; они вернутся в очередь по истечении Lease.
+//
+// Параметры:
+//   - ctx: ограничение времени ожидания
+//
+// Возвращает:
+//   - ошибку ctx, если задачи не успели завершиться
+func (w *Worker) Shutdown(ctx context.Context) error {
+	if w.stop == nil {
+		return nil
+	}
+	w.stop()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.cancel()
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// loop опрашивает очередь, пока не остановлен poll_ctx.
+func (w *Worker) loop(poll_ctx context.Context, run_ctx context.Context, kinds []string) {
+	for {
+		if poll_ctx.Err() != nil {
+			return
+		}
+
+		job, err := w.queue.claim(poll_ctx, w.id, kinds, w.cfg.Lease)
+		if err != nil && poll_ctx.Err() == nil {
+			log.Printf("jobs: claim: %s", err)
+		}
+		if job == nil {
+			select {
+			case <-poll_ctx.Done():
+				return
+			case <-time.After(w.cfg.PollInterval):
+			}
+			continue
+		}
+
+		w.run(run_ctx, job)
+	}
+}
+
+// run выполняет задачу и записывает результат. Результат пишется в run_ctx, а не в контекст опроса,
+// чтобы остановка опроса не мешала сохранить итог уже выполненной задачи.
+func (w *Worker) run(run_ctx context.Context, job *Job) {
+	err := w.execute(run_ctx, job)
+	if err == nil {
+		if err := w.queue.complete(run_ctx, w.id, job.ID); err != nil {
+			log.Printf("jobs: complete %d: %s", job.ID, err)
+		}
+		return
+	}
+
+	log.Printf("jobs: %s %d attempt %d/%d: %s", job.Kind, job.ID, job.Attempts, job.MaxAttempts, err)
+	if err := w.queue.fail(run_ctx, w.id, job.ID, err.Error(), w.backoff(job.Attempts), IsPermanent(err)); err != nil {
+		log.Printf("jobs: fail %d: %s", job.ID, err)
+	}
+}
+
+// execute вызывает обработчик с ограничением времени и превращает панику в ошибку.
+func (w *Worker) execute(run_ctx context.Context, job *Job) (err error) {
+	handler, ok := w.handlers[job.Kind]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for %s", job.Kind))
+	}
+
+	ctx := run_ctx
+	if w.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(run_ctx, w.cfg.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// backoff возвращает задержку перед следующей попыткой: BackoffBase * 2^(attempt-1), не больше BackoffMax,
+// со случайным разбросом до 20%, чтобы повторы упавших одновременно задач не шли одной волной.
+func (w *Worker) backoff(attempt int) time.Duration {
+	delay := w.cfg.BackoffBase
+	for i := 1; i < attempt && delay < w.cfg.BackoffMax; i++ {
+		delay *= 2
+	}
+	delay = min(delay, w.cfg.BackoffMax)
+	if delay <= 0 {
+		return 0
+	}
+	return delay + time.Duration(rand.Int64N(int64(delay)/5+1))
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	w := &Worker{cfg: Config{BackoffBase: time.Second, BackoffMax: 10 * time.Second}}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		// Разброс добавляет не больше 20% задержки
+		for i := 0; i < 20; i++ {
+			got := w.backoff(tt.attempt)
+			if got < tt.want || got > tt.want+tt.want/5 {
+				t.Fatalf("backoff(%d) = %s, want %s..%s", tt.attempt, got, tt.want, tt.want+tt.want/5)
+			}
+		}
+	}
+
+	if got := (&Worker{}).backoff(3); got != 0 {
+		t.Errorf("backoff without base = %s, want 0", got)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	w := &Worker{cfg: Config{Timeout: 20 * time.Millisecond}, handlers: map[string]HandlerFunc{}}
+	w.Handle("ok", func(ctx context.Context, job *Job) error { return nil })
+	w.Handle("fail", func(ctx context.Context, job *Job) error { return errors.New("smtp unavailable") })
+	w.Handle("panic", func(ctx context.Context, job *Job) error { panic("boom") })
+	w.Handle("slow", func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	w.Handle("bad payload", func(ctx context.Context, job *Job) error {
+		var v struct{ To string }
+		return job.Decode(&v)
+	})
+
+	tests := []struct {
+		kind      string
+		err       bool
+		permanent bool
+	}{
+		{"ok", false, false},
+		{"fail", true, false},
+		{"panic", true, false},
+		{"slow", true, false},
+		{"bad payload", true, true},
+		{"unknown", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			err := w.execute(context.Background(), &Job{ID: 1, Kind: tt.kind, Payload: json.RawMessage(`[1]`)})
+			if (err != nil) != tt.err || IsPermanent(err) != tt.permanent {
+				t.Errorf("execute(%s) = %v, permanent %v, want error %v, permanent %v", tt.kind, err, IsPermanent(err), tt.err, tt.permanent)
+			}
+		})
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	cause := errors.New("invalid address")
+	err := fmt.Errorf("send: %w", Permanent(cause))
+	if !IsPermanent(err) {
+		t.Error("IsPermanent(wrapped) = false, want true")
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Permanent hides the cause from errors.Is")
+	}
+	if IsPermanent(cause) || IsPermanent(nil) {
+		t.Error("IsPermanent(plain) = true, want false")
+	}
+}
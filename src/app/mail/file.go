@@ -0,0 +1,63 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+// FileTransport сохраняет письма в каталог в формате .eml или, если каталог не задан, пишет их текст в лог.
+// Используется при разработке вместо настоящей отправки.
+type FileTransport struct {
+	dir  string
+	from string
+}
+
+// NewFileTransport создает файловый транспорт.
+//
+// Параметры:
+//   - dir: каталог для .eml файлов; пустая строка - вывод в лог
+//   - from: адрес отправителя
+//
+// Возвращает:
+//   - указатель на новый экземпляр FileTransport
+func NewFileTransport(dir string, from string) *FileTransport {
+	return &FileTransport{dir: dir, from: from}
+}
+
+// Send сохраняет письмо.
+//
+// Параметры:
+//   - ctx: контекст выполнения, не используется
+//   - msg: письмо
+//
+// Возвращает:
+//   - ошибку core.ErrMailSend, если файл не удалось записать
+func (t *FileTransport) Send(ctx context.Context, msg *Message) error {
+	if t.dir == "" {
+		log.Printf("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Text)
+		return nil
+	}
+
+	body, err := buildMIME(t.from, msg)
+	if err != nil {
+		return &core.ErrMailSend{ErrMessage: err}
+	}
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return &core.ErrMailSend{ErrMessage: err}
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	if err := os.WriteFile(filepath.Join(t.dir, name), body, 0o644); err != nil {
+		return &core.ErrMailSend{ErrMessage: err}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// TransportSMTP - отправка через SMTP сервер
+	TransportSMTP = "smtp"
+	// TransportFile - письма пишутся в каталог в формате .eml или в лог, для разработки
+	TransportFile = "file"
+	// TransportMemory - письма сохраняются в памяти процесса, для тестов
+	TransportMemory = "memory"
+)
+
+// Message - готовое к отправке письмо
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer отправляет письма. Реализации должны быть безопасны для параллельного использования.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Config - настройки отправки писем
+type Config struct {
+	Transport string `yaml:"transport"` // smtp, file или memory
+	From      string `yaml:"from"`      // адрес отправителя
+	Locale    string `yaml:"locale"`    // язык шаблонов по умолчанию
+	// SMTP
+	SMTPHost string `yaml:"smtp_host"`
+	SMTPPort string `yaml:"smtp_port"`
+	SMTPUser string `yaml:"smtp_user"`
+	SMTPPwd  string `yaml:"smtp_pwd"`
+	// File
+	Dir string `yaml:"dir"` // каталог для .eml файлов; пустой - письма пишутся в лог
+	// Throttle
+	ThrottleLimit  int           `yaml:"throttle_limit"`  // писем одному получателю за окно; 0 - без ограничения
+	ThrottleWindow time.Duration `yaml:"throttle_window"` // окно ограничения
+}
+
+// NewMailer создает транспорт, указанный в конфигурации. Ограничение частоты писем (Throttler)
+// проверяется при постановке письма в очередь, а не здесь.
+//
+// Параметры:
+//   - cfg: настройки отправки писем
+//
+// Возвращает:
+//   - Mailer, готовый к отправке
+//   - ошибку, если транспорт неизвестен
+func NewMailer(cfg Config) (Mailer, error) {
+	switch cfg.Transport {
+	case TransportSMTP:
+		return NewSMTPTransport(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPwd, cfg.From), nil
+	case TransportFile:
+		return NewFileTransport(cfg.Dir, cfg.From), nil
+	case TransportMemory:
+		return NewMemoryTransport(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport: %q", cfg.Transport)
+	}
+}
@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryTransport складывает письма в память процесса. Используется в тестах, чтобы проверить отправленные письма.
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemoryTransport создает пустой транспорт в памяти.
+//
+// Возвращает:
+//   - указатель на новый экземпляр MemoryTransport
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+// Send сохраняет копию письма.
+func (t *MemoryTransport) Send(ctx context.Context, msg *Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = append(t.messages, *msg)
+	return nil
+}
+
+// Messages возвращает копию списка отправленных писем в порядке отправки.
+func (t *MemoryTransport) Messages() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Message(nil), t.messages...)
+}
+
+// Reset удаляет все сохраненные письма.
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = nil
+}
@@ -0,0 +1,71 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// buildMIME собирает письмо multipart/alternative с текстовой и HTML частями.
+//
+// Параметры:
+//   - from: адрес отправителя
+//   - msg: письмо
+//
+// Возвращает:
+//   - письмо в формате RFC 5322
+//   - ошибку, если адрес содержит перевод строки или не удалось записать части письма
+func buildMIME(from string, msg *Message) ([]byte, error) {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(from, "\r\n") {
+		return nil, fmt.Errorf("invalid address: %q", msg.To)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var res bytes.Buffer
+	fmt.Fprintf(&res, "From: %s\r\n", from)
+	fmt.Fprintf(&res, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&res, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&res, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&res, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&res, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+	res.Write(body.Bytes())
+
+	return res.Bytes(), nil
+}
@@ -0,0 +1,95 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+
+	"github.com/MedodsTechTask/app/core"
+)
+
+// SMTPTransport отправляет письма через SMTP сервер. Если сервер поддерживает STARTTLS, соединение шифруется.
+type SMTPTransport struct {
+	host string
+	port string
+	user string
+	pwd  string
+	from string
+}
+
+// NewSMTPTransport создает транспорт SMTP.
+//
+// Параметры:
+//   - host: хост SMTP сервера
+//   - port: порт SMTP сервера
+//   - user: имя пользователя; пустое - без авторизации
+//   - pwd: пароль пользователя
+//   - from: адрес отправителя
+//
+// Возвращает:
+//   - указатель на новый экземпляр SMTPTransport
+func NewSMTPTransport(host string, port string, user string, pwd string, from string) *SMTPTransport {
+	return &SMTPTransport{host: host, port: port, user: user, pwd: pwd, from: from}
+}
+
+// Send отправляет письмо. Дедлайн контекста ограничивает весь SMTP диалог.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - msg: письмо
+//
+// Возвращает:
+//   - ошибку core.ErrMailSend, если письмо не принято сервером
+func (t *SMTPTransport) Send(ctx context.Context, msg *Message) error {
+	body, err := buildMIME(t.from, msg)
+	if err != nil {
+		return &core.ErrMailSend{ErrMessage: err}
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(t.host, t.port))
+	if err != nil {
+		return &core.ErrMailSend{ErrMessage: err}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return &core.ErrMailSend{ErrMessage: err}
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: t.host}); err != nil {
+			return &core.ErrMailSend{ErrMessage: err}
+		}
+	}
+	if t.user != "" {
+		if err := c.Auth(smtp.PlainAuth("", t.user, t.pwd, t.host)); err != nil {
+			return &core.ErrMailSend{ErrMessage: err}
+		}
+	}
+	if err := c.Mail(t.from); err != nil {
+		return &core.ErrMailSend{ErrMessage: err}
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return &core.ErrMailSend{ErrMessage: err}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return &core.ErrMailSend{ErrMessage: err}
+	}
+	if _, err := w.Write(body); err != nil {
+		return &core.ErrMailSend{ErrMessage: err}
+	}
+	if err := w.Close(); err != nil {
+		return &core.ErrMailSend{ErrMessage: err}
+	}
+
+	return c.Quit()
+}
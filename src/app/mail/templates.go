@@ -0,0 +1,141 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+)
+
+const (
+	// TemplateConfirmEmail - код подтверждения регистрации, данные ConfirmData
+	TemplateConfirmEmail = "confirm_email"
+	// TemplatePasswordReset - код сброса пароля, данные ResetData
+	TemplatePasswordReset = "password_reset"
+	// TemplateSecurityNotice - уведомление о событии безопасности, данные NoticeData
+	TemplateSecurityNotice = "security_notice"
+)
+
+const (
+	// NoticePasswordChanged - пароль аккаунта изменен
+	NoticePasswordChanged = "password_changed"
+	// NoticeSessionsRevoked - сессии аккаунта завершены
+	NoticeSessionsRevoked = "sessions_revoked"
+	// NoticeAccountBlocked - аккаунт заблокирован администратором
+	NoticeAccountBlocked = "account_blocked"
+	// NoticeSuspiciousLogin - вход с непривычного устройства или места
+	NoticeSuspiciousLogin = "suspicious_login"
+)
+
+// DefaultLocale - язык, который используется, если запрошенный язык не поддерживается
+const DefaultLocale = "ru"
+
+// ConfirmData - данные письма с кодом подтверждения регистрации
+type ConfirmData struct {
+	Email string
+	Code  string
+}
+
+// ResetData - данные письма с кодом сброса пароля
+type ResetData struct {
+	Email     string
+	Code      string
+	ExpiresAt time.Time
+}
+
+// NoticeData - данные уведомления о событии безопасности
+type NoticeData struct {
+	Email     string
+	Event     string // одна из констант Notice*
+	IP        string
+	UserAgent string
+	Time      time.Time
+}
+
+//go:embed templates
+var templatesFS embed.FS
+
+type localized struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// templates - шаблоны по языку и имени; текстовый шаблон содержит блок subject с темой письма
+var templates = loadTemplates()
+
+func loadTemplates() map[string]map[string]localized {
+	res := map[string]map[string]localized{}
+
+	locales, err := templatesFS.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+	for _, locale := range locales {
+		res[locale.Name()] = map[string]localized{}
+		for _, name := range []string{TemplateConfirmEmail, TemplatePasswordReset, TemplateSecurityNotice} {
+			base := "templates/" + locale.Name() + "/" + name
+			res[locale.Name()][name] = localized{
+				text: texttemplate.Must(texttemplate.ParseFS(templatesFS, base+".txt")),
+				html: htmltemplate.Must(htmltemplate.ParseFS(templatesFS, base+".html")),
+			}
+		}
+	}
+	return res
+}
+
+// Render собирает письмо из шаблона на нужном языке.
+//
+// Параметры:
+//   - locale: язык или значение Accept-Language, например "en-US,en;q=0.9"; неподдерживаемый язык заменяется fallback
+//   - fallback: язык по умолчанию; пустая строка - DefaultLocale
+//   - name: имя шаблона, одна из констант Template*
+//   - to: адрес получателя
+//   - data: данные шаблона
+//
+// Возвращает:
+//   - указатель на структуру Message с темой, текстовой и HTML частями
+//   - ошибку core.ErrMailTemplate, если шаблон не найден или не выполнился
+func Render(locale string, fallback string, name string, to string, data any) (*Message, error) {
+	tmpl, ok := templates[matchLocale(locale, fallback)][name]
+	if !ok {
+		return nil, &core.ErrMailTemplate{ErrMessage: name}
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, &core.ErrMailTemplate{ErrMessage: err}
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, &core.ErrMailTemplate{ErrMessage: err}
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return nil, &core.ErrMailTemplate{ErrMessage: err}
+	}
+
+	return &Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()),
+		HTML:    html.String(),
+	}, nil
+}
+
+// matchLocale выбирает первый поддерживаемый язык из значения Accept-Language.
+func matchLocale(locale string, fallback string) string {
+	for _, part := range strings.Split(locale, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		tag, _, _ = strings.Cut(strings.TrimSpace(tag), "-")
+		tag = strings.ToLower(tag)
+		if _, ok := templates[tag]; ok {
+			return tag
+		}
+	}
+	if _, ok := templates[fallback]; ok {
+		return fallback
+	}
+	return DefaultLocale
+}
@@ -0,0 +1,98 @@
+package mail
+
+import (
+	"errors"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+)
+
+func TestMatchLocale(t *testing.T) {
+	tests := []struct {
+		locale   string
+		fallback string
+		want     string
+	}{
+		{"en-US,en;q=0.9", "", "en"},
+		{"de-DE, EN;q=0.5", "", "en"},
+		{"ru", "en", "ru"},
+		{"de", "en", "en"},
+		{"", "", DefaultLocale},
+		{"de", "fr", DefaultLocale},
+	}
+	for _, tt := range tests {
+		if got := matchLocale(tt.locale, tt.fallback); got != tt.want {
+			t.Errorf("matchLocale(%q, %q) = %q, want %q", tt.locale, tt.fallback, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	data := map[string]any{
+		TemplateConfirmEmail:   ConfirmData{Email: "john@example.com", Code: "123456"},
+		TemplatePasswordReset:  ResetData{Email: "john@example.com", Code: "654321", ExpiresAt: time.Now().Add(time.Hour)},
+		TemplateSecurityNotice: NoticeData{Email: "john@example.com", Event: NoticePasswordChanged, IP: "203.0.113.10", Time: time.Now()},
+	}
+	for locale := range templates {
+		for name, d := range data {
+			t.Run(locale+"/"+name, func(t *testing.T) {
+				msg, err := Render(locale, "", name, "john@example.com", d)
+				if err != nil {
+					t.Fatalf("Render: %s", err)
+				}
+				if msg.Subject == "" || msg.Text == "" || msg.HTML == "" {
+					t.Errorf("Render = %+v, want subject, text and html", msg)
+				}
+				if strings.Contains(msg.Subject, "\n") {
+					t.Errorf("Subject = %q, want a single line", msg.Subject)
+				}
+			})
+		}
+	}
+
+	// Данные в HTML части экранируются
+	msg, err := Render("en", "", TemplateConfirmEmail, "john@example.com", ConfirmData{Email: "<b>x</b>@example.com", Code: "1"})
+	if err != nil {
+		t.Fatalf("Render: %s", err)
+	}
+	if strings.Contains(msg.HTML, "<b>x</b>") {
+		t.Errorf("HTML contains unescaped data: %s", msg.HTML)
+	}
+
+	var tmpl_err *core.ErrMailTemplate
+	if _, err := Render("en", "", "unknown", "john@example.com", nil); !errors.As(err, &tmpl_err) {
+		t.Errorf("Render(unknown) error = %v, want ErrMailTemplate", err)
+	}
+}
+
+func TestBuildMIME(t *testing.T) {
+	msg := &Message{To: "john@example.com", Subject: "Код подтверждения", Text: "Код: 123456", HTML: "<p>Код: 123456</p>"}
+	raw, err := buildMIME("noreply@example.com", msg)
+	if err != nil {
+		t.Fatalf("buildMIME: %s", err)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("ReadMessage: %s", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q, %v, want %q", subject, err, msg.Subject)
+	}
+	if got := parsed.Header.Get("To"); got != msg.To {
+		t.Errorf("To = %q, want %q", got, msg.To)
+	}
+	if !strings.HasPrefix(parsed.Header.Get("Content-Type"), "multipart/alternative") {
+		t.Errorf("Content-Type = %q, want multipart/alternative", parsed.Header.Get("Content-Type"))
+	}
+
+	for _, to := range []string{"john@example.com\r\nBcc: all@example.com", "john@example.com\nX: y"} {
+		if _, err := buildMIME("noreply@example.com", &Message{To: to, Text: "x"}); err == nil {
+			t.Errorf("buildMIME(%q) = nil error, want header injection rejected", to)
+		}
+	}
+}
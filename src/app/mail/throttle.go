@@ -0,0 +1,83 @@
+package mail
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/MedodsTechTask/app/ratelimit"
+)
+
+// Throttler ограничивает количество писем одному получателю скользящим окном. Счетчики лежат в хранилище
+// ограничений частоты, поэтому при хранилище postgres или redis лимит общий для всех экземпляров сервиса.
+type Throttler struct {
+	limiter *ratelimit.Limiter
+	rule    ratelimit.Rule
+}
+
+// NewThrottler создает ограничитель частоты писем.
+//
+// Параметры:
+//   - limiter: ограничитель частоты запросов, в хранилище которого ведутся счетчики; nil отключает ограничение
+//   - limit: максимальное количество писем одному получателю за окно; 0 отключает ограничение
+//   - window: длина окна
+//
+// Возвращает:
+//   - указатель на новый экземпляр Throttler
+func NewThrottler(limiter *ratelimit.Limiter, limit int, window time.Duration) *Throttler {
+	return &Throttler{
+		limiter: limiter,
+		rule: ratelimit.Rule{
+			Name:      "mail",
+			Algorithm: ratelimit.AlgorithmSlidingWindow,
+			Limit:     limit,
+			Window:    window,
+		},
+	}
+}
+
+// Allow проверяет, что лимит окна получателя не исчерпан. Письмо при этом не учитывается: его учитывает Sent,
+// когда письмо действительно поставлено в очередь. Адреса сравниваются без учета регистра.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - to: адрес получателя
+//
+// Возвращает:
+//   - false, если получателю уже отправлено limit писем за окно
+//   - ошибку хранилища счетчиков
+func (t *Throttler) Allow(ctx context.Context, to string) (bool, error) {
+	if t.disabled() {
+		return true, nil
+	}
+	res, err := t.limiter.Check(ctx, t.key(to), t.rule)
+	if err != nil {
+		return t.limiter.FailOpen(), err
+	}
+	return res.Allowed, nil
+}
+
+// Sent учитывает письмо получателю.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - to: адрес получателя
+//
+// Возвращает:
+//   - ошибку хранилища счетчиков
+func (t *Throttler) Sent(ctx context.Context, to string) error {
+	if t.disabled() {
+		return nil
+	}
+	_, err := t.limiter.Allow(ctx, t.key(to), t.rule)
+	return err
+}
+
+func (t *Throttler) disabled() bool {
+	return t.limiter == nil || t.rule.Limit <= 0 || t.rule.Window <= 0
+}
+
+// key - ключ счетчика получателя
+func (t *Throttler) key(to string) string {
+	return "mail|" + strings.ToLower(strings.TrimSpace(to))
+}
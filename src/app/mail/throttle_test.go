@@ -0,0 +1,71 @@
+package mail
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MedodsTechTask/app/ratelimit"
+)
+
+func TestThrottler(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter(ratelimit.Config{Store: ratelimit.StoreMemory}, nil)
+	if err != nil {
+		t.Fatalf("NewLimiter: %s", err)
+	}
+	defer limiter.Close()
+	ctx := context.Background()
+
+	th := NewThrottler(limiter, 2, time.Hour)
+	// Проверки без отправки лимит не расходуют
+	for i := 0; i < 5; i++ {
+		if ok, err := th.Allow(ctx, "john@example.com"); !ok || err != nil {
+			t.Fatalf("Allow #%d = %v, %v, want true", i, ok, err)
+		}
+	}
+	th.Sent(ctx, "john@example.com")
+	th.Sent(ctx, " John@Example.com ")
+	if ok, _ := th.Allow(ctx, "JOHN@example.com"); ok {
+		t.Errorf("Allow after limit sent = true, want false")
+	}
+	if ok, _ := th.Allow(ctx, "jane@example.com"); !ok {
+		t.Errorf("Allow for another recipient = false, want true")
+	}
+
+	// Другой экземпляр с тем же хранилищем видит те же счетчики
+	other := NewThrottler(limiter, 2, time.Hour)
+	if ok, _ := other.Allow(ctx, "john@example.com"); ok {
+		t.Errorf("Allow on a throttler sharing the store = true, want false")
+	}
+	// Новый лимит применяется к уже учтенным письмам
+	if ok, _ := NewThrottler(limiter, 3, time.Hour).Allow(ctx, "john@example.com"); !ok {
+		t.Errorf("Allow with a raised limit = false, want true")
+	}
+}
+
+func TestThrottlerDisabled(t *testing.T) {
+	limiter, _ := ratelimit.NewLimiter(ratelimit.Config{Store: ratelimit.StoreMemory}, nil)
+	defer limiter.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		th   *Throttler
+	}{
+		{"no limiter", NewThrottler(nil, 1, time.Hour)},
+		{"zero limit", NewThrottler(limiter, 0, time.Hour)},
+		{"zero window", NewThrottler(limiter, 1, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 3; i++ {
+				if err := tt.th.Sent(ctx, "john@example.com"); err != nil {
+					t.Fatalf("Sent: %s", err)
+				}
+			}
+			if ok, err := tt.th.Allow(ctx, "john@example.com"); !ok || err != nil {
+				t.Errorf("Allow = %v, %v, want true", ok, err)
+			}
+		})
+	}
+}
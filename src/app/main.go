@@ -1,9 +1,32 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/MedodsTechTask/app/captcha"
 	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/egress"
+	"github.com/MedodsTechTask/app/email"
+	"github.com/MedodsTechTask/app/events"
+	"github.com/MedodsTechTask/app/geoip"
+	"github.com/MedodsTechTask/app/jobs"
+	"github.com/MedodsTechTask/app/mail"
+	"github.com/MedodsTechTask/app/metrics"
+	"github.com/MedodsTechTask/app/password"
+	"github.com/MedodsTechTask/app/proxy"
+	"github.com/MedodsTechTask/app/ratelimit"
+	"github.com/MedodsTechTask/app/risk"
+	"github.com/MedodsTechTask/app/session"
+	"github.com/MedodsTechTask/app/tracing"
 	"github.com/MedodsTechTask/app/user/auth"
 	"github.com/MedodsTechTask/app/user/auth/configs"
 	"github.com/MedodsTechTask/app/user/auth/repo"
@@ -12,6 +35,7 @@ import (
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 // @title Service API
@@ -19,30 +43,199 @@ import (
 // @description REST API for authentication
 // @host localhost:8080
 // @BasePath /api/v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access токен в формате "Bearer <token>". Токен действует только в тенанте, для которого выпущен (заголовок X-Tenant-ID)
 func main() {
+	authcfg, err := configs.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("config: %s", err)
+	}
+	// Подписка на SIGHUP до запуска остального, чтобы сигнал во время старта не завершил процесс
+	var authUC *auth.AuthUseCase
+	watcher := configs.NewWatcher(os.Args[1:], authcfg, func(cfg *configs.Config) error {
+		version, err := authUC.Reload(cfg)
+		if err != nil {
+			return err
+		}
+		log.Printf("config: version %d loaded, hash %s, pending restart %v", version.Version, version.Hash, version.PendingRestart)
+		return nil
+	})
+
+	shutdownTracing, err := tracing.Setup(context.Background(), authcfg.Tracing)
+	if err != nil {
+		log.Fatalf("tracing: %s", err)
+	}
+
 	r := gin.Default()
+	// Спан запроса начинается первым, чтобы в него попали все middleware; пробы и метрики не трассируются
+	r.Use(otelgin.Middleware(authcfg.Tracing.ServiceName, otelgin.WithFilter(func(req *http.Request) bool {
+		switch req.URL.Path {
+		case core.HealthzPath, core.ReadyzPath, core.MetricsPath:
+			return false
+		}
+		return true
+	})))
+	r.Use(metrics.Middleware())
+	// Адрес клиента определяет proxy.Resolver, заголовкам прокси gin не доверяет
+	r.SetTrustedProxies(nil)
 
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"}, // Или конкретные домены
+		AllowOrigins:     authcfg.CORSOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", core.TenantHeader},
+		ExposeHeaders:    []string{"Content-Length", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
 		AllowCredentials: true,
 	}))
 
-	authcfg := configs.GetConfig()
+	// Любая ошибка запуска завершает процесс: сервис без базы данных, ключей или брокера не должен принимать запросы
 	authRepo, err := repo.NewAuthRepo(authcfg)
 	if err != nil {
-		fmt.Printf("%s", err)
+		log.Fatalf("db: %s", err)
+	}
+	mailer, err := mail.NewMailer(authcfg.Mail)
+	if err != nil {
+		log.Fatalf("mail: %s", err)
+	}
+	passwords, err := password.NewManager(authcfg.Password)
+	if err != nil {
+		log.Fatalf("password: %s", err)
+	}
+	policy, err := password.NewPolicy(authcfg.PasswordPolicy)
+	if err != nil {
+		log.Fatalf("password policy: %s", err)
+	}
+	emails, err := email.NewValidator(authcfg.Email)
+	if err != nil {
+		log.Fatalf("email: %s", err)
+	}
+	binding, err := session.NewBinding(authcfg.SessionBinding)
+	if err != nil {
+		log.Fatalf("session binding: %s", err)
+	}
+	geo, err := geoip.Open(authcfg.GeoIP)
+	if err != nil {
+		log.Fatalf("geoip: %s", err)
+	}
+	riskEngine := risk.NewEngine(authcfg.Risk, geo)
+	resolver, err := proxy.NewResolver(authcfg.Proxy)
+	if err != nil {
+		log.Fatalf("proxy: %s", err)
+	}
+	guard, err := egress.NewGuard(authcfg.WebhookAllowedNetworks)
+	if err != nil {
+		log.Fatalf("webhook allowed networks: %s", err)
+	}
+	captchaVerifier, err := captcha.NewVerifier(authcfg.Captcha)
+	if err != nil {
+		log.Fatalf("captcha: %s", err)
 	}
-	authAPI := auth.NewAPI(auth.NewAuthUseCase(authcfg, authRepo))
+	limiter, err := ratelimit.NewLimiter(authcfg.RateLimit, authRepo.PgRepo())
+	if err != nil {
+		log.Fatalf("rate limit: %s", err)
+	}
+	metrics.Registry.MustRegister(metrics.NewPoolCollector("auth", authRepo.PgRepo()))
+	queue := jobs.NewQueue(authRepo.PgRepo())
+	authUC = auth.NewAuthUseCase(authcfg, authRepo, mailer, queue, passwords, policy, emails, binding, riskEngine, captchaVerifier, limiter, guard)
+	authAPI := auth.NewAPI(authUC)
+	if health := authUC.Health(context.Background(), true); health.Status != auth.HealthOK {
+		log.Fatalf("startup checks failed: %v", health.Checks)
+	}
+	broker, err := events.NewBroker(authcfg.Events)
+	if err != nil {
+		log.Fatalf("events: %s", err)
+	}
+	listener, err := net.Listen("tcp", authcfg.Listen)
+	if err != nil {
+		log.Fatalf("listen: %s", err)
+	}
+	if authcfg.Proxy.ProxyProtocol {
+		listener = proxy.NewListener(listener, resolver, authcfg.Proxy.HeaderTimeout)
+	}
+
+	// Фоновые задачи
+	worker := jobs.NewWorker(queue, authcfg.Jobs)
+	authUC.RegisterJobs(worker)
+	worker.Start()
+
+	// Публикация доменных событий из outbox
+	relay := events.NewRelay(authRepo.PgRepo(), broker, authcfg.Events)
+	relay.Start()
 
 	// Инициализация роутов
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	authAPI.SetupHealthRoutes(r.Group(""))
+	r.GET(core.MetricsPath, gin.WrapH(metrics.Handler()))
 	api := r.Group(core.BasePath)
+	api.Use(authAPI.ConfigSnapshot(), authAPI.BodyLimit(), authAPI.ResolveTenant(), authAPI.ClientInfo(resolver), authAPI.RateLimit())
 	{
 		authAPI.SetupRoutes(api.Group(core.UserAuthPath))
+		authAPI.SetupAdminRoutes(api.Group(core.AdminPath))
+	}
+
+	srv := &http.Server{
+		Handler:           r,
+		ReadHeaderTimeout: authcfg.ReadHeaderTimeout,
+		ReadTimeout:       authcfg.ReadTimeout,
+		WriteTimeout:      authcfg.WriteTimeout,
+		IdleTimeout:       authcfg.IdleTimeout,
 	}
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(listener)
+	}()
 
-	r.Run(":8080")
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go watcher.Run(ctx)
+
+	failed := false
+	select {
+	case <-ctx.Done():
+		log.Printf("shutting down")
+	case err := <-served:
+		log.Printf("http server: %s", err)
+		failed = true
+	}
+	stop()
+
+	// Балансировщик видит 503 на /readyz и снимает трафик, пока листенер еще принимает запросы
+	authUC.Drain()
+	time.Sleep(authcfg.ShutdownDelay)
+
+	// Листенер закрывается, выполняемые запросы дорабатывают, простаивающие соединения закрываются
+	httpCtx, cancelHTTP := context.WithTimeout(context.Background(), authcfg.ShutdownTimeout)
+	defer cancelHTTP()
+	if err := srv.Shutdown(httpCtx); err != nil {
+		log.Printf("http shutdown: %s", err)
+	}
+
+	// Дожидаемся выполняемых задач, чтобы не оставлять их в running до истечения lease
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), authcfg.JobsShutdownTimeout)
+	defer cancel()
+	if err := worker.Shutdown(shutdownCtx); err != nil {
+		log.Printf("jobs shutdown: %s", err)
+	}
+	if err := relay.Shutdown(shutdownCtx); err != nil {
+		log.Printf("events shutdown: %s", err)
+	}
+	if err := broker.Close(); err != nil {
+		log.Printf("events broker close: %s", err)
+	}
+	if err := limiter.Close(); err != nil {
+		log.Printf("rate limit store close: %s", err)
+	}
+	geo.Close()
+	authRepo.PgRepo().Close()
+	// Отправляем накопленные спаны, включая спаны остановки
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("tracing shutdown: %s", err)
+	}
+	if failed {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute - метка маршрута для запросов, не совпавших ни с одним роутом, чтобы сканеры не плодили ряды
+const unmatchedRoute = "unmatched"
+
+// Middleware возвращает middleware, которое считает запросы и время их обработки по шаблону маршрута
+// (например, /api/v1/admin/accounts/:account_id), а не по пути запроса.
+//
+// Возвращает:
+//   - gin.HandlerFunc
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		HTTPRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		HTTPDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace - префикс имен метрик сервиса
+const namespace = "auth"
+
+var (
+	// Registry - реестр метрик сервиса; кроме метрик ниже в нем метрики рантайма Go и процесса
+	Registry = prometheus.NewRegistry()
+
+	factory = promauto.With(Registry)
+
+	// HTTPRequests - число обработанных запросов по маршруту и статусу ответа
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+	// HTTPDuration - время обработки запросов по маршруту
+	HTTPDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// Signups - созданные регистрации; повторная регистрация email, ожидающего подтверждения, не считается
+	Signups = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signups_total",
+		Help:      "Email signups created.",
+	})
+	// Confirmations - подтвержденные регистрации, по коду и администратором
+	Confirmations = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signup_confirmations_total",
+		Help:      "Signups confirmed into accounts.",
+	})
+	// Logins - успешные входы по паролю
+	Logins = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Successful password logins.",
+	})
+	// LoginFailures - неудачные входы по паролю по причине, см. LoginFailure*
+	LoginFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_failures_total",
+		Help:      "Failed password logins by reason.",
+	}, []string{"reason"})
+	// Refreshes - обновления access токена по результату: success или failure
+	Refreshes = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_refreshes_total",
+		Help:      "Access token refreshes by result.",
+	}, []string{"result"})
+	// BindingMismatches - refresh токены, предъявленные не тем клиентом, по действию политики привязки сессии
+	BindingMismatches = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "session_binding_mismatches_total",
+		Help:      "Refresh attempts from a client with another IP or User-Agent, by session binding action.",
+	}, []string{"action"})
+
+	// PasswordHashDuration - время хеширования (hash) и проверки (verify) паролей
+	PasswordHashDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "password_hash_duration_seconds",
+		Help:      "Password hashing and verification time.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 12),
+	}, []string{"op"})
+	// JWTDuration - время подписи (sign) и проверки (verify) JWT по алгоритму
+	JWTDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "jwt_duration_seconds",
+		Help:      "JWT signing and verification time by algorithm.",
+		Buckets:   prometheus.ExponentialBuckets(0.00002, 2, 14),
+	}, []string{"op", "algorithm"})
+)
+
+// Причины неудачного входа для LoginFailures
+const (
+	LoginFailureEmptyPassword   = "empty_password"
+	LoginFailureCaptcha         = "captcha"
+	LoginFailureAccountNotFound = "account_not_found"
+	LoginFailureWrongPassword   = "wrong_password"
+	LoginFailureAccountBlocked  = "account_blocked"
+	LoginFailureRiskBlock       = "risk_block"
+	LoginFailureError           = "error"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler возвращает обработчик /metrics в текстовом формате Prometheus.
+//
+// Возвращает:
+//   - http.Handler
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
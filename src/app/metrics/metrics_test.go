@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareRouteLabel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/accounts/:account_id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	tests := []struct {
+		path   string
+		route  string
+		status string
+	}{
+		// Метка - шаблон маршрута, а не путь запроса
+		{"/accounts/1", "/accounts/:account_id", "204"},
+		{"/accounts/2", "/accounts/:account_id", "204"},
+		{"/wp-login.php", unmatchedRoute, "404"},
+	}
+
+	before := map[string]float64{}
+	for _, tt := range tests {
+		key := tt.route + " " + tt.status
+		if _, ok := before[key]; !ok {
+			before[key] = testutil.ToFloat64(HTTPRequests.WithLabelValues(http.MethodGet, tt.route, tt.status))
+		}
+	}
+	for _, tt := range tests {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+	}
+
+	want := map[string]float64{}
+	for _, tt := range tests {
+		want[tt.route+" "+tt.status]++
+	}
+	for _, tt := range tests {
+		key := tt.route + " " + tt.status
+		got := testutil.ToFloat64(HTTPRequests.WithLabelValues(http.MethodGet, tt.route, tt.status)) - before[key]
+		if got != want[key] {
+			t.Errorf("requests %s = %v, want %v", key, got, want[key])
+		}
+	}
+
+	// Путь запроса не попадает в метки, иначе каждый идентификатор создаст свой ряд
+	families, err := Registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %s", err)
+	}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "route" && (label.GetValue() == "/accounts/1" || label.GetValue() == "/wp-login.php") {
+					t.Errorf("%s has route label %q", family.GetName(), label.GetValue())
+				}
+			}
+		}
+	}
+}
+
+// statPool - источник статистики пула для проверки сборщика
+type statPool struct {
+	stat *pgxpool.Stat
+}
+
+func (p *statPool) Stat() *pgxpool.Stat {
+	return p.stat
+}
+
+func TestPoolCollectorWithoutPool(t *testing.T) {
+	// Пул еще не создан: сборщик не отдает ни одного ряда и не паникует
+	if n := testutil.CollectAndCount(NewPoolCollector("main", &statPool{})); n != 0 {
+		t.Errorf("series = %d, want 0", n)
+	}
+}
+
+func TestPoolCollectorLint(t *testing.T) {
+	problems, err := testutil.CollectAndLint(NewPoolCollector("main", &statPool{}))
+	if err != nil {
+		t.Fatalf("CollectAndLint: %s", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("lint problems = %v", problems)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	Logins.Inc()
+	LoginFailures.WithLabelValues(LoginFailureWrongPassword).Inc()
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("code = %d, want 200", w.Code)
+	}
+	body, _ := io.ReadAll(w.Body)
+	for _, want := range []string{
+		"auth_logins_total ",
+		`auth_login_failures_total{reason="wrong_password"} `,
+		"go_goroutines ",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics has no %q", want)
+		}
+	}
+
+	problems, err := testutil.GatherAndLint(Registry)
+	if err != nil {
+		t.Fatalf("GatherAndLint: %s", err)
+	}
+	for _, p := range problems {
+		if strings.HasPrefix(p.Metric, namespace+"_") {
+			t.Errorf("lint %s: %s", p.Metric, p.Text)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolStater - источник статистики пула соединений, например core.PgRepo
+type PoolStater interface {
+	// Stat возвращает статистику пула или nil, если пул еще не создан
+	Stat() *pgxpool.Stat
+}
+
+// poolDesc - описание метрики пула и способ получить ее значение из статистики
+type poolDesc struct {
+	desc  *prometheus.Desc
+	kind  prometheus.ValueType
+	value func(*pgxpool.Stat) float64
+}
+
+// PoolCollector собирает статистику пула pgx в момент запроса /metrics
+type PoolCollector struct {
+	pool  PoolStater
+	descs []poolDesc
+}
+
+// NewPoolCollector создает сборщик статистики пула соединений.
+//
+// Параметры:
+//   - name: имя пула, метка pool
+//   - pool: источник статистики
+//
+// Возвращает:
+//   - указатель на PoolCollector для регистрации в Registry
+func NewPoolCollector(name string, pool PoolStater) *PoolCollector {
+	labels := prometheus.Labels{"pool": name}
+	desc := func(metric string, help string, kind prometheus.ValueType, value func(*pgxpool.Stat) float64) poolDesc {
+		return poolDesc{
+			desc:  prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", metric), help, nil, labels),
+			kind:  kind,
+			value: value,
+		}
+	}
+	return &PoolCollector{
+		pool: pool,
+		descs: []poolDesc{
+			desc("acquired_conns", "Connections currently acquired from the pool.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }),
+			desc("idle_conns", "Idle connections in the pool.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }),
+			desc("constructing_conns", "Connections being established.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.ConstructingConns()) }),
+			desc("total_conns", "Total connections in the pool.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }),
+			desc("max_conns", "Maximum size of the pool.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }),
+			desc("acquires_total", "Successful connection acquires.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }),
+			desc("acquire_duration_seconds_total", "Total time spent acquiring connections.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }),
+			desc("empty_acquires_total", "Acquires that waited for a connection because the pool was empty.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }),
+			desc("canceled_acquires_total", "Acquires canceled by their context.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) }),
+			desc("new_conns_total", "Connections opened.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.NewConnsCount()) }),
+			desc("max_lifetime_destroys_total", "Connections closed after max_conn_lifetime.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.MaxLifetimeDestroyCount()) }),
+			desc("max_idle_destroys_total", "Connections closed after max_conn_idle_time.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.MaxIdleDestroyCount()) }),
+		},
+	}
+}
+
+// Describe реализует prometheus.Collector.
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descs {
+		ch <- d.desc
+	}
+}
+
+// Collect реализует prometheus.Collector.
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	if stat == nil {
+		return
+	}
+	for _, d := range c.descs {
+		ch <- prometheus.MustNewConstMetric(d.desc, d.kind, d.value(stat))
+	}
+}
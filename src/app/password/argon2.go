@@ -0,0 +1,111 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// Argon2id хеширует пароли argon2id. Формат: $argon2id$v=19$m=<KiB>,t=<проходы>,p=<потоки>$<соль>$<хеш>,
+// соль и хеш в base64 без дополнения.
+type Argon2id struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+// NewArgon2id создает хешер argon2id. Нулевые параметры заменяются рекомендованными OWASP: 64 MiB, 3 прохода, 2 потока.
+//
+// Параметры:
+//   - memory: память в KiB
+//   - time: количество проходов
+//   - threads: параллельность
+//
+// Возвращает:
+//   - указатель на новый экземпляр Argon2id
+func NewArgon2id(memory uint32, time uint32, threads uint8) *Argon2id {
+	if memory == 0 {
+		memory = 64 * 1024
+	}
+	if time == 0 {
+		time = 3
+	}
+	if threads == 0 {
+		threads = 2
+	}
+	return &Argon2id{memory: memory, time: time, threads: threads}
+}
+
+// Hash хеширует пароль со случайной солью.
+func (a *Argon2id) Hash(pwd string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(pwd), salt, a.time, a.memory, a.threads, argon2KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.memory, a.time, a.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify пересчитывает хеш с параметрами и солью из encoded и сравнивает за постоянное время.
+func (a *Argon2id) Verify(pwd string, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(pwd), salt, params.time, params.memory, params.threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// Identify сообщает, что хеш в формате argon2id.
+func (a *Argon2id) Identify(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+// NeedsRehash сообщает, что хеш посчитан с меньшей памятью, числом проходов или потоков, чем текущие.
+func (a *Argon2id) NeedsRehash(encoded string) bool {
+	params, _, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.memory < a.memory || params.time < a.time || params.threads < a.threads
+}
+
+// decodeArgon2id разбирает хеш argon2id в формате PHC.
+func decodeArgon2id(encoded string) (*Argon2id, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+	var params Argon2id
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return nil, nil, nil, fmt.Errorf("bad argon2id params %q: %w", parts[3], err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("bad argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, fmt.Errorf("bad argon2id hash: %w", err)
+	}
+	return &params, salt, key, nil
+}
@@ -0,0 +1,57 @@
+package password
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt хеширует пароли bcrypt. Формат модульный crypt: $2a$<cost>$<соль и хеш>, он тоже описывает себя сам.
+// bcrypt учитывает только первые 72 байта пароля.
+type Bcrypt struct {
+	cost int
+}
+
+// NewBcrypt создает хешер bcrypt. Нулевая стоимость заменяется на 12.
+//
+// Параметры:
+//   - cost: стоимость (логарифм числа раундов)
+//
+// Возвращает:
+//   - указатель на новый экземпляр Bcrypt
+func NewBcrypt(cost int) *Bcrypt {
+	if cost == 0 {
+		cost = 12
+	}
+	return &Bcrypt{cost: cost}
+}
+
+// Hash хеширует пароль со случайной солью.
+func (b *Bcrypt) Hash(pwd string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pwd), b.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify сверяет пароль с хешем; сравнение выполняется библиотекой за постоянное время.
+func (b *Bcrypt) Verify(pwd string, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(pwd))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Identify сообщает, что хеш в формате bcrypt.
+func (b *Bcrypt) Identify(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// NeedsRehash сообщает, что хеш посчитан с меньшей стоимостью, чем текущая.
+func (b *Bcrypt) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < b.cost
+}
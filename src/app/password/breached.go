@@ -0,0 +1,110 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// breachedPrefixLen - длина префикса SHA-1, по которому группируются хеши, как в range API Have I Been Pwned
+const breachedPrefixLen = 5
+
+// breachedEntry - остаток хеша после префикса и число утечек
+type breachedEntry struct {
+	suffix string
+	count  int
+}
+
+// BreachedList - офлайн список утекших паролей в модели k-анонимности: SHA-1 хеши сгруппированы по
+// префиксу из 5 hex символов, и проверка пароля просматривает только группу его префикса. Файл можно
+// собрать из выгрузки Have I Been Pwned или из ответов range API для нужных префиксов.
+type BreachedList struct {
+	ranges map[string][]breachedEntry
+	size   int
+}
+
+// LoadBreachedList загружает список утекших паролей из файла. Формат строки: "<SHA-1 hex>[:<число утечек>]",
+// пустые строки и строки, начинающиеся с #, пропускаются. Без числа утечек считается одна утечка.
+//
+// Параметры:
+//   - path: путь к файлу
+//
+// Возвращает:
+//   - указатель на загруженный список
+//   - ошибку чтения файла или номер строки с неверным форматом
+func LoadBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open breached passwords list: %w", err)
+	}
+	defer f.Close()
+
+	list := &BreachedList{ranges: map[string][]breachedEntry{}}
+	scanner := bufio.NewScanner(f)
+	line_no := 0
+	for scanner.Scan() {
+		line_no++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hash, count_str, has_count := strings.Cut(line, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("breached passwords list %s:%d: invalid SHA-1 hash", path, line_no)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("breached passwords list %s:%d: invalid SHA-1 hash", path, line_no)
+		}
+		count := 1
+		if has_count {
+			count, err = strconv.Atoi(count_str)
+			if err != nil {
+				return nil, fmt.Errorf("breached passwords list %s:%d: invalid count", path, line_no)
+			}
+		}
+
+		prefix := hash[:breachedPrefixLen]
+		list.ranges[prefix] = append(list.ranges[prefix], breachedEntry{suffix: hash[breachedPrefixLen:], count: count})
+		list.size++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breached passwords list: %w", err)
+	}
+
+	for _, entries := range list.ranges {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].suffix < entries[j].suffix })
+	}
+	return list, nil
+}
+
+// Count возвращает, сколько раз пароль встречался в утечках.
+//
+// Параметры:
+//   - pwd: пароль
+//
+// Возвращает:
+//   - число утечек, 0 - пароль в списке не найден
+func (l *BreachedList) Count(pwd string) int {
+	sum := sha1.Sum([]byte(pwd))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	entries := l.ranges[hash[:breachedPrefixLen]]
+	suffix := hash[breachedPrefixLen:]
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].suffix >= suffix })
+	if i < len(entries) && entries[i].suffix == suffix {
+		return entries[i].count
+	}
+	return 0
+}
+
+// Size возвращает количество хешей в списке.
+func (l *BreachedList) Size() int {
+	return l.size
+}
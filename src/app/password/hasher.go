@@ -0,0 +1,137 @@
+package password
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// AlgorithmArgon2id - argon2id, алгоритм по умолчанию
+	AlgorithmArgon2id = "argon2id"
+	// AlgorithmBcrypt - bcrypt
+	AlgorithmBcrypt = "bcrypt"
+)
+
+// ErrUnknownFormat - хеш не относится ни к одному известному формату
+var ErrUnknownFormat = errors.New("unknown password hash format")
+
+// Hasher хеширует пароли в самоописывающем формате PHC: $<алгоритм>$<параметры>$<соль>$<хеш>.
+// Реализации должны сравнивать хеши за постоянное время.
+type Hasher interface {
+	// Hash хеширует пароль со случайной солью
+	Hash(pwd string) (string, error)
+	// Verify сверяет пароль с хешем своего формата
+	Verify(pwd string, encoded string) (bool, error)
+	// Identify сообщает, относится ли хеш к формату этого алгоритма
+	Identify(encoded string) bool
+	// NeedsRehash сообщает, что хеш этого формата посчитан с параметрами слабее текущих
+	NeedsRehash(encoded string) bool
+}
+
+// Config - настройки хеширования паролей
+type Config struct {
+	Algorithm string `yaml:"algorithm"` // argon2id или bcrypt, им хешируются новые пароли
+	// Argon2id
+	Argon2Memory  uint32 `yaml:"argon2_memory"`  // память в KiB
+	Argon2Time    uint32 `yaml:"argon2_time"`    // количество проходов
+	Argon2Threads uint8  `yaml:"argon2_threads"` // параллельность
+	// Bcrypt
+	BcryptCost int `yaml:"bcrypt_cost"`
+}
+
+// Manager хеширует новые пароли выбранным алгоритмом и проверяет хеши всех известных форматов,
+// включая устаревший SHA-256 с отдельной солью.
+type Manager struct {
+	current Hasher
+	known   []Hasher
+	// dummy - хеш случайного пароля текущим алгоритмом, с ним сверяется пароль, когда аккаунт не найден
+	dummy string
+}
+
+// NewManager создает менеджер паролей по конфигурации.
+//
+// Параметры:
+//   - cfg: настройки хеширования
+//
+// Возвращает:
+//   - указатель на новый экземпляр Manager
+//   - ошибку, если алгоритм неизвестен или не удалось посчитать хеш для VerifyDummy
+func NewManager(cfg Config) (*Manager, error) {
+	argon := NewArgon2id(cfg.Argon2Memory, cfg.Argon2Time, cfg.Argon2Threads)
+	bcrypt := NewBcrypt(cfg.BcryptCost)
+
+	var m *Manager
+	switch cfg.Algorithm {
+	case AlgorithmArgon2id, "":
+		m = &Manager{current: argon, known: []Hasher{argon, bcrypt}}
+	case AlgorithmBcrypt:
+		m = &Manager{current: bcrypt, known: []Hasher{bcrypt, argon}}
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm: %q", cfg.Algorithm)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	dummy, err := m.current.Hash(hex.EncodeToString(secret))
+	if err != nil {
+		return nil, err
+	}
+	m.dummy = dummy
+	return m, nil
+}
+
+// Hash хеширует пароль текущим алгоритмом.
+//
+// Параметры:
+//   - pwd: пароль
+//
+// Возвращает:
+//   - хеш в формате PHC
+//   - ошибку, если не удалось получить случайную соль
+func (m *Manager) Hash(pwd string) (string, error) {
+	return m.current.Hash(pwd)
+}
+
+// Verify сверяет пароль с сохраненным хешем любого известного формата.
+//
+// Параметры:
+//   - pwd: пароль
+//   - encoded: сохраненный хеш
+//   - legacy_salt: соль из отдельной колонки, используется только для устаревших SHA-256 хешей
+//
+// Возвращает:
+//   - true, если пароль верный
+//   - true, если хеш стоит пересчитать текущим алгоритмом: он устаревшего формата, другого алгоритма или со слабыми параметрами
+//   - ErrUnknownFormat или ошибку разбора хеша
+func (m *Manager) Verify(pwd string, encoded string, legacy_salt string) (bool, bool, error) {
+	if !strings.HasPrefix(encoded, "$") {
+		ok, err := verifyLegacySHA256(pwd, encoded, legacy_salt)
+		return ok, ok, err
+	}
+
+	for _, h := range m.known {
+		if !h.Identify(encoded) {
+			continue
+		}
+		ok, err := h.Verify(pwd, encoded)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, h != m.current || h.NeedsRehash(encoded), nil
+	}
+	return false, false, ErrUnknownFormat
+}
+
+// VerifyDummy сверяет пароль с хешем случайного пароля, чтобы ответ для несуществующего аккаунта занимал
+// столько же времени, сколько проверка настоящего пароля. Результат всегда отрицательный.
+//
+// Параметры:
+//   - pwd: пароль из запроса
+func (m *Manager) VerifyDummy(pwd string) {
+	_, _ = m.current.Verify(pwd, m.dummy)
+}
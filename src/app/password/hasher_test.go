@@ -0,0 +1,173 @@
+package password
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Параметры меньше рабочих, чтобы тесты шли быстро
+var testConfig = Config{
+	Algorithm:     AlgorithmArgon2id,
+	Argon2Memory:  1024,
+	Argon2Time:    1,
+	Argon2Threads: 1,
+	BcryptCost:    4,
+}
+
+func newTestManager(t *testing.T, cfg Config) *Manager {
+	t.Helper()
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager: %s", err)
+	}
+	return m
+}
+
+func legacyHash(pwd string, salt string) string {
+	sum := sha256.Sum256([]byte(pwd + salt))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestHashRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		prefix    string
+	}{
+		{"argon2id", AlgorithmArgon2id, "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{"bcrypt", AlgorithmBcrypt, "$2a$04$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig
+			cfg.Algorithm = tt.algorithm
+			m := newTestManager(t, cfg)
+
+			encoded, err := m.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash: %s", err)
+			}
+			if !strings.HasPrefix(encoded, tt.prefix) {
+				t.Fatalf("Hash = %q, want prefix %q", encoded, tt.prefix)
+			}
+
+			ok, rehash, err := m.Verify("correct horse", encoded, "")
+			if err != nil || !ok || rehash {
+				t.Errorf("Verify(correct) = %v, %v, %v, want true, false, nil", ok, rehash, err)
+			}
+			ok, rehash, err = m.Verify("wrong horse", encoded, "")
+			if err != nil || ok || rehash {
+				t.Errorf("Verify(wrong) = %v, %v, %v, want false, false, nil", ok, rehash, err)
+			}
+
+			again, _ := m.Hash("correct horse")
+			if again == encoded {
+				t.Errorf("two hashes of one password are equal, salt is not random")
+			}
+		})
+	}
+}
+
+func TestVerifyRehash(t *testing.T) {
+	weak := testConfig
+	weak.Argon2Time = 1
+	strong := testConfig
+	strong.Argon2Time = 2
+
+	argon_weak, _ := newTestManager(t, weak).Hash("secret")
+	bcrypt_cfg := testConfig
+	bcrypt_cfg.Algorithm = AlgorithmBcrypt
+	bcrypt_hash, _ := newTestManager(t, bcrypt_cfg).Hash("secret")
+
+	tests := []struct {
+		name       string
+		encoded    string
+		salt       string
+		pwd        string
+		wantOk     bool
+		wantRehash bool
+	}{
+		{"weaker argon2id params", argon_weak, "", "secret", true, true},
+		{"other algorithm", bcrypt_hash, "", "secret", true, true},
+		{"legacy sha256", legacyHash("secret", "salt"), "salt", "secret", true, true},
+		{"legacy sha256 wrong password", legacyHash("secret", "salt"), "salt", "other", false, false},
+		{"legacy sha256 wrong salt", legacyHash("secret", "salt"), "pepper", "secret", false, false},
+		{"wrong password is not rehashed", argon_weak, "", "other", false, false},
+	}
+	m := newTestManager(t, strong)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash, err := m.Verify(tt.pwd, tt.encoded, tt.salt)
+			if err != nil {
+				t.Fatalf("Verify: %s", err)
+			}
+			if ok != tt.wantOk || rehash != tt.wantRehash {
+				t.Errorf("Verify = %v, %v, want %v, %v", ok, rehash, tt.wantOk, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+		salt    string
+		unknown bool
+	}{
+		{"legacy without salt", legacyHash("secret", ""), "", true},
+		{"unknown algorithm", "$scrypt$ln=15,r=8,p=1$c2FsdA$aGFzaA", "", true},
+		{"argon2id missing parts", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA", "", true},
+		{"argon2id wrong version", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$aGFzaA", "", false},
+		{"argon2id bad params", "$argon2id$v=19$m=x,t=1,p=1$c2FsdA$aGFzaA", "", false},
+		{"argon2id bad salt", "$argon2id$v=19$m=1024,t=1,p=1$!!!$aGFzaA", "", false},
+		{"argon2id empty hash", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$", "", false},
+		{"bcrypt truncated", "$2a$04$short", "", false},
+	}
+	m := newTestManager(t, testConfig)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash, err := m.Verify("secret", tt.encoded, tt.salt)
+			if ok || rehash {
+				t.Errorf("Verify = %v, %v, want false, false", ok, rehash)
+			}
+			if err == nil {
+				t.Fatalf("Verify: want error")
+			}
+			if errors.Is(err, ErrUnknownFormat) != tt.unknown {
+				t.Errorf("Verify error = %v, ErrUnknownFormat %v", err, tt.unknown)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	a := NewArgon2id(1024, 2, 2)
+	tests := []struct {
+		encoded string
+		want    bool
+	}{
+		{"$argon2id$v=19$m=1024,t=2,p=2$c2FsdA$aGFzaA", false},
+		{"$argon2id$v=19$m=2048,t=3,p=4$c2FsdA$aGFzaA", false},
+		{"$argon2id$v=19$m=512,t=2,p=2$c2FsdA$aGFzaA", true},
+		{"$argon2id$v=19$m=1024,t=1,p=2$c2FsdA$aGFzaA", true},
+		{"$argon2id$v=19$m=1024,t=2,p=1$c2FsdA$aGFzaA", true},
+		{"$argon2id$broken", true},
+	}
+	for _, tt := range tests {
+		if got := a.NeedsRehash(tt.encoded); got != tt.want {
+			t.Errorf("NeedsRehash(%q) = %v, want %v", tt.encoded, got, tt.want)
+		}
+	}
+}
+
+func TestNewManagerUnknownAlgorithm(t *testing.T) {
+	cfg := testConfig
+	cfg.Algorithm = "md5"
+	if _, err := NewManager(cfg); err == nil {
+		t.Fatal("NewManager: want error for unknown algorithm")
+	}
+}
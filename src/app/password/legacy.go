@@ -0,0 +1,18 @@
+package password
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// verifyLegacySHA256 проверяет хеш прежнего формата: hex(SHA-256(пароль + соль)) с солью в отдельной колонке.
+// Такие хеши только проверяются и при успешном входе пересчитываются текущим алгоритмом.
+func verifyLegacySHA256(pwd string, encoded string, salt string) (bool, error) {
+	if salt == "" {
+		return false, ErrUnknownFormat
+	}
+	sum := sha256.Sum256([]byte(pwd + salt))
+	other := hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(encoded), []byte(other)) == 1, nil
+}
@@ -0,0 +1,176 @@
+package password
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Коды правил политики паролей, по ним интерфейс подбирает подсказку
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleLower     = "lower"
+	RuleUpper     = "upper"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleClasses   = "classes"
+	RuleStrength  = "strength"
+	RuleEmail     = "email"
+	RuleBreached  = "breached"
+)
+
+// PolicyConfig - требования к новым паролям
+type PolicyConfig struct {
+	MinLength     int  `yaml:"min_length"`     // минимальная длина в символах
+	MaxLength     int  `yaml:"max_length"`     // максимальная длина в символах, 0 - без ограничения
+	RequireLower  bool `yaml:"require_lower"`  // строчная буква
+	RequireUpper  bool `yaml:"require_upper"`  // заглавная буква
+	RequireDigit  bool `yaml:"require_digit"`  // цифра
+	RequireSymbol bool `yaml:"require_symbol"` // символ, не буква и не цифра
+	MinClasses    int  `yaml:"min_classes"`    // минимум разных классов символов из четырех, независимо от Require*
+	MinStrength   int  `yaml:"min_strength"`   // минимальная оценка стойкости от 0 до 4, см. Strength
+	RejectEmail   bool `yaml:"reject_email"`   // запрещать пароли, содержащие email или его локальную часть
+	// BreachedFile - файл со списком утекших паролей: строки "<SHA-1 hex>[:<число утечек>]", как в выгрузке
+	// Have I Been Pwned. Пустая строка - проверка выключена
+	BreachedFile     string `yaml:"breached_file"`
+	BreachedMinCount int    `yaml:"breached_min_count"` // пароль считается утекшим, если встречался в утечках не меньше этого числа раз
+}
+
+// Violation - нарушенное правило политики
+type Violation struct {
+	Rule    string `json:"rule" example:"min_length"`
+	Message string `json:"message" example:"Пароль короче 10 символов"`
+}
+
+// Feedback - результат проверки пароля: все нарушенные правила и оценка стойкости
+type Feedback struct {
+	Violations []Violation `json:"violations"`
+	Strength   int         `json:"strength" example:"2"` // оценка стойкости от 0 до 4
+}
+
+// Ok сообщает, что пароль прошел все правила.
+func (f *Feedback) Ok() bool {
+	return len(f.Violations) == 0
+}
+
+func (f *Feedback) add(rule string, message string) {
+	f.Violations = append(f.Violations, Violation{Rule: rule, Message: message})
+}
+
+// Policy проверяет новые пароли на соответствие PolicyConfig.
+type Policy struct {
+	cfg      PolicyConfig
+	breached *BreachedList
+}
+
+// NewPolicy создает политику паролей и загружает список утекших паролей, если он задан.
+//
+// Параметры:
+//   - cfg: требования к паролям
+//
+// Возвращает:
+//   - указатель на новый экземпляр Policy
+//   - ошибку чтения или разбора файла утекших паролей
+func NewPolicy(cfg PolicyConfig) (*Policy, error) {
+	p := &Policy{cfg: cfg}
+	if cfg.BreachedFile != "" {
+		list, err := LoadBreachedList(cfg.BreachedFile)
+		if err != nil {
+			return nil, err
+		}
+		p.breached = list
+	}
+	return p, nil
+}
+
+// Check проверяет пароль по всем правилам и возвращает полный список нарушений, а не первое из них.
+//
+// Параметры:
+//   - pwd: новый пароль
+//   - email: email владельца пароля, пустая строка - правило RejectEmail не проверяется
+//
+// Возвращает:
+//   - указатель на структуру Feedback
+func (p *Policy) Check(pwd string, email string) *Feedback {
+	res := &Feedback{Violations: []Violation{}, Strength: Strength(pwd)}
+
+	length := utf8.RuneCountInString(pwd)
+	if length < p.cfg.MinLength {
+		res.add(RuleMinLength, "Пароль короче "+strconv.Itoa(p.cfg.MinLength)+" символов")
+	}
+	if p.cfg.MaxLength > 0 && length > p.cfg.MaxLength {
+		res.add(RuleMaxLength, "Пароль длиннее "+strconv.Itoa(p.cfg.MaxLength)+" символов")
+	}
+
+	lower, upper, digit, symbol := classes(pwd)
+	if p.cfg.RequireLower && !lower {
+		res.add(RuleLower, "Пароль должен содержать строчную букву")
+	}
+	if p.cfg.RequireUpper && !upper {
+		res.add(RuleUpper, "Пароль должен содержать заглавную букву")
+	}
+	if p.cfg.RequireDigit && !digit {
+		res.add(RuleDigit, "Пароль должен содержать цифру")
+	}
+	if p.cfg.RequireSymbol && !symbol {
+		res.add(RuleSymbol, "Пароль должен содержать символ, отличный от букв и цифр")
+	}
+	if n := countTrue(lower, upper, digit, symbol); n < p.cfg.MinClasses {
+		res.add(RuleClasses, "Пароль должен содержать символы хотя бы "+strconv.Itoa(p.cfg.MinClasses)+" типов из: строчные, заглавные, цифры, прочие")
+	}
+
+	if res.Strength < p.cfg.MinStrength {
+		res.add(RuleStrength, "Пароль слишком простой")
+	}
+	if p.cfg.RejectEmail && containsEmail(pwd, email) {
+		res.add(RuleEmail, "Пароль не должен содержать email")
+	}
+	if p.breached != nil && p.breached.Count(pwd) >= max(p.cfg.BreachedMinCount, 1) {
+		res.add(RuleBreached, "Пароль встречается в известных утечках")
+	}
+	return res
+}
+
+// classes определяет, какие классы символов есть в пароле.
+func classes(pwd string) (lower, upper, digit, symbol bool) {
+	for _, r := range pwd {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	return
+}
+
+// containsEmail сообщает, что пароль без учета регистра содержит email или его локальную часть
+// длиной от 3 символов.
+func containsEmail(pwd string, email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+	pwd = strings.ToLower(pwd)
+	if strings.Contains(pwd, email) {
+		return true
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return utf8.RuneCountInString(local) >= 3 && strings.Contains(pwd, local)
+}
+
+func countTrue(flags ...bool) int {
+	n := 0
+	for _, f := range flags {
+		if f {
+			n++
+		}
+	}
+	return n
+}
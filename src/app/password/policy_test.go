@@ -0,0 +1,178 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(pwd string) string {
+	sum := sha1.Sum([]byte(pwd))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeBreached(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		t.Fatalf("write breached list: %s", err)
+	}
+	return path
+}
+
+func rules(f *Feedback) []string {
+	res := []string{}
+	for _, v := range f.Violations {
+		res = append(res, v.Rule)
+	}
+	return res
+}
+
+func TestPolicyCheck(t *testing.T) {
+	breached := writeBreached(t,
+		strings.ToUpper(sha1Hex("Tr0ub4dor&3"))+":5",
+		sha1Hex("Correct-Horse-9"),
+	)
+	tests := []struct {
+		name  string
+		cfg   PolicyConfig
+		pwd   string
+		email string
+		want  []string
+	}{
+		{"empty config accepts anything", PolicyConfig{}, "", "", []string{}},
+		{"min length", PolicyConfig{MinLength: 10}, "short", "", []string{RuleMinLength}},
+		{"min length counts runes", PolicyConfig{MinLength: 6}, "пароль", "", []string{}},
+		{"max length", PolicyConfig{MaxLength: 4}, "longer", "", []string{RuleMaxLength}},
+		{
+			"required classes",
+			PolicyConfig{RequireLower: true, RequireUpper: true, RequireDigit: true, RequireSymbol: true},
+			"abc", "",
+			[]string{RuleUpper, RuleDigit, RuleSymbol},
+		},
+		{"required classes met", PolicyConfig{RequireLower: true, RequireUpper: true, RequireDigit: true, RequireSymbol: true}, "aB3$", "", []string{}},
+		{"min classes", PolicyConfig{MinClasses: 3}, "abc123", "", []string{RuleClasses}},
+		{"min classes met", PolicyConfig{MinClasses: 3}, "Abc123", "", []string{}},
+		{"min strength", PolicyConfig{MinStrength: 3}, "qwertyuiop", "", []string{RuleStrength}},
+		{"email", PolicyConfig{RejectEmail: true}, "x-John.Doe@Example.com-x", "john.doe@example.com", []string{RuleEmail}},
+		{"email local part", PolicyConfig{RejectEmail: true}, "JOHN2024!", "john@example.com", []string{RuleEmail}},
+		{"short local part is allowed", PolicyConfig{RejectEmail: true}, "jo2024!", "jo@example.com", []string{}},
+		{"email rule without email", PolicyConfig{RejectEmail: true}, "john2024", "", []string{}},
+		{"breached", PolicyConfig{BreachedFile: breached}, "Tr0ub4dor&3", "", []string{RuleBreached}},
+		{"breached without count", PolicyConfig{BreachedFile: breached}, "Correct-Horse-9", "", []string{RuleBreached}},
+		{"breached below min count", PolicyConfig{BreachedFile: breached, BreachedMinCount: 10}, "Tr0ub4dor&3", "", []string{}},
+		{"not breached", PolicyConfig{BreachedFile: breached}, "Tr0ub4dor&4", "", []string{}},
+		{
+			"all violations are reported",
+			PolicyConfig{MinLength: 12, RequireDigit: true, MinStrength: 2, RejectEmail: true},
+			"johnny", "johnny@example.com",
+			[]string{RuleMinLength, RuleDigit, RuleStrength, RuleEmail},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPolicy(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewPolicy: %s", err)
+			}
+			res := p.Check(tt.pwd, tt.email)
+			if got := rules(res); !slices.Equal(got, tt.want) {
+				t.Errorf("Check(%q) rules = %v, want %v", tt.pwd, got, tt.want)
+			}
+			if res.Ok() != (len(tt.want) == 0) {
+				t.Errorf("Ok() = %v with rules %v", res.Ok(), rules(res))
+			}
+		})
+	}
+}
+
+func TestStrength(t *testing.T) {
+	tests := []struct {
+		pwd  string
+		want int
+	}{
+		{"", 0},
+		{"password", 0},
+		{"P@ssw0rd", 0},
+		{"aaaaaaaaaaaaaaaa", 0},
+		{"abcdefghijklmnop", 0},
+		{"qwertyuiopasdfgh", 0},
+		{"9876543210", 0},
+		{"Xk9#mQ2$vL7!pR4&wT", 4},
+	}
+	for _, tt := range tests {
+		if got := Strength(tt.pwd); got != tt.want {
+			t.Errorf("Strength(%q) = %d, want %d", tt.pwd, got, tt.want)
+		}
+	}
+
+	// Оценка не убывает при добавлении случайных символов
+	prev := 0
+	for _, pwd := range []string{"k", "k7", "k7#", "k7#Qz", "k7#Qz!m", "k7#Qz!m2X", "k7#Qz!m2Xw$", "k7#Qz!m2Xw$9L", "k7#Qz!m2Xw$9Lr&t"} {
+		s := Strength(pwd)
+		if s < prev {
+			t.Errorf("Strength(%q) = %d, less than for a shorter prefix (%d)", pwd, s, prev)
+		}
+		prev = s
+	}
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	path := writeBreached(t,
+		"# выгрузка для теста",
+		"",
+		strings.ToUpper(sha1Hex("hunter2"))+":42",
+		"  "+sha1Hex("letmein")+"  ",
+		strings.ToUpper(sha1Hex("123456"))+":1000",
+	)
+	list, err := LoadBreachedList(path)
+	if err != nil {
+		t.Fatalf("LoadBreachedList: %s", err)
+	}
+	if list.Size() != 3 {
+		t.Errorf("Size() = %d, want 3", list.Size())
+	}
+	tests := []struct {
+		pwd  string
+		want int
+	}{
+		{"hunter2", 42},
+		{"letmein", 1},
+		{"123456", 1000},
+		{"Hunter2", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := list.Count(tt.pwd); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.pwd, got, tt.want)
+		}
+	}
+}
+
+func TestLoadBreachedListErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"short hash", "5BAA61E4C9", ":1: invalid SHA-1 hash"},
+		{"not hex", strings.Repeat("Z", 40), ":1: invalid SHA-1 hash"},
+		{"bad count", sha1Hex("x") + ":many", ":1: invalid count"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadBreachedList(writeBreached(t, tt.line))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadBreachedList error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewPolicy(PolicyConfig{BreachedFile: filepath.Join(t.TempDir(), "missing.txt")}); err == nil {
+		t.Errorf("NewPolicy with missing breached file: want error")
+	}
+}
@@ -0,0 +1,105 @@
+package password
+
+import (
+	"math"
+	"strings"
+)
+
+// commonPasswords - самые частые пароли, которые получают нулевую оценку независимо от длины и состава
+var commonPasswords = map[string]struct{}{
+	"password": {}, "password1": {}, "123456": {}, "12345678": {}, "123456789": {}, "1234567890": {},
+	"qwerty": {}, "qwerty123": {}, "qwertyuiop": {}, "111111": {}, "123123": {}, "abc123": {},
+	"iloveyou": {}, "admin": {}, "welcome": {}, "letmein": {}, "monkey": {}, "dragon": {},
+	"football": {}, "baseball": {}, "sunshine": {}, "princess": {}, "000000": {}, "1q2w3e4r": {},
+	"zaq12wsx": {}, "passw0rd": {}, "p@ssw0rd": {}, "йцукен": {}, "пароль": {},
+}
+
+// keyboardRows - ряды клавиатуры для поиска последовательностей вроде qwerty и asdf
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+	"йцукенгшщзхъ",
+	"фывапролджэ",
+	"ячсмитьбю",
+}
+
+// Strength оценивает стойкость пароля по шкале от 0 (угадывается сразу) до 4 (очень стойкий).
+// Оценка строится по энтропии: размер алфавита по классам символов в степени длины, где повторы
+// и последовательности (abc, 321, qwerty) учитываются как один символ. Это грубая офлайн оценка,
+// она не заменяет проверку по списку утечек.
+//
+// Параметры:
+//   - pwd: пароль
+//
+// Возвращает:
+//   - оценку от 0 до 4
+func Strength(pwd string) int {
+	if pwd == "" {
+		return 0
+	}
+	if _, ok := commonPasswords[strings.ToLower(pwd)]; ok {
+		return 0
+	}
+
+	lower, upper, digit, symbol := classes(pwd)
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+
+	bits := float64(effectiveLength(pwd)) * math.Log2(float64(pool))
+	switch {
+	case bits < 28:
+		return 0
+	case bits < 36:
+		return 1
+	case bits < 60:
+		return 2
+	case bits < 100:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// effectiveLength считает длину пароля, в которой каждый повтор предыдущего символа и каждый шаг
+// последовательности (по кодам символов или по ряду клавиатуры) не добавляет длины.
+func effectiveLength(pwd string) int {
+	runes := []rune(strings.ToLower(pwd))
+	n := 1
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		switch {
+		case cur == prev:
+		case cur-prev == 1 || prev-cur == 1:
+		case keyboardNeighbours(prev, cur):
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// keyboardNeighbours сообщает, что символы стоят рядом в одном ряду клавиатуры.
+func keyboardNeighbours(a rune, b rune) bool {
+	for _, row := range keyboardRows {
+		r := []rune(row)
+		for i := 1; i < len(r); i++ {
+			if (r[i-1] == a && r[i] == b) || (r[i-1] == b && r[i] == a) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// v2Signature - сигнатура заголовка PROXY protocol v2
+var v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// v1MaxLength - максимальная длина строки заголовка PROXY protocol v1 вместе с CRLF
+const v1MaxLength = 107
+
+// Listener принимает соединения с заголовком PROXY protocol v1 или v2 и подменяет RemoteAddr соединения
+// адресом клиента из заголовка. Заголовок читается только у соединений от доверенных прокси, и у них он
+// необязателен (например, проверки здоровья балансировщика); у остальных соединений байты не трогаются.
+type Listener struct {
+	net.Listener
+	resolver *Resolver
+	timeout  time.Duration
+}
+
+// NewListener оборачивает листенер разбором PROXY protocol.
+//
+// Параметры:
+//   - inner: исходный листенер
+//   - resolver: доверенные прокси
+//   - timeout: сколько ждать заголовок после подключения, 0 - без ограничения
+//
+// Возвращает:
+//   - указатель на новый экземпляр Listener
+func NewListener(inner net.Listener, resolver *Resolver, timeout time.Duration) *Listener {
+	return &Listener{Listener: inner, resolver: resolver, timeout: timeout}
+}
+
+// Accept принимает соединение. Заголовок читается при первом чтении или вызове RemoteAddr в горутине
+// соединения, чтобы медленный клиент не задерживал прием остальных.
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, listener: l, reader: bufio.NewReader(c)}, nil
+}
+
+// conn - соединение с заголовком PROXY protocol
+type conn struct {
+	net.Conn
+	listener *Listener
+	reader   *bufio.Reader
+	once     sync.Once
+	remote   net.Addr
+	err      error
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	c.init()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	c.init()
+	return c.remote
+}
+
+// init читает заголовок один раз; ошибка разбора возвращается из всех следующих Read.
+func (c *conn) init() {
+	c.once.Do(func() {
+		c.remote = c.Conn.RemoteAddr()
+		peer, ok := ParseAddress(c.remote.String())
+		if !ok || !c.listener.resolver.Trusted(peer) {
+			return
+		}
+
+		if c.listener.timeout > 0 {
+			c.Conn.SetReadDeadline(time.Now().Add(c.listener.timeout))
+			defer c.Conn.SetReadDeadline(time.Time{})
+		}
+		remote, err := ReadHeader(c.reader)
+		if err != nil {
+			c.err = err
+			return
+		}
+		if remote != nil {
+			c.remote = remote
+		}
+	})
+}
+
+// ReadHeader читает заголовок PROXY protocol v1 или v2, если поток с него начинается.
+//
+// Параметры:
+//   - r: поток соединения; байты после заголовка остаются в нем
+//
+// Возвращает:
+//   - адрес клиента из заголовка; nil, если заголовка нет или он не передает адрес (LOCAL, UNKNOWN, UNIX)
+//   - ошибку чтения или неверного заголовка
+func ReadHeader(r *bufio.Reader) (net.Addr, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	switch first[0] {
+	case 'P':
+		if prefix, err := r.Peek(6); err != nil || string(prefix) != "PROXY " {
+			return nil, nil
+		}
+		return readV1(r)
+	case '\r':
+		if prefix, err := r.Peek(len(v2Signature)); err != nil || !bytes.Equal(prefix, v2Signature) {
+			return nil, nil
+		}
+		return readV2(r)
+	}
+	return nil, nil
+}
+
+// readV1 разбирает текстовый заголовок "PROXY TCP4 <src> <dst> <sport> <dport>\r\n".
+func readV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < v1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("proxy protocol v1: %w", err)
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("proxy protocol v1: header is too long or not terminated by CRLF")
+	}
+
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("proxy protocol v1: invalid header %q", line)
+	}
+	src, err := netip.ParseAddr(fields[2])
+	if err != nil || src.Is4() != (fields[1] == "TCP4") {
+		return nil, fmt.Errorf("proxy protocol v1: invalid source address %q", fields[2])
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("proxy protocol v1: invalid source port %q", fields[4])
+	}
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(src, uint16(port))), nil
+}
+
+// readV2 разбирает бинарный заголовок: сигнатура, версия и команда, семейство и протокол, длина адресов,
+// адреса и TLV, которые пропускаются.
+func readV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("proxy protocol v2: %w", err)
+	}
+	if header[12]>>4 != 2 {
+		return nil, fmt.Errorf("proxy protocol v2: unsupported version %d", header[12]>>4)
+	}
+	command := header[12] & 0x0f
+	family := header[13] >> 4
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("proxy protocol v2: %w", err)
+	}
+
+	switch command {
+	case 0x0: // LOCAL - соединение самого прокси, например проверка здоровья
+		return nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, fmt.Errorf("proxy protocol v2: unsupported command %d", command)
+	}
+
+	switch family {
+	case 0x1: // AF_INET: src(4) dst(4) sport(2) dport(2)
+		if len(payload) < 12 {
+			return nil, fmt.Errorf("proxy protocol v2: short IPv4 address block")
+		}
+		src := netip.AddrFrom4([4]byte(payload[0:4]))
+		return net.TCPAddrFromAddrPort(netip.AddrPortFrom(src, binary.BigEndian.Uint16(payload[8:10]))), nil
+	case 0x2: // AF_INET6: src(16) dst(16) sport(2) dport(2)
+		if len(payload) < 36 {
+			return nil, fmt.Errorf("proxy protocol v2: short IPv6 address block")
+		}
+		src := netip.AddrFrom16([16]byte(payload[0:16])).Unmap()
+		return net.TCPAddrFromAddrPort(netip.AddrPortFrom(src, binary.BigEndian.Uint16(payload[32:34]))), nil
+	}
+	// AF_UNSPEC и AF_UNIX не передают IP адрес
+	return nil, nil
+}
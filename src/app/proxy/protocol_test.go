@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// v2Header собирает бинарный заголовок PROXY protocol v2.
+func v2Header(version_command byte, family_proto byte, payload []byte) string {
+	header := append([]byte{}, v2Signature...)
+	header = append(header, version_command, family_proto)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	return string(append(header, payload...))
+}
+
+func v2IPv4(src [4]byte, sport uint16) []byte {
+	payload := append(src[:], 10, 0, 0, 1)
+	payload = binary.BigEndian.AppendUint16(payload, sport)
+	return binary.BigEndian.AppendUint16(payload, 443)
+}
+
+func v2IPv6(src [16]byte, sport uint16) []byte {
+	payload := append(src[:], make([]byte, 16)...)
+	payload = binary.BigEndian.AppendUint16(payload, sport)
+	return binary.BigEndian.AppendUint16(payload, 443)
+}
+
+func TestReadHeader(t *testing.T) {
+	ipv6 := [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}
+	mapped := [16]byte{10: 0xff, 11: 0xff, 12: 203, 13: 0, 14: 113, 15: 7}
+	tlv := []byte{0x04, 0x00, 0x03, 'a', 'b', 'c'}
+
+	tests := []struct {
+		name   string
+		input  string
+		remote string
+		rest   string
+	}{
+		{"no header", "GET / HTTP/1.1\r\n", "", "GET / HTTP/1.1\r\n"},
+		{"empty stream", "", "", ""},
+		{"starts like v1", "POST / HTTP/1.1\r\n", "", "POST / HTTP/1.1\r\n"},
+		{"starts like v2", "\r\nGET", "", "\r\nGET"},
+		{"v1 tcp4", "PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\r\nGET", "203.0.113.7:51234", "GET"},
+		{"v1 tcp6", "PROXY TCP6 2001:db8::1 2001:db8::2 51234 443\r\nGET", "[2001:db8::1]:51234", "GET"},
+		{"v1 unknown", "PROXY UNKNOWN\r\nGET", "", "GET"},
+		{"v1 unknown with addresses", "PROXY UNKNOWN ffff:f::1 ffff:f::2 1 2\r\nGET", "", "GET"},
+		{"v2 ipv4", v2Header(0x21, 0x11, v2IPv4([4]byte{203, 0, 113, 7}, 51234)) + "GET", "203.0.113.7:51234", "GET"},
+		{"v2 ipv4 with tlv", v2Header(0x21, 0x11, append(v2IPv4([4]byte{203, 0, 113, 7}, 51234), tlv...)) + "GET", "203.0.113.7:51234", "GET"},
+		{"v2 ipv6", v2Header(0x21, 0x21, v2IPv6(ipv6, 51234)) + "GET", "[2001:db8::1]:51234", "GET"},
+		{"v2 ipv4 mapped ipv6", v2Header(0x21, 0x21, v2IPv6(mapped, 51234)) + "GET", "203.0.113.7:51234", "GET"},
+		{"v2 local", v2Header(0x20, 0x00, nil) + "GET", "", "GET"},
+		{"v2 local skips addresses", v2Header(0x20, 0x11, v2IPv4([4]byte{203, 0, 113, 7}, 1)) + "GET", "", "GET"},
+		{"v2 unix", v2Header(0x21, 0x31, make([]byte, 216)) + "GET", "", "GET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tt.input))
+			remote, err := ReadHeader(r)
+			if err != nil {
+				t.Fatalf("ReadHeader: %s", err)
+			}
+			got := ""
+			if remote != nil {
+				got = remote.String()
+			}
+			if got != tt.remote {
+				t.Errorf("ReadHeader remote = %q, want %q", got, tt.remote)
+			}
+			if rest, _ := io.ReadAll(r); string(rest) != tt.rest {
+				t.Errorf("rest of stream = %q, want %q", rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestReadHeaderInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"v1 no crlf", "PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\n", "not terminated by CRLF"},
+		{"v1 too long", "PROXY TCP4 " + strings.Repeat("1", 200) + "\r\n", "too long"},
+		{"v1 truncated", "PROXY TCP4 203.0.113.7", "EOF"},
+		{"v1 missing fields", "PROXY TCP4 203.0.113.7 10.0.0.1 51234\r\n", "invalid header"},
+		{"v1 unknown protocol", "PROXY UDP4 203.0.113.7 10.0.0.1 51234 443\r\n", "invalid header"},
+		{"v1 bad address", "PROXY TCP4 203.0.113 10.0.0.1 51234 443\r\n", "invalid source address"},
+		{"v1 family mismatch", "PROXY TCP4 2001:db8::1 10.0.0.1 51234 443\r\n", "invalid source address"},
+		{"v1 bad port", "PROXY TCP4 203.0.113.7 10.0.0.1 70000 443\r\n", "invalid source port"},
+		{"v2 wrong version", v2Header(0x11, 0x11, v2IPv4([4]byte{203, 0, 113, 7}, 1)), "unsupported version 1"},
+		{"v2 wrong command", v2Header(0x22, 0x11, v2IPv4([4]byte{203, 0, 113, 7}, 1)), "unsupported command 2"},
+		{"v2 short ipv4", v2Header(0x21, 0x11, make([]byte, 8)), "short IPv4"},
+		{"v2 short ipv6", v2Header(0x21, 0x21, make([]byte, 20)), "short IPv6"},
+		{"v2 truncated payload", v2Header(0x21, 0x11, v2IPv4([4]byte{203, 0, 113, 7}, 1))[:20], "unexpected EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadHeader(bufio.NewReader(strings.NewReader(tt.input)))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ReadHeader error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestListener(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen on loopback: %s", err)
+	}
+	defer inner.Close()
+
+	tests := []struct {
+		name    string
+		trusted []string
+		send    string
+		remote  string
+		read    string
+	}{
+		{"trusted proxy", []string{"127.0.0.1"}, "PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\r\nping", "203.0.113.7:51234", "ping"},
+		{"trusted proxy without header", []string{"127.0.0.0/8"}, "ping", "127.0.0.1", "ping"},
+		{"untrusted peer keeps header bytes", nil, "PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\r\n", "127.0.0.1", "PROXY TCP4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, err := NewResolver(Config{TrustedProxies: tt.trusted})
+			if err != nil {
+				t.Fatalf("NewResolver: %s", err)
+			}
+			l := NewListener(inner, resolver, time.Second)
+
+			client, err := net.Dial("tcp", inner.Addr().String())
+			if err != nil {
+				t.Fatalf("dial: %s", err)
+			}
+			defer client.Close()
+			if _, err := client.Write([]byte(tt.send)); err != nil {
+				t.Fatalf("write: %s", err)
+			}
+
+			c, err := l.Accept()
+			if err != nil {
+				t.Fatalf("Accept: %s", err)
+			}
+			defer c.Close()
+
+			if got := c.RemoteAddr().String(); !strings.HasPrefix(got, tt.remote) {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.remote)
+			}
+			buf := make([]byte, len(tt.read))
+			if _, err := io.ReadFull(c, buf); err != nil || string(buf) != tt.read {
+				t.Errorf("Read = %q, %v, want %q", buf, err, tt.read)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Config - доверенные прокси и прием PROXY protocol
+type Config struct {
+	// TrustedProxies - адреса и подсети (CIDR) прокси, которым разрешено передавать адрес клиента в заголовках
+	// Forwarded и X-Forwarded-For и в PROXY protocol. Пусто - клиентом считается адрес TCP соединения
+	TrustedProxies []string      `yaml:"trusted_proxies"`
+	ProxyProtocol  bool          `yaml:"proxy_protocol"` // принимать заголовок PROXY protocol v1/v2 от доверенных прокси на листенере
+	HeaderTimeout  time.Duration `yaml:"header_timeout"` // сколько ждать заголовок PROXY protocol после подключения
+}
+
+// Resolver определяет адрес клиента запроса с учетом доверенных прокси.
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// NewResolver разбирает список доверенных прокси.
+//
+// Параметры:
+//   - cfg: настройки доверенных прокси
+//
+// Возвращает:
+//   - указатель на новый экземпляр Resolver
+//   - ошибку, если адрес или подсеть записаны неверно
+func NewResolver(cfg Config) (*Resolver, error) {
+	res := &Resolver{}
+	for _, raw := range cfg.TrustedProxies {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if strings.Contains(raw, "/") {
+			prefix, err := netip.ParsePrefix(raw)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", raw, err)
+			}
+			if prefix.Addr().Is4In6() {
+				prefix = netip.PrefixFrom(prefix.Addr().Unmap(), max(prefix.Bits()-96, 0))
+			}
+			res.trusted = append(res.trusted, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(raw)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", raw, err)
+		}
+		addr = addr.Unmap().WithZone("")
+		res.trusted = append(res.trusted, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return res, nil
+}
+
+// Trusted сообщает, что адрес принадлежит доверенному прокси.
+//
+// Параметры:
+//   - addr: адрес
+//
+// Возвращает:
+//   - true, если адрес входит в одну из доверенных подсетей
+func (r *Resolver) Trusted(addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientAddress возвращает нормализованный адрес клиента запроса. Заголовки Forwarded (RFC 7239) или, если его нет,
+// X-Forwarded-For учитываются, только если запрос пришел от доверенного прокси. Цепочка адресов просматривается
+// справа налево: клиент - первый адрес, не принадлежащий доверенному прокси. Адрес, который нельзя разобрать
+// (unknown, обфусцированный идентификатор), обрывает цепочку, тогда клиентом считается последний доверенный прокси.
+//
+// Параметры:
+//   - req: HTTP запрос; RemoteAddr уже учитывает PROXY protocol, если он включен на листенере
+//
+// Возвращает:
+//   - адрес клиента без порта и зоны, IPv4 в IPv6 форме приводится к IPv4; пустая строка, если RemoteAddr не адрес
+func (r *Resolver) ClientAddress(req *http.Request) string {
+	peer, ok := ParseAddress(req.RemoteAddr)
+	if !ok {
+		return ""
+	}
+	if !r.Trusted(peer) {
+		return peer.String()
+	}
+
+	hops := forwardedFor(req.Header)
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := ParseAddress(hops[i])
+		if !ok {
+			break
+		}
+		client = addr
+		if !r.Trusted(addr) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// ParseAddress разбирает адрес в любой из форм: "1.2.3.4", "1.2.3.4:80", "::1", "[::1]:80", "\"[::1]:80\"".
+//
+// Параметры:
+//   - raw: адрес
+//
+// Возвращает:
+//   - адрес без порта и зоны, IPv4 в IPv6 форме приводится к IPv4
+//   - false, если строка не адрес
+func ParseAddress(raw string) (netip.Addr, bool) {
+	raw = strings.Trim(strings.TrimSpace(raw), `"`)
+	if addr_port, err := netip.ParseAddrPort(raw); err == nil {
+		return addr_port.Addr().Unmap().WithZone(""), true
+	}
+	raw = strings.TrimSuffix(strings.TrimPrefix(raw, "["), "]")
+	addr, err := netip.ParseAddr(raw)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
+
+// forwardedFor возвращает цепочку адресов клиента и прокси из заголовка Forwarded или, если его нет, X-Forwarded-For.
+// Элемент Forwarded без параметра for дает пустой адрес, который обрывает цепочку.
+func forwardedFor(header http.Header) []string {
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		var hops []string
+		for _, value := range values {
+			for _, element := range splitQuoted(value, ',') {
+				hop := ""
+				for _, pair := range splitQuoted(element, ';') {
+					key, val, _ := strings.Cut(pair, "=")
+					if strings.EqualFold(strings.TrimSpace(key), "for") {
+						hop = strings.TrimSpace(val)
+					}
+				}
+				hops = append(hops, hop)
+			}
+		}
+		return hops
+	}
+
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// splitQuoted делит строку по разделителю вне кавычек.
+func splitQuoted(s string, sep byte) []string {
+	var res []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			res = append(res, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(res, s[start:])
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"203.0.113.7", "203.0.113.7"},
+		{" 203.0.113.7:8080 ", "203.0.113.7"},
+		{"2001:db8::1", "2001:db8::1"},
+		{"[2001:db8::1]:443", "2001:db8::1"},
+		{"[2001:db8::1]", "2001:db8::1"},
+		{`"[2001:db8::1]:443"`, "2001:db8::1"},
+		{"::ffff:203.0.113.7", "203.0.113.7"},
+		{"[::ffff:203.0.113.7]:80", "203.0.113.7"},
+		{"fe80::1%eth0", "fe80::1"},
+		{"", ""},
+		{"unknown", ""},
+		{"_hidden", ""},
+		{"example.com:80", ""},
+		{"203.0.113.256", ""},
+	}
+	for _, tt := range tests {
+		addr, ok := ParseAddress(tt.raw)
+		if ok != (tt.want != "") {
+			t.Errorf("ParseAddress(%q) ok = %v", tt.raw, ok)
+			continue
+		}
+		if ok && addr.String() != tt.want {
+			t.Errorf("ParseAddress(%q) = %q, want %q", tt.raw, addr, tt.want)
+		}
+	}
+}
+
+func TestNewResolver(t *testing.T) {
+	r, err := NewResolver(Config{TrustedProxies: []string{" 10.0.0.0/8", "", "::ffff:192.168.0.0/112", "2001:db8::1", "::ffff:172.16.0.1"}})
+	if err != nil {
+		t.Fatalf("NewResolver: %s", err)
+	}
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"::ffff:10.1.2.3", true},
+		{"11.0.0.1", false},
+		{"192.168.5.5", true},
+		{"192.169.0.1", false},
+		{"2001:db8::1", true},
+		{"fe80::1", false},
+		{"172.16.0.1", true},
+		{"172.16.0.2", false},
+	}
+	for _, tt := range tests {
+		if got := r.Trusted(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("Trusted(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0.1/x"} {
+		if _, err := NewResolver(Config{TrustedProxies: []string{bad}}); err == nil {
+			t.Errorf("NewResolver(%q): want error", bad)
+		}
+	}
+}
+
+func TestClientAddress(t *testing.T) {
+	r, err := NewResolver(Config{TrustedProxies: []string{"10.0.0.0/8", "2001:db8::/32"}})
+	if err != nil {
+		t.Fatalf("NewResolver: %s", err)
+	}
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		xff       []string
+		want      string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, nil, "203.0.113.7"},
+		{"untrusted peer ignores headers", "203.0.113.7:5000", []string{"for=198.51.100.1"}, []string{"198.51.100.1"}, "203.0.113.7"},
+		{"not an address", "@", nil, nil, ""},
+		{"trusted peer without headers", "10.0.0.1:5000", nil, nil, "10.0.0.1"},
+		{"xff single hop", "10.0.0.1:5000", nil, []string{"203.0.113.7"}, "203.0.113.7"},
+		{"xff skips trusted hops", "10.0.0.1:5000", nil, []string{"203.0.113.7, 10.0.0.3, 10.0.0.2"}, "203.0.113.7"},
+		{"xff spoofed left part", "10.0.0.1:5000", nil, []string{"1.1.1.1, 203.0.113.7"}, "203.0.113.7"},
+		{"xff several headers", "10.0.0.1:5000", nil, []string{"1.1.1.1", "203.0.113.7, 10.0.0.2"}, "203.0.113.7"},
+		{"xff garbage stops chain", "10.0.0.1:5000", nil, []string{"203.0.113.7, garbage, 10.0.0.2"}, "10.0.0.2"},
+		{"xff all trusted", "10.0.0.1:5000", nil, []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"forwarded", "10.0.0.1:5000", []string{"for=203.0.113.7;proto=https"}, nil, "203.0.113.7"},
+		{"forwarded wins over xff", "10.0.0.1:5000", []string{"for=203.0.113.7"}, []string{"198.51.100.1"}, "203.0.113.7"},
+		{"forwarded ipv6 quoted", "[2001:db8::10]:443", []string{`For="[2001:db8:cafe::17]:4711"`}, nil, "2001:db8:cafe::17"},
+		{"forwarded chain", "10.0.0.1:5000", []string{`for=1.1.1.1, for=203.0.113.7;by=10.0.0.2, for="10.0.0.2"`}, nil, "203.0.113.7"},
+		{"forwarded quoted separators", "10.0.0.1:5000", []string{`for=203.0.113.7;host="a,b;c", for=10.0.0.2`}, nil, "203.0.113.7"},
+		{"forwarded unknown stops chain", "10.0.0.1:5000", []string{"for=203.0.113.7, for=unknown"}, nil, "10.0.0.1"},
+		{"forwarded obfuscated stops chain", "10.0.0.1:5000", []string{"for=203.0.113.7, for=_proxy, for=10.0.0.2"}, nil, "10.0.0.2"},
+		{"forwarded element without for", "10.0.0.1:5000", []string{"for=203.0.113.7, proto=https"}, nil, "10.0.0.1"},
+		{"mapped ipv4 client", "10.0.0.1:5000", nil, []string{"::ffff:203.0.113.7"}, "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &http.Request{RemoteAddr: tt.remote, Header: http.Header{}}
+			for _, v := range tt.forwarded {
+				req.Header.Add("Forwarded", v)
+			}
+			for _, v := range tt.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if got := r.ClientAddress(req); got != tt.want {
+				t.Errorf("ClientAddress = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// tokenBucket списывает токен из ведра емкостью Burst, которое пополняется на Limit токенов за Window.
+func tokenBucket(rule Rule, st *State, now time.Time) Result {
+	capacity := float64(rule.Burst)
+	if capacity <= 0 {
+		capacity = float64(rule.Limit)
+	}
+	rate := float64(rule.Limit) / rule.Window.Seconds() // токенов в секунду
+
+	if st.Time.IsZero() {
+		st.Value = capacity
+	} else if elapsed := now.Sub(st.Time).Seconds(); elapsed > 0 {
+		st.Value = math.Min(capacity, st.Value+elapsed*rate)
+	}
+	st.Time = now
+
+	res := Result{Limit: int(capacity)}
+	if st.Value >= 1 {
+		st.Value--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - st.Value) / rate)
+	}
+	res.Remaining = int(math.Floor(st.Value))
+	res.Reset = seconds((capacity - st.Value) / rate)
+	return res
+}
+
+// slidingWindow считает запросы в скользящем окне как сумму счетчика текущего окна и счетчика предыдущего окна
+// с весом доли, на которую скользящее окно его перекрывает.
+func slidingWindow(rule Rule, st *State, now time.Time) Result {
+	start := now.Truncate(rule.Window)
+	if !st.Time.Equal(start) {
+		if st.Time.Equal(start.Add(-rule.Window)) {
+			st.Previous = st.Value
+		} else {
+			st.Previous = 0
+		}
+		st.Value = 0
+		st.Time = start
+	}
+
+	limit := float64(rule.Limit)
+	weight := 1 - float64(now.Sub(start))/float64(rule.Window)
+	estimate := st.Previous*weight + st.Value
+
+	res := Result{Limit: rule.Limit, Reset: seconds(start.Add(rule.Window).Sub(now).Seconds())}
+	if estimate+1 <= limit {
+		st.Value++
+		res.Allowed = true
+		estimate++
+	} else {
+		// Запрос пройдет, когда вес предыдущего окна упадет до (limit-1-текущий)/предыдущий, или в новом окне
+		res.RetryAfter = res.Reset
+		if free := limit - 1 - st.Value; free >= 0 && st.Previous > 0 {
+			at := start.Add(time.Duration((1 - free/st.Previous) * float64(rule.Window)))
+			res.RetryAfter = max(seconds(at.Sub(now).Seconds()), time.Second)
+		}
+	}
+	res.Remaining = max(int(math.Floor(limit-estimate)), 0)
+	return res
+}
+
+// apply считает запрос алгоритмом ограничения.
+func apply(rule Rule, st *State, now time.Time) Result {
+	if rule.Algorithm == AlgorithmSlidingWindow {
+		return slidingWindow(rule, st, now)
+	}
+	return tokenBucket(rule, st, now)
+}
+
+// ttl - сколько хранить счетчик без запросов: пока ведро не наполнится или два окна.
+func ttl(rule Rule) time.Duration {
+	if rule.Algorithm == AlgorithmSlidingWindow {
+		return 2 * rule.Window
+	}
+	burst := rule.Burst
+	if burst <= 0 {
+		burst = rule.Limit
+	}
+	return time.Duration(float64(rule.Window)*float64(burst)/float64(rule.Limit)) + time.Second
+}
+
+// seconds округляет время в секундах вверх до целых секунд для заголовков.
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s)) * time.Second
+}
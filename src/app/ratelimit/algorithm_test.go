@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// step - запрос в момент start+at и ожидаемый итог
+type step struct {
+	at         time.Duration
+	allowed    bool
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+func runSteps(t *testing.T, algorithm func(Rule, *State, time.Time) Result, rule Rule, steps []step) {
+	t.Helper()
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	var st State
+	for i, s := range steps {
+		res := algorithm(rule, &st, start.Add(s.at))
+		if res.Allowed != s.allowed || res.Remaining != s.remaining || res.Reset != s.reset || res.RetryAfter != s.retryAfter {
+			t.Errorf("step %d at %s = %+v, want allowed %v, remaining %d, reset %s, retry after %s",
+				i, s.at, res, s.allowed, s.remaining, s.reset, s.retryAfter)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  Rule
+		limit int
+		steps []step
+	}{
+		{
+			name:  "burst then refill",
+			rule:  Rule{Algorithm: AlgorithmTokenBucket, Limit: 10, Window: 10 * time.Second, Burst: 3},
+			limit: 3,
+			steps: []step{
+				{0, true, 2, time.Second, 0},
+				{0, true, 1, 2 * time.Second, 0},
+				{0, true, 0, 3 * time.Second, 0},
+				{0, false, 0, 3 * time.Second, time.Second},
+				{1500 * time.Millisecond, true, 0, 3 * time.Second, 0},
+				{1500 * time.Millisecond, false, 0, 3 * time.Second, time.Second},
+				{time.Minute, true, 2, time.Second, 0},
+			},
+		},
+		{
+			name:  "burst defaults to limit",
+			rule:  Rule{Algorithm: AlgorithmTokenBucket, Limit: 2, Window: 2 * time.Second},
+			limit: 2,
+			steps: []step{
+				{0, true, 1, time.Second, 0},
+				{0, true, 0, 2 * time.Second, 0},
+				{0, false, 0, 2 * time.Second, time.Second},
+				{500 * time.Millisecond, false, 0, 2 * time.Second, time.Second},
+				{time.Second, true, 0, 2 * time.Second, 0},
+			},
+		},
+		{
+			name:  "clock going back does not refill",
+			rule:  Rule{Algorithm: AlgorithmTokenBucket, Limit: 1, Window: time.Second},
+			limit: 1,
+			steps: []step{
+				{time.Second, true, 0, time.Second, 0},
+				{0, false, 0, time.Second, time.Second},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteps(t, tokenBucket, tt.rule, tt.steps)
+			var st State
+			if res := tokenBucket(tt.rule, &st, time.Now()); res.Limit != tt.limit {
+				t.Errorf("Limit = %d, want %d", res.Limit, tt.limit)
+			}
+		})
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	rule := Rule{Algorithm: AlgorithmSlidingWindow, Limit: 4, Window: 10 * time.Second}
+	runSteps(t, slidingWindow, rule, []step{
+		// Первое окно: четыре запроса, пятый ждет нового окна
+		{0, true, 3, 10 * time.Second, 0},
+		{time.Second, true, 2, 9 * time.Second, 0},
+		{2 * time.Second, true, 1, 8 * time.Second, 0},
+		{3 * time.Second, true, 0, 7 * time.Second, 0},
+		{4 * time.Second, false, 0, 6 * time.Second, 6 * time.Second},
+		// Середина второго окна: предыдущее окно весит половину
+		{15 * time.Second, true, 1, 5 * time.Second, 0},
+		{15 * time.Second, true, 0, 5 * time.Second, 0},
+		{15 * time.Second, false, 0, 5 * time.Second, 3 * time.Second},
+		{18 * time.Second, true, 0, 2 * time.Second, 0},
+		// Пропущенное окно обнуляет предыдущий счетчик
+		{35 * time.Second, true, 3, 5 * time.Second, 0},
+	})
+
+	var st State
+	if res := slidingWindow(rule, &st, time.Now()); res.Limit != 4 {
+		t.Errorf("Limit = %d, want 4", res.Limit)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	tests := []struct {
+		rule Rule
+		want time.Duration
+	}{
+		{Rule{Algorithm: AlgorithmSlidingWindow, Limit: 5, Window: time.Minute}, 2 * time.Minute},
+		{Rule{Algorithm: AlgorithmTokenBucket, Limit: 10, Window: 10 * time.Second, Burst: 3}, 4 * time.Second},
+		{Rule{Algorithm: AlgorithmTokenBucket, Limit: 5, Window: time.Minute}, time.Minute + time.Second},
+	}
+	for _, tt := range tests {
+		if got := ttl(tt.rule); got != tt.want {
+			t.Errorf("ttl(%+v) = %s, want %s", tt.rule, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryCleanupInterval - как часто удаляются истекшие счетчики
+const memoryCleanupInterval = time.Minute
+
+type memoryEntry struct {
+	state     State
+	expiresAt time.Time
+}
+
+// MemoryStore хранит счетчики в памяти процесса. Подходит для одного экземпляра сервиса.
+type MemoryStore struct {
+	mu          sync.Mutex
+	entries     map[string]*memoryEntry
+	lastCleanup time.Time
+}
+
+// NewMemoryStore создает хранилище в памяти.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]*memoryEntry{}, lastCleanup: time.Now()}
+}
+
+// Update обновляет счетчик под общей блокировкой и раз в минуту удаляет истекшие.
+func (s *MemoryStore) Update(_ context.Context, key string, ttl time.Duration, fn func(st *State)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastCleanup) > memoryCleanupInterval {
+		for k, e := range s.entries {
+			if now.After(e.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastCleanup = now
+	}
+
+	e, ok := s.entries[key]
+	if !ok || now.After(e.expiresAt) {
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+	fn(&e.state)
+	e.expiresAt = now.Add(ttl)
+	return nil
+}
+
+// Close ничего не делает.
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+)
+
+// postgresCleanupInterval - как часто удаляются истекшие счетчики
+const postgresCleanupInterval = time.Minute
+
+// PostgresStore хранит счетчики в таблице RateLimit. Строка счетчика блокируется на время обновления
+// (SELECT ... FOR UPDATE), поэтому параллельные запросы разных экземпляров не теряют изменения.
+type PostgresStore struct {
+	pgRepo *core.PgRepo
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+// NewPostgresStore создает хранилище в Postgres.
+//
+// Параметры:
+//   - pgRepo: пул соединений
+//
+// Возвращает:
+//   - указатель на новый экземпляр PostgresStore
+func NewPostgresStore(pgRepo *core.PgRepo) *PostgresStore {
+	return &PostgresStore{pgRepo: pgRepo, lastCleanup: time.Now()}
+}
+
+// Update обновляет счетчик в транзакции.
+func (s *PostgresStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(st *State)) error {
+	const qInsert = `
+		INSERT INTO "RateLimit" (key, expires_at)
+		VALUES ($1, NOW() + make_interval(secs => $2))
+		ON CONFLICT (key) DO NOTHING;
+	`
+	const qSelect = `
+		SELECT
+			value
+			, previous
+			, stamp
+			, expires_at < NOW()
+		FROM "RateLimit"
+		WHERE True
+			AND key = $1
+		FOR UPDATE;
+	`
+	const qUpdate = `
+		UPDATE "RateLimit"
+		SET
+			value = $2
+			, previous = $3
+			, stamp = $4
+			, expires_at = NOW() + make_interval(secs => $5)
+		WHERE True
+			AND key = $1;
+	`
+
+	s.cleanup(ctx)
+
+	conn, err := s.pgRepo.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, qInsert, key, ttl.Seconds()); err != nil {
+		return err
+	}
+	var st State
+	var stamp *time.Time
+	var expired bool
+	if err := tx.QueryRow(ctx, qSelect, key).Scan(&st.Value, &st.Previous, &stamp, &expired); err != nil {
+		return err
+	}
+	if stamp != nil && !expired {
+		st.Time = *stamp
+	} else {
+		st = State{}
+	}
+
+	fn(&st)
+
+	if _, err := tx.Exec(ctx, qUpdate, key, st.Value, st.Previous, st.Time.UTC(), ttl.Seconds()); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// cleanup удаляет истекшие счетчики не чаще раза в минуту.
+func (s *PostgresStore) cleanup(ctx context.Context) {
+	const q = `
+		DELETE FROM "RateLimit"
+		WHERE expires_at < NOW();
+	`
+
+	s.mu.Lock()
+	if time.Since(s.lastCleanup) < postgresCleanupInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastCleanup = time.Now()
+	s.mu.Unlock()
+
+	conn, err := s.pgRepo.Acquire(ctx)
+	if err != nil {
+		log.Printf("rate limit: cleanup: %s", err)
+		return
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, q); err != nil {
+		log.Printf("rate limit: cleanup: %s", err)
+	}
+}
+
+// Close ничего не делает: пулом владеет репозиторий.
+func (s *PostgresStore) Close() error {
+	return nil
+}
@@ -0,0 +1,228 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+)
+
+const (
+	// AlgorithmTokenBucket - ведро на Burst запросов, пополняется со скоростью Limit за Window
+	AlgorithmTokenBucket = "token_bucket"
+	// AlgorithmSlidingWindow - не больше Limit запросов за скользящее окно Window; окно считается по счетчикам
+	// текущего и предыдущего окна с весом пересечения
+	AlgorithmSlidingWindow = "sliding_window"
+)
+
+const (
+	// ByIP - лимит на IP адрес клиента
+	ByIP = "ip"
+	// ByAccount - лимит на аккаунт: из access токена, иначе по email, signup_id или refresh_token из тела запроса,
+	// иначе по IP адресу
+	ByAccount = "account"
+)
+
+const (
+	// StoreMemory - счетчики в памяти процесса, для одного экземпляра сервиса
+	StoreMemory = "memory"
+	// StorePostgres - счетчики в таблице RateLimit, общие для всех экземпляров
+	StorePostgres = "postgres"
+	// StoreRedis - счетчики в Redis, общие для всех экземпляров
+	StoreRedis = "redis"
+)
+
+// Rule - одно ограничение маршрута
+type Rule struct {
+	Name      string        `yaml:"name"`      // имя в ключе счетчика и заголовке RateLimit-Policy
+	By        string        `yaml:"by"`        // ip или account
+	Algorithm string        `yaml:"algorithm"` // token_bucket или sliding_window
+	Limit     int           `yaml:"limit"`     // запросов за Window
+	Window    time.Duration `yaml:"window"`    // окно
+	Burst     int           `yaml:"burst"`     // емкость ведра token_bucket, 0 - Limit
+}
+
+// Config - хранилище счетчиков и ограничения маршрутов
+type Config struct {
+	Store string `yaml:"store"` // memory, postgres или redis
+	// Redis
+	RedisAddr     string `yaml:"redis_addr"`
+	RedisPassword string `yaml:"redis_password"`
+	RedisDB       int    `yaml:"redis_db"`
+	KeyPrefix     string `yaml:"key_prefix"` // префикс ключей счетчиков
+	// Routes - ограничения по маршруту "<метод> <путь без /api/v1>", например "POST /user/auth/signup/email".
+	// Путь записывается как в роутере, с параметрами вида :org_id
+	Routes map[string][]Rule `yaml:"routes"`
+	// Default - ограничения маршрутов, которых нет в Routes; пусто - без ограничений
+	Default []Rule `yaml:"default"`
+	// FailOpen - пропускать запросы, если хранилище недоступно; иначе отвечать 503
+	FailOpen bool `yaml:"fail_open"`
+}
+
+// State - сохраненное состояние счетчика
+type State struct {
+	Value    float64   // токенов в ведре или запросов в текущем окне
+	Previous float64   // запросов в предыдущем окне, только sliding_window
+	Time     time.Time // время последнего пополнения ведра или начало текущего окна; нулевое - счетчика нет
+}
+
+// Store атомарно обновляет состояние счетчика. Реализации должны быть безопасны для параллельного использования.
+type Store interface {
+	// Update читает состояние по ключу (нулевое, если его нет или оно истекло), вызывает fn и сохраняет результат
+	// на ttl. Параллельные Update одного ключа не должны терять изменения.
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(st *State)) error
+	Close() error
+}
+
+// Result - итог проверки одного ограничения
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // через сколько счетчик восстановится полностью
+	RetryAfter time.Duration // через сколько запрос будет разрешен, если сейчас отклонен
+}
+
+// Limiter проверяет запросы по ограничениям маршрутов.
+type Limiter struct {
+	cfg   Config
+	store Store
+}
+
+// NewLimiter создает ограничитель с хранилищем из конфигурации.
+//
+// Параметры:
+//   - cfg: хранилище и ограничения
+//   - pgRepo: пул соединений Postgres для хранилища postgres
+//
+// Возвращает:
+//   - указатель на новый экземпляр Limiter
+//   - ошибку, если хранилище или ограничение заданы неверно
+func NewLimiter(cfg Config, pgRepo *core.PgRepo) (*Limiter, error) {
+	for route, rules := range cfg.Routes {
+		if err := validate(rules); err != nil {
+			return nil, fmt.Errorf("rate limit %s: %w", route, err)
+		}
+	}
+	if err := validate(cfg.Default); err != nil {
+		return nil, fmt.Errorf("rate limit default: %w", err)
+	}
+
+	var store Store
+	switch cfg.Store {
+	case StoreMemory:
+		store = NewMemoryStore()
+	case StorePostgres:
+		store = NewPostgresStore(pgRepo)
+	case StoreRedis:
+		store = NewRedisStore(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store: %q", cfg.Store)
+	}
+	return &Limiter{cfg: cfg, store: store}, nil
+}
+
+// WithConfig возвращает ограничитель с новыми ограничениями маршрутов и тем же хранилищем счетчиков.
+// Настройки хранилища (store, redis_*, key_prefix) берутся из текущего ограничителя.
+//
+// Параметры:
+//   - cfg: ограничения маршрутов и FailOpen
+//
+// Возвращает:
+//   - указатель на новый экземпляр Limiter
+//   - ошибку, если ограничение задано неверно
+func (l *Limiter) WithConfig(cfg Config) (*Limiter, error) {
+	for route, rules := range cfg.Routes {
+		if err := validate(rules); err != nil {
+			return nil, fmt.Errorf("rate limit %s: %w", route, err)
+		}
+	}
+	if err := validate(cfg.Default); err != nil {
+		return nil, fmt.Errorf("rate limit default: %w", err)
+	}
+
+	next := l.cfg
+	next.Routes = cfg.Routes
+	next.Default = cfg.Default
+	next.FailOpen = cfg.FailOpen
+	return &Limiter{cfg: next, store: l.store}, nil
+}
+
+// Rules возвращает ограничения маршрута.
+//
+// Параметры:
+//   - route: "<метод> <путь без /api/v1>"
+//
+// Возвращает:
+//   - ограничения маршрута или ограничения по умолчанию
+func (l *Limiter) Rules(route string) []Rule {
+	if rules, ok := l.cfg.Routes[route]; ok {
+		return rules
+	}
+	return l.cfg.Default
+}
+
+// FailOpen сообщает, что при недоступном хранилище запросы пропускаются.
+func (l *Limiter) FailOpen() bool {
+	return l.cfg.FailOpen
+}
+
+// Allow учитывает запрос в счетчике ограничения.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - key: ключ счетчика без префикса: маршрут, ограничение и клиент
+//   - rule: ограничение
+//
+// Возвращает:
+//   - итог проверки
+//   - ошибку хранилища
+func (l *Limiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	var res Result
+	now := time.Now()
+	err := l.store.Update(ctx, l.cfg.KeyPrefix+key, ttl(rule), func(st *State) {
+		res = apply(rule, st, now)
+	})
+	return res, err
+}
+
+// Check проверяет ограничение, не учитывая запрос: счетчик остается прежним. Подходит для действий,
+// которые учитываются через Allow только после успешного завершения.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - key: ключ счетчика без префикса
+//   - rule: ограничение
+//
+// Возвращает:
+//   - итог проверки: Allowed - запрос был бы разрешен
+//   - ошибку хранилища
+func (l *Limiter) Check(ctx context.Context, key string, rule Rule) (Result, error) {
+	var res Result
+	now := time.Now()
+	err := l.store.Update(ctx, l.cfg.KeyPrefix+key, ttl(rule), func(st *State) {
+		probe := *st
+		res = apply(rule, &probe, now)
+	})
+	return res, err
+}
+
+// Close закрывает хранилище.
+func (l *Limiter) Close() error {
+	return l.store.Close()
+}
+
+func validate(rules []Rule) error {
+	for _, rule := range rules {
+		switch {
+		case rule.By != ByIP && rule.By != ByAccount:
+			return fmt.Errorf("rule %q: unknown key %q", rule.Name, rule.By)
+		case rule.Algorithm != AlgorithmTokenBucket && rule.Algorithm != AlgorithmSlidingWindow:
+			return fmt.Errorf("rule %q: unknown algorithm %q", rule.Name, rule.Algorithm)
+		case rule.Limit <= 0 || rule.Window <= 0:
+			return fmt.Errorf("rule %q: limit and window must be positive", rule.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewLimiterValidate(t *testing.T) {
+	valid := Rule{Name: "ip", By: ByIP, Algorithm: AlgorithmTokenBucket, Limit: 5, Window: time.Minute}
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"valid", Config{Store: StoreMemory, Routes: map[string][]Rule{"POST /user/auth/login/email": {valid}}, Default: []Rule{valid}}, ""},
+		{"unknown store", Config{Store: "etcd"}, "unknown rate limit store"},
+		{"unknown key", Config{Store: StoreMemory, Default: []Rule{{Name: "x", By: "cookie", Algorithm: AlgorithmTokenBucket, Limit: 1, Window: time.Second}}}, "unknown key"},
+		{"unknown algorithm", Config{Store: StoreMemory, Default: []Rule{{Name: "x", By: ByIP, Algorithm: "leaky", Limit: 1, Window: time.Second}}}, "unknown algorithm"},
+		{"zero limit", Config{Store: StoreMemory, Routes: map[string][]Rule{"GET /x": {{Name: "x", By: ByAccount, Algorithm: AlgorithmSlidingWindow, Window: time.Second}}}}, "rate limit GET /x"},
+		{"zero window", Config{Store: StoreMemory, Default: []Rule{{Name: "x", By: ByIP, Algorithm: AlgorithmSlidingWindow, Limit: 1}}}, "must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewLimiter(tt.cfg, nil)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("NewLimiter: %s", err)
+				}
+				l.Close()
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewLimiter error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	login := []Rule{{Name: "ip", By: ByIP, Algorithm: AlgorithmSlidingWindow, Limit: 2, Window: time.Hour}}
+	fallback := []Rule{{Name: "ip", By: ByIP, Algorithm: AlgorithmTokenBucket, Limit: 100, Window: time.Minute}}
+	l, err := NewLimiter(Config{Store: StoreMemory, KeyPrefix: "test:", Routes: map[string][]Rule{"POST /login": login}, Default: fallback}, nil)
+	if err != nil {
+		t.Fatalf("NewLimiter: %s", err)
+	}
+	defer l.Close()
+
+	if got := l.Rules("POST /login"); len(got) != 1 || got[0].Algorithm != AlgorithmSlidingWindow {
+		t.Errorf("Rules(POST /login) = %+v", got)
+	}
+	if got := l.Rules("GET /other"); len(got) != 1 || got[0].Limit != 100 {
+		t.Errorf("Rules(GET /other) = %+v", got)
+	}
+
+	ctx := context.Background()
+	for i, want := range []bool{true, true, false} {
+		res, err := l.Allow(ctx, "POST /login:ip:203.0.113.7", login[0])
+		if err != nil || res.Allowed != want {
+			t.Errorf("Allow #%d = %+v, %v, want allowed %v", i, res, err, want)
+		}
+	}
+	// Счетчики разных клиентов независимы
+	if res, _ := l.Allow(ctx, "POST /login:ip:203.0.113.8", login[0]); !res.Allowed {
+		t.Errorf("Allow for another client = %+v", res)
+	}
+
+	// Новые ограничения применяются к тем же счетчикам
+	reloaded, err := l.WithConfig(Config{Store: StoreRedis, KeyPrefix: "other:", Routes: map[string][]Rule{"POST /login": login}, FailOpen: true})
+	if err != nil {
+		t.Fatalf("WithConfig: %s", err)
+	}
+	if !reloaded.FailOpen() || l.FailOpen() {
+		t.Errorf("FailOpen = %v, old %v, want true, false", reloaded.FailOpen(), l.FailOpen())
+	}
+	if got := reloaded.Rules("GET /other"); len(got) != 0 {
+		t.Errorf("Rules after reload = %+v, want none", got)
+	}
+	if res, _ := reloaded.Allow(ctx, "POST /login:ip:203.0.113.7", login[0]); res.Allowed {
+		t.Errorf("Allow after reload = %+v, want the counter kept", res)
+	}
+	if _, err := l.WithConfig(Config{Default: []Rule{{Name: "x", By: ByIP, Algorithm: "leaky", Limit: 1, Window: time.Second}}}); err == nil {
+		t.Errorf("WithConfig with an invalid rule: want error")
+	}
+}
+
+func TestLimiterCheck(t *testing.T) {
+	rule := Rule{Name: "mail", Algorithm: AlgorithmSlidingWindow, Limit: 2, Window: time.Hour}
+	l, err := NewLimiter(Config{Store: StoreMemory}, nil)
+	if err != nil {
+		t.Fatalf("NewLimiter: %s", err)
+	}
+	defer l.Close()
+
+	ctx := context.Background()
+	// Проверка не расходует лимит
+	for i := 0; i < 3; i++ {
+		if res, err := l.Check(ctx, "key", rule); err != nil || !res.Allowed || res.Remaining != 1 {
+			t.Fatalf("Check #%d = %+v, %v, want allowed with 1 remaining", i, res, err)
+		}
+	}
+	l.Allow(ctx, "key", rule)
+	l.Allow(ctx, "key", rule)
+	if res, _ := l.Check(ctx, "key", rule); res.Allowed {
+		t.Errorf("Check after the limit = %+v, want rejected", res)
+	}
+	if res, _ := l.Allow(ctx, "key", rule); res.Allowed {
+		t.Errorf("Allow after Check = %+v, want rejected", res)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+
+	// Параллельные обновления одного ключа не теряются
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Update(ctx, "key", time.Minute, func(st *State) { st.Value++ })
+		}()
+	}
+	wg.Wait()
+	s.Update(ctx, "key", time.Minute, func(st *State) {
+		if st.Value != 50 {
+			t.Errorf("Value = %v, want 50", st.Value)
+		}
+	})
+
+	// Истекший счетчик читается нулевым
+	s.Update(ctx, "short", -time.Second, func(st *State) { st.Value = 7; st.Time = time.Now() })
+	s.Update(ctx, "short", time.Minute, func(st *State) {
+		if st.Value != 0 || !st.Time.IsZero() {
+			t.Errorf("expired state = %+v, want zero", *st)
+		}
+	})
+}
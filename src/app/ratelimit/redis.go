@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisMaxRetries - сколько раз повторять обновление, если ключ изменил другой запрос
+const redisMaxRetries = 20
+
+// redisRetryDelay - наибольшая случайная пауза перед повтором, растет с номером попытки
+const redisRetryDelay = time.Millisecond
+
+// RedisStore хранит счетчики в Redis: хеш с полями v, p и t (наносекунды Unix) и временем жизни.
+// Обновление - оптимистичная транзакция WATCH/MULTI, повторяемая при конфликте. Работает с любым сервером,
+// поддерживающим эти команды, в том числе с локальной заменой Redis для разработки.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore создает хранилище в Redis. Соединение устанавливается при первом запросе.
+//
+// Параметры:
+//   - addr: адрес сервера host:port
+//   - password: пароль, пустая строка - без пароля
+//   - db: номер базы
+//
+// Возвращает:
+//   - указатель на новый экземпляр RedisStore
+func NewRedisStore(addr string, password string, db int) *RedisStore {
+	return &RedisStore{client: redis.NewClient(&redis.Options{Addr: addr, Password: password, DB: db})}
+}
+
+// Update обновляет счетчик в транзакции WATCH/MULTI.
+func (s *RedisStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(st *State)) error {
+	update := func(tx *redis.Tx) error {
+		vals, err := tx.HMGet(ctx, key, "v", "p", "t").Result()
+		if err != nil {
+			return err
+		}
+		var st State
+		if t, ok := vals[2].(string); ok {
+			nanos, _ := strconv.ParseInt(t, 10, 64)
+			st.Time = time.Unix(0, nanos)
+			st.Value = parseFloat(vals[0])
+			st.Previous = parseFloat(vals[1])
+		}
+
+		fn(&st)
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key, "v", st.Value, "p", st.Previous, "t", st.Time.UnixNano())
+			pipe.PExpire(ctx, key, ttl)
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < redisMaxRetries; i++ {
+		err := s.client.Watch(ctx, update, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+		// Случайная пауза разводит запросы, одновременно изменяющие один ключ
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(rand.Int64N(int64(redisRetryDelay) * int64(i+1)))):
+		}
+	}
+	return redis.TxFailedErr
+}
+
+// Close закрывает соединения с Redis.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+func parseFloat(v any) float64 {
+	s, _ := v.(string)
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
@@ -0,0 +1,208 @@
+package risk
+
+import (
+	"math"
+	"time"
+
+	"github.com/MedodsTechTask/app/geoip"
+)
+
+// Решения по итогам оценки риска
+const (
+	// DecisionAllow - вход разрешен
+	DecisionAllow = "allow"
+	// DecisionNotify - вход разрешен, владельцу отправляется уведомление
+	DecisionNotify = "notify"
+	// DecisionBlock - вход запрещен
+	DecisionBlock = "block"
+)
+
+// Сигналы риска
+const (
+	// SignalNewDevice - браузер и ОС, с которых аккаунт еще не входил
+	SignalNewDevice = "new_device"
+	// SignalNewCountry - страна, из которой аккаунт еще не входил
+	SignalNewCountry = "new_country"
+	// SignalNewASN - сеть (автономная система), из которой аккаунт еще не входил
+	SignalNewASN = "new_asn"
+	// SignalImpossibleTravel - расстояние от прошлого входа нельзя преодолеть за прошедшее время
+	SignalImpossibleTravel = "impossible_travel"
+	// SignalPasswordSpraying - с этого IP недавно были неудачные входы во много разных аккаунтов
+	SignalPasswordSpraying = "password_spraying"
+)
+
+// Config - веса сигналов и пороги решений. Нулевой порог отключает решение.
+type Config struct {
+	NewDeviceScore        int `yaml:"new_device_score"`
+	NewCountryScore       int `yaml:"new_country_score"`
+	NewASNScore           int `yaml:"new_asn_score"`
+	ImpossibleTravelScore int `yaml:"impossible_travel_score"`
+	SprayingScore         int `yaml:"spraying_score"`
+
+	MaxSpeedKmh   float64       `yaml:"max_speed_kmh"`   // скорость, быстрее которой перемещение между входами считается невозможным
+	MinDistanceKm float64       `yaml:"min_distance_km"` // меньшие расстояния не проверяются: точность GeoIP порядка сотни километров
+	SprayAccounts int           `yaml:"spray_accounts"`  // сколько разных email с неудачными входами с одного IP считается перебором
+	SprayWindow   time.Duration `yaml:"spray_window"`    // окно подсчета неудачных входов
+
+	NotifyScore  int    `yaml:"notify_score"`   // от этого балла владельцу отправляется уведомление
+	StepUpScore  int    `yaml:"step_up_score"`  // от этого балла нужна дополнительная проверка
+	StepUpAction string `yaml:"step_up_action"` // чем заменяется дополнительная проверка, пока второго фактора нет: notify или block
+	BlockScore   int    `yaml:"block_score"`    // от этого балла вход запрещается
+}
+
+// Attempt - текущая попытка входа
+type Attempt struct {
+	Time     time.Time
+	Location geoip.Location
+	Device   string // семейство браузера и ОС
+}
+
+// Previous - прошлый успешный вход аккаунта
+type Previous struct {
+	Time      time.Time
+	Latitude  *float64
+	Longitude *float64
+}
+
+// History - что известно об аккаунте и IP из журнала входов
+type History struct {
+	Logins        int       // успешных входов аккаунта в журнале; 0 - первый вход, новизна не оценивается
+	KnownDevice   bool      // с этого устройства уже был успешный вход
+	KnownCountry  bool      // из этой страны уже был успешный вход
+	KnownASN      bool      // из этой сети уже был успешный вход
+	Last          *Previous // прошлый успешный вход, nil - нет
+	SprayAccounts int       // разных email с неудачными входами с этого IP за SprayWindow
+}
+
+// Assessment - результат оценки
+type Assessment struct {
+	Score    int      `json:"score" example:"65"`
+	Signals  []string `json:"signals"`
+	Decision string   `json:"decision" example:"notify"`
+	StepUp   bool     `json:"step_up,omitempty"`   // балл достиг порога дополнительной проверки, решение взято из StepUpAction
+	SpeedKmh float64  `json:"speed_kmh,omitempty"` // скорость перемещения от прошлого входа, если известна
+}
+
+// Engine оценивает риск входа по истории аккаунта и данным GeoIP.
+type Engine struct {
+	cfg Config
+	geo *geoip.Locator
+}
+
+// NewEngine создает движок оценки риска.
+//
+// Параметры:
+//   - cfg: веса и пороги
+//   - geo: базы GeoIP; nil - страна, сеть и координаты неизвестны
+//
+// Возвращает:
+//   - указатель на новый экземпляр Engine
+func NewEngine(cfg Config, geo *geoip.Locator) *Engine {
+	return &Engine{cfg: cfg, geo: geo}
+}
+
+// WithConfig возвращает движок с новыми весами и порогами и теми же базами GeoIP.
+//
+// Параметры:
+//   - cfg: веса и пороги
+//
+// Возвращает:
+//   - указатель на новый экземпляр Engine
+func (e *Engine) WithConfig(cfg Config) *Engine {
+	return &Engine{cfg: cfg, geo: e.geo}
+}
+
+// Config возвращает настройки движка.
+func (e *Engine) Config() Config {
+	return e.cfg
+}
+
+// Locate ищет IP в базах GeoIP.
+//
+// Параметры:
+//   - ip: IP адрес
+//
+// Возвращает:
+//   - структуру geoip.Location, пустую без баз
+func (e *Engine) Locate(ip string) geoip.Location {
+	if e.geo == nil {
+		return geoip.Location{}
+	}
+	return e.geo.Lookup(ip)
+}
+
+// Assess считает балл риска попытки и выбирает решение по порогам.
+//
+// Параметры:
+//   - attempt: текущая попытка
+//   - history: история аккаунта и IP
+//
+// Возвращает:
+//   - структуру Assessment
+func (e *Engine) Assess(attempt Attempt, history History) Assessment {
+	res := Assessment{Signals: []string{}}
+	add := func(signal string, score int) {
+		if score > 0 {
+			res.Signals = append(res.Signals, signal)
+			res.Score += score
+		}
+	}
+
+	if history.Logins > 0 {
+		if attempt.Device != "" && !history.KnownDevice {
+			add(SignalNewDevice, e.cfg.NewDeviceScore)
+		}
+		if attempt.Location.Country != "" && !history.KnownCountry {
+			add(SignalNewCountry, e.cfg.NewCountryScore)
+		}
+		if attempt.Location.ASN != 0 && !history.KnownASN {
+			add(SignalNewASN, e.cfg.NewASNScore)
+		}
+	}
+
+	if last := history.Last; last != nil && last.Latitude != nil && attempt.Location.Latitude != nil {
+		km := Distance(*last.Latitude, *last.Longitude, *attempt.Location.Latitude, *attempt.Location.Longitude)
+		if km >= e.cfg.MinDistanceKm {
+			// Меньше минуты между входами считаем минутой, чтобы не делить на ноль
+			hours := math.Max(attempt.Time.Sub(last.Time).Hours(), 1.0/60)
+			res.SpeedKmh = math.Round(km / hours)
+			if e.cfg.MaxSpeedKmh > 0 && res.SpeedKmh > e.cfg.MaxSpeedKmh {
+				add(SignalImpossibleTravel, e.cfg.ImpossibleTravelScore)
+			}
+		}
+	}
+
+	if e.cfg.SprayAccounts > 0 && history.SprayAccounts >= e.cfg.SprayAccounts {
+		add(SignalPasswordSpraying, e.cfg.SprayingScore)
+	}
+
+	res.Decision = DecisionAllow
+	switch {
+	case e.cfg.BlockScore > 0 && res.Score >= e.cfg.BlockScore:
+		res.Decision = DecisionBlock
+	case e.cfg.StepUpScore > 0 && res.Score >= e.cfg.StepUpScore:
+		// Второго фактора пока нет, поэтому дополнительная проверка заменяется уведомлением или запретом
+		res.StepUp = true
+		res.Decision = e.cfg.StepUpAction
+	case e.cfg.NotifyScore > 0 && res.Score >= e.cfg.NotifyScore:
+		res.Decision = DecisionNotify
+	}
+	return res
+}
+
+// Distance считает расстояние по дуге большого круга между двумя точками (формула гаверсинусов).
+//
+// Параметры:
+//   - lat1, lon1: широта и долгота первой точки в градусах
+//   - lat2, lon2: широта и долгота второй точки в градусах
+//
+// Возвращает:
+//   - расстояние в километрах
+func Distance(lat1 float64, lon1 float64, lat2 float64, lon2 float64) float64 {
+	const earth_radius_km = 6371.0
+	rad := math.Pi / 180
+	d_lat := (lat2 - lat1) * rad
+	d_lon := (lon2 - lon1) * rad
+	a := math.Sin(d_lat/2)*math.Sin(d_lat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(d_lon/2)*math.Sin(d_lon/2)
+	return 2 * earth_radius_km * math.Asin(math.Min(1, math.Sqrt(a)))
+}
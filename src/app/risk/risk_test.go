@@ -0,0 +1,228 @@
+package risk
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MedodsTechTask/app/geoip"
+)
+
+var testConfig = Config{
+	NewDeviceScore:        20,
+	NewCountryScore:       30,
+	NewASNScore:           10,
+	ImpossibleTravelScore: 60,
+	SprayingScore:         50,
+	MaxSpeedKmh:           1000,
+	MinDistanceKm:         300,
+	SprayAccounts:         5,
+	SprayWindow:           time.Hour,
+	NotifyScore:           20,
+	StepUpScore:           50,
+	StepUpAction:          DecisionNotify,
+	BlockScore:            90,
+}
+
+func point(lat float64, lon float64) (*float64, *float64) {
+	return &lat, &lon
+}
+
+func TestAssess(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	moscow_lat, moscow_lon := point(55.7558, 37.6173)
+	london_lat, london_lon := point(51.5074, -0.1278)
+	podolsk_lat, podolsk_lon := point(55.4312, 37.5446)
+
+	here := geoip.Location{Country: "RU", ASN: 8359, Latitude: moscow_lat, Longitude: moscow_lon}
+	known := History{Logins: 3, KnownDevice: true, KnownCountry: true, KnownASN: true}
+	from_london := func(ago time.Duration) *Previous {
+		return &Previous{Time: now.Add(-ago), Latitude: london_lat, Longitude: london_lon}
+	}
+
+	tests := []struct {
+		name     string
+		cfg      *Config
+		attempt  Attempt
+		history  History
+		score    int
+		signals  []string
+		decision string
+		step_up  bool
+	}{
+		{
+			name:     "known everything",
+			attempt:  Attempt{Time: now, Location: here, Device: "Chrome/Linux"},
+			history:  known,
+			signals:  []string{},
+			decision: DecisionAllow,
+		},
+		{
+			name:     "first login does not score novelty",
+			attempt:  Attempt{Time: now, Location: here, Device: "Chrome/Linux"},
+			history:  History{},
+			signals:  []string{},
+			decision: DecisionAllow,
+		},
+		{
+			name:     "new device",
+			attempt:  Attempt{Time: now, Location: here, Device: "Firefox/Windows"},
+			history:  History{Logins: 1, KnownCountry: true, KnownASN: true},
+			score:    20,
+			signals:  []string{SignalNewDevice},
+			decision: DecisionNotify,
+		},
+		{
+			name:     "unknown location is not new",
+			attempt:  Attempt{Time: now, Device: "Chrome/Linux"},
+			history:  History{Logins: 1, KnownDevice: true},
+			signals:  []string{},
+			decision: DecisionAllow,
+		},
+		{
+			name:     "new country and network",
+			attempt:  Attempt{Time: now, Location: here, Device: "Chrome/Linux"},
+			history:  History{Logins: 1, KnownDevice: true},
+			score:    40,
+			signals:  []string{SignalNewCountry, SignalNewASN},
+			decision: DecisionNotify,
+		},
+		{
+			name:     "impossible travel",
+			attempt:  Attempt{Time: now, Location: here, Device: "Chrome/Linux"},
+			history:  History{Logins: 3, KnownDevice: true, KnownCountry: true, KnownASN: true, Last: from_london(time.Hour)},
+			score:    60,
+			signals:  []string{SignalImpossibleTravel},
+			decision: DecisionNotify,
+			step_up:  true,
+		},
+		{
+			name:     "possible travel",
+			attempt:  Attempt{Time: now, Location: here, Device: "Chrome/Linux"},
+			history:  History{Logins: 3, KnownDevice: true, KnownCountry: true, KnownASN: true, Last: from_london(5 * time.Hour)},
+			signals:  []string{},
+			decision: DecisionAllow,
+		},
+		{
+			name:    "short distance is not checked",
+			attempt: Attempt{Time: now, Location: here, Device: "Chrome/Linux"},
+			history: History{Logins: 3, KnownDevice: true, KnownCountry: true, KnownASN: true,
+				Last: &Previous{Time: now.Add(-time.Second), Latitude: podolsk_lat, Longitude: podolsk_lon}},
+			signals:  []string{},
+			decision: DecisionAllow,
+		},
+		{
+			name:     "password spraying",
+			attempt:  Attempt{Time: now, Location: here, Device: "Chrome/Linux"},
+			history:  History{Logins: 3, KnownDevice: true, KnownCountry: true, KnownASN: true, SprayAccounts: 5},
+			score:    50,
+			signals:  []string{SignalPasswordSpraying},
+			decision: DecisionNotify,
+			step_up:  true,
+		},
+		{
+			name:     "spraying below threshold",
+			attempt:  Attempt{Time: now, Location: here, Device: "Chrome/Linux"},
+			history:  History{Logins: 3, KnownDevice: true, KnownCountry: true, KnownASN: true, SprayAccounts: 4},
+			signals:  []string{},
+			decision: DecisionAllow,
+		},
+		{
+			name:     "block",
+			attempt:  Attempt{Time: now, Location: here, Device: "Firefox/Windows"},
+			history:  History{Logins: 3, Last: from_london(time.Hour)},
+			score:    120,
+			signals:  []string{SignalNewDevice, SignalNewCountry, SignalNewASN, SignalImpossibleTravel},
+			decision: DecisionBlock,
+		},
+		{
+			name:     "step up action block",
+			cfg:      &Config{SprayingScore: 50, SprayAccounts: 5, StepUpScore: 50, StepUpAction: DecisionBlock, BlockScore: 90},
+			attempt:  Attempt{Time: now, Location: here},
+			history:  History{SprayAccounts: 10},
+			score:    50,
+			signals:  []string{SignalPasswordSpraying},
+			decision: DecisionBlock,
+			step_up:  true,
+		},
+		{
+			name:     "zero thresholds allow everything",
+			cfg:      &Config{NewDeviceScore: 100, SprayingScore: 100, SprayAccounts: 1},
+			attempt:  Attempt{Time: now, Device: "Firefox/Windows"},
+			history:  History{Logins: 1, SprayAccounts: 1},
+			score:    200,
+			signals:  []string{SignalNewDevice, SignalPasswordSpraying},
+			decision: DecisionAllow,
+		},
+		{
+			name:     "zero weight does not add a signal",
+			cfg:      &Config{NotifyScore: 1},
+			attempt:  Attempt{Time: now, Location: here, Device: "Firefox/Windows"},
+			history:  History{Logins: 1},
+			signals:  []string{},
+			decision: DecisionAllow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig
+			if tt.cfg != nil {
+				cfg = *tt.cfg
+			}
+			res := NewEngine(cfg, nil).Assess(tt.attempt, tt.history)
+			if res.Score != tt.score || res.Decision != tt.decision || res.StepUp != tt.step_up {
+				t.Errorf("Assess = score %d, decision %q, step up %v, want %d, %q, %v",
+					res.Score, res.Decision, res.StepUp, tt.score, tt.decision, tt.step_up)
+			}
+			if !slices.Equal(res.Signals, tt.signals) {
+				t.Errorf("Assess signals = %v, want %v", res.Signals, tt.signals)
+			}
+		})
+	}
+}
+
+func TestAssessSpeed(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	lat, lon := point(55.7558, 37.6173)
+	last_lat, last_lon := point(51.5074, -0.1278)
+	attempt := Attempt{Time: now, Location: geoip.Location{Latitude: lat, Longitude: lon}}
+	e := NewEngine(testConfig, nil)
+
+	// Одновременные входы считаются разнесенными на минуту
+	res := e.Assess(attempt, History{Last: &Previous{Time: now, Latitude: last_lat, Longitude: last_lon}})
+	if want := math.Round(Distance(*last_lat, *last_lon, *lat, *lon) * 60); res.SpeedKmh != want {
+		t.Errorf("SpeedKmh = %v, want %v", res.SpeedKmh, want)
+	}
+
+	// Без координат прошлого входа скорость не считается
+	res = e.Assess(attempt, History{Last: &Previous{Time: now.Add(-time.Minute)}})
+	if res.SpeedKmh != 0 || len(res.Signals) != 0 {
+		t.Errorf("Assess without previous location = %+v", res)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"same point", 55.7558, 37.6173, 55.7558, 37.6173, 0},
+		{"moscow london", 55.7558, 37.6173, 51.5074, -0.1278, 2500},
+		{"berlin paris", 52.5200, 13.4050, 48.8566, 2.3522, 878},
+		{"antimeridian", 0, 179.5, 0, -179.5, 111},
+		{"antipodes", 0, 0, 0, 180, 20015},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Distance(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(got-tt.want) > math.Max(1, tt.want*0.01) {
+				t.Errorf("Distance = %.1f, want %.0f", got, tt.want)
+			}
+			if back := Distance(tt.lat2, tt.lon2, tt.lat1, tt.lon1); math.Abs(back-got) > 1e-9 {
+				t.Errorf("Distance is not symmetric: %v and %v", got, back)
+			}
+		})
+	}
+}
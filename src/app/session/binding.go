@@ -0,0 +1,113 @@
+package session
+
+import (
+	"fmt"
+	"net"
+)
+
+// Режимы привязки refresh токена к клиенту
+const (
+	// ModeStrict - совпадают IP и User-Agent целиком
+	ModeStrict = "strict"
+	// ModeSubnet - IP из той же подсети (IPv4 /24, IPv6 /64), совпадают семейство браузера и ОС
+	ModeSubnet = "subnet"
+	// ModeUserAgent - совпадают семейство браузера и ОС, IP не проверяется
+	ModeUserAgent = "ua"
+	// ModeOff - привязка не проверяется
+	ModeOff = "off"
+)
+
+// Действия при нарушении привязки
+const (
+	// ActionReject - отклонить запрос и отозвать только предъявленный токен; если он уже заменен
+	// при рефреше, действующий токен сессии сохраняется
+	ActionReject = "reject"
+	// ActionRevokeFamily - отозвать сессию: все токены семейства, выпущенного одним входом,
+	// включая выданный взамен предъявленного
+	ActionRevokeFamily = "revoke_family"
+	// ActionReauth - отозвать все сессии аккаунта и потребовать повторный вход с паролем
+	ActionReauth = "reauth"
+)
+
+// Config - политика привязки сессии
+type Config struct {
+	Mode   string `yaml:"mode"`   // strict, subnet, ua или off
+	Action string `yaml:"action"` // reject, revoke_family или reauth
+}
+
+// Binding проверяет, что refresh токен предъявлен тем же клиентом, которому он выдан.
+type Binding struct {
+	cfg Config
+}
+
+// NewBinding создает политику привязки сессии.
+//
+// Параметры:
+//   - cfg: режим и действие при нарушении
+//
+// Возвращает:
+//   - указатель на новый экземпляр Binding
+//   - ошибку, если режим или действие неизвестны
+func NewBinding(cfg Config) (*Binding, error) {
+	switch cfg.Mode {
+	case ModeStrict, ModeSubnet, ModeUserAgent, ModeOff:
+	default:
+		return nil, fmt.Errorf("unknown session binding mode: %q", cfg.Mode)
+	}
+	switch cfg.Action {
+	case ActionReject, ActionRevokeFamily, ActionReauth:
+	default:
+		return nil, fmt.Errorf("unknown session binding action: %q", cfg.Action)
+	}
+	return &Binding{cfg: cfg}, nil
+}
+
+// Action возвращает действие при нарушении привязки.
+func (b *Binding) Action() string {
+	return b.cfg.Action
+}
+
+// Matches сравнивает клиента, которому выдан токен, с клиентом текущего запроса.
+//
+// Параметры:
+//   - bound_ip, bound_ua: IP и User-Agent при выдаче токена
+//   - ip, ua: IP и User-Agent текущего запроса
+//
+// Возвращает:
+//   - true, если клиент соответствует привязке
+func (b *Binding) Matches(bound_ip string, bound_ua string, ip string, ua string) bool {
+	switch b.cfg.Mode {
+	case ModeStrict:
+		return bound_ip == ip && bound_ua == ua
+	case ModeSubnet:
+		return SameSubnet(bound_ip, ip) && ParseUserAgent(bound_ua) == ParseUserAgent(ua)
+	case ModeUserAgent:
+		return ParseUserAgent(bound_ua) == ParseUserAgent(ua)
+	default:
+		return true
+	}
+}
+
+// SameSubnet сообщает, что адреса из одной подсети: /24 для IPv4 и /64 для IPv6.
+// Нераспознанные адреса сравниваются как строки.
+//
+// Параметры:
+//   - a, b: IP адреса
+//
+// Возвращает:
+//   - true, если адреса из одной подсети
+func SameSubnet(a string, b string) bool {
+	ip_a, ip_b := net.ParseIP(a), net.ParseIP(b)
+	if ip_a == nil || ip_b == nil {
+		return a == b
+	}
+	if v4_a, v4_b := ip_a.To4(), ip_b.To4(); v4_a != nil || v4_b != nil {
+		if v4_a == nil || v4_b == nil {
+			return false
+		}
+		mask := net.CIDRMask(24, 32)
+		return v4_a.Mask(mask).Equal(v4_b.Mask(mask))
+	}
+	mask := net.CIDRMask(64, 128)
+	return ip_a.Mask(mask).Equal(ip_b.Mask(mask))
+}
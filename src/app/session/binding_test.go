@@ -0,0 +1,107 @@
+package session
+
+import "testing"
+
+const (
+	chromeLinux  = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	chromeLinux2 = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.6167.85 Safari/537.36"
+	firefoxLinux = "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+)
+
+func TestSameSubnet(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"203.0.113.10", "203.0.113.10", true},
+		{"203.0.113.10", "203.0.113.250", true},
+		{"203.0.113.10", "203.0.114.10", false},
+		{"203.0.113.10", "::ffff:203.0.113.77", true},
+		{"2001:db8:1:2::1", "2001:db8:1:2:ffff::9", true},
+		{"2001:db8:1:2::1", "2001:db8:1:3::1", false},
+		{"203.0.113.10", "2001:db8::1", false},
+		{"unknown", "unknown", true},
+		{"unknown", "203.0.113.10", false},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		if got := SameSubnet(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameSubnet(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want UserAgent
+	}{
+		{chromeLinux, UserAgent{"Chrome", "Linux"}},
+		{firefoxLinux, UserAgent{"Firefox", "Linux"}},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91", UserAgent{"Edge", "Windows"}},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15", UserAgent{"Safari", "macOS"}},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1", UserAgent{"Chrome", "iOS"}},
+		{"Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36", UserAgent{"Samsung", "Android"}},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 YaBrowser/23.11.0.0 Safari/537.36", UserAgent{"Yandex", "Windows"}},
+		{"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", UserAgent{"Chrome", "ChromeOS"}},
+		{"curl/8.4.0", UserAgent{"curl", ""}},
+		{"okhttp/4.12.0", UserAgent{"okhttp", ""}},
+		{"", UserAgent{}},
+	}
+	for _, tt := range tests {
+		if got := ParseUserAgent(tt.ua); got != tt.want {
+			t.Errorf("ParseUserAgent(%q) = %+v, want %+v", tt.ua, got, tt.want)
+		}
+	}
+}
+
+func TestBindingMatches(t *testing.T) {
+	const ip = "203.0.113.10"
+	tests := []struct {
+		mode   string
+		ip, ua string
+		want   bool
+	}{
+		{ModeStrict, ip, chromeLinux, true},
+		{ModeStrict, "203.0.113.11", chromeLinux, false},
+		{ModeStrict, ip, chromeLinux2, false},
+		// Обновление браузера и адрес из той же подсети не разрывают сессию
+		{ModeSubnet, "203.0.113.11", chromeLinux2, true},
+		{ModeSubnet, "198.51.100.20", chromeLinux, false},
+		{ModeSubnet, ip, firefoxLinux, false},
+		{ModeUserAgent, "198.51.100.20", chromeLinux2, true},
+		{ModeUserAgent, ip, firefoxLinux, false},
+		{ModeOff, "198.51.100.20", "curl/8.4.0", true},
+	}
+	for _, tt := range tests {
+		b, err := NewBinding(Config{Mode: tt.mode, Action: ActionReject})
+		if err != nil {
+			t.Fatalf("NewBinding(%s): %s", tt.mode, err)
+		}
+		if got := b.Matches(ip, chromeLinux, tt.ip, tt.ua); got != tt.want {
+			t.Errorf("%s: Matches(%s, %.40q) = %v, want %v", tt.mode, tt.ip, tt.ua, got, tt.want)
+		}
+	}
+}
+
+func TestNewBinding(t *testing.T) {
+	tests := []struct {
+		cfg Config
+		ok  bool
+	}{
+		{Config{Mode: ModeSubnet, Action: ActionRevokeFamily}, true},
+		{Config{Mode: ModeOff, Action: ActionReauth}, true},
+		{Config{Mode: "ip", Action: ActionReject}, false},
+		{Config{Mode: ModeStrict, Action: "block"}, false},
+		{Config{}, false},
+	}
+	for _, tt := range tests {
+		b, err := NewBinding(tt.cfg)
+		if (err == nil) != tt.ok {
+			t.Errorf("NewBinding(%+v) error = %v, want ok %v", tt.cfg, err, tt.ok)
+		}
+		if err == nil && b.Action() != tt.cfg.Action {
+			t.Errorf("Action = %q, want %q", b.Action(), tt.cfg.Action)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"strings"
+)
+
+// UserAgent - семейство браузера и ОС, разобранные из заголовка User-Agent.
+// Версии не сохраняются, чтобы обновление браузера не разрывало привязку сессии.
+type UserAgent struct {
+	Family string // Chrome, Firefox, Safari, Edge, Opera, Yandex, Samsung, IE или имя первого продукта для не-браузеров (curl, okhttp)
+	OS     string // Windows, macOS, iOS, Android, ChromeOS, Linux или пустая строка
+}
+
+// browsers - признаки браузеров в порядке проверки: производные от Chromium браузеры содержат
+// и "Chrome/", и "Safari/", поэтому проверяются раньше них
+var browsers = []struct {
+	token  string
+	family string
+}{
+	{"Edg/", "Edge"},
+	{"EdgA/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"OPR/", "Opera"},
+	{"YaBrowser/", "Yandex"},
+	{"SamsungBrowser/", "Samsung"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Chromium/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"MSIE ", "IE"},
+	{"Trident/", "IE"},
+}
+
+// systems - признаки ОС в порядке проверки: Android содержит "Linux", iOS содержит "Mac OS X"
+var systems = []struct {
+	token string
+	os    string
+}{
+	{"Windows", "Windows"},
+	{"iPhone", "iOS"},
+	{"iPad", "iOS"},
+	{"iPod", "iOS"},
+	{"Android", "Android"},
+	{"CrOS", "ChromeOS"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// ParseUserAgent определяет семейство браузера и ОС по заголовку User-Agent.
+//
+// Параметры:
+//   - ua: значение заголовка User-Agent
+//
+// Возвращает:
+//   - структуру UserAgent; для пустого заголовка оба поля пустые
+func ParseUserAgent(ua string) UserAgent {
+	var res UserAgent
+	for _, b := range browsers {
+		if strings.Contains(ua, b.token) {
+			res.Family = b.family
+			break
+		}
+	}
+	if res.Family == "" {
+		// Не-браузер: первое слово до "/" или пробела, например curl/8.4.0 или okhttp/4.12.0
+		product, _, _ := strings.Cut(strings.TrimSpace(ua), " ")
+		product, _, _ = strings.Cut(product, "/")
+		res.Family = product
+	}
+	for _, s := range systems {
+		if strings.Contains(ua, s.token) {
+			res.OS = s.os
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// ExporterNone - трассировка выключена
+	ExporterNone = "none"
+	// ExporterOTLP - отправка в коллектор по OTLP/HTTP
+	ExporterOTLP = "otlp"
+	// ExporterStdout - вывод спанов в stdout в JSON, для локальной отладки
+	ExporterStdout = "stdout"
+)
+
+// Config - куда и какую долю трасс отправлять
+type Config struct {
+	Exporter    string        `yaml:"exporter"`     // none, otlp или stdout
+	Endpoint    string        `yaml:"endpoint"`     // host:port коллектора OTLP/HTTP; пусто - OTEL_EXPORTER_OTLP_ENDPOINT или localhost:4318
+	Insecure    bool          `yaml:"insecure"`     // отправлять в коллектор по HTTP без TLS
+	ServiceName string        `yaml:"service_name"` // service.name в ресурсе трасс
+	SampleRatio float64       `yaml:"sample_ratio"` // доля новых трасс, 0..1; решение вызывающего сервиса из traceparent соблюдается
+	Timeout     time.Duration `yaml:"timeout"`      // ограничение времени отправки пачки спанов
+}
+
+// Setup настраивает глобальные TracerProvider и распространение контекста (W3C traceparent и baggage).
+// Без экспортера спаны не создаются, но входящий traceparent все равно передается дальше.
+//
+// Параметры:
+//   - ctx: контекст создания экспортера
+//   - cfg: настройки трассировки
+//
+// Возвращает:
+//   - функцию, которая отправляет накопленные спаны и останавливает экспортер; вызывается при остановке сервиса
+//   - ошибку, если экспортер неизвестен или не создан
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("tracing sample ratio must be between 0 and 1, got %g", cfg.SampleRatio)
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlptracehttp.WithTimeout(cfg.Timeout))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing exporter %s: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
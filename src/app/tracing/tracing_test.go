@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		err  string
+	}{
+		{"disabled", Config{}, ""},
+		{"none", Config{Exporter: ExporterNone, SampleRatio: 1}, ""},
+		{"stdout", Config{Exporter: ExporterStdout, ServiceName: "auth", SampleRatio: 0.5}, ""},
+		{"unknown exporter", Config{Exporter: "jaeger"}, `unknown tracing exporter "jaeger"`},
+		{"negative ratio", Config{Exporter: ExporterStdout, SampleRatio: -0.1}, "sample ratio"},
+		{"ratio above one", Config{Exporter: ExporterNone, SampleRatio: 2}, "sample ratio"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), tt.cfg)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Setup = %v, want error with %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Setup: %s", err)
+			}
+			if err := shutdown(context.Background()); err != nil {
+				t.Errorf("shutdown: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MedodsTechTask/app/core"
+	share "github.com/MedodsTechTask/app/user/auth/share"
+)
+
+func (h *API) SetupAdminRoutes(r *gin.RouterGroup) {
+	r.Use(h.Authenticate(), h.DenyImpersonation())
+
+	r.GET(core.AdminAccounts, h.RequirePermission(core.PermAccountsRead), h.searchAccounts)
+	r.GET(core.AdminAccount, h.RequirePermission(core.PermAccountsRead), h.getAccountDetail)
+	r.POST(core.AdminAccountLogout, h.RequirePermission(core.PermAccountsManage), h.forceLogout)
+	r.POST(core.AdminAccountStatus, h.RequirePermission(core.PermAccountsManage), h.setAccountStatus)
+	r.POST(core.AdminAccountPasswordReset, h.RequirePermission(core.PermAccountsManage), h.adminResetPassword)
+	r.POST(core.AdminAccountImpersonate, h.RequirePermission(core.PermAccountsImpersonate), h.impersonate)
+	r.GET(core.AdminImpersonations, h.RequirePermission(core.PermAccountsRead), h.searchImpersonations)
+	r.POST(core.AdminImpersonationEnd, h.RequirePermission(core.PermAccountsImpersonate), h.endImpersonation)
+	r.GET(core.AdminSignups, h.RequirePermission(core.PermAccountsRead), h.searchSignups)
+	r.POST(core.AdminSignupConfirm, h.RequirePermission(core.PermAccountsManage), h.adminConfirmSignup)
+	r.POST(core.AdminTenants, h.RequirePermission(core.PermTenantsManage), h.DenyImpersonation(), h.createTenant)
+	r.GET(core.AdminAudit, h.RequirePermission(core.PermAuditRead), h.searchAudit)
+	r.GET(core.AdminAuditVerify, h.RequirePermission(core.PermAuditRead), h.verifyAudit)
+	r.GET(core.AdminJobs, h.RequirePermission(core.PermJobsRead), h.searchJobs)
+	r.GET(core.AdminJobStats, h.RequirePermission(core.PermJobsRead), h.getJobStats)
+	r.POST(core.AdminJobRetry, h.RequirePermission(core.PermJobsManage), h.retryJob)
+	r.GET(core.AdminWebhooks, h.RequirePermission(core.PermWebhooksRead), h.getWebhooks)
+	r.POST(core.AdminWebhooks, h.RequirePermission(core.PermWebhooksManage), h.createWebhook)
+	r.PUT(core.AdminWebhook, h.RequirePermission(core.PermWebhooksManage), h.updateWebhook)
+	r.DELETE(core.AdminWebhook, h.RequirePermission(core.PermWebhooksManage), h.deleteWebhook)
+	r.GET(core.AdminWebhookDeliveries, h.RequirePermission(core.PermWebhooksRead), h.searchWebhookDeliveries)
+	r.GET(core.AdminConfigVersion, h.RequirePermission(core.PermConfigRead), h.getConfigVersion)
+}
+
+// @Summary Поиск аккаунтов
+// @Description Постраничный поиск аккаунтов по префиксу email, диапазону даты создания и статусу. Требует разрешение accounts:read
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param email_prefix query string false "Префикс email"
+// @Param created_from query string false "Создан не раньше (RFC3339)"
+// @Param created_to query string false "Создан раньше (RFC3339)"
+// @Param status query string false "Статус аккаунта" Enums(active, blocked)
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 200)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} share.ZAccountPage
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/accounts [get]
+func (h *API) searchAccounts(c *gin.Context) {
+	var req share.QAccountSearch
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.SearchAccounts(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Детали аккаунта
+// @Description Возвращает аккаунт, его роли, разрешения и активные сессии. Требует разрешение accounts:read
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param account_id path string true "ID аккаунта"
+// @Success 200 {object} share.ZAccountDetail
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/accounts/{account_id} [get]
+func (h *API) getAccountDetail(c *gin.Context) {
+	res, err := h.uc.GetAccountDetail(c.Request.Context(), c.Param("account_id"))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Принудительный выход
+// @Description Отзывает все refresh токены аккаунта. Требует разрешение accounts:manage
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param account_id path string true "ID аккаунта"
+// @Success 200 {object} share.ZOk
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/accounts/{account_id}/logout [post]
+func (h *API) forceLogout(c *gin.Context) {
+	res, err := h.uc.ForceLogout(c.Request.Context(), c.Param("account_id"))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Смена статуса аккаунта
+// @Description Блокирует или разблокирует аккаунт. При блокировке отзывает все refresh токены. Требует разрешение accounts:manage
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param account_id path string true "ID аккаунта"
+// @Param request body share.QAccountStatus true "Новый статус"
+// @Success 200 {object} share.ZAdminAccount
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/accounts/{account_id}/status [post]
+func (h *API) setAccountStatus(c *gin.Context) {
+	var req share.QAccountStatus
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.SetAccountStatus(c.Request.Context(), c.Param("account_id"), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Сброс пароля администратором
+// @Description Создает запрос на сброс пароля и возвращает код подтверждения для передачи пользователю. Требует разрешение accounts:manage
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param account_id path string true "ID аккаунта"
+// @Success 200 {object} share.ZPasswordReset
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/accounts/{account_id}/password/reset [post]
+func (h *API) adminResetPassword(c *gin.Context) {
+	res, err := h.uc.AdminResetPassword(c.Request.Context(), c.Param("account_id"), accountID(c))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Поиск неподтвержденных регистраций
+// @Description Постраничный поиск регистраций, ожидающих подтверждения, по префиксу email. Требует разрешение accounts:read
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param email_prefix query string false "Префикс email"
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 200)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} share.ZSignupPage
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/signups [get]
+func (h *API) searchSignups(c *gin.Context) {
+	var req share.QSignupSearch
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.SearchSignups(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Ручное подтверждение регистрации
+// @Description Подтверждает регистрацию без кода и создает аккаунт. Требует разрешение accounts:manage
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param signup_id path string true "ID регистрации"
+// @Success 200 {object} share.ZAccount
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/signups/{signup_id}/confirm [post]
+func (h *API) adminConfirmSignup(c *gin.Context) {
+	res, err := h.uc.AdminConfirmSignup(c.Request.Context(), c.Param("signup_id"))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Вход под пользователем
+// @Description Выдает короткоживущий access токен аккаунта без refresh токена. Токен содержит claim act с ID администратора и claim imp с ID сессии. Причина обязательна, начало сессии пишется в журнал. Требует разрешение accounts:impersonate
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param account_id path string true "ID аккаунта"
+// @Param request body share.QImpersonate true "Причина входа"
+// @Success 200 {object} share.ZImpersonationToken
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/accounts/{account_id}/impersonate [post]
+func (h *API) impersonate(c *gin.Context) {
+	var req share.QImpersonate
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.Impersonate(c.Request.Context(), accountID(c), c.Param("account_id"), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Журнал входов под пользователями
+// @Description Постраничный поиск сессий входа под пользователями по аккаунту и администратору. Требует разрешение accounts:read
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param account_id query string false "ID аккаунта, под которым выполнялся вход"
+// @Param admin_id query string false "ID администратора"
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 200)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} share.ZImpersonationPage
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/impersonations [get]
+func (h *API) searchImpersonations(c *gin.Context) {
+	var req share.QImpersonationSearch
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.SearchImpersonations(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Завершение входа под пользователем
+// @Description Досрочно завершает сессию, выданный токен перестает приниматься. Завершение пишется в журнал. Требует разрешение accounts:impersonate
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param impersonation_id path string true "ID сессии"
+// @Success 200 {object} share.ZImpersonation
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/impersonations/{impersonation_id}/end [post]
+func (h *API) endImpersonation(c *gin.Context) {
+	res, err := h.uc.EndImpersonation(c.Request.Context(), accountID(c), c.Param("impersonation_id"))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+// adminRepo - репозиторий с одним аккаунтом, запоминающий отзывы токенов и записи журнала
+type adminRepo struct {
+	repo.IAuthRepo
+	account *repo.XAccount
+	revoked []string
+	audit   []string
+}
+
+func (r *adminRepo) GetAccount(ctx context.Context, tenant_id string, id string) (*repo.XAccount, error) {
+	if r.account == nil || r.account.TenantID != tenant_id || r.account.ID != id {
+		return nil, &core.ErrAccountNotFound{ErrMessage: id}
+	}
+	return r.account, nil
+}
+
+func (r *adminRepo) RevokeToken(ctx context.Context, tenant_id string, account_id string, reason string) (bool, error) {
+	r.revoked = append(r.revoked, account_id+":"+reason)
+	return true, nil
+}
+
+func (r *adminRepo) WriteAudit(ctx context.Context, rec *repo.XAuditRecord) (*repo.XAuditRecord, error) {
+	r.audit = append(r.audit, rec.Event+":"+rec.Outcome)
+	return rec, nil
+}
+
+func TestNormalizePage(t *testing.T) {
+	tests := []struct {
+		limit, offset           int
+		want_limit, want_offset int
+		bad                     bool
+	}{
+		{0, 0, adminPageLimitDefault, 0, false},
+		{10, 20, 10, 20, false},
+		{adminPageLimitMax, 0, adminPageLimitMax, 0, false},
+		{adminPageLimitMax + 1, 0, 0, 0, true},
+		{-1, 0, 0, 0, true},
+		{10, -1, 0, 0, true},
+	}
+	for _, tt := range tests {
+		limit, offset, zerr := normalizePage(tt.limit, tt.offset)
+		if tt.bad {
+			if zerr == nil || zerr.Code != 400 {
+				t.Errorf("normalizePage(%d, %d) error = %+v, want 400", tt.limit, tt.offset, zerr)
+			}
+			continue
+		}
+		if zerr != nil || limit != tt.want_limit || offset != tt.want_offset {
+			t.Errorf("normalizePage(%d, %d) = %d, %d, %+v, want %d, %d", tt.limit, tt.offset, limit, offset, zerr, tt.want_limit, tt.want_offset)
+		}
+	}
+}
+
+func TestForceLogout(t *testing.T) {
+	const account_id = "7d1e0c6a-2f0b-4d8e-9a35-5c4b3a2d1e0f"
+	tests := []struct {
+		name    string
+		tenant  string
+		code    int
+		revoked []string
+		audit   []string
+	}{
+		{"own tenant", core.DefaultTenantID, 0, []string{account_id + ":admin_logout"},
+			[]string{AuditTokenRevoke + ":" + AuditOutcomeSuccess, AuditAdminAccountLogout + ":" + AuditOutcomeSuccess}},
+		// Аккаунт другого тенанта для администратора не существует
+		{"other tenant", "5f0c8a1e-3b7d-4c2a-9e6f-1d2c3b4a5e6f", 404, nil,
+			[]string{AuditAdminAccountLogout + ":" + AuditOutcomeFailure}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &adminRepo{account: &repo.XAccount{ID: account_id, TenantID: core.DefaultTenantID}}
+			uc := &AuthUseCase{repo: fake}
+
+			res, zerr := uc.ForceLogout(core.WithTenant(context.Background(), tt.tenant), account_id)
+			if tt.code == 0 && (zerr != nil || res == nil || !res.Ok) {
+				t.Fatalf("ForceLogout = %+v, %+v, want ok", res, zerr)
+			}
+			if tt.code != 0 && (zerr == nil || zerr.Code != tt.code) {
+				t.Fatalf("ForceLogout error = %+v, want code %d", zerr, tt.code)
+			}
+			if !slices.Equal(fake.revoked, tt.revoked) {
+				t.Errorf("revoked = %v, want %v", fake.revoked, tt.revoked)
+			}
+			if !slices.Equal(fake.audit, tt.audit) {
+				t.Errorf("audit = %v, want %v", fake.audit, tt.audit)
+			}
+		})
+	}
+}
+
+func TestAdminAccountStatusValidation(t *testing.T) {
+	fake := &adminRepo{}
+	uc := &AuthUseCase{repo: fake}
+	ctx := core.WithTenant(context.Background(), core.DefaultTenantID)
+
+	if _, zerr := uc.SetAccountStatus(ctx, "7d1e0c6a-2f0b-4d8e-9a35-5c4b3a2d1e0f", &share.QAccountStatus{Status: "deleted"}); zerr == nil || zerr.Code != 400 {
+		t.Errorf("SetAccountStatus error = %+v, want 400", zerr)
+	}
+	if want := []string{AuditAdminAccountStatus + ":" + AuditOutcomeFailure}; !slices.Equal(fake.audit, want) {
+		t.Errorf("audit = %v, want %v", fake.audit, want)
+	}
+	if _, zerr := uc.SearchAccounts(ctx, &share.QAccountSearch{Status: "deleted"}); zerr == nil || zerr.Code != 400 {
+		t.Errorf("SearchAccounts error = %+v, want 400", zerr)
+	}
+}
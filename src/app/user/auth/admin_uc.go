@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/mail"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+const (
+	// adminPageLimitDefault - размер страницы поиска по умолчанию
+	adminPageLimitDefault = 50
+	// adminPageLimitMax - максимальный размер страницы поиска
+	adminPageLimitMax = 200
+)
+
+// SearchAccounts ищет аккаунты для администратора с фильтрами и пагинацией.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - req: фильтры поиска (префикс email, диапазон даты создания, статус) и параметры страницы
+//
+// Возвращает:
+//   - указатель на структуру ZAccountPage со страницей аккаунтов и общим количеством
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) SearchAccounts(ctx context.Context, req *share.QAccountSearch) (*share.ZAccountPage, *core.ZError) {
+	if req.Status != "" && req.Status != AccountStatusActive && req.Status != AccountStatusBlocked {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Неизвестный статус аккаунта",
+			Exception: req.Status,
+		}
+	}
+	limit, offset, zerr := normalizePage(req.Limit, req.Offset)
+	if zerr != nil {
+		return nil, zerr
+	}
+
+	xres, total, err := s.repo.SearchAccounts(ctx, &repo.XAccountFilter{
+		TenantID:    core.TenantFromContext(ctx),
+		EmailPrefix: req.EmailPrefix,
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+		Status:      req.Status,
+		Limit:       limit,
+		Offset:      offset,
+	})
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	items := make([]share.ZAdminAccount, 0, len(xres))
+	for i := range xres {
+		items = append(items, toZAdminAccount(&xres[i]))
+	}
+	return &share.ZAccountPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// GetAccountDetail возвращает данные аккаунта, его роли, разрешения и активные сессии.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - account_id: идентификатор аккаунта
+//
+// Возвращает:
+//   - указатель на структуру ZAccountDetail
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) GetAccountDetail(ctx context.Context, account_id string) (*share.ZAccountDetail, *core.ZError) {
+	tenant_id := core.TenantFromContext(ctx)
+
+	acc, err := s.repo.GetAccount(ctx, tenant_id, account_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	access, err := s.repo.GetAccountAccess(ctx, tenant_id, acc.ID)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	tokens, err := s.repo.GetActiveSessions(ctx, tenant_id, acc.ID)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	sessions := make([]share.ZSession, 0, len(tokens))
+	for _, t := range tokens {
+		sessions = append(sessions, share.ZSession{
+			ID:        t.ID,
+			UserAgent: t.UserAgent,
+			IpAddress: t.IpAddress,
+			ExpiresAt: t.ExpiresAt,
+			CreatedAt: t.CreatedAt,
+		})
+	}
+
+	return &share.ZAccountDetail{
+		ZAdminAccount: toZAdminAccount(acc),
+		Roles:         access.Roles,
+		Permissions:   access.Permissions,
+		Sessions:      sessions,
+	}, nil
+}
+
+// ForceLogout отзывает все refresh токены аккаунта. Выпущенные access токены действуют до истечения.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - account_id: идентификатор аккаунта
+//
+// Возвращает:
+//   - указатель на структуру ZOk, если токены отозваны
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) ForceLogout(ctx context.Context, account_id string) (_ *share.ZOk, zerr *core.ZError) {
+	defer func() { s.audit(ctx, AuditAdminAccountLogout, zerr, account_id, "", nil) }()
+
+	tenant_id := core.TenantFromContext(ctx)
+
+	acc, err := s.repo.GetAccount(ctx, tenant_id, account_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	if err := s.revokeTokens(ctx, tenant_id, acc.ID, "admin_logout"); err != nil {
+		return nil, repoError(err)
+	}
+	return &share.ZOk{Ok: true}, nil
+}
+
+// SetAccountStatus блокирует или разблокирует аккаунт. При блокировке отзывает все refresh токены аккаунта
+// и уведомляет владельца письмом.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - account_id: идентификатор аккаунта
+//   - req: структура с новым статусом
+//
+// Возвращает:
+//   - указатель на структуру ZAdminAccount с обновленными данными аккаунта
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) SetAccountStatus(ctx context.Context, account_id string, req *share.QAccountStatus) (_ *share.ZAdminAccount, zerr *core.ZError) {
+	defer func() {
+		s.audit(ctx, AuditAdminAccountStatus, zerr, account_id, "", map[string]string{"status": req.Status})
+	}()
+
+	if req.Status != AccountStatusActive && req.Status != AccountStatusBlocked {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Неизвестный статус аккаунта",
+			Exception: req.Status,
+		}
+	}
+
+	tenant_id := core.TenantFromContext(ctx)
+
+	acc, err := s.repo.SetAccountStatus(ctx, tenant_id, account_id, req.Status)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	if acc.Status == AccountStatusBlocked {
+		if err := s.revokeTokens(ctx, tenant_id, acc.ID, "account_blocked"); err != nil {
+			return nil, repoError(err)
+		}
+		s.notifySecurity(ctx, acc.Email, mail.NoticeAccountBlocked)
+	}
+
+	res := toZAdminAccount(acc)
+	return &res, nil
+}
+
+// SearchSignups ищет неподтвержденные регистрации по префиксу email.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - req: префикс email и параметры страницы
+//
+// Возвращает:
+//   - указатель на структуру ZSignupPage со страницей регистраций и общим количеством
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) SearchSignups(ctx context.Context, req *share.QSignupSearch) (*share.ZSignupPage, *core.ZError) {
+	limit, offset, zerr := normalizePage(req.Limit, req.Offset)
+	if zerr != nil {
+		return nil, zerr
+	}
+
+	xres, total, err := s.repo.SearchEmailSignups(ctx, core.TenantFromContext(ctx), req.EmailPrefix, limit, offset)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	items := make([]share.ZAdminSignup, 0, len(xres))
+	for _, signup := range xres {
+		items = append(items, share.ZAdminSignup{
+			ID:        signup.ID,
+			Email:     signup.Email,
+			CreatedAt: signup.CreatedAt,
+			UpdatedAt: signup.UpdatedAt,
+		})
+	}
+	return &share.ZSignupPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// AdminConfirmSignup подтверждает регистрацию без кода и создает аккаунт.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - signup_id: идентификатор записи о регистрации
+//
+// Возвращает:
+//   - указатель на структуру ZAccount с данными созданного аккаунта
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) AdminConfirmSignup(ctx context.Context, signup_id string) (res *share.ZAccount, zerr *core.ZError) {
+	defer func() {
+		account_id, email := "", ""
+		if res != nil {
+			account_id, email = res.ID, res.Email
+		}
+		s.audit(ctx, AuditAdminSignupConfirm, zerr, account_id, email, map[string]string{"signup_id": signup_id})
+	}()
+
+	signup_acc, err := s.repo.GetEmailSignup(ctx, core.TenantFromContext(ctx), signup_id)
+	if err != nil {
+		switch e := err.(type) {
+		case *core.ErrEmailSignupNotFound:
+			return nil, &core.ZError{
+				Code:      404,
+				Where:     "Repo",
+				Message:   "Регистрация не найдена",
+				Exception: e.ErrMessage,
+			}
+		default:
+			return nil, repoError(err)
+		}
+	}
+
+	return s.confirmSignup(ctx, signup_acc)
+}
+
+// AdminResetPassword запускает сброс пароля аккаунта от имени администратора.
+// Код подтверждения возвращается администратору, чтобы передать его пользователю.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - account_id: идентификатор аккаунта
+//   - admin_id: идентификатор администратора из access токена
+//
+// Возвращает:
+//   - указатель на структуру ZPasswordReset с кодом подтверждения
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) AdminResetPassword(ctx context.Context, account_id string, admin_id string) (res *share.ZPasswordReset, zerr *core.ZError) {
+	defer func() {
+		details := map[string]string{}
+		if res != nil {
+			details["reset_id"] = res.ID
+		}
+		s.audit(ctx, AuditAdminPasswordReset, zerr, account_id, "", details)
+	}()
+
+	acc, err := s.repo.GetAccount(ctx, core.TenantFromContext(ctx), account_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	return s.startPasswordReset(ctx, acc.ID, "", &admin_id)
+}
+
+// ----------- Tools -----------
+
+// normalizePage подставляет размер страницы по умолчанию и проверяет границы пагинации.
+func normalizePage(limit int, offset int) (int, int, *core.ZError) {
+	if limit == 0 {
+		limit = adminPageLimitDefault
+	}
+	if limit < 0 || limit > adminPageLimitMax || offset < 0 {
+		return 0, 0, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Неверные параметры пагинации",
+			Exception: nil,
+		}
+	}
+	return limit, offset, nil
+}
+
+// toZAdminAccount переводит аккаунт из слоя репозитория в DTO без хеша пароля и соли.
+func toZAdminAccount(x *repo.XAccount) share.ZAdminAccount {
+	return share.ZAdminAccount{
+		ID:        x.ID,
+		Email:     x.Email,
+		Status:    x.Status,
+		CreatedAt: x.CreatedAt,
+		UpdatedAt: x.UpdatedAt,
+	}
+}
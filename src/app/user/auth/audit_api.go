@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	share "github.com/MedodsTechTask/app/user/auth/share"
+)
+
+// @Summary Журнал безопасности
+// @Description Постраничный поиск событий журнала безопасности по аккаунту, исполнителю, событию, результату и периоду. Требует разрешение audit:read
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param account_id query string false "ID аккаунта"
+// @Param actor_id query string false "ID исполнителя"
+// @Param event query string false "Тип события, например login"
+// @Param outcome query string false "Результат" Enums(success, failure)
+// @Param from query string false "Не раньше (RFC3339)"
+// @Param to query string false "Раньше (RFC3339)"
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 200)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} share.ZAuditPage
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/audit [get]
+func (h *API) searchAudit(c *gin.Context) {
+	var req share.QAuditSearch
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.SearchAudit(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Проверка целостности журнала
+// @Description Пересчитывает цепочку хешей журнала безопасности тенанта и возвращает номер первой измененной записи. Требует разрешение audit:read
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} share.ZAuditVerify
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/audit/verify [get]
+func (h *API) verifyAudit(c *gin.Context) {
+	res, err := h.uc.VerifyAudit(c.Request.Context())
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+)
+
+// auditRepo - журнал безопасности в памяти
+type auditRepo struct {
+	repo.IAuthRepo
+	chain []repo.XAuditRecord
+}
+
+func (r *auditRepo) GetAuditChain(ctx context.Context, tenant_id string, after_seq int64, limit int) ([]repo.XAuditRecord, error) {
+	res := []repo.XAuditRecord{}
+	for _, rec := range r.chain {
+		if rec.TenantID == tenant_id && rec.Seq > after_seq && len(res) < limit {
+			res = append(res, rec)
+		}
+	}
+	return res, nil
+}
+
+// auditChain строит корректную цепочку из n записей так же, как WriteAudit
+func auditChain(n int) []repo.XAuditRecord {
+	chain := make([]repo.XAuditRecord, 0, n)
+	prev := repo.AuditGenesisHash
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= n; i++ {
+		rec := repo.XAuditRecord{
+			Seq:       int64(i),
+			TenantID:  core.DefaultTenantID,
+			Event:     AuditLogin,
+			Outcome:   AuditOutcomeSuccess,
+			Email:     optional(fmt.Sprintf("user%d@example.com", i)),
+			Details:   map[string]string{"n": fmt.Sprint(i)},
+			PrevHash:  prev,
+			CreatedAt: created.Add(time.Duration(i) * time.Second),
+		}
+		rec.Hash = repo.AuditHash(&rec)
+		prev = rec.Hash
+		chain = append(chain, rec)
+	}
+	return chain
+}
+
+func TestVerifyAudit(t *testing.T) {
+	// Цепочка длиннее пачки, чтобы проверить переход между пачками
+	size := auditChainBatch + 3
+
+	tests := []struct {
+		name   string
+		change func(chain []repo.XAuditRecord) []repo.XAuditRecord
+		broken int64
+	}{
+		{"intact", func(chain []repo.XAuditRecord) []repo.XAuditRecord { return chain }, 0},
+		{"empty", func(chain []repo.XAuditRecord) []repo.XAuditRecord { return nil }, 0},
+		{"changed outcome", func(chain []repo.XAuditRecord) []repo.XAuditRecord {
+			chain[9].Outcome = AuditOutcomeFailure
+			return chain
+		}, 10},
+		{"changed details", func(chain []repo.XAuditRecord) []repo.XAuditRecord {
+			chain[auditChainBatch+1].Details["n"] = "0"
+			return chain
+		}, int64(auditChainBatch + 2)},
+		{"rehashed record", func(chain []repo.XAuditRecord) []repo.XAuditRecord {
+			// Пересчитанный хеш измененной записи не совпадает с prev_hash следующей
+			chain[4].Email = optional("attacker@example.com")
+			chain[4].Hash = repo.AuditHash(&chain[4])
+			return chain
+		}, 6},
+		{"deleted record", func(chain []repo.XAuditRecord) []repo.XAuditRecord {
+			return append(chain[:2], chain[3:]...)
+		}, 4},
+		{"first record deleted", func(chain []repo.XAuditRecord) []repo.XAuditRecord { return chain[1:] }, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := tt.change(auditChain(size))
+			uc := &AuthUseCase{repo: &auditRepo{chain: chain}}
+
+			res, zerr := uc.VerifyAudit(core.WithTenant(context.Background(), core.DefaultTenantID))
+			if zerr != nil {
+				t.Fatalf("VerifyAudit: %+v", zerr)
+			}
+			if tt.broken == 0 {
+				if !res.Ok || res.BrokenAtSeq != nil || res.Checked != len(chain) {
+					t.Errorf("VerifyAudit = %+v, want ok with %d checked", res, len(chain))
+				}
+				if len(chain) > 0 && res.LastHash != chain[len(chain)-1].Hash {
+					t.Errorf("LastHash = %s, want %s", res.LastHash, chain[len(chain)-1].Hash)
+				}
+				return
+			}
+			if res.Ok || res.BrokenAtSeq == nil || *res.BrokenAtSeq != tt.broken {
+				t.Errorf("VerifyAudit = %+v, want broken at %d", res, tt.broken)
+			}
+		})
+	}
+}
+
+func TestAuditHash(t *testing.T) {
+	rec := auditChain(1)[0]
+	rec.Details = map[string]string{"b": "2", "a": "1", "c": "3"}
+	same := rec
+	same.Details = map[string]string{"c": "3", "a": "1", "b": "2"}
+	if repo.AuditHash(&rec) != repo.AuditHash(&same) {
+		t.Error("AuditHash depends on details order")
+	}
+
+	// nil и пустые детали хешируются одинаково: после чтения из базы nil приходит пустой картой
+	rec.Details = nil
+	same.Details = map[string]string{}
+	if repo.AuditHash(&rec) != repo.AuditHash(&same) {
+		t.Error("AuditHash differs for nil and empty details")
+	}
+
+	other := rec
+	other.AccountID = optional("7d1e0c6a-2f0b-4d8e-9a35-5c4b3a2d1e0f")
+	if repo.AuditHash(&rec) == repo.AuditHash(&other) {
+		t.Error("AuditHash ignores account_id")
+	}
+}
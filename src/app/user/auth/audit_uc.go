@@ -0,0 +1,274 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"regexp"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+const (
+	// AuditOutcomeSuccess - действие выполнено
+	AuditOutcomeSuccess = "success"
+	// AuditOutcomeFailure - действие отклонено или завершилось ошибкой
+	AuditOutcomeFailure = "failure"
+)
+
+// События журнала безопасности
+const (
+	AuditSignupEmail             = "signup.email"
+	AuditSignupConfirm           = "signup.confirm"
+	AuditLogin                   = "login"
+	AuditTokenRefresh            = "token.refresh"
+	AuditTokenRevoke             = "token.revoke"
+	AuditPasswordResetRequest    = "password_reset.request"
+	AuditPasswordResetConfirm    = "password_reset.confirm"
+	AuditAdminAccountLogout      = "admin.account.logout"
+	AuditAdminAccountStatus      = "admin.account.status"
+	AuditAdminPasswordReset      = "admin.account.password_reset"
+	AuditAdminSignupConfirm      = "admin.signup.confirm"
+	AuditAdminImpersonationStart = "admin.impersonation.start"
+	AuditAdminImpersonationEnd   = "admin.impersonation.end"
+	AuditAdminTenantCreate       = "admin.tenant.create"
+	AuditAdminRoleCreate         = "admin.rbac.role.create"
+	AuditAdminPermissionCreate   = "admin.rbac.permission.create"
+	AuditAdminPermissionGrant    = "admin.rbac.permission.grant"
+	AuditAdminPermissionRevoke   = "admin.rbac.permission.revoke"
+	AuditAdminRoleAssign         = "admin.rbac.role.assign"
+	AuditAdminRoleUnassign       = "admin.rbac.role.unassign"
+	AuditAdminWebhookCreate      = "admin.webhook.create"
+	AuditAdminWebhookUpdate      = "admin.webhook.update"
+	AuditAdminWebhookDelete      = "admin.webhook.delete"
+)
+
+// auditChainBatch - размер пачки записей при проверке цепочки
+const auditChainBatch = 500
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// SearchAudit ищет записи журнала безопасности тенанта запроса.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - req: фильтры по аккаунту, исполнителю, событию, результату, периоду и параметры страницы
+//
+// Возвращает:
+//   - указатель на структуру ZAuditPage со страницей записей и общим количеством
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) SearchAudit(ctx context.Context, req *share.QAuditSearch) (*share.ZAuditPage, *core.ZError) {
+	if req.Outcome != "" && req.Outcome != AuditOutcomeSuccess && req.Outcome != AuditOutcomeFailure {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Неизвестный результат события",
+			Exception: req.Outcome,
+		}
+	}
+	limit, offset, zerr := normalizePage(req.Limit, req.Offset)
+	if zerr != nil {
+		return nil, zerr
+	}
+
+	xres, total, err := s.repo.SearchAudit(ctx, &repo.XAuditFilter{
+		TenantID:  core.TenantFromContext(ctx),
+		AccountID: req.AccountID,
+		ActorID:   req.ActorID,
+		Event:     req.Event,
+		Outcome:   req.Outcome,
+		From:      req.From,
+		To:        req.To,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	items := make([]share.ZAuditRecord, 0, len(xres))
+	for i := range xres {
+		items = append(items, toZAuditRecord(&xres[i]))
+	}
+	return &share.ZAuditPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// VerifyAudit проходит цепочку журнала тенанта от начала и пересчитывает хеши.
+// Изменение, удаление или вставка записи задним числом ломает цепочку на первой затронутой записи.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//
+// Возвращает:
+//   - указатель на структуру ZAuditVerify с результатом проверки и номером первой некорректной записи
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка базы данных
+func (s *AuthUseCase) VerifyAudit(ctx context.Context) (*share.ZAuditVerify, *core.ZError) {
+	tenant_id := core.TenantFromContext(ctx)
+	res := &share.ZAuditVerify{Ok: true, LastHash: repo.AuditGenesisHash}
+
+	var after_seq int64
+	for {
+		chain, err := s.repo.GetAuditChain(ctx, tenant_id, after_seq, auditChainBatch)
+		if err != nil {
+			return nil, repoError(err)
+		}
+
+		for i := range chain {
+			rec := &chain[i]
+			if rec.PrevHash != res.LastHash || repo.AuditHash(rec) != rec.Hash {
+				res.Ok = false
+				res.BrokenAtSeq = &rec.Seq
+				return res, nil
+			}
+			res.LastHash = rec.Hash
+			res.Checked++
+			after_seq = rec.Seq
+		}
+
+		if len(chain) < auditChainBatch {
+			return res, nil
+		}
+	}
+}
+
+// ----------- Tools -----------
+
+// audit записывает событие в журнал безопасности тенанта запроса. IP, User-Agent и исполнитель берутся из контекста.
+// Ошибка записи журнала не прерывает основное действие и только логируется.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - event: тип события
+//   - zerr: ошибка действия; nil означает успех
+//   - account_id: аккаунт, к которому относится событие, может быть пустым
+//   - email: email из запроса, может быть пустым
+//   - details: дополнительные поля события, может быть nil
+func (s *AuthUseCase) audit(ctx context.Context, event string, zerr *core.ZError, account_id string, email string, details map[string]string) {
+	if details == nil {
+		details = map[string]string{}
+	}
+	if account_id != "" && !uuidRe.MatchString(account_id) {
+		// Некорректный ID из запроса не влезет в колонку uuid, но сам факт попытки должен попасть в журнал
+		details["account_id"] = account_id
+		account_id = ""
+	}
+	outcome := AuditOutcomeSuccess
+	if zerr != nil {
+		outcome = AuditOutcomeFailure
+		details["error"] = zerr.Message
+	}
+
+	client := core.ClientFromContext(ctx)
+	_, err := s.repo.WriteAudit(ctx, &repo.XAuditRecord{
+		TenantID:  core.TenantFromContext(ctx),
+		Event:     event,
+		Outcome:   outcome,
+		AccountID: optional(account_id),
+		ActorID:   optional(core.ActorFromContext(ctx)),
+		Email:     optional(email),
+		IpAddress: optional(client.IP),
+		UserAgent: optional(client.UserAgent),
+		Details:   details,
+	})
+	if err != nil {
+		log.Printf("audit %s: %s", event, err)
+	}
+}
+
+// revokeTokens отзывает все refresh токены аккаунта и записывает отзыв в журнал с указанием причины.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - tenant_id: идентификатор тенанта
+//   - account_id: идентификатор аккаунта
+//   - reason: причина отзыва, например session_binding
+//
+// Возвращает:
+//   - ошибку репозитория, если отзыв не удался
+func (s *AuthUseCase) revokeTokens(ctx context.Context, tenant_id string, account_id string, reason string) error {
+	_, err := s.repo.RevokeToken(ctx, tenant_id, account_id, reason)
+
+	var zerr *core.ZError
+	if err != nil {
+		zerr = repoError(err)
+	}
+	s.audit(ctx, AuditTokenRevoke, zerr, account_id, "", map[string]string{"reason": reason})
+
+	return err
+}
+
+// revokeTokenFamily отзывает токены одной сессии аккаунта и записывает отзыв в журнал с указанием причины.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - tenant_id: идентификатор тенанта
+//   - account_id: идентификатор аккаунта
+//   - family_id: идентификатор семейства токенов
+//   - reason: причина отзыва, например session_binding
+//
+// Возвращает:
+//   - ошибку репозитория, если отзыв не удался
+func (s *AuthUseCase) revokeTokenFamily(ctx context.Context, tenant_id string, account_id string, family_id string, reason string) error {
+	_, err := s.repo.RevokeTokenFamily(ctx, tenant_id, account_id, family_id, reason)
+
+	var zerr *core.ZError
+	if err != nil {
+		zerr = repoError(err)
+	}
+	s.audit(ctx, AuditTokenRevoke, zerr, account_id, "", map[string]string{"reason": reason, "family_id": family_id})
+
+	return err
+}
+
+// revokeRefreshToken отзывает один refresh токен и записывает отзыв в журнал с указанием причины.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - token: отзываемый токен
+//   - reason: причина отзыва, например session_binding
+//
+// Возвращает:
+//   - ошибку репозитория, если отзыв не удался
+func (s *AuthUseCase) revokeRefreshToken(ctx context.Context, token *repo.XRefreshToken, reason string) error {
+	_, err := s.repo.RevokeRefreshToken(ctx, token.TenantID, token.AccountID, token.ID, reason)
+
+	var zerr *core.ZError
+	if err != nil {
+		zerr = repoError(err)
+	}
+	s.audit(ctx, AuditTokenRevoke, zerr, token.AccountID, "", map[string]string{"reason": reason, "family_id": token.FamilyID})
+
+	return err
+}
+
+// optional возвращает nil для пустой строки, чтобы в журнал попадал NULL.
+func optional(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+// toZAuditRecord переводит запись журнала из слоя репозитория в DTO.
+func toZAuditRecord(x *repo.XAuditRecord) share.ZAuditRecord {
+	return share.ZAuditRecord{
+		Seq:       x.Seq,
+		Event:     x.Event,
+		Outcome:   x.Outcome,
+		AccountID: x.AccountID,
+		ActorID:   x.ActorID,
+		Email:     x.Email,
+		IpAddress: x.IpAddress,
+		UserAgent: x.UserAgent,
+		Details:   x.Details,
+		PrevHash:  x.PrevHash,
+		Hash:      x.Hash,
+		CreatedAt: x.CreatedAt,
+	}
+}
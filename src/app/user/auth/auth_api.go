@@ -22,17 +22,26 @@ func (h *API) SetupRoutes(r *gin.RouterGroup) {
 	r.POST(core.UserAuthConfirmEmail, h.confirmEmail)
 	r.POST(core.UserAuthLoginEmail, h.loginEmail)
 	r.POST(core.UserAuthRefreshToken, h.refreshToken)
+	r.POST(core.UserAuthResetPassword, h.resetPassword)
+	r.POST(core.UserAuthResetPasswordConfirm, h.resetPasswordConfirm)
+	r.GET(core.UserAuthCaptcha, h.captcha)
+	r.POST(core.UserAuthImpersonationEnd, h.Authenticate(), h.endOwnImpersonation)
+
+	h.setupRBACRoutes(r)
+	h.setupTenantRoutes(r)
 }
 
 // @Summary Регистрация пользователя
-// @Description Эндпоинт позволяет зарегистрировать свой аккаунт и получить код для подтверждения. Возвращает данные регистрируемого аккаунта
+// @Description Эндпоинт позволяет зарегистрировать свой аккаунт, код подтверждения отправляется на email (в ответе только в окружениях local и test). Возвращает данные регистрируемого аккаунта. Если CAPTCHA включена, ее ответ передается в поле captcha
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param request body share.QEmailSignup true "Данные регистрации"
 // @Success 200 {object} share.ZEmailSignup
 // @Failure 400 {object} core.ZError
+// @Failure 429 {object} core.ZError
 // @Failure 500 {object} core.ZError
+// @Failure 502 {object} core.ZError
 // @Router /user/auth/signup/email [post]
 func (h *API) signupEmail(c *gin.Context) {
 	var req share.QEmailSignup
@@ -43,15 +52,8 @@ func (h *API) signupEmail(c *gin.Context) {
 	}
 	res, err := h.uc.SignupEmail(c.Request.Context(), &req)
 	if err != nil {
-		switch err.Code {
-		case 400:
-			c.JSON(http.StatusBadRequest, err)
-			return
-		case 500:
-			c.JSON(http.StatusInternalServerError, err)
-			return
-		}
-
+		c.JSON(err.Code, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, res)
@@ -77,32 +79,25 @@ func (h *API) confirmEmail(c *gin.Context) {
 	}
 	res, err := h.uc.ConfirmEmail(c.Request.Context(), &req)
 	if err != nil {
-		switch err.Code {
-		case 400:
-			c.JSON(http.StatusBadRequest, err)
-			return
-		case 404:
-			c.JSON(http.StatusNotFound, err)
-			return
-		case 500:
-			c.JSON(http.StatusInternalServerError, err)
-			return
-		}
+		c.JSON(err.Code, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, res)
 }
 
 // @Summary Вход в аккаунт через email
-// @Description Эндпоинт позволяет пользователю войти в систему, указав свой email. Возвращает пару токенов access и refresh.
+// @Description Эндпоинт позволяет пользователю войти в систему, указав свой email. Возвращает пару токенов access и refresh. Неизвестный email и неверный пароль дают одинаковый ответ 400, 404 только при PreciseAuthErrors. По оценке риска вход может быть запрещен (403), тогда в exception - балл и сигналы. После нескольких неудачных входов с email или IP нужен ответ CAPTCHA (поле captcha)
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param request body share.QLoginEmail true "Данные аккаунта"
 // @Success 200 {object} share.ZToken
 // @Failure 400 {object} core.ZError
+// @Failure 403 {object} core.ZError
 // @Failure 404 {object} core.ZError
 // @Failure 500 {object} core.ZError
+// @Failure 502 {object} core.ZError
 // @Router /user/auth/login/email [post]
 func (h *API) loginEmail(c *gin.Context) {
 	var req share.QLoginEmail
@@ -111,32 +106,25 @@ func (h *API) loginEmail(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	res, err := h.uc.LoginEmail(c.Request.Context(), &req, c.GetHeader("User-Agent"), c.ClientIP())
+	res, err := h.uc.LoginEmail(c.Request.Context(), &req, c.GetHeader("User-Agent"), core.ClientFromContext(c.Request.Context()).IP)
 	if err != nil {
-		switch err.Code {
-		case 400:
-			c.JSON(http.StatusBadRequest, err)
-			return
-		case 404:
-			c.JSON(http.StatusNotFound, err)
-			return
-		case 500:
-			c.JSON(http.StatusInternalServerError, err)
-			return
-		}
+		c.JSON(err.Code, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, res)
 }
 
 // @Summary Рефреш токена
-// @Description Эндпоинт позволяет обновлять access jwt token, используя парный refresh token. Возвращает пару токенов access и refresh
+// @Description Эндпоинт позволяет обновлять access jwt token, используя парный refresh token. Возвращает пару токенов access и refresh, предъявленный refresh токен отзывается. Повторное предъявление замененного токена отзывает всю сессию (401). Рефреш проходит ту же оценку риска, что и вход
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param request body share.QRefreshToken true "Токен"
 // @Success 200 {object} share.ZToken
 // @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
 // @Failure 404 {object} core.ZError
 // @Failure 500 {object} core.ZError
 // @Router /user/auth/refresh/token [post]
@@ -147,19 +135,106 @@ func (h *API) refreshToken(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	res, err := h.uc.RefreshToken(c.Request.Context(), &req, c.GetHeader("User-Agent"), c.ClientIP())
+	res, err := h.uc.RefreshToken(c.Request.Context(), &req, c.GetHeader("User-Agent"), core.ClientFromContext(c.Request.Context()).IP)
 	if err != nil {
-		switch err.Code {
-		case 400:
-			c.JSON(http.StatusBadRequest, err)
-			return
-		case 404:
-			c.JSON(http.StatusNotFound, err)
-			return
-		case 500:
-			c.JSON(http.StatusInternalServerError, err)
-			return
-		}
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Запрос на сброс пароля
+// @Description Эндпоинт создает запрос на сброс пароля для аккаунта с указанным email. Код подтверждения возвращается только в окружениях local и test. Для неизвестного email ответ такой же, как для существующего, 404 только при PreciseAuthErrors. Если CAPTCHA включена, ее ответ передается в поле captcha
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body share.QPasswordReset true "Email аккаунта"
+// @Success 200 {object} share.ZPasswordReset
+// @Failure 400 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 429 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Failure 502 {object} core.ZError
+// @Router /user/auth/reset/password [post]
+func (h *API) resetPassword(c *gin.Context) {
+	var req share.QPasswordReset
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.RequestPasswordReset(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Установка нового пароля
+// @Description Эндпоинт устанавливает новый пароль по коду сброса и отзывает все refresh токены аккаунта
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body share.QPasswordResetConfirm true "Код сброса и новый пароль"
+// @Success 200 {object} share.ZOk
+// @Failure 400 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/reset/password/confirm [post]
+func (h *API) resetPasswordConfirm(c *gin.Context) {
+	var req share.QPasswordResetConfirm
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.ConfirmPasswordReset(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Завершение входа под пользователем
+// @Description Завершает сессию входа администратора под пользователем, которой выдан токен запроса. Токен перестает приниматься
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} share.ZImpersonation
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/impersonation/end [post]
+func (h *API) endOwnImpersonation(c *gin.Context) {
+	claims, _ := Claims(c)
+
+	res, err := h.uc.EndOwnImpersonation(c.Request.Context(), claims)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Параметры CAPTCHA
+// @Description Эндпоинт возвращает провайдера CAPTCHA и ключ виджета или новую задачу proof-of-work, а также где CAPTCHA требуется. Ответ передается в поле captcha регистрации, входа и запроса сброса пароля
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} share.ZCaptcha
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/captcha [get]
+func (h *API) captcha(c *gin.Context) {
+	res, err := h.uc.Captcha(c.Request.Context())
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, res)
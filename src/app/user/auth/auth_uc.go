@@ -2,33 +2,83 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"log"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/MedodsTechTask/app/captcha"
 	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/egress"
+	"github.com/MedodsTechTask/app/email"
+	"github.com/MedodsTechTask/app/jobs"
+	"github.com/MedodsTechTask/app/mail"
+	"github.com/MedodsTechTask/app/metrics"
+	"github.com/MedodsTechTask/app/password"
+	"github.com/MedodsTechTask/app/ratelimit"
+	"github.com/MedodsTechTask/app/risk"
+	"github.com/MedodsTechTask/app/session"
 	"github.com/MedodsTechTask/app/user/auth/configs"
 	"github.com/MedodsTechTask/app/user/auth/repo"
 	"github.com/MedodsTechTask/app/user/auth/share"
 )
 
+const (
+	// AccountStatusActive - аккаунт активен
+	AccountStatusActive = "active"
+	// AccountStatusBlocked - аккаунт заблокирован администратором, вход запрещен
+	AccountStatusBlocked = "blocked"
+)
+
 type AuthUseCase struct {
-	cfg  *configs.Config
-	repo repo.IAuthRepo
+	repo   repo.IAuthRepo
+	mailer mail.Mailer
+	queue  *jobs.Queue
+	// live - текущие конфигурация и построенные по ней компоненты, заменяются при перезагрузке, см. Reload
+	live     atomic.Pointer[settings]
+	reloadMu sync.Mutex
+	// draining - сервис останавливается, см. Drain
+	draining atomic.Bool
+
+	// tenants - кеш идентификаторов тенантов по slug, ID и хосту; тенанты не удаляются, поэтому кеш не инвалидируется
+	tenants sync.Map
 }
 
-// NewAuthUseCase создает новый экземпляр AuthUseCase с заданной конфигурацией и репозиторием.
+// NewAuthUseCase создает новый экземпляр AuthUseCase с заданной конфигурацией, репозиторием, отправщиком писем, очередью задач,
+// менеджером и политикой паролей, валидатором адресов email, политикой привязки сессий, движком оценки риска входа и проверкой CAPTCHA.
 //
 // Параметры:
 //   - cfg: конфигурация приложения, содержащая параметры для аутентификации
 //   - repo: интерфейс репозитория для работы с данными аутентификации
+//   - mailer: отправщик писем с кодами подтверждения и уведомлениями, используется воркером очереди
+//   - queue: очередь фоновых задач, через которую отправляются письма и уведомления подписок
+//   - passwords: менеджер хеширования паролей
+//   - policy: политика паролей для регистрации и сброса
+//   - emails: валидатор адресов email для регистрации
+//   - binding: политика привязки refresh токенов к клиенту
+//   - risk: движок оценки риска входа
+//   - captcha: проверка CAPTCHA, nil - выключена
+//   - limiter: ограничитель частоты запросов
+//   - guard: запрет доставки уведомлений подписок во внутренние сети
 //
 // Возвращает:
 //   - указатель на новый экземпляр AuthUseCase
-func NewAuthUseCase(cfg *configs.Config, repo repo.IAuthRepo) *AuthUseCase {
-	return &AuthUseCase{cfg, repo}
+func NewAuthUseCase(cfg *configs.Config, repo repo.IAuthRepo, mailer mail.Mailer, queue *jobs.Queue, passwords *password.Manager, policy *password.Policy, emails *email.Validator, binding *session.Binding, risk *risk.Engine, captcha captcha.Verifier, limiter *ratelimit.Limiter, guard *egress.Guard) *AuthUseCase {
+	uc := &AuthUseCase{
+		repo:   repo,
+		mailer: mailer,
+		queue:  queue,
+	}
+	uc.live.Store(newSettings(cfg, passwords, policy, emails, binding, risk, captcha, limiter, guard))
+	return uc
 }
 
 // SignupEmail обрабатывает процесс регистрации пользователя через email. Проверяет совпадение паролей, валидирует email и пароль,
-// генерирует код подтверждения и хеширует пароль, после чего сохраняет данные в базе данных.
+// генерирует код подтверждения и хеширует пароль, после чего сохраняет данные в базе данных и в той же транзакции
+// ставит в очередь письмо с кодом.
+// В ответе код возвращается только в окружениях local и test.
 //
 // Параметры:
 //   - ctx: контекст выполнения для управления временем жизни операции
@@ -37,7 +87,18 @@ func NewAuthUseCase(cfg *configs.Config, repo repo.IAuthRepo) *AuthUseCase {
 // Возвращает:
 //   - указатель на структуру ZEmailSignup с данными пользователя, если регистрация прошла успешно
 //   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
-func (s *AuthUseCase) SignupEmail(ctx context.Context, req *share.QEmailSignup) (*share.ZEmailSignup, *core.ZError) {
+func (s *AuthUseCase) SignupEmail(ctx context.Context, req *share.QEmailSignup) (_ *share.ZEmailSignup, zerr *core.ZError) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.SignupEmail")
+	defer func() { endSpan(span, zerr) }()
+
+	var details map[string]string
+	defer func() { s.audit(ctx, AuditSignupEmail, zerr, "", req.Email, details) }()
+
+	if s.conf(ctx).cfg.Captcha.OnSignup {
+		if zerr = s.verifyCaptcha(ctx, req.Captcha); zerr != nil {
+			return nil, zerr
+		}
+	}
 	if !equal_passwords(req.Password, req.ConfirmedPwd) {
 		return nil, &core.ZError{
 			Code:      400,
@@ -47,14 +108,14 @@ func (s *AuthUseCase) SignupEmail(ctx context.Context, req *share.QEmailSignup)
 		}
 	}
 
-	res, err := ValidateCredentials(req.Email, req.Password)
-	if !res && err != nil {
+	addr, err := ValidateCredentials(s.conf(ctx).emails, s.conf(ctx).policy, req.Email, req.Password)
+	if err != nil {
 		switch e := err.(type) {
-		case *core.ErrInvalidLenPassword:
+		case *core.ErrPasswordPolicy:
 			return nil, &core.ZError{
 				Code:      400,
 				Where:     "UseCase",
-				Message:   "Неверная длина пароля (мин. 6 символов)",
+				Message:   "Пароль не соответствует требованиям",
 				Exception: e.ErrMessage,
 			}
 		case *core.ErrEmailValidate:
@@ -64,6 +125,13 @@ func (s *AuthUseCase) SignupEmail(ctx context.Context, req *share.QEmailSignup)
 				Message:   "Неверная почта",
 				Exception: e.ErrMessage,
 			}
+		case *core.ErrEmailDisposable:
+			return nil, &core.ZError{
+				Code:      400,
+				Where:     "UseCase",
+				Message:   "Регистрация на одноразовую почту недоступна",
+				Exception: e.ErrMessage,
+			}
 		}
 	}
 
@@ -76,7 +144,7 @@ func (s *AuthUseCase) SignupEmail(ctx context.Context, req *share.QEmailSignup)
 			Exception: nil,
 		}
 	}
-	passwd_hash, salt, err := CreatePasswordHash(req.Password, "")
+	passwd_hash, err := CreatePasswordHash(ctx, s.conf(ctx).passwords, req.Password)
 	if err != nil {
 		switch e := err.(type) {
 		case *core.ErrPasswordEmpty:
@@ -86,13 +154,6 @@ func (s *AuthUseCase) SignupEmail(ctx context.Context, req *share.QEmailSignup)
 				Message:   "Пароль пустой",
 				Exception: nil,
 			}
-		case *core.ErrGenerationSalt:
-			return nil, &core.ZError{
-				Code:      400,
-				Where:     "UseCase",
-				Message:   "Ошибка генерации соли пароля",
-				Exception: e.ErrMessage,
-			}
 		case *core.ErrGenerationHash:
 			return nil, &core.ZError{
 				Code:      400,
@@ -103,10 +164,28 @@ func (s *AuthUseCase) SignupEmail(ctx context.Context, req *share.QEmailSignup)
 		}
 	}
 
-	xres, err := s.repo.CreateEmailSignup(ctx, req.Email, passwd_hash, code, salt)
+	mail_job, zerr := s.mailJob(ctx, addr.String(), mail.TemplateConfirmEmail, mail.ConfirmData{
+		Email: addr.String(),
+		Code:  code,
+	})
+	if zerr != nil {
+		return nil, zerr
+	}
+
+	xres, err := s.repo.CreateEmailSignup(ctx, core.TenantFromContext(ctx), addr.String(), addr.Canonical, passwd_hash, code, "", mail_job)
 	if err != nil {
 		switch e := err.(type) {
 		case *core.ErrCreateSignup:
+			if !s.conf(ctx).cfg.PreciseAuthErrors {
+				// Email уже ожидает подтверждения: отвечаем так же, как на новую регистрацию
+				details = map[string]string{"reason": "duplicate_signup"}
+				return &share.ZEmailSignup{
+					ID:           CreateDecoyID(),
+					Email:        addr.String(),
+					PasswordHash: passwd_hash,
+					CreatedAt:    time.Now().UTC(),
+				}, nil
+			}
 			return nil, &core.ZError{
 				Code:      400,
 				Where:     "Repo",
@@ -123,7 +202,9 @@ func (s *AuthUseCase) SignupEmail(ctx context.Context, req *share.QEmailSignup)
 		}
 	}
 
-	return &share.ZEmailSignup{
+	s.mailSent(ctx, addr.String())
+
+	res_signup := &share.ZEmailSignup{
 		ID:           xres.ID,
 		Email:        xres.Email,
 		Code:         xres.Code,
@@ -131,7 +212,16 @@ func (s *AuthUseCase) SignupEmail(ctx context.Context, req *share.QEmailSignup)
 		Salt:         xres.Salt,
 		CreatedAt:    xres.CreatedAt,
 		UpdatedAt:    xres.UpdatedAt,
-	}, nil
+	}
+	if !s.conf(ctx).cfg.ReturnCodes() {
+		res_signup.Code = ""
+	}
+	metrics.Signups.Inc()
+	s.emitWebhook(ctx, WebhookUserSignup, map[string]string{
+		"signup_id": xres.ID,
+		"email":     xres.Email,
+	})
+	return res_signup, nil
 }
 
 // ConfirmEmail подтверждает регистрацию пользователя по коду, отправленному на email.
@@ -144,8 +234,19 @@ func (s *AuthUseCase) SignupEmail(ctx context.Context, req *share.QEmailSignup)
 // Возвращает:
 //   - указатель на структуру ZAccount с данными созданного аккаунта, если подтверждение прошло успешно
 //   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
-func (s *AuthUseCase) ConfirmEmail(ctx context.Context, req *share.QConfirmEmail) (*share.ZAccount, *core.ZError) {
-	signup_acc, err := s.repo.GetEmailSignup(ctx, req.SignupID)
+func (s *AuthUseCase) ConfirmEmail(ctx context.Context, req *share.QConfirmEmail) (res *share.ZAccount, zerr *core.ZError) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.ConfirmEmail")
+	defer func() { endSpan(span, zerr) }()
+
+	defer func() {
+		account_id, email := "", ""
+		if res != nil {
+			account_id, email = res.ID, res.Email
+		}
+		s.audit(ctx, AuditSignupConfirm, zerr, account_id, email, map[string]string{"signup_id": req.SignupID})
+	}()
+
+	signup_acc, err := s.repo.GetEmailSignup(ctx, core.TenantFromContext(ctx), req.SignupID)
 	if err != nil {
 		switch e := err.(type) {
 		case *core.ErrEmailSignupNotFound:
@@ -165,7 +266,7 @@ func (s *AuthUseCase) ConfirmEmail(ctx context.Context, req *share.QConfirmEmail
 		}
 	}
 
-	if req.Code != signup_acc.Code {
+	if !CompareCodes(req.Code, signup_acc.Code) {
 		return nil, &core.ZError{
 			Code:      400,
 			Where:     "UseCase",
@@ -174,58 +275,12 @@ func (s *AuthUseCase) ConfirmEmail(ctx context.Context, req *share.QConfirmEmail
 		}
 	}
 
-	del, err := s.repo.DeleteEmailSignup(ctx, req.SignupID)
-	if !del && err != nil {
-		switch e := err.(type) {
-		case *core.ErrEmailSignupNotFound:
-			return nil, &core.ZError{
-				Code:      404,
-				Where:     "Repo",
-				Message:   "Аккаунт не найден",
-				Exception: e.ErrMessage,
-			}
-		case *core.ErrPGRepo:
-			return nil, &core.ZError{
-				Code:      500,
-				Where:     "Repo",
-				Message:   "Неизвестная ошибка базы данных",
-				Exception: e.ErrMessage,
-			}
-		}
-	}
-
-	xres, err := s.repo.CreateAccount(ctx, signup_acc)
-	if err != nil {
-		switch e := err.(type) {
-		case *core.ErrCreateAccount:
-			return nil, &core.ZError{
-				Code:      400,
-				Where:     "Repo",
-				Message:   "Не удалось создать аккаунт",
-				Exception: e.ErrMessage,
-			}
-		case *core.ErrPGRepo:
-			return nil, &core.ZError{
-				Code:      500,
-				Where:     "Repo",
-				Message:   "Неизвестная ошибка базы данных",
-				Exception: e.ErrMessage,
-			}
-		}
-	}
-
-	return &share.ZAccount{
-		ID:         xres.ID,
-		Email:      xres.Email,
-		PasswdHash: xres.PasswordHash,
-		Salt:       xres.Salt,
-		CreatedAt:  xres.CreatedAt,
-		UpdatedAt:  xres.UpdatedAt,
-	}, nil
+	return s.confirmSignup(ctx, signup_acc)
 }
 
 // LoginEmail обрабатывает процесс входа пользователя через email и пароль.
 // Он проверяет существование аккаунта, валидирует пароль и генерирует токены доступа и обновления.
+// В access токен включаются тенант, роли, разрешения и членство в организациях аккаунта.
 // Также сохраняет refresh токен в базе данных.
 //
 // Параметры:
@@ -237,11 +292,80 @@ func (s *AuthUseCase) ConfirmEmail(ctx context.Context, req *share.QConfirmEmail
 // Возвращает:
 //   - указатель на структуру ZToken с access и refresh токенами, если авторизация прошла успешно
 //   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
-func (s *AuthUseCase) LoginEmail(ctx context.Context, login *share.QLoginEmail, user_agent string, ip string) (*share.ZToken, *core.ZError) {
-	acc, err := s.repo.GetAccountForEmail(ctx, login.Email)
+func (s *AuthUseCase) LoginEmail(ctx context.Context, login *share.QLoginEmail, user_agent string, ip string) (_ *share.ZToken, zerr *core.ZError) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.LoginEmail")
+	defer func() { endSpan(span, zerr) }()
+
+	ctx = core.WithClient(ctx, ip, user_agent)
+	tenant_id := core.TenantFromContext(ctx)
+
+	var acc *repo.XAccount
+	var details map[string]string
+	var assessment *risk.Assessment
+	credentials_failed := false
+	// failure - причина отказа для метрик, ошибки базы данных и подписи попадают в LoginFailureError
+	failure := metrics.LoginFailureError
+	defer func() {
+		account_id := ""
+		if acc != nil {
+			account_id = acc.ID
+		}
+		if credentials_failed {
+			s.recordLoginFailure(ctx, account_id, email.Canonical(login.Email))
+		}
+		if zerr == nil {
+			metrics.Logins.Inc()
+		} else {
+			metrics.LoginFailures.WithLabelValues(failure).Inc()
+		}
+		s.audit(ctx, AuditLogin, zerr, account_id, login.Email, riskDetails(details, assessment))
+
+		client := core.ClientFromContext(ctx)
+		switch {
+		case zerr == nil:
+			s.emitWebhook(ctx, WebhookUserLogin, map[string]string{
+				"account_id": account_id,
+				"email":      acc.Email,
+				"ip":         client.IP,
+				"user_agent": client.UserAgent,
+			})
+		case acc != nil:
+			s.emitWebhook(ctx, WebhookUserLoginFailed, map[string]string{
+				"account_id": account_id,
+				"email":      acc.Email,
+				"reason":     zerr.Message,
+				"ip":         client.IP,
+				"user_agent": client.UserAgent,
+			})
+		}
+	}()
+
+	if login.Password == "" {
+		failure = metrics.LoginFailureEmptyPassword
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Пароль пустой",
+			Exception: nil,
+		}
+	}
+	if zerr = s.verifyLoginCaptcha(ctx, login.Email, login.Captcha); zerr != nil {
+		failure = metrics.LoginFailureCaptcha
+		return nil, zerr
+	}
+
+	acc, err := s.repo.GetAccountForEmail(ctx, tenant_id, email.Canonical(login.Email))
 	if err != nil {
 		switch e := err.(type) {
 		case *core.ErrAccountNotFound:
+			// Проверка пароля по хешу-пустышке уравнивает время ответа с существующим аккаунтом
+			s.conf(ctx).passwords.VerifyDummy(login.Password)
+			credentials_failed = true
+			failure = metrics.LoginFailureAccountNotFound
+			if !s.conf(ctx).cfg.PreciseAuthErrors {
+				details = map[string]string{"reason": "account_not_found"}
+				return nil, invalidCredentials()
+			}
 			return nil, &core.ZError{
 				Code:      404,
 				Where:     "Repo",
@@ -258,7 +382,7 @@ func (s *AuthUseCase) LoginEmail(ctx context.Context, login *share.QLoginEmail,
 		}
 	}
 
-	pwd_hash, _, err := CreatePasswordHash(login.Password, acc.Salt)
+	pwd_ok, rehash, err := VerifyPasswordHash(ctx, s.conf(ctx).passwords, login.Password, acc.PasswordHash, acc.Salt)
 	if err != nil {
 		switch e := err.(type) {
 		case *core.ErrPasswordEmpty:
@@ -277,7 +401,13 @@ func (s *AuthUseCase) LoginEmail(ctx context.Context, login *share.QLoginEmail,
 			}
 		}
 	}
-	if pwd_hash != acc.PasswordHash {
+	if !pwd_ok {
+		credentials_failed = true
+		failure = metrics.LoginFailureWrongPassword
+		if !s.conf(ctx).cfg.PreciseAuthErrors {
+			details = map[string]string{"reason": "wrong_password"}
+			return nil, invalidCredentials()
+		}
 		return nil, &core.ZError{
 			Code:      400,
 			Where:     "UseCase",
@@ -285,13 +415,29 @@ func (s *AuthUseCase) LoginEmail(ctx context.Context, login *share.QLoginEmail,
 			Exception: nil,
 		}
 	}
+	if acc.Status == AccountStatusBlocked {
+		failure = metrics.LoginFailureAccountBlocked
+		return nil, &core.ZError{
+			Code:      403,
+			Where:     "UseCase",
+			Message:   "Аккаунт заблокирован",
+			Exception: nil,
+		}
+	}
+	if rehash {
+		s.rehashPassword(ctx, acc, login.Password)
+	}
+	if assessment, zerr = s.assessLogin(ctx, acc.ID, acc.Email, LoginKindLogin); zerr != nil {
+		failure = metrics.LoginFailureRiskBlock
+		return nil, zerr
+	}
 
-	access_payload := map[string]interface{}{
-		"sub":  acc.ID,
-		"type": "access",
+	access_payload, zerr := s.accessPayload(ctx, tenant_id, acc.ID)
+	if zerr != nil {
+		return nil, zerr
 	}
 
-	token, err := CreateJWT(access_payload, s.cfg.JWTPrivateKey)
+	token, err := CreateJWT(ctx, access_payload, s.conf(ctx).cfg.JWTPrivateKey, s.conf(ctx).cfg.JWTAlgorithm, s.conf(ctx).cfg.JWTIssuer, s.conf(ctx).cfg.AccessTokenTTL)
 	if err != nil {
 		switch e := err.(type) {
 		case *core.ErrParsePrivateKey:
@@ -311,32 +457,17 @@ func (s *AuthUseCase) LoginEmail(ctx context.Context, login *share.QLoginEmail,
 		}
 	}
 
-	refresh_payload := map[string]interface{}{
-		"sub":  acc.ID,
-		"type": "refresh",
-	}
-
-	refresh_token, err := CreateJWT(refresh_payload, s.cfg.JWTPrivateKey)
+	refresh_token, lookup_id, token_hash, err := CreateRefreshToken()
 	if err != nil {
-		switch e := err.(type) {
-		case *core.ErrParsePrivateKey:
-			return nil, &core.ZError{
-				Code:      400,
-				Where:     "UseCasUseCase/Securitye",
-				Message:   "Ошибка парсинга приватного ключа",
-				Exception: e.ErrMessage,
-			}
-		case *core.ErrSignedJwt:
-			return nil, &core.ZError{
-				Code:      400,
-				Where:     "UseCase/Security",
-				Message:   "Ошибка подписания jwt ключа",
-				Exception: e.ErrMessage,
-			}
+		return nil, &core.ZError{
+			Code:      500,
+			Where:     "UseCase/Security",
+			Message:   "Ошибка генерации refresh токена",
+			Exception: err,
 		}
 	}
 
-	_, err = s.repo.SaveRefreshToken(ctx, acc.ID, user_agent, ip, refresh_token)
+	_, err = s.repo.SaveRefreshToken(ctx, tenant_id, acc.ID, "", user_agent, ip, lookup_id, token_hash, s.conf(ctx).cfg.RefreshTokenTTL)
 	if err != nil {
 		switch e := err.(type) {
 		case *core.ErrSaveToken:
@@ -365,7 +496,10 @@ func (s *AuthUseCase) LoginEmail(ctx context.Context, login *share.QLoginEmail,
 
 // RefreshToken обрабатывает запрос на обновление токена доступа с использованием refresh токена.
 // Он проверяет действительность refresh токена, его тип и соответствие с данными пользователя,
-// а также проверяет, не был ли токен отозван. В случае успеха возвращает новый токен доступа.
+// а также проверяет, что токен выпущен в тенанте запроса и не был отозван. В случае успеха возвращает
+// новый токен доступа с актуальными ролями, разрешениями и организациями аккаунта и новый refresh токен
+// того же семейства, а предъявленный отзывает. Повторное предъявление уже замененного токена означает,
+// что его копия есть у кого-то еще, поэтому отзывается все семейство.
 //
 // Параметры:
 //   - ctx: контекст выполнения для управления временем жизни операции
@@ -374,68 +508,328 @@ func (s *AuthUseCase) LoginEmail(ctx context.Context, login *share.QLoginEmail,
 //   - ip: строка с IP-адресом пользователя
 //
 // Возвращает:
-//   - указатель на структуру ZToken с новым access токеном и старым refresh токеном, если обновление прошло успешно
+//   - указатель на структуру ZToken с новыми access и refresh токенами, если обновление прошло успешно
 //   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
-func (s *AuthUseCase) RefreshToken(ctx context.Context, req *share.QRefreshToken, user_agent string, ip string) (*share.ZToken, *core.ZError) {
-	payload, err := DecodeJWT(req.RefreshToken, s.cfg.JWTPublicKey)
+func (s *AuthUseCase) RefreshToken(ctx context.Context, req *share.QRefreshToken, user_agent string, ip string) (_ *share.ZToken, zerr *core.ZError) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.RefreshToken")
+	defer func() { endSpan(span, zerr) }()
+
+	ctx = core.WithClient(ctx, ip, user_agent)
+
+	var acc_id string
+	var assessment *risk.Assessment
+	defer func() {
+		s.audit(ctx, AuditTokenRefresh, zerr, acc_id, "", riskDetails(nil, assessment))
+		result := "success"
+		if zerr != nil {
+			result = "failure"
+		}
+		metrics.Refreshes.WithLabelValues(result).Inc()
+	}()
+
+	tenant_id := core.TenantFromContext(ctx)
+
+	lookup_id, token_hash, ok := ParseRefreshToken(req.RefreshToken)
+	legacy_acc_id := ""
+	if !ok {
+		lookup_id, token_hash, legacy_acc_id, zerr = s.legacyRefreshToken(ctx, req.RefreshToken)
+		if zerr != nil {
+			return nil, zerr
+		}
+	}
+
+	res, err := s.repo.GetRefreshToken(ctx, tenant_id, lookup_id)
 	if err != nil {
 		switch e := err.(type) {
-		case *core.ErrParsePublicKey:
+		case *core.ErrTokenNotFound:
 			return nil, &core.ZError{
-				Code:      400,
-				Where:     "UseCase/Security",
-				Message:   "Ошибка парсинга публичного ключа",
+				Code:      404,
+				Where:     "Repo",
+				Message:   "Токен не найден",
 				Exception: e.ErrMessage,
 			}
-		case *core.ErrUnExpectedSign:
+		case *core.ErrPGRepo:
 			return nil, &core.ZError{
-				Code:      400,
-				Where:     "UseCase/Security",
-				Message:   "Ошибка не верный метод подписания JWT ключа",
+				Code:      500,
+				Where:     "Repo",
+				Message:   "Неизвестная ошибка базы данных",
 				Exception: e.ErrMessage,
 			}
-		case *core.ErrJwtExpired:
+		}
+	}
+	if !CompareRefreshTokenHash(res.TokenHash, token_hash) || (legacy_acc_id != "" && legacy_acc_id != res.AccountID) {
+		return nil, &core.ZError{
+			Code:      404,
+			Where:     "UseCase",
+			Message:   "Токен не найден",
+			Exception: nil,
+		}
+	}
+	acc_id = res.AccountID
+
+	// Повтор замененного токена проверяется до привязки: копия с другого адреса все равно отзывает семейство
+	if res.IsRevoked {
+		if res.ReplacedBy != nil {
+			return nil, s.refreshReuse(ctx, res)
+		}
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Токен был отозван",
+			Exception: nil,
+		}
+	}
+	if !s.conf(ctx).binding.Matches(res.IpAddress, res.UserAgent, ip, user_agent) {
+		return nil, s.bindingViolation(ctx, res)
+	}
+	if assessment, zerr = s.assessLogin(ctx, acc_id, "", LoginKindRefresh); zerr != nil {
+		return nil, zerr
+	}
+
+	access_payload, zerr := s.accessPayload(ctx, tenant_id, acc_id)
+	if zerr != nil {
+		return nil, zerr
+	}
+
+	token, err := CreateJWT(ctx, access_payload, s.conf(ctx).cfg.JWTPrivateKey, s.conf(ctx).cfg.JWTAlgorithm, s.conf(ctx).cfg.JWTIssuer, s.conf(ctx).cfg.AccessTokenTTL)
+	if err != nil {
+		switch e := err.(type) {
+		case *core.ErrParsePrivateKey:
 			return nil, &core.ZError{
 				Code:      400,
 				Where:     "UseCase/Security",
-				Message:   "JWT ключ истек",
+				Message:   "Ошибка парсинга приватного ключа",
 				Exception: e.ErrMessage,
 			}
-		case *core.ErrIncorrectJwt:
+		case *core.ErrSignedJwt:
 			return nil, &core.ZError{
 				Code:      400,
 				Where:     "UseCase/Security",
-				Message:   "Неверный JWT ключ",
+				Message:   "Ошибка подписания jwt ключа",
 				Exception: e.ErrMessage,
 			}
-		case *core.ErrInvalidJwtPayload:
+		}
+	}
+
+	refresh_token, lookup_id, token_hash, err := CreateRefreshToken()
+	if err != nil {
+		return nil, &core.ZError{
+			Code:      500,
+			Where:     "UseCase/Security",
+			Message:   "Ошибка генерации refresh токена",
+			Exception: err,
+		}
+	}
+
+	_, err = s.repo.RotateRefreshToken(ctx, res, user_agent, ip, lookup_id, token_hash, s.conf(ctx).cfg.RefreshTokenTTL)
+	if err != nil {
+		switch e := err.(type) {
+		case *core.ErrTokenReplaced:
+			return nil, s.refreshReuse(ctx, res)
+		case *core.ErrSaveToken:
 			return nil, &core.ZError{
 				Code:      400,
-				Where:     "UseCase/Security",
-				Message:   "Недействительная полезная нагрузка JWT",
+				Where:     "Repo",
+				Message:   "Не удалось сохранить токен",
+				Exception: e.ErrMessage,
+			}
+		case *core.ErrPGRepo:
+			return nil, &core.ZError{
+				Code:      500,
+				Where:     "Repo",
+				Message:   "Неизвестная ошибка базы данных",
 				Exception: e.ErrMessage,
 			}
 		}
 	}
-	if payload["type"] != "refresh" {
+
+	return &share.ZToken{
+		AccessToken:  token,
+		RefreshToken: refresh_token,
+		TokenType:    "bearer",
+	}, nil
+}
+
+// RequestPasswordReset создает запрос на сброс пароля для аккаунта с указанным email и ставит в очередь письмо с кодом.
+// В ответе код подтверждения возвращается только в окружениях local и test.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - req: структура с email аккаунта
+//
+// Возвращает:
+//   - указатель на структуру ZPasswordReset с идентификатором запроса на сброс
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) RequestPasswordReset(ctx context.Context, req *share.QPasswordReset) (_ *share.ZPasswordReset, zerr *core.ZError) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.RequestPasswordReset")
+	defer func() { endSpan(span, zerr) }()
+
+	var acc *repo.XAccount
+	var details map[string]string
+	defer func() {
+		account_id := ""
+		if acc != nil {
+			account_id = acc.ID
+		}
+		s.audit(ctx, AuditPasswordResetRequest, zerr, account_id, req.Email, details)
+	}()
+
+	if s.conf(ctx).cfg.Captcha.OnReset {
+		if zerr = s.verifyCaptcha(ctx, req.Captcha); zerr != nil {
+			return nil, zerr
+		}
+	}
+	acc, err := s.repo.GetAccountForEmail(ctx, core.TenantFromContext(ctx), email.Canonical(req.Email))
+	if err != nil {
+		switch e := err.(type) {
+		case *core.ErrAccountNotFound:
+			if !s.conf(ctx).cfg.PreciseAuthErrors {
+				// Ответ неотличим от настоящего запроса сброса, письмо не отправляется
+				details = map[string]string{"reason": "account_not_found"}
+				return &share.ZPasswordReset{
+					ID:        CreateDecoyID(),
+					ExpiresAt: time.Now().UTC().Add(s.conf(ctx).cfg.PasswordResetTTL),
+				}, nil
+			}
+			return nil, &core.ZError{
+				Code:      404,
+				Where:     "Repo",
+				Message:   "Аккаунт не найден",
+				Exception: e.ErrMessage,
+			}
+		default:
+			return nil, &core.ZError{
+				Code:      500,
+				Where:     "Repo",
+				Message:   "Неизвестная ошибка базы данных",
+				Exception: err.Error(),
+			}
+		}
+	}
+
+	res, zerr := s.startPasswordReset(ctx, acc.ID, acc.Email, nil)
+	if zerr != nil {
+		return nil, zerr
+	}
+	if !s.conf(ctx).cfg.ReturnCodes() {
+		res.Code = ""
+	}
+	if !s.conf(ctx).cfg.PreciseAuthErrors {
+		res.AccountID = ""
+	}
+	return res, nil
+}
+
+// ConfirmPasswordReset устанавливает новый пароль по коду сброса, отзывает все refresh токены аккаунта
+// и отправляет владельцу уведомление о смене пароля.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - req: структура с идентификатором запроса на сброс, кодом и новым паролем
+//
+// Возвращает:
+//   - указатель на структуру ZOk, если пароль изменен
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) ConfirmPasswordReset(ctx context.Context, req *share.QPasswordResetConfirm) (_ *share.ZOk, zerr *core.ZError) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.ConfirmPasswordReset")
+	defer func() { endSpan(span, zerr) }()
+
+	var acc *repo.XAccount
+	defer func() {
+		account_id := ""
+		if acc != nil {
+			account_id = acc.ID
+		}
+		s.audit(ctx, AuditPasswordResetConfirm, zerr, account_id, "", map[string]string{"reset_id": req.ResetID})
+	}()
+
+	if !equal_passwords(req.Password, req.ConfirmedPwd) {
 		return nil, &core.ZError{
 			Code:      400,
 			Where:     "UseCase",
-			Message:   "Неверный тип JWT ключа",
+			Message:   "Пароли не совпадают",
 			Exception: nil,
 		}
 	}
 
-	acc_id := payload["sub"].(string)
+	tenant_id := core.TenantFromContext(ctx)
 
-	res, err := s.repo.GetRefreshTokenForAccount(ctx, acc_id, req.RefreshToken)
+	reset, err := s.repo.GetPasswordReset(ctx, tenant_id, req.ResetID)
 	if err != nil {
+		return nil, repoError(err)
+	}
+	if !CompareCodes(req.Code, reset.Code) {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Неверный код подтверждения",
+			Exception: nil,
+		}
+	}
+
+	acc, err = s.repo.GetAccount(ctx, tenant_id, reset.AccountID)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	if feedback := s.conf(ctx).policy.Check(req.Password, acc.Email); !feedback.Ok() {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Пароль не соответствует требованиям",
+			Exception: feedback,
+		}
+	}
+
+	passwd_hash, err := CreatePasswordHash(ctx, s.conf(ctx).passwords, req.Password)
+	if err != nil {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Ошибка генерации хеша пароля",
+			Exception: err.Error(),
+		}
+	}
+
+	if _, err := s.repo.CompletePasswordReset(ctx, tenant_id, reset.ID, passwd_hash, ""); err != nil {
+		return nil, repoError(err)
+	}
+
+	if err := s.revokeTokens(ctx, tenant_id, acc.ID, "password_reset"); err != nil {
+		return nil, &core.ZError{
+			Code:      500,
+			Where:     "Repo",
+			Message:   "Неизвестная ошибка базы данных",
+			Exception: err.Error(),
+		}
+	}
+	s.notifySecurity(ctx, acc.Email, mail.NoticePasswordChanged)
+	s.emitWebhook(ctx, WebhookUserPasswordReset, map[string]string{
+		"account_id": acc.ID,
+		"email":      acc.Email,
+	})
+
+	return &share.ZOk{Ok: true}, nil
+}
+
+// confirmSignup создает аккаунт из записи о регистрации и удаляет эту запись.
+// Используется как при подтверждении кодом, так и при ручном подтверждении администратором.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - signup_acc: запись о регистрации
+//
+// Возвращает:
+//   - указатель на структуру ZAccount с данными созданного аккаунта
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) confirmSignup(ctx context.Context, signup_acc *repo.XEmailSignup) (*share.ZAccount, *core.ZError) {
+	del, err := s.repo.DeleteEmailSignup(ctx, signup_acc.TenantID, signup_acc.ID)
+	if !del && err != nil {
 		switch e := err.(type) {
-		case *core.ErrTokenNotFound:
+		case *core.ErrEmailSignupNotFound:
 			return nil, &core.ZError{
 				Code:      404,
 				Where:     "Repo",
-				Message:   "Токен не найден",
+				Message:   "Аккаунт не найден",
 				Exception: e.ErrMessage,
 			}
 		case *core.ErrPGRepo:
@@ -447,67 +841,241 @@ func (s *AuthUseCase) RefreshToken(ctx context.Context, req *share.QRefreshToken
 			}
 		}
 	}
-	if res.IsRevoked {
-		return nil, &core.ZError{
-			Code:      400,
+
+	xres, err := s.repo.CreateAccount(ctx, signup_acc)
+	if err != nil {
+		switch e := err.(type) {
+		case *core.ErrCreateAccount:
+			return nil, &core.ZError{
+				Code:      400,
+				Where:     "Repo",
+				Message:   "Не удалось создать аккаунт",
+				Exception: e.ErrMessage,
+			}
+		case *core.ErrPGRepo:
+			return nil, &core.ZError{
+				Code:      500,
+				Where:     "Repo",
+				Message:   "Неизвестная ошибка базы данных",
+				Exception: e.ErrMessage,
+			}
+		}
+	}
+
+	metrics.Confirmations.Inc()
+	s.emitWebhook(ctx, WebhookUserCreated, map[string]string{
+		"account_id": xres.ID,
+		"email":      xres.Email,
+	})
+
+	return &share.ZAccount{
+		ID:         xres.ID,
+		Email:      xres.Email,
+		PasswdHash: xres.PasswordHash,
+		Salt:       xres.Salt,
+		CreatedAt:  xres.CreatedAt,
+		UpdatedAt:  xres.UpdatedAt,
+	}, nil
+}
+
+// rehashPassword пересчитывает хеш пароля текущим алгоритмом после успешного входа. Вход не должен
+// срываться из-за этого, поэтому ошибка только логируется: хеш будет пересчитан при следующем входе.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - acc: аккаунт с хешем, проверенным при входе
+//   - pwd: пароль, введенный при входе
+func (s *AuthUseCase) rehashPassword(ctx context.Context, acc *repo.XAccount, pwd string) {
+	passwd_hash, err := CreatePasswordHash(ctx, s.conf(ctx).passwords, pwd)
+	if err != nil {
+		log.Printf("rehash password of %s: %s", acc.ID, err)
+		return
+	}
+	if _, err := s.repo.UpdatePasswordHash(ctx, acc.TenantID, acc.ID, acc.PasswordHash, passwd_hash); err != nil {
+		log.Printf("rehash password of %s: %s", acc.ID, err)
+	}
+}
+
+// bindingViolation выполняет действие политики привязки сессии, когда refresh токен предъявлен не тем клиентом,
+// которому он выдан.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - token: предъявленный refresh токен
+//
+// Возвращает:
+//   - указатель на структуру ZError для ответа клиенту
+func (s *AuthUseCase) bindingViolation(ctx context.Context, token *repo.XRefreshToken) *core.ZError {
+	metrics.BindingMismatches.WithLabelValues(s.conf(ctx).binding.Action()).Inc()
+	var err error
+	switch s.conf(ctx).binding.Action() {
+	case session.ActionReject:
+		if err := s.revokeRefreshToken(ctx, token, "session_binding"); err != nil {
+			return repoError(err)
+		}
+		return &core.ZError{
+			Code:      401,
 			Where:     "UseCase",
-			Message:   "Токен был отозван",
+			Message:   "Токен выдан другому устройству",
 			Exception: nil,
 		}
-	} else {
-		if user_agent != res.UserAgent || ip != res.IpAddress {
-			_, err = s.repo.RevokeToken(ctx, acc_id)
-			if err != nil {
-				return nil, &core.ZError{
-					Code:      500,
-					Where:     "Repo",
-					Message:   "Неизвестная ошибка базы данных",
-					Exception: err,
-				}
-			}
-			return nil, &core.ZError{
-				Code:      400,
-				Where:     "UseCase",
-				Message:   "Токен отозван",
-				Exception: nil,
+	case session.ActionRevokeFamily:
+		err = s.revokeTokenFamily(ctx, token.TenantID, token.AccountID, token.FamilyID, "session_binding")
+	default:
+		err = s.revokeTokens(ctx, token.TenantID, token.AccountID, "session_binding")
+		if err == nil {
+			if acc, err := s.repo.GetAccount(ctx, token.TenantID, token.AccountID); err == nil {
+				s.notifySecurity(ctx, acc.Email, mail.NoticeSessionsRevoked)
 			}
 		}
 	}
+	if err != nil {
+		return &core.ZError{
+			Code:      500,
+			Where:     "Repo",
+			Message:   "Неизвестная ошибка базы данных",
+			Exception: err,
+		}
+	}
+	if s.conf(ctx).binding.Action() == session.ActionReauth {
+		return &core.ZError{
+			Code:      401,
+			Where:     "UseCase",
+			Message:   "Требуется повторный вход",
+			Exception: nil,
+		}
+	}
+	return &core.ZError{
+		Code:      401,
+		Where:     "UseCase",
+		Message:   "Токен отозван",
+		Exception: nil,
+	}
+}
 
-	access_payload := map[string]interface{}{
-		"sub":  acc_id,
-		"type": "access",
+// refreshReuse отзывает семейство токенов, когда предъявлен уже замененный refresh токен: его копией
+// пользуется кто-то еще, и неизвестно, какой из клиентов законный.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - token: предъявленный токен
+//
+// Возвращает:
+//   - указатель на структуру ZError для ответа клиенту
+func (s *AuthUseCase) refreshReuse(ctx context.Context, token *repo.XRefreshToken) *core.ZError {
+	if err := s.revokeTokenFamily(ctx, token.TenantID, token.AccountID, token.FamilyID, "refresh_reuse"); err != nil {
+		return repoError(err)
+	}
+	return &core.ZError{
+		Code:      401,
+		Where:     "UseCase",
+		Message:   "Токен уже был использован, сессия отозвана",
+		Exception: nil,
+	}
+}
+
+// legacyRefreshToken проверяет refresh токен в формате JWT, выпущенный до перехода на непрозрачные токены.
+// Такие токены хранятся с хешем от всего JWT в качестве идентификатора поиска и принимаются,
+// пока включена настройка LegacyRefreshJWT.
+//
+// Параметры:
+//   - ctx: контекст запроса с тенантом
+//   - token: refresh токен от клиента
+//
+// Возвращает:
+//   - идентификатор поиска токена в базе
+//   - хеш токена
+//   - ID аккаунта из claim sub
+//   - указатель на структуру ZError, если токен не принят
+func (s *AuthUseCase) legacyRefreshToken(ctx context.Context, token string) (string, string, string, *core.ZError) {
+	if !s.conf(ctx).cfg.LegacyRefreshJWT || strings.Count(token, ".") != 2 {
+		return "", "", "", &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Неверный refresh токен",
+			Exception: nil,
+		}
 	}
 
-	token, err := CreateJWT(access_payload, s.cfg.JWTPrivateKey)
+	payload, err := s.decodeJWT(ctx, token)
 	if err != nil {
 		switch e := err.(type) {
-		case *core.ErrParsePrivateKey:
-			return nil, &core.ZError{
+		case *core.ErrParsePublicKey:
+			return "", "", "", &core.ZError{
 				Code:      400,
 				Where:     "UseCase/Security",
-				Message:   "Ошибка парсинга приватного ключа",
+				Message:   "Ошибка парсинга публичного ключа",
 				Exception: e.ErrMessage,
 			}
-		case *core.ErrSignedJwt:
-			return nil, &core.ZError{
+		case *core.ErrUnExpectedSign:
+			return "", "", "", &core.ZError{
 				Code:      400,
 				Where:     "UseCase/Security",
-				Message:   "Ошибка подписания jwt ключа",
+				Message:   "Ошибка не верный метод подписания JWT ключа",
 				Exception: e.ErrMessage,
 			}
+		case *core.ErrJwtExpired:
+			return "", "", "", &core.ZError{
+				Code:      400,
+				Where:     "UseCase/Security",
+				Message:   "JWT ключ истек",
+				Exception: e.ErrMessage,
+			}
+		case *core.ErrIncorrectJwt:
+			return "", "", "", &core.ZError{
+				Code:      400,
+				Where:     "UseCase/Security",
+				Message:   "Неверный JWT ключ",
+				Exception: e.ErrMessage,
+			}
+		case *core.ErrInvalidJwtPayload:
+			return "", "", "", &core.ZError{
+				Code:      400,
+				Where:     "UseCase/Security",
+				Message:   "Недействительная полезная нагрузка JWT",
+				Exception: e.ErrMessage,
+			}
+		}
+	}
+	if payload["type"] != "refresh" {
+		return "", "", "", &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Неверный тип JWT ключа",
+			Exception: nil,
 		}
 	}
 
-	return &share.ZToken{
-		AccessToken:  token,
-		RefreshToken: req.RefreshToken,
-		TokenType:    "bearer",
-	}, nil
+	if tokenTenant(payload) != core.TenantFromContext(ctx) {
+		return "", "", "", &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Токен выпущен для другого тенанта",
+			Exception: nil,
+		}
+	}
+
+	acc_id, _ := payload["sub"].(string)
+	token_hash := HashRefreshToken(token)
+	return token_hash, token_hash, acc_id, nil
 }
 
 // ----------- Tools -----------
 
+// invalidCredentials возвращает единый ответ на неизвестный email и неверный пароль, чтобы по ответу
+// нельзя было узнать, зарегистрирован ли email. Точная причина пишется в журнал безопасности.
+//
+// Возвращает:
+//   - указатель на структуру ZError
+func invalidCredentials() *core.ZError {
+	return &core.ZError{
+		Code:      400,
+		Where:     "UseCase",
+		Message:   "Неверный email или пароль",
+		Exception: nil,
+	}
+}
+
 // equal_passwords сравнивает пароль и подтверждение пароля на совпадение.
 //
 // Параметры:
@@ -524,19 +1092,181 @@ func equal_passwords(password string, confirm_pwd string) bool {
 // ValidateCredentials проверяет валидность учетных данных пользователя.
 //
 // Параметры:
-//   - email: строка с адресом электронной почты
-//   - password: строка с паролем
+//   - emails: валидатор адресов email
+//   - policy: политика паролей
+//   - raw_email: строка с адресом электронной почты
+//   - pwd: строка с паролем
 //
 // Возвращает:
-//   - true, если учетные данные валидны
-//   - ошибку, если длина пароля меньше 6 символов или email не содержит символ "@"
-func ValidateCredentials(email, password string) (bool, error) {
-	if len(password) < 6 {
-		return false, &core.ErrInvalidLenPassword{ErrMessage: nil}
+//   - разобранный адрес email с канонической формой
+//   - ErrEmailValidate, если адрес не соответствует RFC 5322, ErrEmailDisposable для домена одноразовой почты
+//     или ErrPasswordPolicy со списком всех нарушенных правил политики (password.Feedback)
+func ValidateCredentials(emails *email.Validator, policy *password.Policy, raw_email string, pwd string) (*email.Address, error) {
+	addr, err := emails.Validate(raw_email)
+	if err != nil {
+		if errors.Is(err, email.ErrDisposable) {
+			return nil, &core.ErrEmailDisposable{ErrMessage: err.Error()}
+		}
+		return nil, &core.ErrEmailValidate{ErrMessage: err.Error()}
+	}
+	if feedback := policy.Check(pwd, addr.String()); !feedback.Ok() {
+		return nil, &core.ErrPasswordPolicy{ErrMessage: feedback}
+	}
+	return addr, nil
+}
+
+// startPasswordReset генерирует код подтверждения и создает запрос на сброс пароля.
+// Если указан mail_to, письмо с кодом ставится в очередь в той же транзакции.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - account_id: идентификатор аккаунта
+//   - mail_to: адрес для письма с кодом; пустая строка - письмо не отправляется
+//   - initiated_by: идентификатор администратора, запустившего сброс, или nil
+//
+// Возвращает:
+//   - указатель на структуру ZPasswordReset с кодом подтверждения
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) startPasswordReset(ctx context.Context, account_id string, mail_to string, initiated_by *string) (*share.ZPasswordReset, *core.ZError) {
+	code, err := CreateConfirmCode()
+	if err != nil {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Ошибка генерации кода подтверждения",
+			Exception: nil,
+		}
+	}
+
+	var enqueue []jobs.NewJob
+	if mail_to != "" {
+		mail_job, zerr := s.mailJob(ctx, mail_to, mail.TemplatePasswordReset, mail.ResetData{
+			Email: mail_to,
+			Code:  code,
+			// Запрос создается с тем же сроком в одной транзакции с письмом, расхождение - доли секунды
+			ExpiresAt: time.Now().Add(s.conf(ctx).cfg.PasswordResetTTL),
+		})
+		if zerr != nil {
+			return nil, zerr
+		}
+		enqueue = append(enqueue, mail_job)
+	}
+
+	xres, err := s.repo.CreatePasswordReset(ctx, core.TenantFromContext(ctx), account_id, code, initiated_by, s.conf(ctx).cfg.PasswordResetTTL, enqueue...)
+	if err != nil {
+		return nil, repoError(err)
 	}
-	if !strings.Contains(email, "@") {
-		return false, &core.ErrEmailValidate{ErrMessage: nil}
+	if mail_to != "" {
+		s.mailSent(ctx, mail_to)
 	}
-	return true, nil
 
+	return &share.ZPasswordReset{
+		ID:        xres.ID,
+		AccountID: xres.AccountID,
+		Code:      xres.Code,
+		ExpiresAt: xres.ExpiresAt,
+	}, nil
+}
+
+// repoError переводит типовую ошибку репозитория в ZError.
+//
+// Параметры:
+//   - err: ошибка репозитория
+//
+// Возвращает:
+//   - указатель на структуру ZError с кодом 404 для ненайденных сущностей и 500 для остальных ошибок
+func repoError(err error) *core.ZError {
+	switch e := err.(type) {
+	case *core.ErrRoleNotFound:
+		return &core.ZError{
+			Code:      404,
+			Where:     "Repo",
+			Message:   "Роль или аккаунт не найдены",
+			Exception: e.ErrMessage,
+		}
+	case *core.ErrPermissionNotFound:
+		return &core.ZError{
+			Code:      404,
+			Where:     "Repo",
+			Message:   "Разрешение не найдено",
+			Exception: e.ErrMessage,
+		}
+	case *core.ErrPasswordResetNotFound:
+		return &core.ZError{
+			Code:      404,
+			Where:     "Repo",
+			Message:   "Запрос на сброс пароля не найден или истек",
+			Exception: e.ErrMessage,
+		}
+	case *core.ErrAccountNotFound:
+		return &core.ZError{
+			Code:      404,
+			Where:     "Repo",
+			Message:   "Аккаунт не найден",
+			Exception: e.ErrMessage,
+		}
+	case *core.ErrTenantNotFound:
+		return &core.ZError{
+			Code:      404,
+			Where:     "Repo",
+			Message:   "Тенант не найден",
+			Exception: e.ErrMessage,
+		}
+	case *core.ErrCreateTenant:
+		return &core.ZError{
+			Code:      409,
+			Where:     "Repo",
+			Message:   "Тенант с таким slug или хостом уже существует",
+			Exception: e.ErrMessage,
+		}
+	case *core.ErrOrganizationNotFound:
+		return &core.ZError{
+			Code:      404,
+			Where:     "Repo",
+			Message:   "Организация не найдена",
+			Exception: e.ErrMessage,
+		}
+	case *core.ErrMemberNotFound:
+		return &core.ZError{
+			Code:      404,
+			Where:     "Repo",
+			Message:   "Участник организации не найден",
+			Exception: e.ErrMessage,
+		}
+	case *core.ErrInviteNotFound:
+		return &core.ZError{
+			Code:      404,
+			Where:     "Repo",
+			Message:   "Приглашение не найдено или истекло",
+			Exception: e.ErrMessage,
+		}
+	case *core.ErrImpersonationNotFound:
+		return &core.ZError{
+			Code:      404,
+			Where:     "Repo",
+			Message:   "Сессия входа под пользователем не найдена или уже завершена",
+			Exception: e.ErrMessage,
+		}
+	case *core.ErrWebhookNotFound:
+		return &core.ZError{
+			Code:      404,
+			Where:     "Repo",
+			Message:   "Подписка на события не найдена",
+			Exception: e.ErrMessage,
+		}
+	case *core.ErrJobNotFound:
+		return &core.ZError{
+			Code:      404,
+			Where:     "Repo",
+			Message:   "Задача не найдена или не в статусе dead",
+			Exception: e.ErrMessage,
+		}
+	default:
+		return &core.ZError{
+			Code:      500,
+			Where:     "Repo",
+			Message:   "Неизвестная ошибка базы данных",
+			Exception: err.Error(),
+		}
+	}
 }
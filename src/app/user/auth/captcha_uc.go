@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MedodsTechTask/app/captcha"
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/email"
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+// Captcha возвращает параметры CAPTCHA для клиента: провайдера и ключ виджета или новую задачу proof-of-work.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//
+// Возвращает:
+//   - указатель на структуру ZCaptcha; провайдер off, если проверка выключена
+//   - указатель на структуру ZError, если не удалось создать задачу
+func (s *AuthUseCase) Captcha(ctx context.Context) (*share.ZCaptcha, *core.ZError) {
+	if s.conf(ctx).captcha == nil {
+		return &share.ZCaptcha{Provider: captcha.ProviderOff}, nil
+	}
+	challenge, err := s.conf(ctx).captcha.Challenge()
+	if err != nil {
+		return nil, &core.ZError{
+			Code:      500,
+			Where:     "UseCase",
+			Message:   "Ошибка создания задачи CAPTCHA",
+			Exception: err.Error(),
+		}
+	}
+	return &share.ZCaptcha{
+		Provider:           challenge.Provider,
+		SiteKey:            challenge.SiteKey,
+		Challenge:          challenge.Challenge,
+		Difficulty:         challenge.Difficulty,
+		ExpiresAt:          challenge.ExpiresAt,
+		OnSignup:           s.conf(ctx).cfg.Captcha.OnSignup,
+		OnReset:            s.conf(ctx).cfg.Captcha.OnReset,
+		LoginAfterFailures: s.conf(ctx).cfg.Captcha.LoginAfterFailures,
+	}, nil
+}
+
+// verifyCaptcha проверяет ответ CAPTCHA клиента. Без настроенного провайдера проверка пропускается.
+//
+// Параметры:
+//   - ctx: контекст запроса, из него берется IP клиента
+//   - token: ответ CAPTCHA из запроса
+//
+// Возвращает:
+//   - указатель на структуру ZError, если ответа нет, он неверен или провайдер недоступен
+func (s *AuthUseCase) verifyCaptcha(ctx context.Context, token string) *core.ZError {
+	if s.conf(ctx).captcha == nil {
+		return nil
+	}
+	err := s.conf(ctx).captcha.Verify(ctx, token, core.ClientFromContext(ctx).IP)
+	if err != nil {
+		switch e := err.(type) {
+		case *core.ErrCaptchaRequired:
+			return &core.ZError{
+				Code:      400,
+				Where:     "UseCase",
+				Message:   "Требуется проверка CAPTCHA",
+				Exception: "captcha_required",
+			}
+		case *core.ErrCaptchaInvalid:
+			return &core.ZError{
+				Code:      400,
+				Where:     "UseCase",
+				Message:   "Проверка CAPTCHA не пройдена",
+				Exception: e.ErrMessage,
+			}
+		case *core.ErrCaptchaUnavailable:
+			return &core.ZError{
+				Code:      502,
+				Where:     "UseCase",
+				Message:   "Сервис CAPTCHA недоступен",
+				Exception: e.ErrMessage,
+			}
+		default:
+			return &core.ZError{
+				Code:      500,
+				Where:     "UseCase",
+				Message:   "Ошибка проверки CAPTCHA",
+				Exception: err.Error(),
+			}
+		}
+	}
+	return nil
+}
+
+// verifyLoginCaptcha требует CAPTCHA на входе, если с email или IP клиента за окно набралось
+// LoginAfterFailures неудачных входов. Если неудачные входы не удалось подсчитать, CAPTCHA требуется,
+// пока не включена настройка LoginFailOpen: сбой базы не должен отключать защиту от перебора.
+//
+// Параметры:
+//   - ctx: контекст запроса, из него берутся тенант и IP
+//   - raw_email: email из запроса
+//   - token: ответ CAPTCHA из запроса
+//
+// Возвращает:
+//   - указатель на структуру ZError, если CAPTCHA нужна и не пройдена
+func (s *AuthUseCase) verifyLoginCaptcha(ctx context.Context, raw_email string, token string) *core.ZError {
+	after := s.conf(ctx).cfg.Captcha.LoginAfterFailures
+	if s.conf(ctx).captcha == nil || after <= 0 {
+		return nil
+	}
+	tenant_id := core.TenantFromContext(ctx)
+	ip := core.ClientFromContext(ctx).IP
+	failures, err := s.repo.CountLoginFailures(ctx, tenant_id, email.Canonical(raw_email), ip, time.Now().Add(-s.conf(ctx).cfg.Captcha.FailureWindow))
+	if err != nil {
+		log.Printf("login failures in tenant %s from %s: %s", tenant_id, ip, err)
+		if s.conf(ctx).cfg.Captcha.LoginFailOpen {
+			return nil
+		}
+		return s.verifyCaptcha(ctx, token)
+	}
+	if failures < after {
+		return nil
+	}
+	return s.verifyCaptcha(ctx, token)
+}
@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Версия конфигурации
+// @Description Номер загрузки, SHA-256 и время загрузки действующей конфигурации, а также измененные разделы, которые применятся только после перезапуска. Конфигурация перезагружается по SIGHUP и при изменении ее файлов. Требует разрешение config:read
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} share.ZConfigVersion
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Router /admin/config/version [get]
+func (h *API) getConfigVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, h.uc.ConfigVersion())
+}
@@ -0,0 +1,375 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/MedodsTechTask/app/captcha"
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/egress"
+	"github.com/MedodsTechTask/app/email"
+	"github.com/MedodsTechTask/app/mail"
+	"github.com/MedodsTechTask/app/password"
+	"github.com/MedodsTechTask/app/ratelimit"
+	"github.com/MedodsTechTask/app/risk"
+	"github.com/MedodsTechTask/app/session"
+	"github.com/MedodsTechTask/app/user/auth/configs"
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+// settings - конфигурация и построенные по ней компоненты. При перезагрузке набор заменяется целиком,
+// запрос до конца работает с тем набором, который был текущим на его начало (см. API.ConfigSnapshot)
+type settings struct {
+	cfg *configs.Config
+	// passwords хеширует новые пароли и проверяет хеши всех поддерживаемых форматов, включая устаревший SHA-256
+	passwords *password.Manager
+	// policy проверяет новые пароли при регистрации и сбросе
+	policy *password.Policy
+	// emails разбирает адреса при регистрации и проверяет их по списку одноразовых доменов
+	emails *email.Validator
+	// binding проверяет, что refresh токен предъявлен клиентом, которому он выдан
+	binding *session.Binding
+	// risk оценивает риск входа и рефреша по журналу входов и GeoIP
+	risk *risk.Engine
+	// captcha проверяет ответ CAPTCHA на регистрации, сбросе пароля и входе; nil - проверка выключена
+	captcha captcha.Verifier
+	// limiter ограничивает частоту запросов; хранилище счетчиков общее для всех версий настроек
+	limiter *ratelimit.Limiter
+	// throttle ограничивает частоту писем одному получателю, счетчики ведутся в хранилище limiter
+	throttle *mail.Throttler
+	// egress запрещает адреса подписок во внутренних сетях, webhooks - клиент доставки, подключающийся только
+	// к разрешенным им адресам
+	egress   *egress.Guard
+	webhooks *http.Client
+	// retiredKeys - публичные ключи до ротации, ими проверяются уже выданные токены, пока те не истекут
+	retiredKeys []retiredKey
+	// keys - результат проверки ключей JWT пробным токеном, nil - ключи подписывают и проверяют токены
+	keys error
+	// fingerprint - SHA-256 всей конфигурации вместе с секретами, по нему определяется, что конфигурация изменилась;
+	// наружу не отдается
+	fingerprint string
+	version     share.ZConfigVersion
+}
+
+// retiredKey - публичный ключ JWT, замененный при перезагрузке, с алгоритмом, к которому он был привязан,
+// и iss, с которым им подписывались токены
+type retiredKey struct {
+	publicKey string
+	algorithm string
+	issuer    string
+	until     time.Time
+}
+
+// settingsKey - ключ набора настроек запроса в контексте
+type settingsKey struct{}
+
+// conf возвращает настройки, закрепленные за запросом, или текущие, если запрос их не закреплял (фоновые задачи).
+//
+// Параметры:
+//   - ctx: контекст запроса
+//
+// Возвращает:
+//   - указатель на набор настроек
+func (s *AuthUseCase) conf(ctx context.Context) *settings {
+	if st, ok := ctx.Value(settingsKey{}).(*settings); ok {
+		return st
+	}
+	return s.live.Load()
+}
+
+// WithSettings закрепляет за контекстом текущие настройки; перезагрузка не меняет их до конца запроса.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//
+// Возвращает:
+//   - контекст с закрепленными настройками
+func (s *AuthUseCase) WithSettings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, settingsKey{}, s.live.Load())
+}
+
+// ConfigVersion возвращает версию загруженной конфигурации.
+//
+// Возвращает:
+//   - указатель на структуру ZConfigVersion
+func (s *AuthUseCase) ConfigVersion() *share.ZConfigVersion {
+	version := s.live.Load().version
+	return &version
+}
+
+// Reload применяет новую конфигурацию без перезапуска. Компоненты, настройки которых не изменились, переиспользуются,
+// новые ключи JWT проверяются подписью пробного токена. Если что-то не собирается, прежняя конфигурация остается в силе.
+// Разделы, которые применяются только при запуске (адрес и таймауты сервера, база данных, прокси, хранилище счетчиков
+// ограничения частоты, почта, очередь, события, трассировка, GeoIP), сохраняют текущие значения и перечисляются
+// в pending_restart. Сами ограничения частоты применяются сразу.
+//
+// Параметры:
+//   - cfg: новая конфигурация, уже прошедшая configs.Load
+//
+// Возвращает:
+//   - указатель на структуру ZConfigVersion с версией, которая действует после вызова
+//   - ошибку, если конфигурация отклонена
+func (s *AuthUseCase) Reload(cfg *configs.Config) (*share.ZConfigVersion, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	current := s.live.Load()
+	pending := keepRestartSettings(current.cfg, cfg)
+	fingerprint, err := configHash(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if fingerprint == current.fingerprint && slices.Equal(pending, current.version.PendingRestart) {
+		return &current.version, nil
+	}
+	hash, err := configHash(redactSecrets(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := buildSettings(current, cfg)
+	if err != nil {
+		return nil, err
+	}
+	next.fingerprint = fingerprint
+	next.version = share.ZConfigVersion{
+		Version:        current.version.Version + 1,
+		Hash:           hash,
+		Env:            cfg.AppEnv,
+		LoadedAt:       time.Now().UTC(),
+		PendingRestart: pending,
+	}
+	s.live.Store(next)
+
+	return &next.version, nil
+}
+
+// newSettings собирает первый набор настроек из компонентов, созданных при запуске.
+func newSettings(cfg *configs.Config, passwords *password.Manager, policy *password.Policy, emails *email.Validator, binding *session.Binding, risk *risk.Engine, captcha captcha.Verifier, limiter *ratelimit.Limiter, guard *egress.Guard) *settings {
+	// Хеши нужны только для сравнения версий, поэтому ошибка сериализации дает пустой хеш
+	fingerprint, _ := configHash(cfg)
+	hash, _ := configHash(redactSecrets(cfg))
+	return &settings{
+		cfg:         cfg,
+		passwords:   passwords,
+		policy:      policy,
+		emails:      emails,
+		binding:     binding,
+		risk:        risk,
+		captcha:     captcha,
+		limiter:     limiter,
+		throttle:    mail.NewThrottler(limiter, cfg.Mail.ThrottleLimit, cfg.Mail.ThrottleWindow),
+		egress:      guard,
+		webhooks:    guard.Client(),
+		keys:        checkKeyPair(cfg.JWTAlgorithm, cfg.JWTIssuer, cfg.JWTPrivateKey, cfg.JWTPublicKey),
+		fingerprint: fingerprint,
+		version: share.ZConfigVersion{
+			Version:  1,
+			Hash:     hash,
+			Env:      cfg.AppEnv,
+			LoadedAt: time.Now().UTC(),
+		},
+	}
+}
+
+// buildSettings собирает набор настроек для новой конфигурации; компоненты пересоздаются, только если изменились
+// их настройки, иначе берутся из current.
+func buildSettings(current *settings, cfg *configs.Config) (*settings, error) {
+	next := *current
+	next.cfg = cfg
+	var err error
+
+	if !reflect.DeepEqual(current.cfg.Password, cfg.Password) {
+		if next.passwords, err = password.NewManager(cfg.Password); err != nil {
+			return nil, fmt.Errorf("password: %w", err)
+		}
+	}
+	if !reflect.DeepEqual(current.cfg.PasswordPolicy, cfg.PasswordPolicy) {
+		if next.policy, err = password.NewPolicy(cfg.PasswordPolicy); err != nil {
+			return nil, fmt.Errorf("password_policy: %w", err)
+		}
+	}
+	if !reflect.DeepEqual(current.cfg.Email, cfg.Email) {
+		if next.emails, err = email.NewValidator(cfg.Email); err != nil {
+			return nil, fmt.Errorf("email: %w", err)
+		}
+	}
+	if current.cfg.SessionBinding != cfg.SessionBinding {
+		if next.binding, err = session.NewBinding(cfg.SessionBinding); err != nil {
+			return nil, fmt.Errorf("session_binding: %w", err)
+		}
+	}
+	if current.cfg.Risk != cfg.Risk && current.risk != nil {
+		next.risk = current.risk.WithConfig(cfg.Risk)
+	}
+	if current.cfg.Captcha != cfg.Captcha {
+		if next.captcha, err = captcha.NewVerifier(cfg.Captcha); err != nil {
+			return nil, fmt.Errorf("captcha: %w", err)
+		}
+	}
+	if !slices.Equal(current.cfg.WebhookAllowedNetworks, cfg.WebhookAllowedNetworks) {
+		if next.egress, err = egress.NewGuard(cfg.WebhookAllowedNetworks); err != nil {
+			return nil, fmt.Errorf("webhook_allowed_networks: %w", err)
+		}
+		next.webhooks = next.egress.Client()
+	}
+	if !reflect.DeepEqual(current.cfg.RateLimit, cfg.RateLimit) && current.limiter != nil {
+		if next.limiter, err = current.limiter.WithConfig(cfg.RateLimit); err != nil {
+			return nil, fmt.Errorf("rate_limit: %w", err)
+		}
+	}
+	throttled := current.cfg.Mail.ThrottleLimit != cfg.Mail.ThrottleLimit || current.cfg.Mail.ThrottleWindow != cfg.Mail.ThrottleWindow
+	if throttled || next.limiter != current.limiter {
+		next.throttle = mail.NewThrottler(next.limiter, cfg.Mail.ThrottleLimit, cfg.Mail.ThrottleWindow)
+	}
+
+	rotated := current.cfg.JWTPublicKey != cfg.JWTPublicKey || current.cfg.JWTAlgorithm != cfg.JWTAlgorithm || current.cfg.JWTIssuer != cfg.JWTIssuer
+	if rotated || current.cfg.JWTPrivateKey != cfg.JWTPrivateKey {
+		if err := checkKeyPair(cfg.JWTAlgorithm, cfg.JWTIssuer, cfg.JWTPrivateKey, cfg.JWTPublicKey); err != nil {
+			return nil, fmt.Errorf("jwt keys: %w", err)
+		}
+		next.keys = nil
+	}
+	now := time.Now()
+	next.retiredKeys = nil
+	for _, key := range current.retiredKeys {
+		if key.until.After(now) && (key.publicKey != cfg.JWTPublicKey || key.algorithm != cfg.JWTAlgorithm || key.issuer != cfg.JWTIssuer) {
+			next.retiredKeys = append(next.retiredKeys, key)
+		}
+	}
+	if rotated {
+		// Токены, подписанные прежним ключом, живут не дольше прежнего срока access токена
+		ttl := max(current.cfg.AccessTokenTTL, current.cfg.ImpersonationTTL)
+		next.retiredKeys = append(next.retiredKeys, retiredKey{
+			publicKey: current.cfg.JWTPublicKey,
+			algorithm: current.cfg.JWTAlgorithm,
+			issuer:    current.cfg.JWTIssuer,
+			until:     now.Add(ttl),
+		})
+	}
+
+	return &next, nil
+}
+
+// keepRestartSettings переносит в новую конфигурацию текущие значения разделов, которые применяются только
+// при запуске, и возвращает ключи тех из них, что изменились.
+func keepRestartSettings(current *configs.Config, cfg *configs.Config) []string {
+	var pending []string
+	keep(&pending, "listen", current.Listen, &cfg.Listen)
+	keep(&pending, "cors_origins", current.CORSOrigins, &cfg.CORSOrigins)
+	keep(&pending, "read_header_timeout", current.ReadHeaderTimeout, &cfg.ReadHeaderTimeout)
+	keep(&pending, "read_timeout", current.ReadTimeout, &cfg.ReadTimeout)
+	keep(&pending, "write_timeout", current.WriteTimeout, &cfg.WriteTimeout)
+	keep(&pending, "idle_timeout", current.IdleTimeout, &cfg.IdleTimeout)
+	keep(&pending, "shutdown_delay", current.ShutdownDelay, &cfg.ShutdownDelay)
+	keep(&pending, "shutdown_timeout", current.ShutdownTimeout, &cfg.ShutdownTimeout)
+	keep(&pending, "reload_interval", current.ReloadInterval, &cfg.ReloadInterval)
+	keep(&pending, "db", current.DB, &cfg.DB)
+	keep(&pending, "geoip", current.GeoIP, &cfg.GeoIP)
+	keep(&pending, "proxy", current.Proxy, &cfg.Proxy)
+	keep(&pending, "rate_limit.store", current.RateLimit.Store, &cfg.RateLimit.Store)
+	keep(&pending, "rate_limit.redis_addr", current.RateLimit.RedisAddr, &cfg.RateLimit.RedisAddr)
+	keep(&pending, "rate_limit.redis_password", current.RateLimit.RedisPassword, &cfg.RateLimit.RedisPassword)
+	keep(&pending, "rate_limit.redis_db", current.RateLimit.RedisDB, &cfg.RateLimit.RedisDB)
+	keep(&pending, "rate_limit.key_prefix", current.RateLimit.KeyPrefix, &cfg.RateLimit.KeyPrefix)
+	// Ограничение частоты писем применяется сразу, остальные настройки почты - при запуске
+	running_mail := current.Mail
+	running_mail.ThrottleLimit, running_mail.ThrottleWindow = cfg.Mail.ThrottleLimit, cfg.Mail.ThrottleWindow
+	keep(&pending, "mail", running_mail, &cfg.Mail)
+	keep(&pending, "jobs", current.Jobs, &cfg.Jobs)
+	keep(&pending, "jobs_shutdown_timeout", current.JobsShutdownTimeout, &cfg.JobsShutdownTimeout)
+	keep(&pending, "events", current.Events, &cfg.Events)
+	keep(&pending, "tracing", current.Tracing, &cfg.Tracing)
+	return pending
+}
+
+// keep возвращает в loaded значение running и записывает ключ в pending, если значения различаются.
+func keep[T any](pending *[]string, key string, running T, loaded *T) {
+	if !reflect.DeepEqual(running, *loaded) {
+		*pending = append(*pending, key)
+		*loaded = running
+	}
+}
+
+// configHash возвращает SHA-256 конфигурации в YAML.
+func configHash(cfg *configs.Config) (string, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("config hash: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// redactSecrets возвращает копию конфигурации, в которой секреты заменены меткой. По ее хешу, который отдается
+// в версии конфигурации, видно, одинаковая ли конфигурация загружена на экземплярах, но нельзя подобрать секреты.
+func redactSecrets(cfg *configs.Config) *configs.Config {
+	redact := func(v *string) {
+		if *v != "" {
+			*v = "redacted"
+		}
+	}
+	res := *cfg
+	redact(&res.JWTPrivateKey)
+	redact(&res.DB.Password)
+	redact(&res.Mail.SMTPPwd)
+	redact(&res.Captcha.SecretKey)
+	redact(&res.Captcha.PoWSecret)
+	redact(&res.RateLimit.RedisPassword)
+	if u, err := url.Parse(res.Events.NATSURL); err == nil && u.User != nil {
+		res.Events.NATSURL = u.Redacted()
+	}
+	return &res
+}
+
+// checkKeyPair проверяет, что ключи соответствуют алгоритму и подписанный приватным ключом токен проверяется публичным.
+func checkKeyPair(algorithm string, issuer string, private_key string, public_key string) error {
+	token, err := CreateJWT(context.Background(), map[string]interface{}{"type": "probe"}, private_key, algorithm, issuer, time.Minute)
+	if err != nil {
+		return err
+	}
+	if _, err := DecodeJWT(context.Background(), token, public_key, algorithm, issuer); err != nil {
+		return errors.Join(errors.New("public key does not match private key"), err)
+	}
+	return nil
+}
+
+// decodeJWT проверяет токен текущим публичным ключом, а подписанный до ротации - ключом, который тогда действовал.
+// Каждый ключ принимает только свой алгоритм и свой iss.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - token: JWT токен
+//
+// Возвращает:
+//   - карту с полезной нагрузкой токена
+//   - ошибку проверки текущим ключом, если токен не принял ни один ключ
+func (s *AuthUseCase) decodeJWT(ctx context.Context, token string) (map[string]interface{}, error) {
+	st := s.conf(ctx)
+	payload, err := DecodeJWT(ctx, token, st.cfg.JWTPublicKey, st.cfg.JWTAlgorithm, st.cfg.JWTIssuer)
+	var incorrect *core.ErrIncorrectJwt
+	if err == nil || !errors.As(err, &incorrect) {
+		return payload, err
+	}
+
+	now := time.Now()
+	for _, key := range st.retiredKeys {
+		if key.until.Before(now) {
+			continue
+		}
+		if retired, retired_err := DecodeJWT(ctx, token, key.publicKey, key.algorithm, key.issuer); retired_err == nil {
+			return retired, nil
+		}
+	}
+	return nil, err
+}
@@ -1,84 +1,402 @@
 package configs
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/MedodsTechTask/app/captcha"
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/email"
+	"github.com/MedodsTechTask/app/events"
+	"github.com/MedodsTechTask/app/geoip"
+	"github.com/MedodsTechTask/app/jobs"
+	"github.com/MedodsTechTask/app/mail"
+	"github.com/MedodsTechTask/app/password"
+	"github.com/MedodsTechTask/app/proxy"
+	"github.com/MedodsTechTask/app/ratelimit"
+	"github.com/MedodsTechTask/app/risk"
+	"github.com/MedodsTechTask/app/session"
+	"github.com/MedodsTechTask/app/tracing"
+)
+
+const (
+	EnvLocal      = "local"
+	EnvTest       = "test"
+	EnvProduction = "production"
+)
+
+// Алгоритмы подписи JWT
+const (
+	JWTRS512 = "RS512" // RSA PKCS#1 v1.5 с SHA-512
+	JWTES256 = "ES256" // ECDSA на кривой P-256 с SHA-256
+	JWTEdDSA = "EdDSA" // Ed25519
+)
+
+// Адреса сервисов из docker-compose.yml, значения по умолчанию для окружений local и test.
+// Переопределяются переменными DB_HOST и EVENTS_NATS_URL, YAML файлом или флагами
+const (
+	composeDBHost  = "175.243.0.50"
+	composeNATSURL = "nats://175.243.0.60:4222"
 )
 
 type Config struct {
-	AppEnv string
+	AppEnv string   `yaml:"-"` // профиль значений по умолчанию, задается только APP_ENV или флагом -app_env
+	Files  []string `yaml:"-"` // файлы, из которых собрана конфигурация: YAML и секреты <ПЕРЕМЕННАЯ>_FILE
+	// ReloadInterval - как часто Watcher проверяет изменение Files; 0 - перезагрузка только по SIGHUP
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+	// HTTP
+	Listen            string        `yaml:"listen"`              // адрес HTTP сервера
+	CORSOrigins       []string      `yaml:"cors_origins"`        // источники, которым разрешены запросы из браузера
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"` // сколько ждать заголовки запроса
+	ReadTimeout       time.Duration `yaml:"read_timeout"`        // сколько ждать запрос целиком, вместе с телом
+	WriteTimeout      time.Duration `yaml:"write_timeout"`       // сколько может выполняться запрос от конца заголовков до конца ответа
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`        // сколько держать keep-alive соединение между запросами
+	MaxBodyBytes      int64         `yaml:"max_body_bytes"`      // максимальный размер тела запроса, больше - 413
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay"`      // сколько после SIGTERM отвечать 503 на /readyz до закрытия листенера, чтобы балансировщик успел снять трафик
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`    // сколько ждать завершения запросов при остановке
 	// DB
-	AuthDBDBN    string
-	AuthDBUSR    string
-	AuthDBPWD    string
-	DBMasterHost string
-	DBMasterPort string
+	DB core.PgConfig `yaml:"db"`
 	// JWT
-	JWTPublicKey          string
-	JWTPrivateKey         string
-	AuthJWTTokenExpireMin int
+	JWTAlgorithm      string        `yaml:"jwt_algorithm"` // алгоритм подписи: RS512, ES256 или EdDSA; тип ключей должен ему соответствовать
+	JWTIssuer         string        `yaml:"jwt_issuer"`    // claim iss выпускаемых токенов; токены с другим iss не принимаются
+	JWTPublicKey      string        `yaml:"jwt_public_key"`
+	JWTPrivateKey     string        `yaml:"jwt_private_key"`
+	AccessTokenTTL    time.Duration `yaml:"access_token_ttl"`    // время жизни access токена
+	RefreshTokenTTL   time.Duration `yaml:"refresh_token_ttl"`   // время жизни refresh токена
+	ImpersonationTTL  time.Duration `yaml:"impersonation_ttl"`   // время жизни access токена входа под пользователем
+	PasswordResetTTL  time.Duration `yaml:"password_reset_ttl"`  // срок действия кода сброса пароля
+	InviteTTL         time.Duration `yaml:"invite_ttl"`          // срок действия приглашения в организацию
+	PreciseAuthErrors bool          `yaml:"precise_auth_errors"` // Точные ошибки входа, регистрации и сброса пароля (аккаунт не найден, email занят) вместо единых ответов; только для внутренних инструментов
+	LegacyRefreshJWT  bool          `yaml:"legacy_refresh_jwt"`  // Принимать refresh токены в формате JWT, выпущенные до перехода на непрозрачные; включено по умолчанию, выключается после окна миграции
+	// SessionBinding - проверка клиента при refresh и действие при несовпадении
+	SessionBinding session.Config `yaml:"session_binding"`
+	// Risk - оценка риска входа; без баз GeoIP работают только сигналы нового устройства и перебора паролей
+	GeoIP geoip.Config `yaml:"geoip"`
+	Risk  risk.Config  `yaml:"risk"`
+	// Password
+	Password       password.Config       `yaml:"password"`
+	PasswordPolicy password.PolicyConfig `yaml:"password_policy"`
+	// Email
+	Email email.Config `yaml:"email"`
+	// Proxy
+	Proxy proxy.Config `yaml:"proxy"`
+	// Captcha
+	Captcha captcha.Config `yaml:"captcha"`
+	// RateLimit
+	RateLimit ratelimit.Config `yaml:"rate_limit"`
+	// Tenant
+	DefaultTenant string `yaml:"default_tenant"` // slug или ID тенанта для запросов без X-Tenant-ID и известного хоста
+	// Mail
+	Mail mail.Config `yaml:"mail"`
+	// Jobs
+	Jobs                jobs.Config   `yaml:"jobs"`
+	JobsShutdownTimeout time.Duration `yaml:"jobs_shutdown_timeout"` // сколько ждать завершения выполняемых задач при остановке
+	// Events
+	Events events.Config `yaml:"events"`
+	// Webhooks
+	WebhookTimeout      time.Duration `yaml:"webhook_timeout"`       // ограничение времени одного запроса к получателю
+	WebhookMaxAttempts  int           `yaml:"webhook_max_attempts"`  // попыток доставки одного события, задержку между ними задает Jobs
+	WebhookDisableAfter int           `yaml:"webhook_disable_after"` // неудачных доставок подряд, после которых подписка отключается
+	// WebhookAllowedNetworks - адреса и подсети (CIDR) внутренних сетей, в которые разрешена доставка, например 127.0.0.1
+	// для локального получателя. Пусто - доставка только на публичные адреса
+	WebhookAllowedNetworks []string `yaml:"webhook_allowed_networks"`
+	// Tracing - экспорт трасс OpenTelemetry; по умолчанию выключен
+	Tracing tracing.Config `yaml:"tracing"`
 }
 
 var (
-	cfg *Config
+	// disposableDomains - самые распространенные домены одноразовой почты, дополняются файлом EMAIL_DISPOSABLE_FILE
+	disposableDomains = []string{
+		"mailinator.com", "guerrillamail.com", "guerrillamail.net", "sharklasers.com", "10minutemail.com",
+		"temp-mail.org", "tempmail.com", "yopmail.com", "trashmail.com", "getnada.com", "dispostable.com",
+		"maildrop.cc", "throwawaymail.com", "fakeinbox.com", "mohmal.com", "emailondeck.com",
+	}
+	// rateLimitRoutes - ограничения маршрутов, на которые чаще всего идет перебор и массовая регистрация
+	rateLimitRoutes = map[string][]ratelimit.Rule{
+		"POST /user/auth/signup/email": {
+			{Name: "ip", By: ratelimit.ByIP, Algorithm: ratelimit.AlgorithmSlidingWindow, Limit: 10, Window: time.Hour},
+			{Name: "account", By: ratelimit.ByAccount, Algorithm: ratelimit.AlgorithmSlidingWindow, Limit: 3, Window: time.Hour},
+		},
+		"POST /user/auth/confirm/email": {
+			{Name: "ip", By: ratelimit.ByIP, Algorithm: ratelimit.AlgorithmTokenBucket, Limit: 30, Window: 10 * time.Minute, Burst: 10},
+			{Name: "account", By: ratelimit.ByAccount, Algorithm: ratelimit.AlgorithmSlidingWindow, Limit: 5, Window: 15 * time.Minute},
+		},
+		"POST /user/auth/login/email": {
+			{Name: "ip", By: ratelimit.ByIP, Algorithm: ratelimit.AlgorithmSlidingWindow, Limit: 30, Window: time.Minute},
+			{Name: "account", By: ratelimit.ByAccount, Algorithm: ratelimit.AlgorithmSlidingWindow, Limit: 10, Window: 15 * time.Minute},
+		},
+		"POST /user/auth/refresh/token": {
+			{Name: "ip", By: ratelimit.ByIP, Algorithm: ratelimit.AlgorithmTokenBucket, Limit: 60, Window: time.Minute, Burst: 20},
+			{Name: "account", By: ratelimit.ByAccount, Algorithm: ratelimit.AlgorithmTokenBucket, Limit: 10, Window: time.Minute, Burst: 5},
+		},
+		"POST /user/auth/reset/password": {
+			{Name: "ip", By: ratelimit.ByIP, Algorithm: ratelimit.AlgorithmSlidingWindow, Limit: 10, Window: time.Hour},
+			{Name: "account", By: ratelimit.ByAccount, Algorithm: ratelimit.AlgorithmSlidingWindow, Limit: 3, Window: time.Hour},
+		},
+	}
 )
 
-// GetConfig возвращает конфигурацию приложения в зависимости от значения переменной окружения APP_ENV.
+// Defaults возвращает значения по умолчанию для окружения - первый слой конфигурации, см. Load.
 //
-// Поддерживаемые значения APP_ENV:
-//   - "local": конфигурация для локальной среды
-//   - "test": конфигурация для тестовой среды
+// Поддерживаемые окружения:
+//   - "production": без адреса базы данных и ключей JWT, их нужно задать
+//   - "local": база данных и NATS из docker-compose, письма в файлы
+//   - "test": отдельная база данных, брокер и письма в памяти, CAPTCHA и ограничения частоты выключены; ключи JWT нужно задать
+//
+// Параметры:
+//   - env: окружение
 //
 // Возвращает:
 //   - указатель на структуру конфигурации Config
-func GetConfig() *Config {
-	env := os.Getenv("APP_ENV")
+//   - ошибку, если окружение неизвестно
+func Defaults(env string) (*Config, error) {
+	cfg := &Config{
+		AppEnv:         env,
+		ReloadInterval: 30 * time.Second,
+		// HTTP
+		Listen:            ":8080",
+		CORSOrigins:       []string{"*"},
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		MaxBodyBytes:      1 << 20,
+		ShutdownTimeout:   30 * time.Second,
+		// DB
+		DB: core.PgConfig{
+			Port:              5432,
+			Name:              "auth",
+			User:              "auth",
+			MinConns:          1,
+			MaxConns:          10,
+			MaxConnLifetime:   time.Hour,
+			MaxConnIdleTime:   30 * time.Minute,
+			HealthCheckPeriod: time.Minute,
+		},
+		// JWT
+		JWTAlgorithm:     JWTRS512,
+		JWTIssuer:        "MedodsTechTask",
+		AccessTokenTTL:   24 * time.Hour,
+		RefreshTokenTTL:  5 * 24 * time.Hour,
+		ImpersonationTTL: 15 * time.Minute,
+		PasswordResetTTL: time.Hour,
+		InviteTTL:        7 * 24 * time.Hour,
+		LegacyRefreshJWT: true,
+		SessionBinding: session.Config{
+			Mode:   session.ModeSubnet,
+			Action: session.ActionRevokeFamily,
+		},
+		Risk: risk.Config{
+			NewDeviceScore:        20,
+			NewCountryScore:       25,
+			NewASNScore:           5,
+			ImpossibleTravelScore: 60,
+			SprayingScore:         70,
+			MaxSpeedKmh:           1000,
+			MinDistanceKm:         300,
+			SprayAccounts:         10,
+			SprayWindow:           time.Hour,
+			NotifyScore:           20,
+			StepUpScore:           0, // второго фактора нет, порог выключен
+			StepUpAction:          risk.DecisionBlock,
+			BlockScore:            90,
+		},
+		// Password
+		Password: password.Config{
+			Algorithm:     password.AlgorithmArgon2id,
+			Argon2Memory:  64 * 1024,
+			Argon2Time:    3,
+			Argon2Threads: 2,
+			BcryptCost:    12,
+		},
+		PasswordPolicy: password.PolicyConfig{
+			MinLength:        10,
+			MaxLength:        128,
+			RequireLower:     true,
+			RequireDigit:     true,
+			MinClasses:       2,
+			MinStrength:      2,
+			RejectEmail:      true,
+			BreachedMinCount: 1,
+		},
+		// Email
+		Email: email.Config{
+			BlockDisposable:   true,
+			DisposableDomains: slices.Clone(disposableDomains),
+		},
+		// Proxy
+		Proxy: proxy.Config{
+			HeaderTimeout: 5 * time.Second,
+		},
+		// Captcha
+		Captcha: captcha.Config{
+			Provider:           captcha.ProviderPoW,
+			MinScore:           0.5,
+			Timeout:            5 * time.Second,
+			PoWDifficulty:      18,
+			PoWTTL:             5 * time.Minute,
+			OnSignup:           true,
+			OnReset:            true,
+			LoginAfterFailures: 3,
+			FailureWindow:      15 * time.Minute,
+		},
+		// RateLimit
+		RateLimit: ratelimit.Config{
+			Store:     ratelimit.StoreMemory,
+			KeyPrefix: "auth:rl:",
+			Routes:    maps.Clone(rateLimitRoutes),
+			Default: []ratelimit.Rule{
+				{Name: "ip", By: ratelimit.ByIP, Algorithm: ratelimit.AlgorithmTokenBucket, Limit: 300, Window: time.Minute, Burst: 100},
+			},
+			FailOpen: true,
+		},
+		// Tenant
+		DefaultTenant: "default",
+		// Mail
+		Mail: mail.Config{
+			Transport:      mail.TransportSMTP,
+			From:           "no-reply@medods.local",
+			Locale:         mail.DefaultLocale,
+			ThrottleLimit:  5,
+			ThrottleWindow: time.Hour,
+		},
+		// Jobs
+		Jobs: jobs.Config{
+			Concurrency:  4,
+			PollInterval: time.Second,
+			Lease:        5 * time.Minute,
+			BackoffBase:  10 * time.Second,
+			BackoffMax:   time.Hour,
+			Timeout:      time.Minute,
+		},
+		JobsShutdownTimeout: 30 * time.Second,
+		// Events
+		Events: events.Config{
+			Broker:        events.BrokerNATS,
+			SubjectPrefix: "auth",
+			NATSStream:    "AUTH_EVENTS",
+			PollInterval:  time.Second,
+			BatchSize:     100,
+			BackoffBase:   time.Second,
+			BackoffMax:    5 * time.Minute,
+			Retention:     7 * 24 * time.Hour,
+		},
+		// Webhooks
+		WebhookTimeout:      10 * time.Second,
+		WebhookMaxAttempts:  8,
+		WebhookDisableAfter: 50,
+		// Tracing
+		Tracing: tracing.Config{
+			Exporter:    tracing.ExporterNone,
+			ServiceName: "auth",
+			SampleRatio: 1,
+			Timeout:     10 * time.Second,
+		},
+	}
+
 	switch env {
-	case "local":
-		cfg = &Config{
-			AppEnv: "local",
-			// DB
-			AuthDBDBN:    "auth",
-			AuthDBUSR:    "auth",
-			AuthDBPWD:    getEnv("AUTH_DB_PWD"),
-			DBMasterHost: "175.243.0.50",
-			DBMasterPort: "5432",
-			// JWT
-			JWTPublicKey:          getEnv("JWT_PUBLIC_KEY"),
-			JWTPrivateKey:         getEnv("JWT_PRIVATE_KEY"),
-			AuthJWTTokenExpireMin: 60 * 24,
+	case EnvProduction:
+	case EnvLocal:
+		cfg.DB.Host = composeDBHost
+		cfg.DB.MaxConns = 2
+		cfg.Proxy.TrustedProxies = []string{"127.0.0.1", "::1"}
+		cfg.Mail.Transport = mail.TransportFile
+		cfg.Events.NATSURL = composeNATSURL
+	case EnvTest:
+		cfg.DB.Host = composeDBHost
+		cfg.DB.Port = 5433
+		cfg.DB.Password = "auth_pwd"
+		cfg.DB.MaxConns = 2
+		cfg.PasswordPolicy = password.PolicyConfig{
+			MinLength:   6,
+			MaxLength:   128,
+			RejectEmail: true,
+		}
+		cfg.Captcha = captcha.Config{
+			Provider: captcha.ProviderOff,
 		}
-	case "test":
-		cfg = &Config{
-			AppEnv: "local",
-			// DB
-			AuthDBDBN:    "auth",
-			AuthDBUSR:    "auth",
-			AuthDBPWD:    "auth_pwd",
-			DBMasterHost: "175.243.0.50",
-			DBMasterPort: "5433",
-			// JWT
-			JWTPublicKey:          "testjwt",
-			JWTPrivateKey:         "testjwt",
-			AuthJWTTokenExpireMin: 60 * 24,
+		cfg.RateLimit = ratelimit.Config{
+			Store:    ratelimit.StoreMemory,
+			FailOpen: true,
 		}
+		cfg.Mail.Transport = mail.TransportMemory
+		cfg.Events.Broker = events.BrokerMemory
+		cfg.Events.NATSStream = ""
+	default:
+		return nil, fmt.Errorf("unknown APP_ENV %q, expected %s, %s or %s", env, EnvLocal, EnvTest, EnvProduction)
 	}
-	return cfg
+
+	return cfg, nil
 }
 
-// getEnv возвращает значение переменной окружения по ключу.
-//
-// Параметры:
-//   - key: имя переменной окружения
+// Validate проверяет конфигурацию, собранную из всех слоев. Значения пакетов (почта, CAPTCHA, ограничения частоты
+// и другие) дополнительно проверяют их конструкторы.
 //
 // Возвращает:
-//   - значение переменной окружения
-//
-// Паника:
-//   - если переменная окружения не установлена
-func getEnv(key string) string {
-	val := os.Getenv(key)
-	if val == "" {
-		panic("Environment variable " + key + " is not set")
+//   - все найденные ошибки, объединенные errors.Join, или nil
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
 	}
-	return val
+
+	check(c.Listen != "", "listen is not set")
+	check(c.ReadHeaderTimeout > 0, "read_header_timeout must be positive")
+	check(c.ReadTimeout > 0, "read_timeout must be positive")
+	check(c.WriteTimeout > 0, "write_timeout must be positive")
+	check(c.IdleTimeout > 0, "idle_timeout must be positive")
+	check(c.MaxBodyBytes >= 1, "max_body_bytes must be positive, got %d", c.MaxBodyBytes)
+	check(c.ShutdownDelay >= 0, "shutdown_delay must not be negative")
+	check(c.ShutdownTimeout >= 0, "shutdown_timeout must not be negative")
+	check(c.ReloadInterval >= 0, "reload_interval must not be negative")
+	// DB
+	check(c.DB.Host != "", "db.host is not set")
+	check(c.DB.Port > 0 && c.DB.Port <= 65535, "db.port must be between 1 and 65535, got %d", c.DB.Port)
+	check(c.DB.Name != "", "db.name is not set")
+	check(c.DB.User != "", "db.user is not set")
+	check(c.DB.Password != "", "db.password is not set")
+	check(c.DB.MaxConns >= 1, "db.max_conns must be positive, got %d", c.DB.MaxConns)
+	check(c.DB.MinConns >= 0 && c.DB.MinConns <= c.DB.MaxConns, "db.min_conns must be between 0 and db.max_conns, got %d", c.DB.MinConns)
+	check(c.DB.MaxConnLifetime > 0, "db.max_conn_lifetime must be positive")
+	check(c.DB.MaxConnIdleTime > 0, "db.max_conn_idle_time must be positive")
+	check(c.DB.HealthCheckPeriod >= 0, "db.health_check_period must not be negative")
+	// JWT
+	check(slices.Contains([]string{JWTRS512, JWTES256, JWTEdDSA}, c.JWTAlgorithm), "unknown jwt_algorithm %q, expected %s, %s or %s", c.JWTAlgorithm, JWTRS512, JWTES256, JWTEdDSA)
+	check(c.JWTPublicKey != "", "jwt_public_key is not set")
+	check(c.JWTIssuer != "", "jwt_issuer is not set")
+	check(c.JWTPrivateKey != "", "jwt_private_key is not set")
+	check(c.AccessTokenTTL >= time.Minute, "access_token_ttl must be at least 1m, got %s", c.AccessTokenTTL)
+	check(c.RefreshTokenTTL >= time.Minute, "refresh_token_ttl must be at least 1m, got %s", c.RefreshTokenTTL)
+	check(c.ImpersonationTTL >= time.Minute, "impersonation_ttl must be at least 1m, got %s", c.ImpersonationTTL)
+	check(c.PasswordResetTTL >= time.Minute, "password_reset_ttl must be at least 1m, got %s", c.PasswordResetTTL)
+	check(c.InviteTTL >= time.Minute, "invite_ttl must be at least 1m, got %s", c.InviteTTL)
+	// Остальное
+	check(c.DefaultTenant != "", "default_tenant is not set")
+	check(c.JobsShutdownTimeout >= 0, "jobs_shutdown_timeout must not be negative")
+	check(c.Jobs.Concurrency >= 1, "jobs.concurrency must be positive, got %d", c.Jobs.Concurrency)
+	check(c.Jobs.PollInterval > 0, "jobs.poll_interval must be positive")
+	check(c.Jobs.Lease > 0, "jobs.lease must be positive")
+	check(c.Jobs.Timeout > 0, "jobs.timeout must be positive")
+	check(c.Events.PollInterval > 0, "events.poll_interval must be positive")
+	check(c.Events.BatchSize >= 1, "events.batch_size must be positive, got %d", c.Events.BatchSize)
+	check(c.Events.Broker != events.BrokerNATS || c.Events.NATSURL != "", "events.nats_url is not set")
+	check(c.WebhookTimeout > 0, "webhook_timeout must be positive")
+	check(c.WebhookMaxAttempts >= 1, "webhook_max_attempts must be positive, got %d", c.WebhookMaxAttempts)
+	check(c.WebhookDisableAfter >= 0, "webhook_disable_after must not be negative")
+	check(c.Risk.StepUpAction == risk.DecisionNotify || c.Risk.StepUpAction == risk.DecisionBlock, "unknown risk.step_up_action %q, expected %s or %s", c.Risk.StepUpAction, risk.DecisionNotify, risk.DecisionBlock)
+
+	return errors.Join(errs...)
+}
+
+// ReturnCodes сообщает, возвращаются ли коды подтверждения в ответах API; только в окружениях local и test.
+//
+// Возвращает:
+//   - true, если код можно вернуть в ответе
+func (c *Config) ReturnCodes() bool {
+	return c.AppEnv == EnvLocal || c.AppEnv == EnvTest
 }
@@ -0,0 +1,279 @@
+package configs
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	// legacyEnv - прежние имена переменных окружения, они читаются перед основными и уступают им
+	legacyEnv = map[string]string{
+		"AUTH_DB_PWD":            "db.password",
+		"AUTH_PRECISE_ERRORS":    "precise_auth_errors",
+		"PASSWORD_BREACHED_FILE": "password_policy.breached_file",
+		"TRUSTED_PROXIES":        "proxy.trusted_proxies",
+		"PROXY_PROTOCOL":         "proxy.proxy_protocol",
+		"REDIS_ADDR":             "rate_limit.redis_addr",
+		"REDIS_PWD":              "rate_limit.redis_password",
+	}
+)
+
+// Load собирает конфигурацию слоями, каждый следующий переопределяет предыдущий:
+//  1. значения по умолчанию окружения APP_ENV (флаг -app_env), по умолчанию production, см. Defaults
+//  2. YAML файл из CONFIG_FILE (флаг -config), ключи как в тегах yaml, неизвестные ключи - ошибка
+//  3. переменные окружения: ключ в верхнем регистре с _ вместо точек, например DB_MAX_CONNS для db.max_conns;
+//     <ИМЯ>_FILE читает значение из файла (секреты Docker и Kubernetes)
+//  4. флаги командной строки с именем ключа, например -db.max_conns=10
+//
+// Длительности записываются как в time.ParseDuration ("90s", "5m", "120h"), списки в переменных и флагах - через
+// запятую. Словари и списки структур (ограничения частоты маршрутов) задаются только в YAML.
+//
+// Параметры:
+//   - args: аргументы командной строки без имени программы
+//
+// Возвращает:
+//   - указатель на проверенную конфигурацию
+//   - ошибку со всеми найденными проблемами, если конфигурацию не удалось собрать или она не прошла проверку
+func Load(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("auth", flag.ContinueOnError)
+	config_file := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML файл конфигурации")
+	app_env := fs.String("app_env", getEnvDefault("APP_ENV", EnvProduction), "окружение: local, test или production")
+	flags := map[string]string{}
+	walk(reflect.ValueOf(&Config{}).Elem(), "", func(path string, field reflect.Value) {
+		fs.Var(&flagValue{path: path, values: flags}, path, field.Type().String())
+	})
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg, err := Defaults(*app_env)
+	if err != nil {
+		return nil, err
+	}
+	if *config_file != "" {
+		if err := loadFile(cfg, *config_file); err != nil {
+			return nil, err
+		}
+		cfg.Files = append(cfg.Files, *config_file)
+	}
+
+	fields := map[string]reflect.Value{}
+	walk(reflect.ValueOf(cfg).Elem(), "", func(path string, field reflect.Value) {
+		fields[path] = field
+	})
+
+	errs := loadEnv(cfg, fields)
+	for _, path := range sortedKeys(flags) {
+		if err := setField(fields[path], flags[path]); err != nil {
+			errs = append(errs, fmt.Errorf("flag -%s: %w", path, err))
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile накладывает на конфигурацию значения из YAML файла; незаданные в файле ключи сохраняют прежние значения.
+func loadFile(cfg *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv накладывает на поля значения переменных окружения, сначала прежних имен из legacyEnv, затем основных.
+// Прочитанные файлы секретов добавляются в cfg.Files.
+func loadEnv(cfg *Config, fields map[string]reflect.Value) []error {
+	var errs []error
+	apply := func(name string, path string) {
+		val, ok, err := lookupEnv(name)
+		if err == nil && ok {
+			err = setField(fields[path], val)
+		}
+		if file := os.Getenv(name + "_FILE"); err == nil && file != "" {
+			cfg.Files = append(cfg.Files, file)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("env %s: %w", name, err))
+		}
+	}
+
+	for _, name := range sortedKeys(legacyEnv) {
+		apply(name, legacyEnv[name])
+	}
+	for _, path := range sortedKeys(fields) {
+		apply(envName(path), path)
+	}
+	return errs
+}
+
+// lookupEnv возвращает значение переменной окружения или содержимое файла из <name>_FILE без завершающего перевода
+// строки. Пустая переменная считается незаданной.
+func lookupEnv(name string) (string, bool, error) {
+	val := os.Getenv(name)
+	file := os.Getenv(name + "_FILE")
+	if file == "" {
+		return val, val != "", nil
+	}
+	if val != "" {
+		return "", false, fmt.Errorf("both %s and %s_FILE are set", name, name)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// getEnvDefault возвращает значение переменной окружения или значение по умолчанию, если она не задана.
+func getEnvDefault(key string, def string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return def
+}
+
+// walk обходит поля конфигурации с тегом yaml и вызывает fn для значений, которые можно задать строкой.
+// Путь поля - ключи yaml через точку, например "db.max_conns".
+func walk(v reflect.Value, prefix string, fn func(path string, field reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct:
+			walk(field, prefix+name+".", fn)
+		case scalar(field.Type()) || field.Kind() == reflect.Slice && scalar(field.Type().Elem()):
+			fn(prefix+name, field)
+		}
+	}
+}
+
+// scalar сообщает, разбирает ли setField значение этого типа из строки.
+func scalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// setField разбирает строку в значение типа поля: длительности - time.ParseDuration, списки - через запятую.
+func setField(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		items := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(elem, item); err != nil {
+				return err
+			}
+			items = reflect.Append(items, elem)
+		}
+		field.Set(items)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// envName возвращает имя переменной окружения для пути поля: "db.max_conns" - "DB_MAX_CONNS".
+func envName(path string) string {
+	return strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// sortedKeys возвращает ключи словаря по порядку, чтобы ошибки и переопределения не зависели от порядка обхода.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// flagValue запоминает значение флага, оно применяется после переменных окружения.
+type flagValue struct {
+	path   string
+	values map[string]string
+}
+
+func (f *flagValue) String() string {
+	if f.values == nil {
+		return ""
+	}
+	return f.values[f.path]
+}
+
+func (f *flagValue) Set(raw string) error {
+	f.values[f.path] = raw
+	return nil
+}
@@ -0,0 +1,118 @@
+package configs
+
+import (
+	"context"
+	"crypto/sha256"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Watcher перечитывает конфигурацию по SIGHUP и при изменении файлов, из которых она собрана (Config.Files).
+// Новая конфигурация передается в apply только если Load ее принял; ошибка apply оставляет прежнюю конфигурацию.
+type Watcher struct {
+	args     []string
+	apply    func(*Config) error
+	interval time.Duration
+	signals  chan os.Signal
+	files    map[string][sha256.Size]byte
+}
+
+// NewWatcher создает наблюдателя за конфигурацией и сразу подписывается на SIGHUP, чтобы сигнал не завершил процесс.
+//
+// Параметры:
+//   - args: аргументы командной строки, с которыми конфигурация загружается заново
+//   - cfg: текущая конфигурация; ее Files и ReloadInterval задают, за чем и как часто следить
+//   - apply: применяет новую конфигурацию, ошибка означает, что она отклонена
+//
+// Возвращает:
+//   - указатель на Watcher
+func NewWatcher(args []string, cfg *Config, apply func(*Config) error) *Watcher {
+	w := &Watcher{
+		args:     args,
+		apply:    apply,
+		interval: cfg.ReloadInterval,
+		signals:  make(chan os.Signal, 1),
+	}
+	w.files = fingerprints(cfg.Files)
+	signal.Notify(w.signals, syscall.SIGHUP)
+	return w
+}
+
+// Run ждет SIGHUP или изменения файлов и перезагружает конфигурацию, пока не завершится ctx.
+//
+// Параметры:
+//   - ctx: контекст, завершение которого останавливает наблюдение
+func (w *Watcher) Run(ctx context.Context) {
+	defer signal.Stop(w.signals)
+
+	var tick <-chan time.Time
+	if w.interval > 0 {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.signals:
+			log.Printf("config: reload on SIGHUP")
+			w.reload()
+		case <-tick:
+			if w.changed() {
+				log.Printf("config: reload on file change")
+				w.reload()
+			}
+		}
+	}
+}
+
+// reload загружает конфигурацию и передает ее в apply; при ошибке прежняя конфигурация остается в силе.
+func (w *Watcher) reload() {
+	cfg, err := Load(w.args)
+	if err != nil {
+		log.Printf("config: reload rejected, keeping current config: %s", err)
+		return
+	}
+	w.files = fingerprints(cfg.Files)
+	if err := w.apply(cfg); err != nil {
+		log.Printf("config: reload rejected, keeping current config: %s", err)
+	}
+}
+
+// changed сообщает, изменилось ли содержимое файлов с прошлой загрузки. Отпечатки обновляются сразу, чтобы
+// отклоненная конфигурация не перечитывалась на каждом тике до следующего изменения.
+func (w *Watcher) changed() bool {
+	files := make([]string, 0, len(w.files))
+	for file := range w.files {
+		files = append(files, file)
+	}
+	current := fingerprints(files)
+
+	changed := false
+	for file, sum := range current {
+		if w.files[file] != sum {
+			changed = true
+		}
+	}
+	w.files = current
+	return changed
+}
+
+// fingerprints возвращает SHA-256 содержимого файлов; недоступный файл получает нулевой отпечаток.
+func fingerprints(files []string) map[string][sha256.Size]byte {
+	res := make(map[string][sha256.Size]byte, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			res[file] = [sha256.Size]byte{}
+			continue
+		}
+		res[file] = sha256.Sum256(data)
+	}
+	return res
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/email"
+	"github.com/MedodsTechTask/app/jobs"
+	"github.com/MedodsTechTask/app/mail"
+	"github.com/MedodsTechTask/app/metrics"
+	"github.com/MedodsTechTask/app/password"
+	"github.com/MedodsTechTask/app/ratelimit"
+	"github.com/MedodsTechTask/app/user/auth/configs"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+	"github.com/MedodsTechTask/app/user/auth/share"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// enumRepo - один аккаунт и одна ожидающая подтверждения регистрация
+type enumRepo struct {
+	repo.IAuthRepo
+	account *repo.XAccount
+	pending string // каноническая форма email ожидающей регистрации
+}
+
+func (r *enumRepo) GetAccountForEmail(ctx context.Context, tenant_id string, email_canonical string) (*repo.XAccount, error) {
+	if r.account.TenantID != tenant_id || email.Canonical(r.account.Email) != email_canonical {
+		return nil, &core.ErrAccountNotFound{ErrMessage: email_canonical}
+	}
+	return r.account, nil
+}
+
+func (r *enumRepo) CreateEmailSignup(ctx context.Context, tenant_id string, email string, email_canonical string, passwd_hash string, code string, salt string, enqueue ...jobs.NewJob) (*repo.XEmailSignup, error) {
+	if email_canonical == r.pending {
+		return nil, &core.ErrCreateSignup{ErrMessage: email_canonical}
+	}
+	return &repo.XEmailSignup{
+		ID:             "0b5c3f2e-6f1d-4a57-9d0a-3f7e1c2b4a69",
+		TenantID:       tenant_id,
+		Email:          email,
+		EmailCanonical: email_canonical,
+		Code:           code,
+		PasswordHash:   passwd_hash,
+		CreatedAt:      time.Now().UTC(),
+	}, nil
+}
+
+func (r *enumRepo) RecordLoginEvent(ctx context.Context, ev *repo.XLoginEvent) error {
+	return nil
+}
+
+func (r *enumRepo) CreateWebhookDeliveries(ctx context.Context, tenant_id string, event_id string, event string, payload []byte, job func(delivery_id string) jobs.NewJob) (int, error) {
+	return 0, nil
+}
+
+func (r *enumRepo) WriteAudit(ctx context.Context, rec *repo.XAuditRecord) (*repo.XAuditRecord, error) {
+	return rec, nil
+}
+
+// enumUseCase создает use case с аккаунтом john@example.com и паролем correct-Horse-42
+func enumUseCase(t *testing.T, precise bool) (*AuthUseCase, *mail.Throttler) {
+	t.Helper()
+	passwords, err := password.NewManager(password.Config{Algorithm: password.AlgorithmBcrypt, BcryptCost: 4})
+	if err != nil {
+		t.Fatalf("NewManager: %s", err)
+	}
+	policy, _ := password.NewPolicy(password.PolicyConfig{MinLength: 8})
+	emails, _ := email.NewValidator(email.Config{})
+	limiter, _ := ratelimit.NewLimiter(ratelimit.Config{Store: ratelimit.StoreMemory}, nil)
+	t.Cleanup(func() { limiter.Close() })
+	throttle := mail.NewThrottler(limiter, 5, time.Hour)
+
+	hash, err := CreatePasswordHash(context.Background(), passwords, "correct-Horse-42")
+	if err != nil {
+		t.Fatalf("CreatePasswordHash: %s", err)
+	}
+	fake := &enumRepo{
+		account: &repo.XAccount{ID: "7d1e0c6a-2f0b-4d8e-9a35-5c4b3a2d1e0f", TenantID: core.DefaultTenantID, Email: "john@example.com", PasswordHash: hash, Status: AccountStatusActive},
+		pending: "jane@example.com",
+	}
+	uc := &AuthUseCase{repo: fake}
+	uc.live.Store(&settings{
+		cfg:       &configs.Config{PreciseAuthErrors: precise},
+		passwords: passwords,
+		policy:    policy,
+		emails:    emails,
+		throttle:  throttle,
+	})
+	return uc, throttle
+}
+
+func TestLoginUniformErrors(t *testing.T) {
+	ctx := core.WithTenant(context.Background(), core.DefaultTenantID)
+	login := func(uc *AuthUseCase, addr string) *core.ZError {
+		res, zerr := uc.LoginEmail(ctx, &share.QLoginEmail{Email: addr, Password: "wrong-Horse-42"}, "test", "203.0.113.10")
+		if res != nil || zerr == nil {
+			t.Fatalf("LoginEmail(%s) = %+v, %+v, want error", addr, res, zerr)
+		}
+		return zerr
+	}
+
+	uc, _ := enumUseCase(t, false)
+	unknown := login(uc, "nobody@example.com")
+	wrong := login(uc, "john@example.com")
+	if !reflect.DeepEqual(unknown, wrong) {
+		t.Errorf("unknown email = %+v, wrong password = %+v, want equal", unknown, wrong)
+	}
+	if !reflect.DeepEqual(wrong, invalidCredentials()) {
+		t.Errorf("wrong password = %+v, want %+v", wrong, invalidCredentials())
+	}
+
+	// Подробные ошибки различают случаи, если они явно включены
+	uc, _ = enumUseCase(t, true)
+	if zerr := login(uc, "nobody@example.com"); zerr.Code != 404 {
+		t.Errorf("precise unknown email code = %d, want 404", zerr.Code)
+	}
+	if zerr := login(uc, "john@example.com"); zerr.Code != 400 || reflect.DeepEqual(zerr, invalidCredentials()) {
+		t.Errorf("precise wrong password = %+v, want its own error", zerr)
+	}
+}
+
+func TestLoginFailureMetrics(t *testing.T) {
+	ctx := core.WithTenant(context.Background(), core.DefaultTenantID)
+	uc, _ := enumUseCase(t, false)
+
+	// Ответ одинаковый, но метрика хранит настоящую причину отказа
+	tests := []struct {
+		email  string
+		reason string
+	}{
+		{"nobody@example.com", metrics.LoginFailureAccountNotFound},
+		{"john@example.com", metrics.LoginFailureWrongPassword},
+	}
+	for _, tt := range tests {
+		before := testutil.ToFloat64(metrics.LoginFailures.WithLabelValues(tt.reason))
+		uc.LoginEmail(ctx, &share.QLoginEmail{Email: tt.email, Password: "wrong-Horse-42"}, "test", "203.0.113.10")
+		if got := testutil.ToFloat64(metrics.LoginFailures.WithLabelValues(tt.reason)) - before; got != 1 {
+			t.Errorf("%s: login failures %s = %v, want 1", tt.email, tt.reason, got)
+		}
+	}
+}
+
+func TestSignupUniformResponse(t *testing.T) {
+	ctx := core.WithTenant(context.Background(), core.DefaultTenantID)
+	signup := func(uc *AuthUseCase, addr string) *share.ZEmailSignup {
+		res, zerr := uc.SignupEmail(ctx, &share.QEmailSignup{Email: addr, Password: "correct-Horse-42", ConfirmedPwd: "correct-Horse-42"})
+		if zerr != nil {
+			t.Fatalf("SignupEmail(%s): %+v", addr, zerr)
+		}
+		return res
+	}
+
+	uc, throttle := enumUseCase(t, false)
+	fresh := signup(uc, "new@example.com")
+	duplicate := signup(uc, "jane@example.com")
+
+	for name, res := range map[string]*share.ZEmailSignup{"new": fresh, "duplicate": duplicate} {
+		if res.ID == "" || res.PasswordHash == "" || res.Code != "" || res.CreatedAt.IsZero() {
+			t.Errorf("%s signup = %+v, want id, hash and time without code", name, res)
+		}
+	}
+	if duplicate.Email != "jane@example.com" {
+		t.Errorf("duplicate email = %q, want jane@example.com", duplicate.Email)
+	}
+
+	// Письмо на повторную регистрацию не ставится и не расходует лимит получателя
+	for i := 0; i < 10; i++ {
+		signup(uc, "jane@example.com")
+	}
+	if ok, _ := throttle.Allow(ctx, "jane@example.com"); !ok {
+		t.Error("duplicate signups used up the mail limit")
+	}
+
+	uc, _ = enumUseCase(t, true)
+	if _, zerr := uc.SignupEmail(ctx, &share.QEmailSignup{Email: "jane@example.com", Password: "correct-Horse-42", ConfirmedPwd: "correct-Horse-42"}); zerr == nil || zerr.Code != 400 {
+		t.Errorf("precise duplicate signup error = %+v, want 400", zerr)
+	}
+}
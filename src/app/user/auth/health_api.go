@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupHealthRoutes регистрирует проверки живости и готовности. Они вне BasePath и без middleware API: тенант,
+// ограничение частоты и авторизация для них не нужны.
+func (h *API) SetupHealthRoutes(r *gin.RouterGroup) {
+	r.GET(core.HealthzPath, h.healthz)
+	r.GET(core.ReadyzPath, h.readyz)
+}
+
+// healthz - проверка живости: 200, пока процесс обслуживает запросы, даже если база данных недоступна
+func (h *API) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, h.uc.Health(c.Request.Context(), false))
+}
+
+// readyz - проверка готовности: 503, если база данных или ключи JWT недоступны или сервис останавливается
+func (h *API) readyz(c *gin.Context) {
+	res := h.uc.Health(c.Request.Context(), true)
+	if res.Status != HealthOK {
+		c.JSON(http.StatusServiceUnavailable, res)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
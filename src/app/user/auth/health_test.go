@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/user/auth/configs"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+	"github.com/MedodsTechTask/app/user/auth/share"
+	"github.com/gin-gonic/gin"
+)
+
+// pingRepo - репозиторий, у которого вызывается только Ping
+type pingRepo struct {
+	repo.IAuthRepo
+	err error
+}
+
+func (r *pingRepo) Ping(ctx context.Context) error {
+	return r.err
+}
+
+// probe выполняет запрос к проверке живости или готовности
+func probe(t *testing.T, h *API, path string) (int, *share.ZHealth) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h.SetupHealthRoutes(r.Group(""))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var res share.ZHealth
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("%s: decode %q: %s", path, w.Body.String(), err)
+	}
+	return w.Code, &res
+}
+
+func TestHealthProbes(t *testing.T) {
+	tests := []struct {
+		name       string
+		db         error
+		keys       error
+		drain      bool
+		live       string
+		ready_code int
+		ready      string
+		failed     string
+	}{
+		{"ok", nil, nil, false, HealthOK, http.StatusOK, HealthOK, ""},
+		{"db down", errors.New("connection refused"), nil, false, HealthDegraded, http.StatusServiceUnavailable, HealthUnavailable, "db"},
+		{"bad keys", nil, errors.New("key mismatch"), false, HealthDegraded, http.StatusServiceUnavailable, HealthUnavailable, "keys"},
+		// При остановке процесс жив, но новые запросы принимать не должен
+		{"draining", nil, nil, true, HealthOK, http.StatusServiceUnavailable, HealthUnavailable, "shutdown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := testUseCase(t, &pingRepo{err: tt.db})
+			uc.live.Load().keys = tt.keys
+			if tt.drain {
+				uc.Drain()
+			}
+			h := NewAPI(uc)
+
+			code, res := probe(t, h, core.HealthzPath)
+			if code != http.StatusOK || res.Status != tt.live {
+				t.Errorf("healthz = %d %s, want 200 %s", code, res.Status, tt.live)
+			}
+			if _, ok := res.Checks["shutdown"]; ok {
+				t.Errorf("healthz checks = %v, want no shutdown check", res.Checks)
+			}
+
+			code, res = probe(t, h, core.ReadyzPath)
+			if code != tt.ready_code || res.Status != tt.ready {
+				t.Errorf("readyz = %d %s, want %d %s", code, res.Status, tt.ready_code, tt.ready)
+			}
+			for name, status := range res.Checks {
+				want := HealthOK
+				if name == tt.failed {
+					want = "fail"
+				}
+				if status != want {
+					t.Errorf("readyz check %s = %q, want %q", name, status, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthShowsReasonInTest(t *testing.T) {
+	uc := testUseCase(t, &pingRepo{err: errors.New("connection refused")})
+	uc.live.Load().cfg.AppEnv = configs.EnvTest
+
+	res := uc.Health(context.Background(), true)
+	if res.Checks["db"] != "connection refused" {
+		t.Errorf("db check = %q, want the error text", res.Checks["db"])
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	uc := testUseCase(t, nil)
+	uc.live.Load().cfg.MaxBodyBytes = 16
+	h := NewAPI(uc)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", h.BodyLimit(), func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name    string
+		body    string
+		chunked bool
+		code    int
+	}{
+		{"within limit", `{"a":1}`, false, http.StatusNoContent},
+		{"too large", strings.Repeat("a", 17), false, http.StatusRequestEntityTooLarge},
+		// Без Content-Length тело обрывается при чтении
+		{"chunked too large", strings.Repeat("a", 17), true, http.StatusBadRequest},
+		{"chunked within limit", `{"a":1}`, true, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Errorf("code = %d, want %d", w.Code, tt.code)
+			}
+		})
+	}
+}
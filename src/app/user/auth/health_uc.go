@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+const (
+	// HealthOK - все проверки прошли
+	HealthOK = "ok"
+	// HealthDegraded - процесс работает, но часть проверок не прошла
+	HealthDegraded = "degraded"
+	// HealthUnavailable - сервис не готов принимать запросы
+	HealthUnavailable = "unavailable"
+	// healthTimeout - сколько ждать ответа базы данных при проверке
+	healthTimeout = 2 * time.Second
+)
+
+// Drain переводит сервис в режим остановки: /readyz отвечает 503, чтобы балансировщик перестал направлять запросы.
+func (s *AuthUseCase) Drain() {
+	s.draining.Store(true)
+}
+
+// Health проверяет пул соединений с базой данных и ключи JWT. Для проверки живости (/healthz) отказ проверок
+// не делает процесс мертвым: статус degraded, перезапуск не поможет базе данных. Для проверки готовности (/readyz)
+// любой отказ или остановка сервиса дают статус unavailable.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - ready: true - проверка готовности, false - проверка живости
+//
+// Возвращает:
+//   - указатель на структуру ZHealth
+func (s *AuthUseCase) Health(ctx context.Context, ready bool) *share.ZHealth {
+	st := s.conf(ctx)
+	res := &share.ZHealth{Status: HealthOK, Checks: map[string]string{}}
+	fail := HealthDegraded
+	if ready {
+		fail = HealthUnavailable
+	}
+	check := func(name string, err error) {
+		if err == nil {
+			res.Checks[name] = HealthOK
+			return
+		}
+		res.Status = fail
+		// Причину показываем только в local и test: ошибка базы данных может содержать адрес сервера
+		res.Checks[name] = "fail"
+		if st.cfg.ReturnCodes() {
+			res.Checks[name] = err.Error()
+		}
+	}
+
+	db_ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	check("db", s.repo.Ping(db_ctx))
+	check("keys", st.keys)
+	if ready {
+		var err error
+		if s.draining.Load() {
+			err = errors.New("shutting down")
+		}
+		check("shutdown", err)
+	}
+
+	return res
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+// impersonationRepo - репозиторий с журналом входов под пользователями в памяти
+type impersonationRepo struct {
+	adminRepo
+	sessions map[string]*repo.XImpersonation
+}
+
+func (r *impersonationRepo) GetAccountAccess(ctx context.Context, tenant_id string, account_id string) (*repo.XAccountAccess, error) {
+	return &repo.XAccountAccess{AccountID: account_id, Roles: []string{"user"}, Permissions: []string{}}, nil
+}
+
+func (r *impersonationRepo) GetOrganizationsForAccount(ctx context.Context, tenant_id string, account_id string) ([]repo.XOrganization, error) {
+	return nil, nil
+}
+
+func (r *impersonationRepo) CreateImpersonation(ctx context.Context, tenant_id string, admin_id string, account_id string, reason string, ttl time.Duration) (*repo.XImpersonation, error) {
+	imp := &repo.XImpersonation{
+		ID:        "e4b7c2a1-9d3f-4e5a-8b6c-7d8e9f0a1b2c",
+		TenantID:  tenant_id,
+		AdminID:   admin_id,
+		AccountID: account_id,
+		Reason:    reason,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	r.sessions[imp.ID] = imp
+	return imp, nil
+}
+
+func (r *impersonationRepo) GetActiveImpersonation(ctx context.Context, tenant_id string, id string) (*repo.XImpersonation, error) {
+	imp, ok := r.sessions[id]
+	if !ok || imp.TenantID != tenant_id || imp.EndedAt != nil {
+		return nil, &core.ErrImpersonationNotFound{ErrMessage: id}
+	}
+	return imp, nil
+}
+
+func (r *impersonationRepo) EndImpersonation(ctx context.Context, tenant_id string, id string, ended_by string) (*repo.XImpersonation, error) {
+	imp, err := r.GetActiveImpersonation(ctx, tenant_id, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	imp.EndedAt = &now
+	imp.EndedBy = &ended_by
+	return imp, nil
+}
+
+func TestImpersonationEnd(t *testing.T) {
+	const (
+		admin_id   = "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+		account_id = "7d1e0c6a-2f0b-4d8e-9a35-5c4b3a2d1e0f"
+	)
+	fake := &impersonationRepo{
+		adminRepo: adminRepo{account: &repo.XAccount{ID: account_id, TenantID: core.DefaultTenantID, Status: AccountStatusActive}},
+		sessions:  map[string]*repo.XImpersonation{},
+	}
+	uc := testUseCase(t, fake)
+	h := NewAPI(uc)
+	ctx := core.WithTenant(context.Background(), core.DefaultTenantID)
+
+	res, zerr := uc.Impersonate(ctx, admin_id, account_id, &share.QImpersonate{Reason: "support ticket 42"})
+	if zerr != nil {
+		t.Fatalf("Impersonate: %+v", zerr)
+	}
+	payload, zerr := uc.Authorize(ctx, res.AccessToken)
+	if zerr != nil {
+		t.Fatalf("Authorize before end: %+v", zerr)
+	}
+	if got, ok := Impersonator(payload); !ok || got != admin_id {
+		t.Errorf("Impersonator = %q, %v, want %q", got, ok, admin_id)
+	}
+	if payload["sub"] != account_id {
+		t.Errorf("sub = %v, want %s", payload["sub"], account_id)
+	}
+	// Сессия входа под пользователем не допускается к опасным действиям
+	if code := serveWith(h.DenyImpersonation(), core.DefaultTenantID, res.AccessToken); code != http.StatusForbidden {
+		t.Errorf("DenyImpersonation status = %d, want %d", code, http.StatusForbidden)
+	}
+
+	ended, zerr := uc.EndOwnImpersonation(ctx, payload)
+	if zerr != nil {
+		t.Fatalf("EndOwnImpersonation: %+v", zerr)
+	}
+	if ended.EndedBy == nil || *ended.EndedBy != admin_id {
+		t.Errorf("EndedBy = %v, want %s", ended.EndedBy, admin_id)
+	}
+
+	// Токен завершенной сессии отклоняется до истечения срока
+	if _, zerr := uc.Authorize(ctx, res.AccessToken); zerr == nil || zerr.Code != 401 {
+		t.Errorf("Authorize after end error = %+v, want 401", zerr)
+	}
+	if code := serveWith(h.Authenticate(), core.DefaultTenantID, res.AccessToken); code != http.StatusUnauthorized {
+		t.Errorf("Authenticate after end status = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if _, zerr := uc.EndImpersonation(ctx, admin_id, res.ImpersonationID); zerr == nil || zerr.Code != 404 {
+		t.Errorf("second EndImpersonation error = %+v, want 404", zerr)
+	}
+}
+
+func TestImpersonateValidation(t *testing.T) {
+	const admin_id = "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+	blocked := "7d1e0c6a-2f0b-4d8e-9a35-5c4b3a2d1e0f"
+	fake := &impersonationRepo{
+		adminRepo: adminRepo{account: &repo.XAccount{ID: blocked, TenantID: core.DefaultTenantID, Status: AccountStatusBlocked}},
+		sessions:  map[string]*repo.XImpersonation{},
+	}
+	uc := testUseCase(t, fake)
+	ctx := core.WithTenant(context.Background(), core.DefaultTenantID)
+
+	tests := []struct {
+		name       string
+		account_id string
+		reason     string
+		code       int
+	}{
+		{"no reason", blocked, "  ", 400},
+		{"own account", admin_id, "check", 400},
+		{"blocked account", blocked, "check", 403},
+		{"unknown account", "0b5c3f2e-6f1d-4a57-9d0a-3f7e1c2b4a69", "check", 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, zerr := uc.Impersonate(ctx, admin_id, tt.account_id, &share.QImpersonate{Reason: tt.reason}); zerr == nil || zerr.Code != tt.code {
+				t.Errorf("Impersonate error = %+v, want code %d", zerr, tt.code)
+			}
+		})
+	}
+	if len(fake.sessions) != 0 {
+		t.Errorf("sessions = %d, want 0", len(fake.sessions))
+	}
+}
@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+// impersonationReasonMaxLen - максимальная длина причины входа под пользователем в символах
+const impersonationReasonMaxLen = 500
+
+// Impersonate выдает администратору короткоживущий access токен целевого аккаунта без refresh токена.
+// Токен содержит claim act с идентификатором администратора и claim imp с идентификатором сессии,
+// начало сессии записывается в журнал Impersonation.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - admin_id: идентификатор администратора из access токена
+//   - account_id: идентификатор целевого аккаунта
+//   - req: структура с обязательной причиной входа
+//
+// Возвращает:
+//   - указатель на структуру ZImpersonationToken с access токеном и временем его истечения
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) Impersonate(ctx context.Context, admin_id string, account_id string, req *share.QImpersonate) (res *share.ZImpersonationToken, zerr *core.ZError) {
+	defer func() {
+		details := map[string]string{"reason": req.Reason}
+		if res != nil {
+			details["impersonation_id"] = res.ImpersonationID
+		}
+		s.audit(ctx, AuditAdminImpersonationStart, zerr, account_id, "", details)
+	}()
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" || utf8.RuneCountInString(reason) > impersonationReasonMaxLen {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Нужно указать причину входа под пользователем (до 500 символов)",
+			Exception: nil,
+		}
+	}
+	if admin_id == account_id {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Нельзя войти под собственным аккаунтом",
+			Exception: nil,
+		}
+	}
+
+	tenant_id := core.TenantFromContext(ctx)
+
+	acc, err := s.repo.GetAccount(ctx, tenant_id, account_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	if acc.Status == AccountStatusBlocked {
+		return nil, &core.ZError{
+			Code:      403,
+			Where:     "UseCase",
+			Message:   "Аккаунт заблокирован",
+			Exception: nil,
+		}
+	}
+
+	payload, zerr := s.accessPayload(ctx, tenant_id, acc.ID)
+	if zerr != nil {
+		return nil, zerr
+	}
+
+	imp, err := s.repo.CreateImpersonation(ctx, tenant_id, admin_id, acc.ID, reason, s.conf(ctx).cfg.ImpersonationTTL)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	payload["act"] = map[string]interface{}{"sub": admin_id}
+	payload["imp"] = imp.ID
+	payload["exp"] = imp.ExpiresAt.Unix()
+	payload["exp_at"] = imp.ExpiresAt.UTC().Format(time.RFC3339)
+	payload["exp_in"] = int(s.conf(ctx).cfg.ImpersonationTTL.Seconds())
+
+	token, err := CreateJWT(ctx, payload, s.conf(ctx).cfg.JWTPrivateKey, s.conf(ctx).cfg.JWTAlgorithm, s.conf(ctx).cfg.JWTIssuer, s.conf(ctx).cfg.ImpersonationTTL)
+	if err != nil {
+		// Токен не выдан, поэтому сессию сразу закрываем, чтобы в журнале не висела активная запись
+		s.repo.EndImpersonation(ctx, tenant_id, imp.ID, admin_id)
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase/Security",
+			Message:   "Ошибка подписания jwt ключа",
+			Exception: err.Error(),
+		}
+	}
+
+	return &share.ZImpersonationToken{
+		ImpersonationID: imp.ID,
+		AccessToken:     token,
+		TokenType:       "bearer",
+		ExpiresAt:       imp.ExpiresAt,
+	}, nil
+}
+
+// EndImpersonation досрочно завершает сессию входа под пользователем и записывает, кто ее завершил.
+// Выданный токен перестает приниматься сразу.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - actor_id: идентификатор аккаунта, завершающего сессию
+//   - impersonation_id: идентификатор сессии
+//
+// Возвращает:
+//   - указатель на структуру ZImpersonation с данными завершенной сессии
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) EndImpersonation(ctx context.Context, actor_id string, impersonation_id string) (res *share.ZImpersonation, zerr *core.ZError) {
+	defer func() {
+		account_id := ""
+		if res != nil {
+			account_id = res.AccountID
+		}
+		s.audit(ctx, AuditAdminImpersonationEnd, zerr, account_id, "", map[string]string{"impersonation_id": impersonation_id})
+	}()
+
+	xres, err := s.repo.EndImpersonation(ctx, core.TenantFromContext(ctx), impersonation_id, actor_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return toZImpersonation(xres), nil
+}
+
+// EndOwnImpersonation завершает сессию входа под пользователем, которой принадлежит токен запроса.
+// Завершившим сессию записывается администратор из claim act.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - payload: полезная нагрузка проверенного access токена
+//
+// Возвращает:
+//   - указатель на структуру ZImpersonation с данными завершенной сессии
+//   - указатель на структуру ZError с кодом 400, если токен выдан не при входе под пользователем
+func (s *AuthUseCase) EndOwnImpersonation(ctx context.Context, payload map[string]interface{}) (*share.ZImpersonation, *core.ZError) {
+	admin_id, ok := Impersonator(payload)
+	if !ok {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Токен выдан не при входе под пользователем",
+			Exception: nil,
+		}
+	}
+	imp_id, _ := payload["imp"].(string)
+
+	return s.EndImpersonation(ctx, admin_id, imp_id)
+}
+
+// SearchImpersonations ищет записи журнала входов под пользователями.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - req: фильтры по аккаунту и администратору и параметры страницы
+//
+// Возвращает:
+//   - указатель на структуру ZImpersonationPage со страницей записей и общим количеством
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) SearchImpersonations(ctx context.Context, req *share.QImpersonationSearch) (*share.ZImpersonationPage, *core.ZError) {
+	limit, offset, zerr := normalizePage(req.Limit, req.Offset)
+	if zerr != nil {
+		return nil, zerr
+	}
+
+	xres, total, err := s.repo.SearchImpersonations(ctx, &repo.XImpersonationFilter{
+		TenantID:  core.TenantFromContext(ctx),
+		AccountID: req.AccountID,
+		AdminID:   req.AdminID,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	items := make([]share.ZImpersonation, 0, len(xres))
+	for i := range xres {
+		items = append(items, *toZImpersonation(&xres[i]))
+	}
+	return &share.ZImpersonationPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// ----------- Tools -----------
+
+// Impersonator возвращает идентификатор администратора, если токен выдан при входе под пользователем.
+// Сервисы используют его, чтобы распознавать такие сессии и ограничивать в них опасные действия.
+//
+// Параметры:
+//   - payload: полезная нагрузка access токена
+//
+// Возвращает:
+//   - идентификатор администратора из claim act.sub и true, если токен выдан при входе под пользователем
+func Impersonator(payload map[string]interface{}) (string, bool) {
+	act, ok := payload["act"].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	admin_id, ok := act["sub"].(string)
+	return admin_id, ok && admin_id != ""
+}
+
+// checkImpersonation проверяет, что сессия входа под пользователем, выдавшая токен, не завершена досрочно.
+func (s *AuthUseCase) checkImpersonation(ctx context.Context, payload map[string]interface{}) *core.ZError {
+	if _, ok := Impersonator(payload); !ok {
+		return nil
+	}
+
+	imp_id, _ := payload["imp"].(string)
+	if _, err := s.repo.GetActiveImpersonation(ctx, core.TenantFromContext(ctx), imp_id); err != nil {
+		if _, ok := err.(*core.ErrImpersonationNotFound); ok {
+			return &core.ZError{
+				Code:      401,
+				Where:     "UseCase",
+				Message:   "Сессия входа под пользователем завершена",
+				Exception: nil,
+			}
+		}
+		return repoError(err)
+	}
+	return nil
+}
+
+// toZImpersonation переводит запись журнала входов под пользователями из слоя репозитория в DTO.
+func toZImpersonation(x *repo.XImpersonation) *share.ZImpersonation {
+	return &share.ZImpersonation{
+		ID:        x.ID,
+		AdminID:   x.AdminID,
+		AccountID: x.AccountID,
+		Reason:    x.Reason,
+		ExpiresAt: x.ExpiresAt,
+		EndedAt:   x.EndedAt,
+		EndedBy:   x.EndedBy,
+		CreatedAt: x.CreatedAt,
+	}
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	share "github.com/MedodsTechTask/app/user/auth/share"
+)
+
+// @Summary Поиск фоновых задач
+// @Description Постраничный поиск задач очереди по типу и статусу, данные задач не возвращаются. Требует разрешение jobs:read
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param kind query string false "Тип задачи, например mail.send"
+// @Param status query string false "Статус задачи" Enums(pending, running, done, dead)
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 200)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} share.ZJobPage
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/jobs [get]
+func (h *API) searchJobs(c *gin.Context) {
+	var req share.QJobSearch
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.SearchJobs(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Состояние очереди задач
+// @Description Количество задач по типу и статусу. Требует разрешение jobs:read
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} share.ZJobStat
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/jobs/stats [get]
+func (h *API) getJobStats(c *gin.Context) {
+	res, err := h.uc.GetJobStats(c.Request.Context())
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Повтор задачи
+// @Description Возвращает задачу из dead в очередь с обнуленным счетчиком попыток. Требует разрешение jobs:manage
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param job_id path int true "ID задачи"
+// @Success 200 {object} share.ZJob
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/jobs/{job_id}/retry [post]
+func (h *API) retryJob(c *gin.Context) {
+	res, err := h.uc.RetryJob(c.Request.Context(), c.Param("job_id"))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
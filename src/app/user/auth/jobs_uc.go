@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/jobs"
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+// GetJobStats возвращает количество задач очереди тенанта запроса по типу и статусу.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//
+// Возвращает:
+//   - список счетчиков
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка базы данных
+func (s *AuthUseCase) GetJobStats(ctx context.Context) ([]share.ZJobStat, *core.ZError) {
+	xres, err := s.queue.Stats(ctx, core.TenantFromContext(ctx))
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	res := make([]share.ZJobStat, 0, len(xres))
+	for _, stat := range xres {
+		res = append(res, share.ZJobStat{
+			Kind:   stat.Kind,
+			Status: stat.Status,
+			Count:  stat.Count,
+		})
+	}
+	return res, nil
+}
+
+// SearchJobs ищет задачи очереди тенанта запроса по типу и статусу.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - req: фильтры по типу и статусу и параметры страницы
+//
+// Возвращает:
+//   - указатель на структуру ZJobPage со страницей задач и общим количеством
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) SearchJobs(ctx context.Context, req *share.QJobSearch) (*share.ZJobPage, *core.ZError) {
+	switch req.Status {
+	case "", jobs.StatusPending, jobs.StatusRunning, jobs.StatusDone, jobs.StatusDead:
+	default:
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Неизвестный статус задачи",
+			Exception: req.Status,
+		}
+	}
+	limit, offset, zerr := normalizePage(req.Limit, req.Offset)
+	if zerr != nil {
+		return nil, zerr
+	}
+
+	xres, total, err := s.queue.Search(ctx, &jobs.Filter{
+		TenantID: core.TenantFromContext(ctx),
+		Kind:     req.Kind,
+		Status:   req.Status,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	items := make([]share.ZJob, 0, len(xres))
+	for i := range xres {
+		items = append(items, toZJob(&xres[i]))
+	}
+	return &share.ZJobPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// RetryJob возвращает задачу из dead в очередь с обнуленным счетчиком попыток.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - job_id: идентификатор задачи
+//
+// Возвращает:
+//   - указатель на структуру ZJob с обновленной задачей
+//   - указатель на структуру ZError с кодом 404, если задачи нет или она не в статусе dead
+func (s *AuthUseCase) RetryJob(ctx context.Context, job_id string) (*share.ZJob, *core.ZError) {
+	id, err := strconv.ParseInt(job_id, 10, 64)
+	if err != nil {
+		return nil, repoError(&core.ErrJobNotFound{ErrMessage: err.Error()})
+	}
+
+	xres, err := s.queue.Retry(ctx, core.TenantFromContext(ctx), id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	res := toZJob(xres)
+	return &res, nil
+}
+
+// ----------- Tools -----------
+
+// toZJob переводит задачу очереди в DTO без данных задачи: в них могут быть коды подтверждения.
+func toZJob(x *jobs.Job) share.ZJob {
+	return share.ZJob{
+		ID:          x.ID,
+		Kind:        x.Kind,
+		Status:      x.Status,
+		Attempts:    x.Attempts,
+		MaxAttempts: x.MaxAttempts,
+		RunAt:       x.RunAt,
+		LockedBy:    x.LockedBy,
+		LastError:   x.LastError,
+		FinishedAt:  x.FinishedAt,
+		CreatedAt:   x.CreatedAt,
+		UpdatedAt:   x.UpdatedAt,
+	}
+}
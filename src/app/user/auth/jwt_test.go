@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/user/auth/configs"
+)
+
+const testIssuer = "auth.test"
+
+// testKeys генерирует пару ключей алгоритма в PEM: приватный в PKCS#8, публичный в PKIX
+func testKeys(t *testing.T, algorithm string) (string, string) {
+	t.Helper()
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case configs.JWTRS512:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case configs.JWTES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case configs.JWTEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("unknown algorithm %q", algorithm)
+	}
+	if err != nil {
+		t.Fatalf("generate %s key: %s", algorithm, err)
+	}
+
+	private_der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("marshal private key: %s", err)
+	}
+	public_der, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		t.Fatalf("marshal public key: %s", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private_der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public_der}))
+}
+
+func TestJWTIssuer(t *testing.T) {
+	ctx := context.Background()
+	private_key, public_key := testKeys(t, configs.JWTES256)
+
+	token, err := CreateJWT(ctx, map[string]interface{}{"sub": "account"}, private_key, configs.JWTES256, testIssuer, time.Minute)
+	if err != nil {
+		t.Fatalf("CreateJWT: %s", err)
+	}
+	payload, err := DecodeJWT(ctx, token, public_key, configs.JWTES256, testIssuer)
+	if err != nil {
+		t.Fatalf("DecodeJWT: %s", err)
+	}
+	if payload["iss"] != testIssuer {
+		t.Errorf("iss = %v, want %q", payload["iss"], testIssuer)
+	}
+
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		issuer  string
+	}{
+		{"other issuer", nil, "other.test"},
+		{"empty issuer", map[string]interface{}{"iss": ""}, testIssuer},
+		{"issuer overridden by payload", map[string]interface{}{"iss": "other.test"}, testIssuer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := CreateJWT(ctx, tt.payload, private_key, configs.JWTES256, tt.issuer, time.Minute)
+			if err != nil {
+				t.Fatalf("CreateJWT: %s", err)
+			}
+			var incorrect *core.ErrIncorrectJwt
+			if _, err := DecodeJWT(ctx, token, public_key, configs.JWTES256, testIssuer); !errors.As(err, &incorrect) {
+				t.Errorf("DecodeJWT = %v, want ErrIncorrectJwt", err)
+			}
+		})
+	}
+}
+
+func TestJWTAlgorithms(t *testing.T) {
+	ctx := context.Background()
+	for _, algorithm := range []string{configs.JWTRS512, configs.JWTES256, configs.JWTEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			private_key, public_key := testKeys(t, algorithm)
+			token, err := CreateJWT(ctx, map[string]interface{}{"sub": "account", "type": "access"}, private_key, algorithm, testIssuer, time.Minute)
+			if err != nil {
+				t.Fatalf("CreateJWT: %s", err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified: %s", err)
+			}
+			if parsed.Header["alg"] != algorithm {
+				t.Errorf("alg = %v, want %s", parsed.Header["alg"], algorithm)
+			}
+
+			payload, err := DecodeJWT(ctx, token, public_key, algorithm, testIssuer)
+			if err != nil {
+				t.Fatalf("DecodeJWT: %s", err)
+			}
+			if payload["sub"] != "account" || payload["type"] != "access" {
+				t.Errorf("payload = %v", payload)
+			}
+
+			// Ключ не подходит к другому алгоритму
+			for _, other := range []string{configs.JWTRS512, configs.JWTES256, configs.JWTEdDSA} {
+				if other == algorithm {
+					continue
+				}
+				if _, err := CreateJWT(ctx, nil, private_key, other, testIssuer, time.Minute); err == nil {
+					t.Errorf("CreateJWT with %s key as %s = nil error", algorithm, other)
+				}
+				if _, err := DecodeJWT(ctx, token, public_key, other, testIssuer); err == nil {
+					t.Errorf("DecodeJWT with %s key as %s = nil error", algorithm, other)
+				}
+			}
+		})
+	}
+}
+
+func TestJWTWrongAlgorithm(t *testing.T) {
+	ctx := context.Background()
+	_, public_key := testKeys(t, configs.JWTES256)
+	claims := jwt.MapClaims{"sub": "account", "iss": testIssuer, "exp": time.Now().Add(time.Minute).Unix()}
+
+	// HS256 с публичным ключом в качестве секрета
+	hs, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(public_key))
+	if err != nil {
+		t.Fatalf("sign HS256: %s", err)
+	}
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("sign none: %s", err)
+	}
+	// Другой вариант ECDSA не принимается, даже если подпись корректна
+	other, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	es384, err := jwt.NewWithClaims(jwt.SigningMethodES384, claims).SignedString(other)
+	if err != nil {
+		t.Fatalf("sign ES384: %s", err)
+	}
+
+	for name, token := range map[string]string{"HS256": hs, "none": none, "ES384": es384} {
+		var incorrect *core.ErrIncorrectJwt
+		if _, err := DecodeJWT(ctx, token, public_key, configs.JWTES256, testIssuer); !errors.As(err, &incorrect) {
+			t.Errorf("DecodeJWT(%s) = %v, want ErrIncorrectJwt", name, err)
+		}
+	}
+}
+
+func TestJWTExpired(t *testing.T) {
+	ctx := context.Background()
+	private_key, public_key := testKeys(t, configs.JWTEdDSA)
+	token, err := CreateJWT(ctx, nil, private_key, configs.JWTEdDSA, testIssuer, -time.Minute)
+	if err != nil {
+		t.Fatalf("CreateJWT: %s", err)
+	}
+	var expired *core.ErrJwtExpired
+	if _, err := DecodeJWT(ctx, token, public_key, configs.JWTEdDSA, testIssuer); !errors.As(err, &expired) {
+		t.Errorf("DecodeJWT = %v, want ErrJwtExpired", err)
+	}
+}
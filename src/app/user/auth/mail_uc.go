@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/jobs"
+	"github.com/MedodsTechTask/app/mail"
+)
+
+// JobMailSend - тип фоновой задачи отправки письма
+const JobMailSend = "mail.send"
+
+// mailPayload - данные задачи отправки письма; шаблон рендерится воркером на языке, запомненном при постановке
+type mailPayload struct {
+	To       string          `json:"to"`
+	Template string          `json:"template"`
+	Locale   string          `json:"locale"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// RegisterJobs регистрирует обработчики фоновых задач сервиса авторизации.
+//
+// Параметры:
+//   - w: воркер очереди задач
+func (s *AuthUseCase) RegisterJobs(w *jobs.Worker) {
+	w.Handle(JobMailSend, s.handleMailJob)
+	w.Handle(JobWebhookDeliver, s.handleWebhookJob)
+}
+
+// mailJob готовит задачу отправки письма на языке клиента запроса. Сама задача ставится вызывающим,
+// обычно в одной транзакции с записью, к которой относится письмо; после фиксации вызывающий учитывает
+// письмо через mailSent, поэтому неудачные и отклоненные запросы не расходуют лимит получателя.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - to: адрес получателя
+//   - template: имя шаблона, одна из констант mail.Template*
+//   - data: данные шаблона
+//
+// Возвращает:
+//   - задачу для постановки в очередь
+//   - указатель на структуру ZError с кодом 429, если получателю отправлено слишком много писем,
+//     или 503, если хранилище счетчиков недоступно и fail_open выключен
+func (s *AuthUseCase) mailJob(ctx context.Context, to string, template string, data any) (jobs.NewJob, *core.ZError) {
+	allowed, err := s.conf(ctx).throttle.Allow(ctx, to)
+	if err != nil {
+		log.Printf("mail throttle: %s", err)
+		if !allowed {
+			return jobs.NewJob{}, &core.ZError{
+				Code:      503,
+				Where:     "UseCase/Mail",
+				Message:   "Ограничение частоты писем недоступно",
+				Exception: nil,
+			}
+		}
+	}
+	if !allowed {
+		return jobs.NewJob{}, &core.ZError{
+			Code:      429,
+			Where:     "UseCase/Mail",
+			Message:   "Слишком много писем на этот адрес, попробуйте позже",
+			Exception: nil,
+		}
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return jobs.NewJob{}, &core.ZError{
+			Code:      500,
+			Where:     "UseCase/Mail",
+			Message:   "Ошибка подготовки письма",
+			Exception: err.Error(),
+		}
+	}
+
+	return jobs.NewJob{
+		TenantID: core.TenantFromContext(ctx),
+		Kind:     JobMailSend,
+		Payload: mailPayload{
+			To:       to,
+			Template: template,
+			Locale:   core.ClientFromContext(ctx).Locale,
+			Data:     raw,
+		},
+	}, nil
+}
+
+// notifySecurity ставит в очередь уведомление владельцу аккаунта о событии безопасности.
+// Уведомление не должно срывать основное действие, поэтому ошибка только логируется.
+//
+// Параметры:
+//   - ctx: контекст запроса, из него берутся IP и User-Agent
+//   - email: адрес владельца аккаунта
+//   - event: событие, одна из констант mail.Notice*
+func (s *AuthUseCase) notifySecurity(ctx context.Context, email string, event string) {
+	client := core.ClientFromContext(ctx)
+	job, zerr := s.mailJob(ctx, email, mail.TemplateSecurityNotice, mail.NoticeData{
+		Email:     email,
+		Event:     event,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Time:      time.Now(),
+	})
+	if zerr != nil {
+		log.Printf("security notice %s: %s", event, zerr.Message)
+		return
+	}
+	if _, err := s.queue.Enqueue(ctx, job); err != nil {
+		log.Printf("security notice %s: %s", event, err)
+		return
+	}
+	s.mailSent(ctx, email)
+}
+
+// mailSent учитывает письмо в лимите получателя, когда задача отправки уже поставлена в очередь.
+// Ошибка хранилища счетчиков не отменяет письмо и только логируется.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - to: адрес получателя
+func (s *AuthUseCase) mailSent(ctx context.Context, to string) {
+	if err := s.conf(ctx).throttle.Sent(ctx, to); err != nil {
+		log.Printf("mail throttle: %s", err)
+	}
+}
+
+// handleMailJob рендерит и отправляет письмо из задачи очереди.
+// Ошибки данных и шаблона не исправятся повтором, поэтому такие задачи сразу уходят в dead.
+func (s *AuthUseCase) handleMailJob(ctx context.Context, job *jobs.Job) error {
+	var payload mailPayload
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+
+	var data any
+	switch payload.Template {
+	case mail.TemplateConfirmEmail:
+		data = &mail.ConfirmData{}
+	case mail.TemplatePasswordReset:
+		data = &mail.ResetData{}
+	case mail.TemplateSecurityNotice:
+		data = &mail.NoticeData{}
+	default:
+		return jobs.Permanent(fmt.Errorf("unknown mail template %q", payload.Template))
+	}
+	if err := json.Unmarshal(payload.Data, data); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	msg, err := mail.Render(payload.Locale, s.conf(ctx).cfg.Mail.Locale, payload.Template, payload.To, data)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	return s.mailer.Send(ctx, msg)
+}
@@ -0,0 +1,357 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/email"
+	"github.com/MedodsTechTask/app/proxy"
+	"github.com/MedodsTechTask/app/ratelimit"
+)
+
+// ClaimsKey - ключ gin.Context, под которым лежит полезная нагрузка проверенного access токена
+const ClaimsKey = "auth.claims"
+
+// ConfigSnapshot возвращает middleware, которое закрепляет за запросом текущую конфигурацию и ключи: перезагрузка
+// конфигурации во время запроса не меняет их до его конца. Должно стоять первым.
+//
+// Возвращает:
+//   - gin.HandlerFunc
+func (h *API) ConfigSnapshot() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(h.uc.WithSettings(c.Request.Context()))
+		c.Next()
+	}
+}
+
+// BodyLimit возвращает middleware, которое ограничивает размер тела запроса значением max_body_bytes: запрос с большим
+// Content-Length сразу получает 413, а тело без длины (chunked) обрывается на пределе и не разбирается.
+//
+// Возвращает:
+//   - gin.HandlerFunc
+func (h *API) BodyLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := h.uc.conf(c.Request.Context()).cfg.MaxBodyBytes
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, &core.ZError{
+				Code:      413,
+				Where:     "Middleware",
+				Message:   "Слишком большое тело запроса",
+				Exception: limit,
+			})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// ResolveTenant возвращает middleware, которое определяет тенант запроса по заголовку X-Tenant-ID,
+// хосту или конфигурации и сохраняет его в контексте запроса (core.WithTenant).
+//
+// Возвращает:
+//   - gin.HandlerFunc, прерывающий запрос с кодом 404, если тенант из заголовка не найден
+func (h *API) ResolveTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		host := c.Request.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+
+		tenant_id, err := h.uc.ResolveTenant(c.Request.Context(), strings.TrimSpace(c.GetHeader(core.TenantHeader)), strings.ToLower(host))
+		if err != nil {
+			c.AbortWithStatusJSON(err.Code, err)
+			return
+		}
+
+		c.Request = c.Request.WithContext(core.WithTenant(c.Request.Context(), tenant_id))
+		c.Next()
+	}
+}
+
+// ClientInfo возвращает middleware, которое сохраняет IP-адрес, User-Agent и язык клиента в контексте запроса
+// (core.WithClient, core.WithLocale), чтобы use case мог записать их в журнал безопасности, привязать к ним сессию
+// и выбрать язык писем. Адрес клиента берется из заголовков прокси, только если запрос пришел от доверенного прокси.
+//
+// Параметры:
+//   - resolver: доверенные прокси
+//
+// Возвращает:
+//   - gin.HandlerFunc
+func (h *API) ClientInfo(resolver *proxy.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := core.WithClient(c.Request.Context(), resolver.ClientAddress(c.Request), c.GetHeader("User-Agent"))
+		c.Request = c.Request.WithContext(core.WithLocale(ctx, c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+// Authenticate возвращает middleware, которое проверяет access токен из заголовка
+// "Authorization: Bearer <token>" и сохраняет его полезную нагрузку в gin.Context под ключом ClaimsKey.
+//
+// Возвращает:
+//   - gin.HandlerFunc, прерывающий запрос с кодом 401, если токен отсутствует или недействителен
+func (h *API) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := h.authenticate(c); !ok {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission возвращает middleware, которое пропускает запрос только если в access токене
+// есть указанное разрешение. Если токен еще не проверен предыдущим middleware, проверяет его сам.
+//
+// Параметры:
+//   - perm: код требуемого разрешения, например core.PermRBACManage
+//
+// Возвращает:
+//   - gin.HandlerFunc, прерывающий запрос с кодом 401 без валидного токена и 403 без разрешения
+func (h *API) RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := h.authenticate(c)
+		if !ok {
+			return
+		}
+		if !HasPermission(payload, perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, &core.ZError{
+				Code:      403,
+				Where:     "Middleware",
+				Message:   "Недостаточно прав",
+				Exception: perm,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// DenyImpersonation возвращает middleware, которое отклоняет запросы с токеном, выданным при входе
+// администратора под пользователем. Ставится после Authenticate на действия, недоступные в таких сессиях.
+//
+// Возвращает:
+//   - gin.HandlerFunc, прерывающий запрос с кодом 401 без валидного токена и 403 для сессии входа под пользователем
+func (h *API) DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := h.authenticate(c)
+		if !ok {
+			return
+		}
+		if admin_id, impersonated := Impersonator(payload); impersonated {
+			c.AbortWithStatusJSON(http.StatusForbidden, &core.ZError{
+				Code:      403,
+				Where:     "Middleware",
+				Message:   "Действие недоступно при входе под пользователем",
+				Exception: admin_id,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Claims возвращает полезную нагрузку access токена, сохраненную middleware Authenticate или RequirePermission.
+//
+// Параметры:
+//   - c: контекст запроса gin
+//
+// Возвращает:
+//   - карту с полезной нагрузкой токена
+//   - false, если запрос не проходил проверку токена
+func Claims(c *gin.Context) (map[string]interface{}, bool) {
+	val, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	payload, ok := val.(map[string]interface{})
+	return payload, ok
+}
+
+// authenticate проверяет access токен запроса, если это еще не сделано, и прерывает запрос с кодом 401 при ошибке.
+func (h *API) authenticate(c *gin.Context) (map[string]interface{}, bool) {
+	if payload, ok := Claims(c); ok {
+		return payload, true
+	}
+
+	header := c.GetHeader("Authorization")
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || strings.TrimSpace(token) == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, &core.ZError{
+			Code:      401,
+			Where:     "Middleware",
+			Message:   "Требуется авторизация",
+			Exception: nil,
+		})
+		return nil, false
+	}
+
+	payload, err := h.uc.Authorize(c.Request.Context(), strings.TrimSpace(token))
+	if err != nil {
+		c.AbortWithStatusJSON(err.Code, err)
+		return nil, false
+	}
+
+	setClaims(c, payload)
+	return payload, true
+}
+
+// setClaims сохраняет полезную нагрузку проверенного access токена в gin.Context и исполнителя запроса в контексте.
+func setClaims(c *gin.Context, payload map[string]interface{}) {
+	// Исполнителем в журнале считается администратор, если токен выдан при входе под пользователем
+	actor_id, impersonated := Impersonator(payload)
+	if !impersonated {
+		actor_id, _ = payload["sub"].(string)
+	}
+	c.Request = c.Request.WithContext(core.WithActor(c.Request.Context(), actor_id))
+
+	c.Set(ClaimsKey, payload)
+}
+
+// rateLimitBodyMax - сколько байт тела запроса читается, чтобы найти аккаунт для лимита
+const rateLimitBodyMax = 64 << 10
+
+// RateLimit возвращает middleware, которое ограничивает частоту запросов по правилам маршрута из конфигурации
+// и отдает заголовки RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset и RateLimit-Policy по самому
+// строгому из правил. Счетчики раздельны по тенанту. Правила берутся из настроек запроса, поэтому применяются
+// при перезагрузке конфигурации. Ставится после ConfigSnapshot, ResolveTenant и ClientInfo.
+//
+// Возвращает:
+//   - gin.HandlerFunc, прерывающий запрос с кодом 429 и заголовком Retry-After при превышении лимита
+//     и с кодом 503, если хранилище недоступно и FailOpen выключен
+func (h *API) RateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter := h.uc.conf(c.Request.Context()).limiter
+		route := c.Request.Method + " " + strings.TrimPrefix(c.FullPath(), core.BasePath)
+		rules := limiter.Rules(route)
+		if len(rules) == 0 {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		tenant_id := core.TenantFromContext(ctx)
+		ip := core.ClientFromContext(ctx).IP
+		account, account_resolved := "", false
+
+		var tightest *ratelimit.Result
+		policies := make([]string, 0, len(rules))
+		for _, rule := range rules {
+			subject := "ip:" + ip
+			if rule.By == ratelimit.ByAccount {
+				if !account_resolved {
+					account, account_resolved = h.rateLimitAccount(c), true
+				}
+				if account != "" {
+					subject = account
+				}
+			}
+
+			res, err := limiter.Allow(ctx, route+"|"+rule.Name+"|"+tenant_id+"|"+subject, rule)
+			if err != nil {
+				log.Printf("rate limit %s: %s", route, err)
+				if limiter.FailOpen() {
+					continue
+				}
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, &core.ZError{
+					Code:      503,
+					Where:     "Middleware",
+					Message:   "Ограничение частоты запросов недоступно",
+					Exception: nil,
+				})
+				return
+			}
+
+			policies = append(policies, strconv.Itoa(rule.Limit)+";w="+strconv.Itoa(int(rule.Window.Seconds()))+`;name="`+rule.Name+`"`)
+			if tightest == nil || !res.Allowed || res.Remaining < tightest.Remaining {
+				tightest = &res
+			}
+			// Отклоненный запрос не расходует лимиты остальных правил
+			if !res.Allowed {
+				break
+			}
+		}
+		if tightest == nil {
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(tightest.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(int(tightest.Reset.Seconds())))
+		c.Header("RateLimit-Policy", strings.Join(policies, ", "))
+		if !tightest.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(tightest.RetryAfter.Seconds())))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, &core.ZError{
+				Code:      429,
+				Where:     "Middleware",
+				Message:   "Слишком много запросов",
+				Exception: tightest.RetryAfter.String(),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitAccount определяет аккаунт запроса для лимитов по аккаунту: по access токену, если он действителен,
+// иначе по email, signup_id или refresh_token из JSON тела. Тело после чтения возвращается в запрос.
+//
+// Параметры:
+//   - c: контекст запроса
+//
+// Возвращает:
+//   - ключ аккаунта; пустая строка, если аккаунт не определить
+func (h *API) rateLimitAccount(c *gin.Context) string {
+	if token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found && strings.TrimSpace(token) != "" {
+		// Недействительный токен отклонит Authenticate, здесь он просто не дает аккаунта
+		if payload, err := h.uc.Authorize(c.Request.Context(), strings.TrimSpace(token)); err == nil {
+			setClaims(c, payload)
+			if sub, _ := payload["sub"].(string); sub != "" {
+				return "account:" + sub
+			}
+		}
+	}
+
+	if c.Request.Body == nil || !strings.HasPrefix(c.ContentType(), "application/json") {
+		return ""
+	}
+	head, err := io.ReadAll(io.LimitReader(c.Request.Body, rateLimitBodyMax))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), c.Request.Body), c.Request.Body}
+	if err != nil {
+		return ""
+	}
+
+	var body struct {
+		Email        string `json:"email"`
+		SignupID     string `json:"signup_id"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if json.Unmarshal(head, &body) != nil {
+		return ""
+	}
+	switch {
+	case body.Email != "":
+		return "email:" + email.Canonical(body.Email)
+	case body.SignupID != "":
+		return "signup:" + body.SignupID
+	case body.RefreshToken != "":
+		sum := sha256.Sum256([]byte(body.RefreshToken))
+		return "refresh:" + hex.EncodeToString(sum[:16])
+	}
+	return ""
+}
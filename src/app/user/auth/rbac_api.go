@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MedodsTechTask/app/core"
+	share "github.com/MedodsTechTask/app/user/auth/share"
+)
+
+func (h *API) setupRBACRoutes(r *gin.RouterGroup) {
+	r.GET(core.UserAuthRoles, h.RequirePermission(core.PermRBACRead), h.getRoles)
+	r.POST(core.UserAuthRoles, h.RequirePermission(core.PermRBACManage), h.DenyImpersonation(), h.createRole)
+	r.POST(core.UserAuthRolePermissions, h.RequirePermission(core.PermRBACManage), h.DenyImpersonation(), h.grantPermission)
+	r.DELETE(core.UserAuthRolePermission, h.RequirePermission(core.PermRBACManage), h.DenyImpersonation(), h.revokePermission)
+	r.GET(core.UserAuthPermissions, h.RequirePermission(core.PermRBACRead), h.getPermissions)
+	r.POST(core.UserAuthPermissions, h.RequirePermission(core.PermRBACManage), h.DenyImpersonation(), h.createPermission)
+	r.GET(core.UserAuthAccountRoles, h.RequirePermission(core.PermRBACRead), h.getAccountAccess)
+	r.POST(core.UserAuthAccountRoles, h.RequirePermission(core.PermRBACManage), h.DenyImpersonation(), h.assignRole)
+	r.DELETE(core.UserAuthAccountRole, h.RequirePermission(core.PermRBACManage), h.DenyImpersonation(), h.unassignRole)
+}
+
+// @Summary Список ролей
+// @Description Возвращает все роли вместе с выданными им разрешениями. Требует разрешение rbac:read
+// @Tags RBAC
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} share.ZRole
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/roles [get]
+func (h *API) getRoles(c *gin.Context) {
+	res, err := h.uc.GetRoles(c.Request.Context())
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Создание роли
+// @Description Создает новую роль без разрешений. Требует разрешение rbac:manage
+// @Tags RBAC
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body share.QRole true "Данные роли"
+// @Success 200 {object} share.ZRole
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/roles [post]
+func (h *API) createRole(c *gin.Context) {
+	var req share.QRole
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.CreateRole(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Выдача разрешения роли
+// @Description Выдает роли разрешение по коду. Аккаунты получат его в токене при следующем входе или рефреше. Требует разрешение rbac:manage
+// @Tags RBAC
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param role_id path string true "ID роли"
+// @Param request body share.QGrantPermission true "Код разрешения"
+// @Success 200 {object} share.ZOk
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/roles/{role_id}/permissions [post]
+func (h *API) grantPermission(c *gin.Context) {
+	var req share.QGrantPermission
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.GrantPermission(c.Request.Context(), c.Param("role_id"), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Отзыв разрешения у роли
+// @Description Отзывает у роли разрешение по коду. Требует разрешение rbac:manage
+// @Tags RBAC
+// @Produce json
+// @Security BearerAuth
+// @Param role_id path string true "ID роли"
+// @Param code path string true "Код разрешения"
+// @Success 200 {object} share.ZOk
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/roles/{role_id}/permissions/{code} [delete]
+func (h *API) revokePermission(c *gin.Context) {
+	res, err := h.uc.RevokePermission(c.Request.Context(), c.Param("role_id"), c.Param("code"))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Список разрешений
+// @Description Возвращает все зарегистрированные разрешения. Требует разрешение rbac:read
+// @Tags RBAC
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} share.ZPermission
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/permissions [get]
+func (h *API) getPermissions(c *gin.Context) {
+	res, err := h.uc.GetPermissions(c.Request.Context())
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Создание разрешения
+// @Description Регистрирует новое разрешение, которое затем можно выдавать ролям. Требует разрешение rbac:manage
+// @Tags RBAC
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body share.QPermission true "Данные разрешения"
+// @Success 200 {object} share.ZPermission
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/permissions [post]
+func (h *API) createPermission(c *gin.Context) {
+	var req share.QPermission
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.CreatePermission(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Роли и разрешения аккаунта
+// @Description Возвращает роли аккаунта и объединение их разрешений. Требует разрешение rbac:read
+// @Tags RBAC
+// @Produce json
+// @Security BearerAuth
+// @Param account_id path string true "ID аккаунта"
+// @Success 200 {object} share.ZAccountAccess
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/accounts/{account_id}/roles [get]
+func (h *API) getAccountAccess(c *gin.Context) {
+	res, err := h.uc.GetAccountAccess(c.Request.Context(), c.Param("account_id"))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Назначение роли аккаунту
+// @Description Назначает роль аккаунту и возвращает его актуальные роли и разрешения. Требует разрешение rbac:manage
+// @Tags RBAC
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param account_id path string true "ID аккаунта"
+// @Param request body share.QAssignRole true "ID роли"
+// @Success 200 {object} share.ZAccountAccess
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/accounts/{account_id}/roles [post]
+func (h *API) assignRole(c *gin.Context) {
+	var req share.QAssignRole
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.AssignRole(c.Request.Context(), c.Param("account_id"), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Снятие роли с аккаунта
+// @Description Снимает роль с аккаунта и возвращает его актуальные роли и разрешения. Требует разрешение rbac:manage
+// @Tags RBAC
+// @Produce json
+// @Security BearerAuth
+// @Param account_id path string true "ID аккаунта"
+// @Param role_id path string true "ID роли"
+// @Success 200 {object} share.ZAccountAccess
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/accounts/{account_id}/roles/{role_id} [delete]
+func (h *API) unassignRole(c *gin.Context) {
+	res, err := h.uc.UnassignRole(c.Request.Context(), c.Param("account_id"), c.Param("role_id"))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/user/auth/configs"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+)
+
+// testUseCase создает use case с репозиторием fake и ключами ES256
+func testUseCase(t *testing.T, fake repo.IAuthRepo) *AuthUseCase {
+	t.Helper()
+	private_key, public_key := testKeys(t, configs.JWTES256)
+	uc := &AuthUseCase{repo: fake}
+	uc.live.Store(&settings{cfg: &configs.Config{
+		JWTAlgorithm:     configs.JWTES256,
+		JWTIssuer:        testIssuer,
+		JWTPrivateKey:    private_key,
+		JWTPublicKey:     public_key,
+		AccessTokenTTL:   time.Minute,
+		ImpersonationTTL: time.Minute,
+	}})
+	return uc
+}
+
+// testToken подписывает токен ключом use case
+func testToken(t *testing.T, uc *AuthUseCase, payload map[string]interface{}) string {
+	t.Helper()
+	cfg := uc.live.Load().cfg
+	token, err := CreateJWT(context.Background(), payload, cfg.JWTPrivateKey, cfg.JWTAlgorithm, cfg.JWTIssuer, time.Minute)
+	if err != nil {
+		t.Fatalf("CreateJWT: %s", err)
+	}
+	return token
+}
+
+// serveWith выполняет запрос к маршруту, защищенному middleware, и возвращает код ответа
+func serveWith(middleware gin.HandlerFunc, tenant_id string, token string) int {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(core.WithTenant(c.Request.Context(), tenant_id))
+	})
+	r.GET("/", middleware, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRequirePermission(t *testing.T) {
+	uc := testUseCase(t, nil)
+	h := NewAPI(uc)
+
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		code    int
+	}{
+		{"granted", map[string]interface{}{"type": "access", "sub": "acc", "permissions": []string{core.PermRBACManage}}, http.StatusNoContent},
+		{"other permission", map[string]interface{}{"type": "access", "sub": "acc", "permissions": []string{"accounts:read"}}, http.StatusForbidden},
+		{"no permissions", map[string]interface{}{"type": "access", "sub": "acc"}, http.StatusForbidden},
+		{"refresh type", map[string]interface{}{"type": "refresh", "sub": "acc", "permissions": []string{core.PermRBACManage}}, http.StatusUnauthorized},
+		{"no token", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := ""
+			if tt.payload != nil {
+				token = testToken(t, uc, tt.payload)
+			}
+			if code := serveWith(h.RequirePermission(core.PermRBACManage), core.DefaultTenantID, token); code != tt.code {
+				t.Errorf("status = %d, want %d", code, tt.code)
+			}
+		})
+	}
+}
+
+func TestClaimStrings(t *testing.T) {
+	payload := map[string]interface{}{
+		"decoded": []interface{}{"a", 1, "b"},
+		"plain":   []string{"c"},
+		"other":   "d",
+	}
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"decoded", []string{"a", "b"}},
+		{"plain", []string{"c"}},
+		{"other", []string{}},
+		{"missing", []string{}},
+	}
+	for _, tt := range tests {
+		if got := ClaimStrings(payload, tt.key); !slices.Equal(got, tt.want) {
+			t.Errorf("ClaimStrings(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,446 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+// CreateRole создает новую роль тенанта запроса без разрешений.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - req: структура с именем и описанием роли
+//
+// Возвращает:
+//   - указатель на структуру ZRole с данными созданной роли
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) CreateRole(ctx context.Context, req *share.QRole) (_ *share.ZRole, zerr *core.ZError) {
+	defer func() { s.audit(ctx, AuditAdminRoleCreate, zerr, "", "", map[string]string{"name": req.Name}) }()
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Имя роли не может быть пустым",
+			Exception: nil,
+		}
+	}
+
+	xres, err := s.repo.CreateRole(ctx, core.TenantFromContext(ctx), name, req.Description)
+	if err != nil {
+		switch e := err.(type) {
+		case *core.ErrCreateRole:
+			return nil, &core.ZError{
+				Code:      400,
+				Where:     "Repo",
+				Message:   "Роль с таким именем уже существует в тенанте",
+				Exception: e.ErrMessage,
+			}
+		default:
+			return nil, &core.ZError{
+				Code:      500,
+				Where:     "Repo",
+				Message:   "Неизвестная ошибка базы данных",
+				Exception: err.Error(),
+			}
+		}
+	}
+
+	return toZRole(xres), nil
+}
+
+// GetRoles возвращает роли тенанта запроса вместе с их разрешениями.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//
+// Возвращает:
+//   - список структур ZRole
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка базы данных
+func (s *AuthUseCase) GetRoles(ctx context.Context) ([]share.ZRole, *core.ZError) {
+	xres, err := s.repo.GetRoles(ctx, core.TenantFromContext(ctx))
+	if err != nil {
+		return nil, &core.ZError{
+			Code:      500,
+			Where:     "Repo",
+			Message:   "Неизвестная ошибка базы данных",
+			Exception: err.Error(),
+		}
+	}
+
+	res := make([]share.ZRole, 0, len(xres))
+	for i := range xres {
+		res = append(res, *toZRole(&xres[i]))
+	}
+	return res, nil
+}
+
+// CreatePermission регистрирует новое разрешение тенанта запроса.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - req: структура с кодом и описанием разрешения
+//
+// Возвращает:
+//   - указатель на структуру ZPermission с данными созданного разрешения
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) CreatePermission(ctx context.Context, req *share.QPermission) (_ *share.ZPermission, zerr *core.ZError) {
+	defer func() { s.audit(ctx, AuditAdminPermissionCreate, zerr, "", "", map[string]string{"code": req.Code}) }()
+
+	code := strings.TrimSpace(req.Code)
+	if code == "" || strings.ContainsAny(code, " \t") {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Код разрешения не может быть пустым или содержать пробелы",
+			Exception: nil,
+		}
+	}
+
+	xres, err := s.repo.CreatePermission(ctx, core.TenantFromContext(ctx), code, req.Description)
+	if err != nil {
+		switch e := err.(type) {
+		case *core.ErrCreatePermission:
+			return nil, &core.ZError{
+				Code:      400,
+				Where:     "Repo",
+				Message:   "Разрешение с таким кодом уже существует",
+				Exception: e.ErrMessage,
+			}
+		default:
+			return nil, &core.ZError{
+				Code:      500,
+				Where:     "Repo",
+				Message:   "Неизвестная ошибка базы данных",
+				Exception: err.Error(),
+			}
+		}
+	}
+
+	return toZPermission(xres), nil
+}
+
+// GetPermissions возвращает встроенные разрешения и разрешения тенанта запроса.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//
+// Возвращает:
+//   - список структур ZPermission
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка базы данных
+func (s *AuthUseCase) GetPermissions(ctx context.Context) ([]share.ZPermission, *core.ZError) {
+	xres, err := s.repo.GetPermissions(ctx, core.TenantFromContext(ctx))
+	if err != nil {
+		return nil, &core.ZError{
+			Code:      500,
+			Where:     "Repo",
+			Message:   "Неизвестная ошибка базы данных",
+			Exception: err.Error(),
+		}
+	}
+
+	res := make([]share.ZPermission, 0, len(xres))
+	for i := range xres {
+		res = append(res, *toZPermission(&xres[i]))
+	}
+	return res, nil
+}
+
+// GrantPermission выдает роли тенанта запроса разрешение. Изменение попадет в токены аккаунтов при следующем входе или рефреше.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - role_id: идентификатор роли
+//   - req: структура с кодом разрешения
+//
+// Возвращает:
+//   - указатель на структуру ZOk, если разрешение выдано
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) GrantPermission(ctx context.Context, role_id string, req *share.QGrantPermission) (_ *share.ZOk, zerr *core.ZError) {
+	defer func() {
+		s.audit(ctx, AuditAdminPermissionGrant, zerr, "", "", map[string]string{"role_id": role_id, "code": req.Code})
+	}()
+
+	_, err := s.repo.GrantPermission(ctx, core.TenantFromContext(ctx), role_id, req.Code)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return &share.ZOk{Ok: true}, nil
+}
+
+// RevokePermission отзывает разрешение у роли тенанта запроса.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - role_id: идентификатор роли
+//   - code: код разрешения
+//
+// Возвращает:
+//   - указатель на структуру ZOk, если разрешение отозвано
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) RevokePermission(ctx context.Context, role_id string, code string) (_ *share.ZOk, zerr *core.ZError) {
+	defer func() {
+		s.audit(ctx, AuditAdminPermissionRevoke, zerr, "", "", map[string]string{"role_id": role_id, "code": code})
+	}()
+
+	_, err := s.repo.RevokePermission(ctx, core.TenantFromContext(ctx), role_id, code)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return &share.ZOk{Ok: true}, nil
+}
+
+// AssignRole назначает роль аккаунту тенанта запроса.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - account_id: идентификатор аккаунта
+//   - req: структура с идентификатором роли
+//
+// Возвращает:
+//   - указатель на структуру ZAccountAccess с актуальными ролями и разрешениями аккаунта
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) AssignRole(ctx context.Context, account_id string, req *share.QAssignRole) (_ *share.ZAccountAccess, zerr *core.ZError) {
+	defer func() {
+		s.audit(ctx, AuditAdminRoleAssign, zerr, account_id, "", map[string]string{"role_id": req.RoleID})
+	}()
+
+	_, err := s.repo.AssignRole(ctx, core.TenantFromContext(ctx), account_id, req.RoleID)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return s.GetAccountAccess(ctx, account_id)
+}
+
+// UnassignRole снимает роль с аккаунта тенанта запроса.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - account_id: идентификатор аккаунта
+//   - role_id: идентификатор роли
+//
+// Возвращает:
+//   - указатель на структуру ZAccountAccess с актуальными ролями и разрешениями аккаунта
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) UnassignRole(ctx context.Context, account_id string, role_id string) (_ *share.ZAccountAccess, zerr *core.ZError) {
+	defer func() {
+		s.audit(ctx, AuditAdminRoleUnassign, zerr, account_id, "", map[string]string{"role_id": role_id})
+	}()
+
+	_, err := s.repo.UnassignRole(ctx, core.TenantFromContext(ctx), account_id, role_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return s.GetAccountAccess(ctx, account_id)
+}
+
+// GetAccountAccess возвращает роли аккаунта тенанта запроса и объединение их разрешений.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - account_id: идентификатор аккаунта
+//
+// Возвращает:
+//   - указатель на структуру ZAccountAccess
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) GetAccountAccess(ctx context.Context, account_id string) (*share.ZAccountAccess, *core.ZError) {
+	xres, err := s.repo.GetAccountAccess(ctx, core.TenantFromContext(ctx), account_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return &share.ZAccountAccess{
+		AccountID:   xres.AccountID,
+		Roles:       xres.Roles,
+		Permissions: xres.Permissions,
+	}, nil
+}
+
+// Authorize проверяет access токен и возвращает его полезную нагрузку.
+// Токен, выпущенный в другом тенанте, отклоняется, как и токен досрочно завершенной сессии входа под пользователем.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - token: access токен из заголовка Authorization
+//
+// Возвращает:
+//   - карту с полезной нагрузкой токена
+//   - указатель на структуру ZError с кодом 401, если токен недействителен или выпущен для другого тенанта
+func (s *AuthUseCase) Authorize(ctx context.Context, token string) (map[string]interface{}, *core.ZError) {
+	payload, err := s.decodeJWT(ctx, token)
+	if err != nil {
+		switch e := err.(type) {
+		case *core.ErrJwtExpired:
+			return nil, &core.ZError{
+				Code:      401,
+				Where:     "UseCase/Security",
+				Message:   "JWT ключ истек",
+				Exception: e.ErrMessage,
+			}
+		default:
+			return nil, &core.ZError{
+				Code:      401,
+				Where:     "UseCase/Security",
+				Message:   "Неверный JWT ключ",
+				Exception: err.Error(),
+			}
+		}
+	}
+	if payload["type"] != "access" {
+		return nil, &core.ZError{
+			Code:      401,
+			Where:     "UseCase",
+			Message:   "Неверный тип JWT ключа",
+			Exception: nil,
+		}
+	}
+	if tokenTenant(payload) != core.TenantFromContext(ctx) {
+		return nil, &core.ZError{
+			Code:      401,
+			Where:     "UseCase",
+			Message:   "Токен выпущен для другого тенанта",
+			Exception: nil,
+		}
+	}
+	if zerr := s.checkImpersonation(ctx, payload); zerr != nil {
+		return nil, zerr
+	}
+	return payload, nil
+}
+
+// ----------- Tools -----------
+
+// accessPayload собирает полезную нагрузку access токена: идентификатор аккаунта, тенант, роли, разрешения
+// и роли в организациях (claim orgs: ID организации -> роль).
+// Данные читаются из базы при каждом выпуске токена, поэтому изменения применяются при следующем рефреше.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта аккаунта
+//   - acc_id: идентификатор аккаунта
+//
+// Возвращает:
+//   - карту с полезной нагрузкой для CreateJWT
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка базы данных
+func (s *AuthUseCase) accessPayload(ctx context.Context, tenant_id string, acc_id string) (map[string]interface{}, *core.ZError) {
+	access, err := s.repo.GetAccountAccess(ctx, tenant_id, acc_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	orgs, err := s.repo.GetOrganizationsForAccount(ctx, tenant_id, acc_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	org_roles := make(map[string]string, len(orgs))
+	for _, org := range orgs {
+		org_roles[org.ID] = org.Role
+	}
+
+	return map[string]interface{}{
+		"sub":         acc_id,
+		"tid":         tenant_id,
+		"type":        "access",
+		"roles":       access.Roles,
+		"permissions": access.Permissions,
+		"orgs":        org_roles,
+	}, nil
+}
+
+// tokenTenant возвращает тенант, для которого выпущен токен.
+// Токены, выпущенные до появления тенантов, не содержат claim tid и относятся к тенанту по умолчанию.
+//
+// Параметры:
+//   - payload: полезная нагрузка токена
+//
+// Возвращает:
+//   - идентификатор тенанта
+func tokenTenant(payload map[string]interface{}) string {
+	if tid, ok := payload["tid"].(string); ok && tid != "" {
+		return tid
+	}
+	return core.DefaultTenantID
+}
+
+// OrgRole возвращает роль аккаунта в организации из claim orgs access токена.
+//
+// Параметры:
+//   - payload: полезная нагрузка access токена
+//   - org_id: идентификатор организации
+//
+// Возвращает:
+//   - роль в организации и true, если аккаунт в ней состоит
+func OrgRole(payload map[string]interface{}, org_id string) (string, bool) {
+	orgs, ok := payload["orgs"].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	role, ok := orgs[org_id].(string)
+	return role, ok
+}
+
+// HasPermission проверяет, содержит ли claim permissions полезной нагрузки указанное разрешение.
+//
+// Параметры:
+//   - payload: полезная нагрузка access токена
+//   - perm: код разрешения
+//
+// Возвращает:
+//   - true, если разрешение присутствует в токене
+func HasPermission(payload map[string]interface{}, perm string) bool {
+	return slices.Contains(ClaimStrings(payload, "permissions"), perm)
+}
+
+// ClaimStrings возвращает claim полезной нагрузки в виде списка строк.
+// После декодирования JWT массивы приходят как []interface{}, поэтому элементы приводятся по одному.
+//
+// Параметры:
+//   - payload: полезная нагрузка токена
+//   - key: имя claim
+//
+// Возвращает:
+//   - список строк; пустой, если claim отсутствует или имеет другой тип
+func ClaimStrings(payload map[string]interface{}, key string) []string {
+	switch v := payload[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		res := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				res = append(res, str)
+			}
+		}
+		return res
+	}
+	return []string{}
+}
+
+// toZRole переводит роль из слоя репозитория в DTO.
+func toZRole(x *repo.XRole) *share.ZRole {
+	return &share.ZRole{
+		ID:          x.ID,
+		Name:        x.Name,
+		Description: x.Description,
+		Platform:    x.Platform,
+		Permissions: x.Permissions,
+		CreatedAt:   x.CreatedAt,
+		UpdatedAt:   x.UpdatedAt,
+	}
+}
+
+func toZPermission(x *repo.XPermission) *share.ZPermission {
+	return &share.ZPermission{
+		ID:          x.ID,
+		Code:        x.Code,
+		Description: x.Description,
+		BuiltIn:     x.BuiltIn,
+		Platform:    x.Platform,
+		CreatedAt:   x.CreatedAt,
+	}
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/session"
+	"github.com/MedodsTechTask/app/user/auth/configs"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+// refreshRepo - репозиторий с одним refresh токеном, запоминающий отзывы
+type refreshRepo struct {
+	repo.IAuthRepo
+	token   *repo.XRefreshToken
+	revoked []string
+}
+
+func (r *refreshRepo) GetRefreshToken(ctx context.Context, tenant_id string, lookup_id string) (*repo.XRefreshToken, error) {
+	if r.token == nil || r.token.TenantID != tenant_id || r.token.LookupID != lookup_id {
+		return nil, &core.ErrTokenNotFound{ErrMessage: lookup_id}
+	}
+	return r.token, nil
+}
+
+func (r *refreshRepo) RevokeRefreshToken(ctx context.Context, tenant_id string, account_id string, token_id string, reason string) (bool, error) {
+	r.revoked = append(r.revoked, "token:"+reason)
+	return true, nil
+}
+
+func (r *refreshRepo) RevokeTokenFamily(ctx context.Context, tenant_id string, account_id string, family_id string, reason string) (bool, error) {
+	r.revoked = append(r.revoked, "family:"+reason)
+	return true, nil
+}
+
+func (r *refreshRepo) RevokeToken(ctx context.Context, tenant_id string, account_id string, reason string) (bool, error) {
+	r.revoked = append(r.revoked, "account:"+reason)
+	return true, nil
+}
+
+func (r *refreshRepo) GetAccount(ctx context.Context, tenant_id string, id string) (*repo.XAccount, error) {
+	return nil, &core.ErrAccountNotFound{ErrMessage: id}
+}
+
+func (r *refreshRepo) WriteAudit(ctx context.Context, rec *repo.XAuditRecord) (*repo.XAuditRecord, error) {
+	return rec, nil
+}
+
+func TestRefreshTokenReplayFromOtherClient(t *testing.T) {
+	const (
+		bound_ip = "203.0.113.10"
+		bound_ua = "Mozilla/5.0 (X11; Linux x86_64) Chrome/120.0 Safari/537.36"
+		other_ip = "198.51.100.20"
+	)
+	replaced_by := "592af5b5-4f60-4ddd-b080-be674c86eda8"
+
+	tests := []struct {
+		name     string
+		action   string
+		replaced bool
+		code     int
+		revoked  []string
+	}{
+		{"replay under reject", session.ActionReject, true, 401, []string{"family:refresh_reuse"}},
+		{"replay under revoke family", session.ActionRevokeFamily, true, 401, []string{"family:refresh_reuse"}},
+		{"replay under reauth", session.ActionReauth, true, 401, []string{"family:refresh_reuse"}},
+		// Без повтора срабатывает действие привязки
+		{"binding reject", session.ActionReject, false, 401, []string{"token:session_binding"}},
+		{"binding revoke family", session.ActionRevokeFamily, false, 401, []string{"family:session_binding"}},
+		{"binding reauth", session.ActionReauth, false, 401, []string{"account:session_binding"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, lookup_id, token_hash, err := CreateRefreshToken()
+			if err != nil {
+				t.Fatalf("CreateRefreshToken: %s", err)
+			}
+			stored := &repo.XRefreshToken{
+				ID:        "0b5c3f2e-6f1d-4a57-9d0a-3f7e1c2b4a69",
+				TenantID:  core.DefaultTenantID,
+				AccountID: "7d1e0c6a-2f0b-4d8e-9a35-5c4b3a2d1e0f",
+				FamilyID:  "c3a1f9d2-8e4b-4f6a-b7c5-d2e1f0a9b8c7",
+				LookupID:  lookup_id,
+				TokenHash: token_hash,
+				UserAgent: bound_ua,
+				IpAddress: bound_ip,
+				ExpiresAt: time.Now().Add(time.Hour),
+			}
+			if tt.replaced {
+				stored.IsRevoked = true
+				stored.ReplacedBy = &replaced_by
+			}
+
+			binding, err := session.NewBinding(session.Config{Mode: session.ModeStrict, Action: tt.action})
+			if err != nil {
+				t.Fatalf("NewBinding: %s", err)
+			}
+			fake := &refreshRepo{token: stored}
+			uc := &AuthUseCase{repo: fake}
+			uc.live.Store(&settings{cfg: &configs.Config{}, binding: binding})
+
+			ctx := core.WithTenant(context.Background(), core.DefaultTenantID)
+			res, zerr := uc.RefreshToken(ctx, &share.QRefreshToken{RefreshToken: token}, bound_ua, other_ip)
+			if res != nil || zerr == nil || zerr.Code != tt.code {
+				t.Fatalf("RefreshToken = %+v, %+v, want error %d", res, zerr, tt.code)
+			}
+			if !slices.Equal(fake.revoked, tt.revoked) {
+				t.Errorf("revoked = %v, want %v", fake.revoked, tt.revoked)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/egress"
+	"github.com/MedodsTechTask/app/email"
+	"github.com/MedodsTechTask/app/password"
+	"github.com/MedodsTechTask/app/ratelimit"
+	"github.com/MedodsTechTask/app/session"
+	"github.com/MedodsTechTask/app/user/auth/configs"
+)
+
+// reloadConfig возвращает конфигурацию окружения test с ключами алгоритма
+func reloadConfig(t *testing.T, algorithm string, private_key string, public_key string) *configs.Config {
+	t.Helper()
+	cfg, err := configs.Defaults(configs.EnvTest)
+	if err != nil {
+		t.Fatalf("Defaults: %s", err)
+	}
+	cfg.JWTAlgorithm, cfg.JWTPrivateKey, cfg.JWTPublicKey = algorithm, private_key, public_key
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %s", err)
+	}
+	return cfg
+}
+
+// reloadUseCase собирает use case так же, как main, из компонентов первой конфигурации
+func reloadUseCase(t *testing.T, cfg *configs.Config) *AuthUseCase {
+	t.Helper()
+	passwords, err := password.NewManager(cfg.Password)
+	if err != nil {
+		t.Fatalf("NewManager: %s", err)
+	}
+	policy, _ := password.NewPolicy(cfg.PasswordPolicy)
+	emails, _ := email.NewValidator(cfg.Email)
+	binding, _ := session.NewBinding(cfg.SessionBinding)
+	limiter, err := ratelimit.NewLimiter(cfg.RateLimit, nil)
+	if err != nil {
+		t.Fatalf("NewLimiter: %s", err)
+	}
+	t.Cleanup(func() { limiter.Close() })
+	guard, _ := egress.NewGuard(cfg.WebhookAllowedNetworks)
+
+	uc := &AuthUseCase{}
+	uc.live.Store(newSettings(cfg, passwords, policy, emails, binding, nil, nil, limiter, guard))
+	return uc
+}
+
+func TestReloadRejectsBadConfig(t *testing.T) {
+	private_key, public_key := testKeys(t, configs.JWTES256)
+	_, other_public := testKeys(t, configs.JWTES256)
+	uc := reloadUseCase(t, reloadConfig(t, configs.JWTES256, private_key, public_key))
+	before := uc.live.Load()
+
+	tests := []struct {
+		name   string
+		change func(cfg *configs.Config)
+	}{
+		{"mismatched key pair", func(cfg *configs.Config) { cfg.JWTPublicKey = other_public }},
+		{"key of other algorithm", func(cfg *configs.Config) { cfg.JWTAlgorithm = configs.JWTEdDSA }},
+		{"unknown binding mode", func(cfg *configs.Config) { cfg.SessionBinding.Mode = "ip" }},
+		{"bad allowed network", func(cfg *configs.Config) { cfg.WebhookAllowedNetworks = []string{"10.0.0.0/33"} }},
+		{"unknown captcha provider", func(cfg *configs.Config) { cfg.Captcha.Provider = "unknown" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := reloadConfig(t, configs.JWTES256, private_key, public_key)
+			tt.change(cfg)
+			if _, err := uc.Reload(cfg); err == nil {
+				t.Fatal("Reload = nil error, want rejected")
+			}
+			// Прежняя конфигурация остается в силе
+			if uc.live.Load() != before {
+				t.Error("settings replaced by a rejected config")
+			}
+			if v := uc.ConfigVersion(); v.Version != 1 {
+				t.Errorf("version = %d, want 1", v.Version)
+			}
+		})
+	}
+}
+
+func TestReloadApplies(t *testing.T) {
+	private_key, public_key := testKeys(t, configs.JWTES256)
+	cfg := reloadConfig(t, configs.JWTES256, private_key, public_key)
+	uc := reloadUseCase(t, cfg)
+
+	// Та же конфигурация не меняет версию
+	if v, err := uc.Reload(reloadConfig(t, configs.JWTES256, private_key, public_key)); err != nil || v.Version != 1 {
+		t.Fatalf("Reload of the same config = %+v, %v, want version 1", v, err)
+	}
+
+	// Запрос, начатый до перезагрузки, работает с прежними настройками
+	in_flight := uc.WithSettings(context.Background())
+
+	next := reloadConfig(t, configs.JWTES256, private_key, public_key)
+	next.Listen = ":9999"
+	next.Mail.From = "other@example.com"
+	next.Mail.ThrottleLimit = 1
+	next.AccessTokenTTL = 5 * time.Minute
+	v, err := uc.Reload(next)
+	if err != nil {
+		t.Fatalf("Reload: %s", err)
+	}
+	if v.Version != 2 {
+		t.Errorf("version = %d, want 2", v.Version)
+	}
+	if want := []string{"listen", "mail"}; !slices.Equal(v.PendingRestart, want) {
+		t.Errorf("pending_restart = %v, want %v", v.PendingRestart, want)
+	}
+
+	live := uc.conf(context.Background())
+	if live.cfg.AccessTokenTTL != 5*time.Minute {
+		t.Errorf("access_token_ttl = %s, want 5m", live.cfg.AccessTokenTTL)
+	}
+	if live.cfg.Listen != cfg.Listen || live.cfg.Mail.From != cfg.Mail.From {
+		t.Errorf("restart-only values = %q, %q, want %q, %q", live.cfg.Listen, live.cfg.Mail.From, cfg.Listen, cfg.Mail.From)
+	}
+	if live.cfg.Mail.ThrottleLimit != 1 || live.throttle == uc.conf(in_flight).throttle {
+		t.Error("mail throttle limit is not applied on reload")
+	}
+	if uc.conf(in_flight).cfg.AccessTokenTTL != cfg.AccessTokenTTL {
+		t.Error("in-flight request sees the reloaded config")
+	}
+
+	// Лимит писем применяется сразу
+	ctx := core.WithTenant(context.Background(), core.DefaultTenantID)
+	live.throttle.Sent(ctx, "john@example.com")
+	if ok, _ := live.throttle.Allow(ctx, "john@example.com"); ok {
+		t.Error("reloaded throttle limit 1 allows a second mail")
+	}
+}
+
+func TestReloadKeyRotation(t *testing.T) {
+	old_private, old_public := testKeys(t, configs.JWTES256)
+	new_private, new_public := testKeys(t, configs.JWTEdDSA)
+	uc := reloadUseCase(t, reloadConfig(t, configs.JWTES256, old_private, old_public))
+	ctx := context.Background()
+
+	old_token := testToken(t, uc, map[string]interface{}{"type": "access", "sub": "acc"})
+	if _, err := uc.Reload(reloadConfig(t, configs.JWTEdDSA, new_private, new_public)); err != nil {
+		t.Fatalf("Reload: %s", err)
+	}
+	new_token := testToken(t, uc, map[string]interface{}{"type": "access", "sub": "acc"})
+
+	// Выданные до ротации токены принимаются до истечения прежнего срока access токена
+	for name, token := range map[string]string{"old key": old_token, "new key": new_token} {
+		if _, err := uc.decodeJWT(ctx, token); err != nil {
+			t.Errorf("decodeJWT(%s): %s", name, err)
+		}
+	}
+
+	st := uc.live.Load()
+	expired := *st
+	expired.retiredKeys = []retiredKey{st.retiredKeys[0]}
+	expired.retiredKeys[0].until = time.Now().Add(-time.Second)
+	uc.live.Store(&expired)
+	if _, err := uc.decodeJWT(ctx, old_token); err == nil {
+		t.Error("token of an expired retired key is accepted")
+	}
+}
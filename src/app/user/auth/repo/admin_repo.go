@@ -0,0 +1,347 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/events"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type IAdminRepo interface {
+	SearchAccounts(ctx context.Context, filter *XAccountFilter) ([]XAccount, int, error)
+	GetAccount(ctx context.Context, tenant_id string, id string) (*XAccount, error)
+	SetAccountStatus(ctx context.Context, tenant_id string, id string, status string) (*XAccount, error)
+	GetActiveSessions(ctx context.Context, tenant_id string, account_id string) ([]XRefreshToken, error)
+	SearchEmailSignups(ctx context.Context, tenant_id string, email_prefix string, limit int, offset int) ([]XEmailSignup, int, error)
+}
+
+// SearchAccounts ищет аккаунты тенанта по префиксу email без учета регистра, диапазону даты создания и статусу.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - filter: тенант, фильтры и параметры пагинации; пустые поля фильтра, кроме тенанта, не применяются
+//
+// Возвращает:
+//   - страницу аккаунтов, отсортированных от новых к старым
+//   - общее количество аккаунтов, подходящих под фильтр
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) SearchAccounts(ctx context.Context, filter *XAccountFilter) ([]XAccount, int, error) {
+	const where = `
+		WHERE True
+			AND tenant_id = $1
+			AND ($2 = '' OR email_canonical LIKE $2 || '%')
+			AND ($3::timestamp IS NULL OR created_at >= $3)
+			AND ($4::timestamp IS NULL OR created_at < $4)
+			AND ($5 = '' OR status = $5)
+	`
+	const qCount = `
+		SELECT COUNT(*)
+		FROM "Account"
+	` + where
+	const q = `
+		SELECT
+			id
+			, tenant_id
+			, email
+			, passwd_hash
+			, salt
+			, status
+			, created_at
+			, updated_at
+		FROM "Account"
+	` + where + `
+		ORDER BY created_at DESC, id
+		LIMIT $6 OFFSET $7;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	prefix := escapeLike(strings.ToLower(filter.EmailPrefix))
+
+	var total int
+	err = conn.QueryRow(ctx, qCount, filter.TenantID, prefix, filter.CreatedFrom, filter.CreatedTo, filter.Status).Scan(&total)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	rows, err := conn.Query(ctx, q, filter.TenantID, prefix, filter.CreatedFrom, filter.CreatedTo, filter.Status, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res := []XAccount{}
+	for rows.Next() {
+		var acc XAccount
+		if err := rows.Scan(&acc.ID, &acc.TenantID, &acc.Email, &acc.PasswordHash, &acc.Salt, &acc.Status, &acc.CreatedAt, &acc.UpdatedAt); err != nil {
+			return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, acc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return res, total, nil
+}
+
+// GetAccount извлекает аккаунт тенанта по идентификатору.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор аккаунта
+//
+// Возвращает:
+//   - указатель на структуру XAccount с данными аккаунта
+//   - ошибку, если аккаунт не найден или произошла ошибка при запросе к базе данных
+func (r *AuthRepo) GetAccount(ctx context.Context, tenant_id string, id string) (*XAccount, error) {
+	const q = `
+		SELECT
+			id
+			, tenant_id
+			, email
+			, passwd_hash
+			, salt
+			, status
+			, created_at
+			, updated_at
+		FROM "Account"
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2
+		LIMIT 1;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XAccount
+	err = conn.QueryRow(ctx, q, tenant_id, id).Scan(&res.ID, &res.TenantID, &res.Email, &res.PasswordHash, &res.Salt, &res.Status, &res.CreatedAt, &res.UpdatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrAccountNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// SetAccountStatus меняет статус аккаунта и в той же транзакции записывает событие account.status_changed.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор аккаунта
+//   - status: новый статус (active или blocked)
+//
+// Возвращает:
+//   - указатель на структуру XAccount с обновленными данными аккаунта
+//   - ошибку, если аккаунт не найден или произошла ошибка при запросе к базе данных
+func (r *AuthRepo) SetAccountStatus(ctx context.Context, tenant_id string, id string, status string) (*XAccount, error) {
+	const q = `
+		UPDATE "Account"
+		SET status = $3,
+		updated_at = NOW()
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2
+		RETURNING
+			id
+			, tenant_id
+			, email
+			, passwd_hash
+			, salt
+			, status
+			, created_at
+			, updated_at;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer tx.Rollback(ctx)
+
+	var res XAccount
+	err = tx.QueryRow(ctx, q, tenant_id, id, status).Scan(&res.ID, &res.TenantID, &res.Email, &res.PasswordHash, &res.Salt, &res.Status, &res.CreatedAt, &res.UpdatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrAccountNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	err = appendEvent(ctx, tx, res.TenantID, res.ID, events.AccountStatusChanged, events.StatusChangedData{
+		Status: res.Status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// GetActiveSessions возвращает неотозванные и неистекшие refresh-токены аккаунта.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - account_id: идентификатор аккаунта
+//
+// Возвращает:
+//   - список структур XRefreshToken, отсортированный от новых к старым
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) GetActiveSessions(ctx context.Context, tenant_id string, account_id string) ([]XRefreshToken, error) {
+	const q = `
+		SELECT
+			id
+			, tenant_id
+			, account_id
+			, family_id
+			, lookup_id
+			, token_hash
+			, user_agent
+			, ip_address
+			, expires_at
+			, is_revoked
+			, created_at
+			, updated_at
+		FROM "RefreshToken"
+		WHERE True
+			AND tenant_id = $1
+			AND account_id = $2
+			AND is_revoked = FALSE
+			AND expires_at > NOW()
+		ORDER BY created_at DESC;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, q, tenant_id, account_id)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res := []XRefreshToken{}
+	for rows.Next() {
+		var t XRefreshToken
+		if err := rows.Scan(&t.ID, &t.TenantID, &t.AccountID, &t.FamilyID, &t.LookupID, &t.TokenHash, &t.UserAgent, &t.IpAddress, &t.ExpiresAt, &t.IsRevoked, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return res, nil
+}
+
+// SearchEmailSignups ищет неподтвержденные регистрации тенанта по префиксу email без учета регистра.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - email_prefix: префикс email; пустая строка не ограничивает выборку
+//   - limit: размер страницы
+//   - offset: смещение страницы
+//
+// Возвращает:
+//   - страницу регистраций, отсортированных от новых к старым
+//   - общее количество регистраций, подходящих под фильтр
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) SearchEmailSignups(ctx context.Context, tenant_id string, email_prefix string, limit int, offset int) ([]XEmailSignup, int, error) {
+	const qCount = `
+		SELECT COUNT(*)
+		FROM "SignupEmail"
+		WHERE True
+			AND tenant_id = $1
+			AND ($2 = '' OR email_canonical LIKE $2 || '%');
+	`
+	const q = `
+		SELECT
+			id
+			, tenant_id
+			, email
+			, code
+			, passwd_hash
+			, salt
+			, created_at
+			, updated_at
+		FROM "SignupEmail"
+		WHERE True
+			AND tenant_id = $1
+			AND ($2 = '' OR email_canonical LIKE $2 || '%')
+		ORDER BY created_at DESC, id
+		LIMIT $3 OFFSET $4;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	prefix := escapeLike(strings.ToLower(email_prefix))
+
+	var total int
+	if err := conn.QueryRow(ctx, qCount, tenant_id, prefix).Scan(&total); err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	rows, err := conn.Query(ctx, q, tenant_id, prefix, limit, offset)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res := []XEmailSignup{}
+	for rows.Next() {
+		var signup XEmailSignup
+		if err := rows.Scan(&signup.ID, &signup.TenantID, &signup.Email, &signup.Code, &signup.PasswordHash, &signup.Salt, &signup.CreatedAt, &signup.UpdatedAt); err != nil {
+			return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, signup)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return res, total, nil
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы пользовательский ввод искался как обычный префикс.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
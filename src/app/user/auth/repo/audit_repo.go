@@ -0,0 +1,265 @@
+package repo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/jackc/pgx/v5"
+)
+
+// AuditGenesisHash - prev_hash первой записи цепочки тенанта
+var AuditGenesisHash = strings.Repeat("0", 64)
+
+type IAuditRepo interface {
+	WriteAudit(ctx context.Context, rec *XAuditRecord) (*XAuditRecord, error)
+	SearchAudit(ctx context.Context, filter *XAuditFilter) ([]XAuditRecord, int, error)
+	GetAuditChain(ctx context.Context, tenant_id string, after_seq int64, limit int) ([]XAuditRecord, error)
+}
+
+const auditColumns = `
+	seq
+	, tenant_id
+	, event
+	, outcome
+	, account_id
+	, actor_id
+	, email
+	, ip_address
+	, user_agent
+	, details
+	, prev_hash
+	, hash
+	, created_at
+`
+
+// WriteAudit дописывает запись в цепочку журнала тенанта. Записи одного тенанта пишутся строго
+// последовательно под advisory-блокировкой, каждая хранит хеш предыдущей.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - rec: запись без seq, prev_hash, hash и created_at - они заполняются здесь
+//
+// Возвращает:
+//   - указатель на сохраненную запись
+//   - ошибку core.ErrAuditInsertFailed, если запись не удалось сохранить
+func (r *AuthRepo) WriteAudit(ctx context.Context, rec *XAuditRecord) (*XAuditRecord, error) {
+	const qLock = `SELECT pg_advisory_xact_lock(hashtext($1));`
+	const qPrev = `
+		SELECT hash
+		FROM "AuditLog"
+		WHERE tenant_id = $1
+		ORDER BY seq DESC
+		LIMIT 1;
+	`
+	const qSeq = `SELECT nextval(pg_get_serial_sequence('"AuditLog"', 'seq'));`
+	const q = `
+		INSERT INTO "AuditLog"
+		(` + auditColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13);
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrAuditInsertFailed, err)
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrAuditInsertFailed, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, qLock, rec.TenantID); err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrAuditInsertFailed, err)
+	}
+
+	res := *rec
+	if res.Details == nil {
+		res.Details = map[string]string{}
+	}
+	err = tx.QueryRow(ctx, qPrev, res.TenantID).Scan(&res.PrevHash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		res.PrevHash = AuditGenesisHash
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrAuditInsertFailed, err)
+	}
+	if err := tx.QueryRow(ctx, qSeq).Scan(&res.Seq); err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrAuditInsertFailed, err)
+	}
+	res.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	res.Hash = AuditHash(&res)
+
+	_, err = tx.Exec(ctx, q, res.Seq, res.TenantID, res.Event, res.Outcome, res.AccountID, res.ActorID, res.Email, res.IpAddress, res.UserAgent, res.Details, res.PrevHash, res.Hash, res.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrAuditInsertFailed, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %v", core.ErrAuditInsertFailed, err)
+	}
+
+	return &res, nil
+}
+
+// SearchAudit ищет записи журнала тенанта по аккаунту, исполнителю, событию, результату и периоду.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - filter: тенант, фильтры и параметры пагинации; пустые фильтры, кроме тенанта, не применяются
+//
+// Возвращает:
+//   - страницу записей, отсортированных от новых к старым
+//   - общее количество записей, подходящих под фильтр
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) SearchAudit(ctx context.Context, filter *XAuditFilter) ([]XAuditRecord, int, error) {
+	const where = `
+		WHERE True
+			AND tenant_id = $1
+			AND ($2 = '' OR account_id::text = $2)
+			AND ($3 = '' OR actor_id::text = $3)
+			AND ($4 = '' OR event = $4)
+			AND ($5 = '' OR outcome = $5)
+			AND ($6::timestamp IS NULL OR created_at >= $6)
+			AND ($7::timestamp IS NULL OR created_at < $7)
+	`
+	const qCount = `
+		SELECT COUNT(*)
+		FROM "AuditLog"
+	` + where
+	const q = `
+		SELECT` + auditColumns + `
+		FROM "AuditLog"
+	` + where + `
+		ORDER BY seq DESC
+		LIMIT $8 OFFSET $9;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	args := []any{filter.TenantID, filter.AccountID, filter.ActorID, filter.Event, filter.Outcome, filter.From, filter.To}
+
+	var total int
+	if err := conn.QueryRow(ctx, qCount, args...).Scan(&total); err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	rows, err := conn.Query(ctx, q, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res, err := scanAuditRecords(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return res, total, nil
+}
+
+// GetAuditChain возвращает записи цепочки тенанта по порядку для проверки целостности.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - after_seq: номер записи, после которой начинать; 0 - с начала цепочки
+//   - limit: максимальное количество записей
+//
+// Возвращает:
+//   - список записей, отсортированный по seq
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) GetAuditChain(ctx context.Context, tenant_id string, after_seq int64, limit int) ([]XAuditRecord, error) {
+	const q = `
+		SELECT` + auditColumns + `
+		FROM "AuditLog"
+		WHERE True
+			AND tenant_id = $1
+			AND seq > $2
+		ORDER BY seq
+		LIMIT $3;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, q, tenant_id, after_seq, limit)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	return scanAuditRecords(rows)
+}
+
+// AuditHash вычисляет хеш записи журнала: SHA-256 от prev_hash и JSON с полями записи в фиксированном порядке.
+//
+// Параметры:
+//   - rec: запись журнала с заполненными seq, prev_hash и created_at
+//
+// Возвращает:
+//   - хеш в hex
+func AuditHash(rec *XAuditRecord) string {
+	details := rec.Details
+	if details == nil {
+		details = map[string]string{}
+	}
+
+	body, _ := json.Marshal(struct {
+		Seq       int64             `json:"seq"`
+		TenantID  string            `json:"tenant_id"`
+		Event     string            `json:"event"`
+		Outcome   string            `json:"outcome"`
+		AccountID *string           `json:"account_id"`
+		ActorID   *string           `json:"actor_id"`
+		Email     *string           `json:"email"`
+		IpAddress *string           `json:"ip_address"`
+		UserAgent *string           `json:"user_agent"`
+		Details   map[string]string `json:"details"`
+		CreatedAt string            `json:"created_at"`
+	}{
+		Seq:       rec.Seq,
+		TenantID:  rec.TenantID,
+		Event:     rec.Event,
+		Outcome:   rec.Outcome,
+		AccountID: rec.AccountID,
+		ActorID:   rec.ActorID,
+		Email:     rec.Email,
+		IpAddress: rec.IpAddress,
+		UserAgent: rec.UserAgent,
+		Details:   details,
+		CreatedAt: rec.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	sum := sha256.Sum256(append([]byte(rec.PrevHash), body...))
+	return hex.EncodeToString(sum[:])
+}
+
+// scanAuditRecords читает записи журнала из результата запроса с колонками auditColumns.
+func scanAuditRecords(rows pgx.Rows) ([]XAuditRecord, error) {
+	res := []XAuditRecord{}
+	for rows.Next() {
+		var rec XAuditRecord
+		err := rows.Scan(&rec.Seq, &rec.TenantID, &rec.Event, &rec.Outcome, &rec.AccountID, &rec.ActorID, &rec.Email, &rec.IpAddress, &rec.UserAgent, &rec.Details, &rec.PrevHash, &rec.Hash, &rec.CreatedAt)
+		if err != nil {
+			return nil, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return res, nil
+}
@@ -4,22 +4,41 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/events"
+	"github.com/MedodsTechTask/app/jobs"
 	"github.com/MedodsTechTask/app/user/auth/configs"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type IAuthRepo interface {
-	CreateEmailSignup(ctx context.Context, email string, passwd_hash string, code string, salt string) (*XEmailSignup, error)
+	IRBACRepo
+	IAdminRepo
+	ITenantRepo
+	IImpersonationRepo
+	IAuditRepo
+	IWebhookRepo
+	IRiskRepo
+
+	Ping(ctx context.Context) error
+	CreateEmailSignup(ctx context.Context, tenant_id string, email string, email_canonical string, passwd_hash string, code string, salt string, enqueue ...jobs.NewJob) (*XEmailSignup, error)
 	CreateAccount(ctx context.Context, req *XEmailSignup) (*XAccount, error)
-	GetEmailSignup(ctx context.Context, id string) (*XEmailSignup, error)
-	GetAccountForEmail(ctx context.Context, email string) (*XAccount, error)
-	DeleteEmailSignup(ctx context.Context, id string) (bool, error)
-	SaveRefreshToken(ctx context.Context, account_id string, user_agent string, ip_address string, token string) (*XRefreshToken, error)
-	GetRefreshTokenForAccount(ctx context.Context, account_id string, token string) (*XRefreshToken, error)
-	RevokeToken(ctx context.Context, account_id string) (bool, error)
+	GetEmailSignup(ctx context.Context, tenant_id string, id string) (*XEmailSignup, error)
+	GetAccountForEmail(ctx context.Context, tenant_id string, email_canonical string) (*XAccount, error)
+	DeleteEmailSignup(ctx context.Context, tenant_id string, id string) (bool, error)
+	SaveRefreshToken(ctx context.Context, tenant_id string, account_id string, family_id string, user_agent string, ip_address string, lookup_id string, token_hash string, ttl time.Duration) (*XRefreshToken, error)
+	GetRefreshToken(ctx context.Context, tenant_id string, lookup_id string) (*XRefreshToken, error)
+	RotateRefreshToken(ctx context.Context, old *XRefreshToken, user_agent string, ip_address string, lookup_id string, token_hash string, ttl time.Duration) (*XRefreshToken, error)
+	RevokeToken(ctx context.Context, tenant_id string, account_id string, reason string) (bool, error)
+	RevokeRefreshToken(ctx context.Context, tenant_id string, account_id string, token_id string, reason string) (bool, error)
+	RevokeTokenFamily(ctx context.Context, tenant_id string, account_id string, family_id string, reason string) (bool, error)
+	CreatePasswordReset(ctx context.Context, tenant_id string, account_id string, code string, initiated_by *string, ttl time.Duration, enqueue ...jobs.NewJob) (*XPasswordReset, error)
+	GetPasswordReset(ctx context.Context, tenant_id string, id string) (*XPasswordReset, error)
+	CompletePasswordReset(ctx context.Context, tenant_id string, id string, passwd_hash string, salt string) (bool, error)
+	UpdatePasswordHash(ctx context.Context, tenant_id string, account_id string, old_hash string, new_hash string) (bool, error)
 }
 
 type AuthRepo struct {
@@ -36,14 +55,7 @@ type AuthRepo struct {
 //   - указатель на новый экземпляр AuthRepo
 //   - ошибку, если не удалось инициализировать репозиторий или пул соединений
 func NewAuthRepo(cfg *configs.Config) (*AuthRepo, error) {
-	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s",
-		cfg.AuthDBUSR,
-		cfg.AuthDBPWD,
-		cfg.DBMasterHost,
-		cfg.DBMasterPort,
-		cfg.AuthDBDBN,
-	)
-	pgRepo := core.NewPgRepo(dsn)
+	pgRepo := core.NewPgRepo(cfg.DB)
 	if err := pgRepo.InitPool(context.Background()); err != nil {
 		return nil, fmt.Errorf("filed to init DB pool %w", err)
 	}
@@ -54,29 +66,62 @@ func NewAuthRepo(cfg *configs.Config) (*AuthRepo, error) {
 	}, nil
 }
 
+// PgRepo возвращает пул соединений репозитория, чтобы другие компоненты (например, очередь задач) работали с той же базой.
+//
+// Возвращает:
+//   - указатель на PgRepo
+func (r *AuthRepo) PgRepo() *core.PgRepo {
+	return r.pgRepo
+}
+
+// Ping проверяет доступность базы данных.
+//
+// Параметры:
+//   - ctx: контекст выполнения, ограничивает время проверки
+//
+// Возвращает:
+//   - ошибку, если база данных недоступна
+func (r *AuthRepo) Ping(ctx context.Context) error {
+	return r.pgRepo.Ping(ctx)
+}
+
 // CreateEmailSignup создает новую запись о регистрации с email в базе данных.
 //
 // Параметры:
 //   - ctx: контекст выполнения для управления временем жизни операции
-//   - email: email пользователя
-//   - passwd_hash: хеш пароля пользователя
+//   - tenant_id: идентификатор тенанта
+//   - email: email пользователя с доменом в ASCII
+//   - email_canonical: каноническая форма email, уникальна в тенанте без учета регистра
+//   - passwd_hash: хеш пароля пользователя в формате PHC
 //   - code: код подтверждения
-//   - salt: соль для хеширования пароля
+//   - salt: соль устаревшего SHA-256 хеша; для хешей в формате PHC пустая строка
+//   - enqueue: фоновые задачи, которые ставятся в той же транзакции, например письмо с кодом
 //
 // Возвращает:
 //   - указатель на структуру XEmailSignup, содержащую информацию о регистрации
 //   - ошибку, если операция не удалась (например, ошибка базы данных или нарушение уникальности)
-func (r *AuthRepo) CreateEmailSignup(ctx context.Context, email string, passwd_hash string, code string, salt string) (*XEmailSignup, error) {
+func (r *AuthRepo) CreateEmailSignup(ctx context.Context, tenant_id string, email string, email_canonical string, passwd_hash string, code string, salt string, enqueue ...jobs.NewJob) (*XEmailSignup, error) {
 	const q = `
 		INSERT INTO "SignupEmail"
 		(
-			email
+			tenant_id
+			, email
+			, email_canonical
 			, code
 			, passwd_hash
 			, salt
 		)
-		VALUES ($1, $2, $3, $4)
-		RETURNING *;
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING
+			id
+			, tenant_id
+			, email
+			, email_canonical
+			, code
+			, passwd_hash
+			, salt
+			, created_at
+			, updated_at;
 	`
 	conn, err := r.pgRepo.Acquire(ctx)
 	if err != nil {
@@ -84,8 +129,14 @@ func (r *AuthRepo) CreateEmailSignup(ctx context.Context, email string, passwd_h
 	}
 	defer conn.Release()
 
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer tx.Rollback(ctx)
+
 	var res XEmailSignup
-	err = conn.QueryRow(ctx, q, email, code, passwd_hash, salt).Scan(&res.ID, &res.Email, &res.Code, &res.PasswordHash, &res.Salt, &res.CreatedAt, &res.UpdatedAt)
+	err = tx.QueryRow(ctx, q, tenant_id, email, email_canonical, code, passwd_hash, salt).Scan(&res.ID, &res.TenantID, &res.Email, &res.EmailCanonical, &res.Code, &res.PasswordHash, &res.Salt, &res.CreatedAt, &res.UpdatedAt)
 
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -96,14 +147,21 @@ func (r *AuthRepo) CreateEmailSignup(ctx context.Context, email string, passwd_h
 		return nil, &core.ErrPGRepo{ErrMessage: err}
 	}
 
+	if err := enqueueJobs(ctx, tx, enqueue); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
 	return &res, nil
 }
 
-// CreateAccount создает новый аккаунт в базе данных.
+// CreateAccount создает новый аккаунт в базе данных и в той же транзакции записывает событие account.created.
 //
 // Параметры:
 //   - ctx: контекст выполнения для управления временем жизни операции
-//   - req: структура XEmailSignup, содержащая данные для создания аккаунта (email, хеш пароля, соль)
+//   - req: структура XEmailSignup, содержащая данные для создания аккаунта (тенант, email, хеш пароля, соль)
 //
 // Возвращает:
 //   - указатель на структуру XAccount, содержащую информацию о созданном аккаунте
@@ -112,12 +170,22 @@ func (r *AuthRepo) CreateAccount(ctx context.Context, req *XEmailSignup) (*XAcco
 	const q = `
 		INSERT INTO "Account"
 		(
-			email
+			tenant_id
+			, email
+			, email_canonical
 			, passwd_hash
 			, salt
 		)
-		VALUES ($1, $2, $3)
-		RETURNING *;
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING
+			id
+			, tenant_id
+			, email
+			, passwd_hash
+			, salt
+			, status
+			, created_at
+			, updated_at;
 	`
 
 	conn, err := r.pgRepo.Acquire(ctx)
@@ -126,8 +194,14 @@ func (r *AuthRepo) CreateAccount(ctx context.Context, req *XEmailSignup) (*XAcco
 	}
 	defer conn.Release()
 
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer tx.Rollback(ctx)
+
 	var res XAccount
-	err = conn.QueryRow(ctx, q, req.Email, req.PasswordHash, req.Salt).Scan(&res.ID, &res.Email, &res.PasswordHash, &res.Salt, &res.CreatedAt, &res.UpdatedAt)
+	err = tx.QueryRow(ctx, q, req.TenantID, req.Email, req.EmailCanonical, req.PasswordHash, req.Salt).Scan(&res.ID, &res.TenantID, &res.Email, &res.PasswordHash, &res.Salt, &res.Status, &res.CreatedAt, &res.UpdatedAt)
 
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -137,6 +211,17 @@ func (r *AuthRepo) CreateAccount(ctx context.Context, req *XEmailSignup) (*XAcco
 
 		return nil, &core.ErrPGRepo{ErrMessage: err}
 	}
+
+	err = appendEvent(ctx, tx, res.TenantID, res.ID, events.AccountCreated, events.AccountCreatedData{
+		Email:  res.Email,
+		Status: res.Status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
 	return &res, nil
 }
 
@@ -144,23 +229,28 @@ func (r *AuthRepo) CreateAccount(ctx context.Context, req *XEmailSignup) (*XAcco
 //
 // Параметры:
 //   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
 //   - id: идентификатор записи о регистрации
 //
 // Возвращает:
 //   - указатель на структуру XEmailSignup с данными о регистрации
 //   - ошибку, если запись не найдена или произошла ошибка при запросе к базе данных
-func (r *AuthRepo) GetEmailSignup(ctx context.Context, id string) (*XEmailSignup, error) {
+func (r *AuthRepo) GetEmailSignup(ctx context.Context, tenant_id string, id string) (*XEmailSignup, error) {
 	const q = `
 		SELECT
 			id
+			, tenant_id
 			, email
+			, email_canonical
 			, code
 			, passwd_hash
 			, salt
 			, created_at
 			, updated_at
 		FROM "SignupEmail"
-		WHERE id = $1
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2
 		LIMIT 1;
 	`
 
@@ -171,10 +261,11 @@ func (r *AuthRepo) GetEmailSignup(ctx context.Context, id string) (*XEmailSignup
 	defer conn.Release()
 
 	var res XEmailSignup
-	err = conn.QueryRow(ctx, q, id).Scan(&res.ID, &res.Email, &res.Code, &res.PasswordHash, &res.Salt, &res.CreatedAt, &res.UpdatedAt)
+	err = conn.QueryRow(ctx, q, tenant_id, id).Scan(&res.ID, &res.TenantID, &res.Email, &res.EmailCanonical, &res.Code, &res.PasswordHash, &res.Salt, &res.CreatedAt, &res.UpdatedAt)
 
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
 			return nil, &core.ErrEmailSignupNotFound{ErrMessage: err}
 		}
 
@@ -184,26 +275,31 @@ func (r *AuthRepo) GetEmailSignup(ctx context.Context, id string) (*XEmailSignup
 	return &res, nil
 }
 
-// GetAccountForEmail извлекает аккаунт из базы данных по заданному email.
+// GetAccountForEmail извлекает аккаунт из базы данных по канонической форме email.
 //
 // Параметры:
 //   - ctx: контекст выполнения для управления временем жизни операции
-//   - email: email для поиска соответствующего аккаунта
+//   - tenant_id: идентификатор тенанта
+//   - email_canonical: каноническая форма email (email.Canonical) для поиска соответствующего аккаунта
 //
 // Возвращает:
 //   - указатель на структуру XAccount с данными аккаунта
 //   - ошибку, если аккаунт не найден или произошла ошибка при запросе к базе данных
-func (r *AuthRepo) GetAccountForEmail(ctx context.Context, email string) (*XAccount, error) {
+func (r *AuthRepo) GetAccountForEmail(ctx context.Context, tenant_id string, email_canonical string) (*XAccount, error) {
 	const q = `
 		SELECT
 			id
+			, tenant_id
 			, email
 			, passwd_hash
 			, salt
+			, status
 			, created_at
 			, updated_at
 		FROM "Account"
-		WHERE email = $1
+		WHERE True
+			AND tenant_id = $1
+			AND email_canonical = $2
 		LIMIT 1;
 	`
 
@@ -214,7 +310,7 @@ func (r *AuthRepo) GetAccountForEmail(ctx context.Context, email string) (*XAcco
 	defer conn.Release()
 
 	var res XAccount
-	err = conn.QueryRow(ctx, q, email).Scan(&res.ID, &res.Email, &res.PasswordHash, &res.Salt, &res.CreatedAt, &res.UpdatedAt)
+	err = conn.QueryRow(ctx, q, tenant_id, email_canonical).Scan(&res.ID, &res.TenantID, &res.Email, &res.PasswordHash, &res.Salt, &res.Status, &res.CreatedAt, &res.UpdatedAt)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -230,15 +326,18 @@ func (r *AuthRepo) GetAccountForEmail(ctx context.Context, email string) (*XAcco
 //
 // Параметры:
 //   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
 //   - id: идентификатор записи о регистрации для удаления
 //
 // Возвращает:
 //   - булевое значение, указывающее на успешность операции (true, если запись удалена)
 //   - ошибку, если операция не удалась или запись не найдена
-func (r *AuthRepo) DeleteEmailSignup(ctx context.Context, id string) (bool, error) {
+func (r *AuthRepo) DeleteEmailSignup(ctx context.Context, tenant_id string, id string) (bool, error) {
 	const q = `
 		DELETE FROM "SignupEmail"
-		WHERE id = $1;
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2;
 	`
 
 	conn, err := r.pgRepo.Acquire(ctx)
@@ -247,7 +346,7 @@ func (r *AuthRepo) DeleteEmailSignup(ctx context.Context, id string) (bool, erro
 	}
 	defer conn.Release()
 
-	_, err = conn.Exec(ctx, q, id)
+	_, err = conn.Exec(ctx, q, tenant_id, id)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -261,29 +360,49 @@ func (r *AuthRepo) DeleteEmailSignup(ctx context.Context, id string) (bool, erro
 }
 
 // SaveRefreshToken сохраняет новый refresh-токен в базе данных для заданного аккаунта.
+// Сам токен не сохраняется, только идентификатор поиска и хеш.
 //
 // Параметры:
 //   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта, в котором выпущен токен
 //   - account_id: идентификатор аккаунта, к которому привязан токен
+//   - family_id: идентификатор семейства токенов, пустая строка - новое семейство (вход)
 //   - user_agent: строка, представляющая user-agent устройства пользователя
 //   - ip_address: IP-адрес пользователя
-//   - token: сам refresh-токен для сохранения
+//   - lookup_id: идентификатор поиска токена
+//   - token_hash: хеш refresh-токена
+//   - ttl: время жизни токена
 //
 // Возвращает:
 //   - указатель на структуру XRefreshToken с данными сохраненного токена
 //   - ошибку, если операция не удалась (например, ошибка базы данных или нарушение уникальности)
-func (r *AuthRepo) SaveRefreshToken(ctx context.Context, account_id string, user_agent string, ip_address string, token string) (*XRefreshToken, error) {
+func (r *AuthRepo) SaveRefreshToken(ctx context.Context, tenant_id string, account_id string, family_id string, user_agent string, ip_address string, lookup_id string, token_hash string, ttl time.Duration) (*XRefreshToken, error) {
 	const q = `
 		INSERT INTO "RefreshToken"
 		(
-			account_id
-			, token
+			tenant_id
+			, account_id
+			, family_id
+			, lookup_id
+			, token_hash
 			, user_agent
 			, ip_address
 			, expires_at
 		)
-		VALUES ($1, $2, $3, $4, NOW() + INTERVAL '5 days')
-		RETURNING *;
+		VALUES ($1, $2, COALESCE(NULLIF($3, '')::uuid, uuid_generate_v4()), $4, $5, $6, $7, NOW() + make_interval(secs => $8))
+		RETURNING
+			id
+			, tenant_id
+			, account_id
+			, family_id
+			, lookup_id
+			, token_hash
+			, user_agent
+			, ip_address
+			, expires_at
+			, is_revoked
+			, created_at
+			, updated_at;
 	`
 
 	conn, err := r.pgRepo.Acquire(ctx)
@@ -293,7 +412,7 @@ func (r *AuthRepo) SaveRefreshToken(ctx context.Context, account_id string, user
 	defer conn.Release()
 
 	var res XRefreshToken
-	err = conn.QueryRow(ctx, q, account_id, token, user_agent, ip_address).Scan(&res.ID, &res.AccountID, &res.Token, &res.UserAgent, &res.IpAddress, &res.ExpiresAt, &res.IsRevoked, &res.CreatedAt, &res.UpdatedAt)
+	err = conn.QueryRow(ctx, q, tenant_id, account_id, family_id, lookup_id, token_hash, user_agent, ip_address, ttl.Seconds()).Scan(&res.ID, &res.TenantID, &res.AccountID, &res.FamilyID, &res.LookupID, &res.TokenHash, &res.UserAgent, &res.IpAddress, &res.ExpiresAt, &res.IsRevoked, &res.CreatedAt, &res.UpdatedAt)
 
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -307,32 +426,37 @@ func (r *AuthRepo) SaveRefreshToken(ctx context.Context, account_id string, user
 	return &res, nil
 }
 
-// GetRefreshTokenForAccount извлекает refresh-токен для заданного аккаунта из базы данных.
+// GetRefreshToken извлекает действующий refresh-токен тенанта по идентификатору поиска.
+// Хеш токена сверяет вызывающий код.
 //
 // Параметры:
 //   - ctx: контекст выполнения для управления временем жизни операции
-//   - account_id: идентификатор аккаунта, для которого нужно получить токен
-//   - token: сам refresh-токен для поиска в базе данных
+//   - tenant_id: идентификатор тенанта
+//   - lookup_id: идентификатор поиска токена
 //
 // Возвращает:
 //   - указатель на структуру XRefreshToken с данными найденного токена
 //   - ошибку, если токен не найден или произошла ошибка при запросе к базе данных
-func (r *AuthRepo) GetRefreshTokenForAccount(ctx context.Context, account_id string, token string) (*XRefreshToken, error) {
+func (r *AuthRepo) GetRefreshToken(ctx context.Context, tenant_id string, lookup_id string) (*XRefreshToken, error) {
 	const q = `
-		SELECT 
+		SELECT
 			id
+			, tenant_id
 			, account_id
-			, token
+			, family_id
+			, lookup_id
+			, token_hash
 			, user_agent
 			, ip_address
 			, expires_at
 			, is_revoked
+			, replaced_by
 			, created_at
 			, updated_at
 		FROM "RefreshToken"
 		WHERE True
-			AND account_id = $1
-			AND token = $2
+			AND tenant_id = $1
+			AND lookup_id = $2
 			AND expires_at > NOW()
 	`
 
@@ -343,7 +467,7 @@ func (r *AuthRepo) GetRefreshTokenForAccount(ctx context.Context, account_id str
 	defer conn.Release()
 
 	var res XRefreshToken
-	err = conn.QueryRow(ctx, q, account_id, token).Scan(&res.ID, &res.AccountID, &res.Token, &res.UserAgent, &res.IpAddress, &res.ExpiresAt, &res.IsRevoked, &res.CreatedAt, &res.UpdatedAt)
+	err = conn.QueryRow(ctx, q, tenant_id, lookup_id).Scan(&res.ID, &res.TenantID, &res.AccountID, &res.FamilyID, &res.LookupID, &res.TokenHash, &res.UserAgent, &res.IpAddress, &res.ExpiresAt, &res.IsRevoked, &res.ReplacedBy, &res.CreatedAt, &res.UpdatedAt)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -356,21 +480,159 @@ func (r *AuthRepo) GetRefreshTokenForAccount(ctx context.Context, account_id str
 	return &res, nil
 }
 
+// RotateRefreshToken заменяет предъявленный refresh-токен новым из того же семейства: в одной транзакции
+// сохраняет новый токен, а старый отзывает со ссылкой на замену. Если старый токен уже отозван, например
+// параллельным рефрешем, новый токен не сохраняется.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - old: заменяемый токен
+//   - user_agent: строка, представляющая user-agent устройства пользователя
+//   - ip_address: IP-адрес пользователя
+//   - lookup_id: идентификатор поиска нового токена
+//   - token_hash: хеш нового токена
+//   - ttl: время жизни нового токена
+//
+// Возвращает:
+//   - указатель на структуру XRefreshToken с данными нового токена
+//   - ErrTokenReplaced, если старый токен уже отозван, или ошибку базы данных
+func (r *AuthRepo) RotateRefreshToken(ctx context.Context, old *XRefreshToken, user_agent string, ip_address string, lookup_id string, token_hash string, ttl time.Duration) (*XRefreshToken, error) {
+	const qInsert = `
+		INSERT INTO "RefreshToken"
+		(
+			tenant_id
+			, account_id
+			, family_id
+			, lookup_id
+			, token_hash
+			, user_agent
+			, ip_address
+			, expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW() + make_interval(secs => $8))
+		RETURNING
+			id
+			, tenant_id
+			, account_id
+			, family_id
+			, lookup_id
+			, token_hash
+			, user_agent
+			, ip_address
+			, expires_at
+			, is_revoked
+			, created_at
+			, updated_at;
+	`
+	const qRevoke = `
+		UPDATE "RefreshToken"
+		SET is_revoked = TRUE,
+		replaced_by = $3,
+		updated_at = NOW()
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2
+			AND is_revoked = FALSE
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer tx.Rollback(ctx)
+
+	var res XRefreshToken
+	err = tx.QueryRow(ctx, qInsert, old.TenantID, old.AccountID, old.FamilyID, lookup_id, token_hash, user_agent, ip_address, ttl.Seconds()).Scan(&res.ID, &res.TenantID, &res.AccountID, &res.FamilyID, &res.LookupID, &res.TokenHash, &res.UserAgent, &res.IpAddress, &res.ExpiresAt, &res.IsRevoked, &res.CreatedAt, &res.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, &core.ErrSaveToken{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	tag, err := tx.Exec(ctx, qRevoke, old.TenantID, old.ID, res.ID)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, &core.ErrTokenReplaced{ErrMessage: old.ID}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return &res, nil
+}
+
 // RevokeToken обновляет статус refresh-токенов для заданного аккаунта, помечая их как отозванные.
+// Если были отозваны действующие токены, в той же транзакции записывается событие session.revoked.
 //
 // Параметры:
 //   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
 //   - account_id: идентификатор аккаунта, для которого нужно отозвать токены
+//   - reason: причина отзыва для события, например password_reset
 //
 // Возвращает:
 //   - булевое значение, указывающее на успешность операции (true, если токены были отозваны)
 //   - ошибку, если операция не удалась
-func (r *AuthRepo) RevokeToken(ctx context.Context, account_id string) (bool, error) {
+func (r *AuthRepo) RevokeToken(ctx context.Context, tenant_id string, account_id string, reason string) (bool, error) {
+	return r.revokeTokens(ctx, tenant_id, account_id, "", "", reason)
+}
+
+// RevokeTokenFamily отзывает refresh-токены одного семейства, то есть одной сессии, начатой входом.
+// Остальные сессии аккаунта остаются действующими.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - account_id: идентификатор аккаунта
+//   - family_id: идентификатор семейства токенов
+//   - reason: причина отзыва для события, например session_binding
+//
+// Возвращает:
+//   - true, если токены были отозваны
+//   - ошибку, если операция не удалась
+func (r *AuthRepo) RevokeTokenFamily(ctx context.Context, tenant_id string, account_id string, family_id string, reason string) (bool, error) {
+	return r.revokeTokens(ctx, tenant_id, account_id, family_id, "", reason)
+}
+
+// RevokeRefreshToken отзывает один refresh-токен. Токены, выданные взамен него, остаются действующими.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - account_id: идентификатор аккаунта
+//   - token_id: идентификатор токена
+//   - reason: причина отзыва для события, например session_binding
+//
+// Возвращает:
+//   - true, если операция выполнена
+//   - ошибку, если операция не удалась
+func (r *AuthRepo) RevokeRefreshToken(ctx context.Context, tenant_id string, account_id string, token_id string, reason string) (bool, error) {
+	return r.revokeTokens(ctx, tenant_id, account_id, "", token_id, reason)
+}
+
+// revokeTokens отзывает действующие токены аккаунта: все, одного семейства или один токен, и записывает событие session.revoked.
+func (r *AuthRepo) revokeTokens(ctx context.Context, tenant_id string, account_id string, family_id string, token_id string, reason string) (bool, error) {
 	const q = `
 		UPDATE "RefreshToken"
 		SET is_revoked = TRUE,
 		updated_at = NOW()
-		WHERE account_id = $1
+		WHERE True
+			AND tenant_id = $1
+			AND account_id = $2
+			AND ($3 = '' OR family_id::text = $3)
+			AND ($4 = '' OR id::text = $4)
+			AND is_revoked = FALSE
 	`
 
 	conn, err := r.pgRepo.Acquire(ctx)
@@ -379,10 +641,287 @@ func (r *AuthRepo) RevokeToken(ctx context.Context, account_id string) (bool, er
 	}
 	defer conn.Release()
 
-	_, err = conn.Exec(ctx, q, account_id)
+	tx, err := conn.Begin(ctx)
 	if err != nil {
 		return false, &core.ErrPGRepo{ErrMessage: err}
 	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, q, tenant_id, account_id, family_id, token_id)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	if tag.RowsAffected() > 0 {
+		err = appendEvent(ctx, tx, tenant_id, account_id, events.SessionRevoked, events.SessionRevokedData{
+			Reason:   reason,
+			Sessions: int(tag.RowsAffected()),
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
 
 	return true, nil
 }
+
+// CreatePasswordReset создает запрос на сброс пароля с кодом подтверждения, действующим ttl.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - account_id: идентификатор аккаунта, пароль которого сбрасывается
+//   - code: код подтверждения сброса
+//   - initiated_by: идентификатор администратора, запустившего сброс, или nil, если сброс запросил пользователь
+//   - ttl: срок действия кода
+//   - enqueue: фоновые задачи, которые ставятся в той же транзакции, например письмо с кодом
+//
+// Возвращает:
+//   - указатель на структуру XPasswordReset с данными запроса
+//   - ошибку, если аккаунт не найден или произошла ошибка базы данных
+func (r *AuthRepo) CreatePasswordReset(ctx context.Context, tenant_id string, account_id string, code string, initiated_by *string, ttl time.Duration, enqueue ...jobs.NewJob) (*XPasswordReset, error) {
+	const q = `
+		INSERT INTO "PasswordReset"
+		(
+			tenant_id
+			, account_id
+			, code
+			, initiated_by
+			, expires_at
+		)
+		SELECT a.tenant_id, a.id, $3, $4::uuid, NOW() + make_interval(secs => $5)
+		FROM "Account" a
+		WHERE True
+			AND a.tenant_id = $1
+			AND a.id = $2
+		RETURNING
+			id
+			, tenant_id
+			, account_id
+			, code
+			, initiated_by
+			, expires_at
+			, used_at
+			, created_at;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer tx.Rollback(ctx)
+
+	var res XPasswordReset
+	err = tx.QueryRow(ctx, q, tenant_id, account_id, code, initiated_by, ttl.Seconds()).Scan(&res.ID, &res.TenantID, &res.AccountID, &res.Code, &res.InitiatedBy, &res.ExpiresAt, &res.UsedAt, &res.CreatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrAccountNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	if err := enqueueJobs(ctx, tx, enqueue); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return &res, nil
+}
+
+// GetPasswordReset извлекает действующий (неиспользованный и неистекший) запрос на сброс пароля.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор запроса на сброс
+//
+// Возвращает:
+//   - указатель на структуру XPasswordReset с данными запроса
+//   - ошибку, если запрос не найден, уже использован, истек или произошла ошибка базы данных
+func (r *AuthRepo) GetPasswordReset(ctx context.Context, tenant_id string, id string) (*XPasswordReset, error) {
+	const q = `
+		SELECT
+			id
+			, tenant_id
+			, account_id
+			, code
+			, initiated_by
+			, expires_at
+			, used_at
+			, created_at
+		FROM "PasswordReset"
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2
+			AND used_at IS NULL
+			AND expires_at > NOW()
+		LIMIT 1;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XPasswordReset
+	err = conn.QueryRow(ctx, q, tenant_id, id).Scan(&res.ID, &res.TenantID, &res.AccountID, &res.Code, &res.InitiatedBy, &res.ExpiresAt, &res.UsedAt, &res.CreatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrPasswordResetNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return &res, nil
+}
+
+// CompletePasswordReset одним запросом помечает запрос на сброс использованным и устанавливает аккаунту новый хеш пароля.
+// В той же транзакции записывается событие account.password_changed.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор запроса на сброс
+//   - passwd_hash: новый хеш пароля в формате PHC
+//   - salt: соль устаревшего SHA-256 хеша; для хешей в формате PHC пустая строка
+//
+// Возвращает:
+//   - true, если пароль обновлен
+//   - ошибку, если запрос уже использован, истек или произошла ошибка базы данных
+func (r *AuthRepo) CompletePasswordReset(ctx context.Context, tenant_id string, id string, passwd_hash string, salt string) (bool, error) {
+	const q = `
+		WITH used AS (
+			UPDATE "PasswordReset"
+			SET used_at = NOW()
+			WHERE True
+				AND tenant_id = $1
+				AND id = $2
+				AND used_at IS NULL
+				AND expires_at > NOW()
+			RETURNING account_id
+		)
+		UPDATE "Account" a
+		SET passwd_hash = $3,
+		salt = $4,
+		updated_at = NOW()
+		FROM used
+		WHERE True
+			AND a.tenant_id = $1
+			AND a.id = used.account_id
+		RETURNING a.id;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer tx.Rollback(ctx)
+
+	var account_id string
+	err = tx.QueryRow(ctx, q, tenant_id, id, passwd_hash, salt).Scan(&account_id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, &core.ErrPasswordResetNotFound{ErrMessage: err}
+		}
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	err = appendEvent(ctx, tx, tenant_id, account_id, events.AccountPasswordChanged, events.PasswordChangedData{
+		Method: "password_reset",
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return true, nil
+}
+
+// enqueueJobs ставит фоновые задачи в транзакции бизнес-записи.
+func enqueueJobs(ctx context.Context, tx pgx.Tx, enqueue []jobs.NewJob) error {
+	for _, job := range enqueue {
+		if _, err := jobs.Enqueue(ctx, tx, job); err != nil {
+			return &core.ErrPGRepo{ErrMessage: err}
+		}
+	}
+	return nil
+}
+
+// UpdatePasswordHash заменяет хеш пароля аккаунта пересчитанным и очищает соль устаревшего формата.
+// Хеш заменяется, только если он не изменился с момента проверки, чтобы не затереть параллельную смену пароля.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - account_id: идентификатор аккаунта
+//   - old_hash: хеш, с которым был проверен пароль
+//   - new_hash: новый хеш в формате PHC
+//
+// Возвращает:
+//   - true, если хеш заменен; false, если пароль успели сменить
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) UpdatePasswordHash(ctx context.Context, tenant_id string, account_id string, old_hash string, new_hash string) (bool, error) {
+	const q = `
+		UPDATE "Account"
+		SET passwd_hash = $4,
+		salt = '',
+		updated_at = NOW()
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2
+			AND passwd_hash = $3;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, q, tenant_id, account_id, old_hash, new_hash)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// appendEvent записывает доменное событие в outbox в транзакции изменения состояния.
+func appendEvent(ctx context.Context, tx pgx.Tx, tenant_id string, account_id string, kind string, data any) error {
+	err := events.Append(ctx, tx, events.NewEvent{
+		TenantID:  tenant_id,
+		Type:      kind,
+		AccountID: account_id,
+		Data:      data,
+	})
+	if err != nil {
+		return &core.ErrPGRepo{ErrMessage: err}
+	}
+	return nil
+}
@@ -5,20 +5,24 @@ import (
 )
 
 type XEmailSignup struct {
-	ID           string     `db:"id"`
-	Email        string     `db:"email"`
-	Code         string     `db:"code"` // Добавлено для local debug
-	PasswordHash string     `db:"passwd_hash"`
-	Salt         string     `db:"salt"`
-	CreatedAt    time.Time  `db:"created_at"`
-	UpdatedAt    *time.Time `db:"updated_at"`
+	ID             string     `db:"id"`
+	TenantID       string     `db:"tenant_id"`
+	Email          string     `db:"email"`
+	EmailCanonical string     `db:"email_canonical"`
+	Code           string     `db:"code"` // Код подтверждения, отправляется на email
+	PasswordHash   string     `db:"passwd_hash"`
+	Salt           string     `db:"salt"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      *time.Time `db:"updated_at"`
 }
 
 type XAccount struct {
 	ID           string     `db:"id"`
+	TenantID     string     `db:"tenant_id"`
 	Email        string     `db:"email"`
 	PasswordHash string     `db:"passwd_hash"`
 	Salt         string     `db:"salt"`
+	Status       string     `db:"status"`
 	CreatedAt    time.Time  `db:"created_at"`
 	UpdatedAt    *time.Time `db:"updated_at"`
 }
@@ -29,13 +33,220 @@ type XConfirmEmail struct {
 }
 
 type XRefreshToken struct {
+	ID         string     `db:"id"`
+	TenantID   string     `db:"tenant_id"`
+	AccountID  string     `db:"accouint_id"`
+	FamilyID   string     `db:"family_id"`
+	LookupID   string     `db:"lookup_id"`
+	ReplacedBy *string    `db:"replaced_by"`
+	TokenHash  string     `db:"token_hash"`
+	UserAgent  string     `db:"user_agent"`
+	IpAddress  string     `db:"ip_address"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	IsRevoked  bool       `db:"is_revoked"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  *time.Time `db:"updated_at"`
+}
+
+type XRole struct {
+	ID          string     `db:"id"`
+	Name        string     `db:"name"`
+	Description string     `db:"description"`
+	Platform    bool       `db:"platform"`
+	Permissions []string   `db:"permissions"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   *time.Time `db:"updated_at"`
+}
+
+type XPermission struct {
+	ID          string    `db:"id"`
+	Code        string    `db:"code"`
+	Description string    `db:"description"`
+	BuiltIn     bool      `db:"built_in"`
+	Platform    bool      `db:"platform"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+type XAccountAccess struct {
+	AccountID   string   `db:"account_id"`
+	Roles       []string `db:"roles"`
+	Permissions []string `db:"permissions"`
+}
+
+type XPasswordReset struct {
+	ID          string     `db:"id"`
+	TenantID    string     `db:"tenant_id"`
+	AccountID   string     `db:"account_id"`
+	Code        string     `db:"code"`
+	InitiatedBy *string    `db:"initiated_by"`
+	ExpiresAt   time.Time  `db:"expires_at"`
+	UsedAt      *time.Time `db:"used_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
+
+type XAccountFilter struct {
+	TenantID    string
+	EmailPrefix string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Status      string
+	Limit       int
+	Offset      int
+}
+
+type XTenant struct {
 	ID        string     `db:"id"`
-	AccountID string     `db:"accouint_id"`
-	Token     string     `db:"token"`
-	UserAgent string     `db:"user_agent"`
-	IpAddress string     `db:"ip_address"`
-	ExpiresAt time.Time  `db:"expires_at"`
-	IsRevoked bool       `db:"is_revoked"`
+	Slug      string     `db:"slug"`
+	Name      string     `db:"name"`
+	Host      *string    `db:"host"`
 	CreatedAt time.Time  `db:"created_at"`
 	UpdatedAt *time.Time `db:"updated_at"`
 }
+
+type XOrganization struct {
+	ID        string     `db:"id"`
+	TenantID  string     `db:"tenant_id"`
+	Name      string     `db:"name"`
+	Role      string     `db:"role"` // Роль запросившего аккаунта в организации
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
+
+type XOrgMember struct {
+	OrgID     string     `db:"org_id"`
+	AccountID string     `db:"account_id"`
+	Email     string     `db:"email"`
+	Role      string     `db:"role"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
+
+type XOrgInvite struct {
+	ID         string     `db:"id"`
+	OrgID      string     `db:"org_id"`
+	Email      string     `db:"email"`
+	Role       string     `db:"role"`
+	Code       string     `db:"code"`
+	InvitedBy  string     `db:"invited_by"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	AcceptedAt *time.Time `db:"accepted_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+type XImpersonation struct {
+	ID        string     `db:"id"`
+	TenantID  string     `db:"tenant_id"`
+	AdminID   string     `db:"admin_id"`
+	AccountID string     `db:"account_id"`
+	Reason    string     `db:"reason"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	EndedAt   *time.Time `db:"ended_at"`
+	EndedBy   *string    `db:"ended_by"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+type XImpersonationFilter struct {
+	TenantID  string
+	AccountID string
+	AdminID   string
+	Limit     int
+	Offset    int
+}
+
+type XAuditRecord struct {
+	Seq       int64             `db:"seq"`
+	TenantID  string            `db:"tenant_id"`
+	Event     string            `db:"event"`
+	Outcome   string            `db:"outcome"`
+	AccountID *string           `db:"account_id"`
+	ActorID   *string           `db:"actor_id"`
+	Email     *string           `db:"email"`
+	IpAddress *string           `db:"ip_address"`
+	UserAgent *string           `db:"user_agent"`
+	Details   map[string]string `db:"details"`
+	PrevHash  string            `db:"prev_hash"`
+	Hash      string            `db:"hash"`
+	CreatedAt time.Time         `db:"created_at"`
+}
+
+type XAuditFilter struct {
+	TenantID  string
+	AccountID string
+	ActorID   string
+	Event     string
+	Outcome   string
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+	Offset    int
+}
+
+type XWebhook struct {
+	ID                  string     `db:"id"`
+	TenantID            string     `db:"tenant_id"`
+	URL                 string     `db:"url"`
+	Events              []string   `db:"events"`
+	Secret              string     `db:"secret"`
+	Description         string     `db:"description"`
+	Enabled             bool       `db:"enabled"`
+	ConsecutiveFailures int        `db:"consecutive_failures"`
+	DisabledAt          *time.Time `db:"disabled_at"`
+	DisabledReason      *string    `db:"disabled_reason"`
+	CreatedAt           time.Time  `db:"created_at"`
+	UpdatedAt           time.Time  `db:"updated_at"`
+}
+
+type XWebhookDelivery struct {
+	ID             string     `db:"id"`
+	TenantID       string     `db:"tenant_id"`
+	WebhookID      string     `db:"webhook_id"`
+	EventID        string     `db:"event_id"`
+	Event          string     `db:"event"`
+	Payload        []byte     `db:"payload"`
+	Status         string     `db:"status"`
+	Attempts       int        `db:"attempts"`
+	ResponseStatus *int       `db:"response_status"`
+	ResponseBody   *string    `db:"response_body"`
+	LastError      *string    `db:"last_error"`
+	DurationMs     *int       `db:"duration_ms"`
+	DeliveredAt    *time.Time `db:"delivered_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+}
+
+// XWebhookAttempt - результат одной попытки доставки
+type XWebhookAttempt struct {
+	Status         string // success, retrying или failed
+	ResponseStatus *int
+	ResponseBody   *string
+	Error          *string
+	DurationMs     int
+}
+
+type XLoginEvent struct {
+	ID             string    `db:"id"`
+	TenantID       string    `db:"tenant_id"`
+	AccountID      *string   `db:"account_id"`
+	EmailCanonical string    `db:"email_canonical"`
+	Kind           string    `db:"kind"`
+	Outcome        string    `db:"outcome"`
+	IpAddress      string    `db:"ip_address"`
+	Device         string    `db:"device"`
+	Country        string    `db:"country"`
+	ASN            uint      `db:"asn"`
+	Latitude       *float64  `db:"latitude"`
+	Longitude      *float64  `db:"longitude"`
+	Score          int       `db:"score"`
+	Decision       string    `db:"decision"`
+	Signals        []string  `db:"signals"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+type XRiskHistory struct {
+	Logins        int
+	KnownDevice   bool
+	KnownCountry  bool
+	KnownASN      bool
+	Last          *XLoginEvent
+	SprayAccounts int
+}
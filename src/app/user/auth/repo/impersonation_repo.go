@@ -0,0 +1,254 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type IImpersonationRepo interface {
+	CreateImpersonation(ctx context.Context, tenant_id string, admin_id string, account_id string, reason string, ttl time.Duration) (*XImpersonation, error)
+	GetActiveImpersonation(ctx context.Context, tenant_id string, id string) (*XImpersonation, error)
+	EndImpersonation(ctx context.Context, tenant_id string, id string, ended_by string) (*XImpersonation, error)
+	SearchImpersonations(ctx context.Context, filter *XImpersonationFilter) ([]XImpersonation, int, error)
+}
+
+// CreateImpersonation записывает начало сессии входа администратора под аккаунтом пользователя.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - admin_id: идентификатор администратора
+//   - account_id: идентификатор аккаунта, под которым выполняется вход
+//   - reason: причина входа
+//   - ttl: время жизни выдаваемого access токена
+//
+// Возвращает:
+//   - указатель на структуру XImpersonation с данными сессии
+//   - ошибку, если аккаунт не найден в тенанте или произошла ошибка базы данных
+func (r *AuthRepo) CreateImpersonation(ctx context.Context, tenant_id string, admin_id string, account_id string, reason string, ttl time.Duration) (*XImpersonation, error) {
+	const q = `
+		INSERT INTO "Impersonation"
+		(
+			tenant_id
+			, admin_id
+			, account_id
+			, reason
+			, expires_at
+		)
+		SELECT a.tenant_id, $2::uuid, a.id, $4, NOW() + make_interval(secs => $5)
+		FROM "Account" a
+		WHERE True
+			AND a.tenant_id = $1
+			AND a.id = $3
+		RETURNING
+			id
+			, tenant_id
+			, admin_id
+			, account_id
+			, reason
+			, expires_at
+			, ended_at
+			, ended_by
+			, created_at;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XImpersonation
+	err = conn.QueryRow(ctx, q, tenant_id, admin_id, account_id, reason, ttl.Seconds()).Scan(&res.ID, &res.TenantID, &res.AdminID, &res.AccountID, &res.Reason, &res.ExpiresAt, &res.EndedAt, &res.EndedBy, &res.CreatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrAccountNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// GetActiveImpersonation извлекает незавершенную и неистекшую сессию входа под пользователем.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор сессии
+//
+// Возвращает:
+//   - указатель на структуру XImpersonation с данными сессии
+//   - ошибку, если сессия не найдена, завершена, истекла или произошла ошибка базы данных
+func (r *AuthRepo) GetActiveImpersonation(ctx context.Context, tenant_id string, id string) (*XImpersonation, error) {
+	const q = `
+		SELECT
+			id
+			, tenant_id
+			, admin_id
+			, account_id
+			, reason
+			, expires_at
+			, ended_at
+			, ended_by
+			, created_at
+		FROM "Impersonation"
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2
+			AND ended_at IS NULL
+			AND expires_at > NOW()
+		LIMIT 1;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XImpersonation
+	err = conn.QueryRow(ctx, q, tenant_id, id).Scan(&res.ID, &res.TenantID, &res.AdminID, &res.AccountID, &res.Reason, &res.ExpiresAt, &res.EndedAt, &res.EndedBy, &res.CreatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrImpersonationNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// EndImpersonation досрочно завершает сессию входа под пользователем. После этого выданный токен
+// перестает приниматься, даже если его срок еще не истек.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор сессии
+//   - ended_by: идентификатор аккаунта, завершившего сессию
+//
+// Возвращает:
+//   - указатель на структуру XImpersonation с данными завершенной сессии
+//   - ошибку, если сессия не найдена, уже завершена, истекла или произошла ошибка базы данных
+func (r *AuthRepo) EndImpersonation(ctx context.Context, tenant_id string, id string, ended_by string) (*XImpersonation, error) {
+	const q = `
+		UPDATE "Impersonation"
+		SET ended_at = NOW(),
+		ended_by = $3
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2
+			AND ended_at IS NULL
+			AND expires_at > NOW()
+		RETURNING
+			id
+			, tenant_id
+			, admin_id
+			, account_id
+			, reason
+			, expires_at
+			, ended_at
+			, ended_by
+			, created_at;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XImpersonation
+	err = conn.QueryRow(ctx, q, tenant_id, id, ended_by).Scan(&res.ID, &res.TenantID, &res.AdminID, &res.AccountID, &res.Reason, &res.ExpiresAt, &res.EndedAt, &res.EndedBy, &res.CreatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrImpersonationNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// SearchImpersonations ищет сессии входа под пользователями тенанта по аккаунту и администратору.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - filter: тенант, фильтры и параметры пагинации; пустые фильтры, кроме тенанта, не применяются
+//
+// Возвращает:
+//   - страницу сессий, отсортированных от новых к старым
+//   - общее количество сессий, подходящих под фильтр
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) SearchImpersonations(ctx context.Context, filter *XImpersonationFilter) ([]XImpersonation, int, error) {
+	const where = `
+		WHERE True
+			AND tenant_id = $1
+			AND ($2 = '' OR account_id::text = $2)
+			AND ($3 = '' OR admin_id::text = $3)
+	`
+	const qCount = `
+		SELECT COUNT(*)
+		FROM "Impersonation"
+	` + where
+	const q = `
+		SELECT
+			id
+			, tenant_id
+			, admin_id
+			, account_id
+			, reason
+			, expires_at
+			, ended_at
+			, ended_by
+			, created_at
+		FROM "Impersonation"
+	` + where + `
+		ORDER BY created_at DESC, id
+		LIMIT $4 OFFSET $5;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var total int
+	err = conn.QueryRow(ctx, qCount, filter.TenantID, filter.AccountID, filter.AdminID).Scan(&total)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	rows, err := conn.Query(ctx, q, filter.TenantID, filter.AccountID, filter.AdminID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res := []XImpersonation{}
+	for rows.Next() {
+		var imp XImpersonation
+		if err := rows.Scan(&imp.ID, &imp.TenantID, &imp.AdminID, &imp.AccountID, &imp.Reason, &imp.ExpiresAt, &imp.EndedAt, &imp.EndedBy, &imp.CreatedAt); err != nil {
+			return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, imp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return res, total, nil
+}
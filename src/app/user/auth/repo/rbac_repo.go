@@ -0,0 +1,499 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type IRBACRepo interface {
+	CreateRole(ctx context.Context, tenant_id string, name string, description string) (*XRole, error)
+	GetRoles(ctx context.Context, tenant_id string) ([]XRole, error)
+	CreatePermission(ctx context.Context, tenant_id string, code string, description string) (*XPermission, error)
+	GetPermissions(ctx context.Context, tenant_id string) ([]XPermission, error)
+	GrantPermission(ctx context.Context, tenant_id string, role_id string, code string) (bool, error)
+	RevokePermission(ctx context.Context, tenant_id string, role_id string, code string) (bool, error)
+	AssignRole(ctx context.Context, tenant_id string, account_id string, role_id string) (bool, error)
+	UnassignRole(ctx context.Context, tenant_id string, account_id string, role_id string) (bool, error)
+	GetAccountAccess(ctx context.Context, tenant_id string, account_id string) (*XAccountAccess, error)
+}
+
+// CreateRole создает новую роль тенанта.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта роли
+//   - name: имя роли, уникальное в пределах тенанта
+//   - description: описание роли
+//
+// Возвращает:
+//   - указатель на структуру XRole с данными созданной роли
+//   - ошибку, если роль с таким именем уже существует или произошла ошибка базы данных
+func (r *AuthRepo) CreateRole(ctx context.Context, tenant_id string, name string, description string) (*XRole, error) {
+	const q = `
+		INSERT INTO "Role"
+		(
+			tenant_id
+			, name
+			, description
+		)
+		VALUES ($1, $2, $3)
+		RETURNING
+			id
+			, name
+			, description
+			, platform
+			, created_at
+			, updated_at;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	res := XRole{Permissions: []string{}}
+	err = conn.QueryRow(ctx, q, tenant_id, name, description).Scan(&res.ID, &res.Name, &res.Description, &res.Platform, &res.CreatedAt, &res.UpdatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, &core.ErrCreateRole{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return &res, nil
+}
+
+// GetRoles возвращает роли тенанта вместе с кодами выданных им разрешений.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//
+// Возвращает:
+//   - список структур XRole, отсортированный по имени роли
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) GetRoles(ctx context.Context, tenant_id string) ([]XRole, error) {
+	const q = `
+		SELECT
+			r.id
+			, r.name
+			, r.description
+			, r.platform
+			, COALESCE(array_agg(p.code ORDER BY p.code) FILTER (WHERE p.code IS NOT NULL), '{}') AS permissions
+			, r.created_at
+			, r.updated_at
+		FROM "Role" r
+		LEFT JOIN "RolePermission" rp ON rp.role_id = r.id
+		LEFT JOIN "Permission" p ON p.id = rp.permission_id
+		WHERE r.tenant_id = $1
+		GROUP BY r.id
+		ORDER BY r.name;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, q, tenant_id)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res := []XRole{}
+	for rows.Next() {
+		var role XRole
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.Platform, &role.Permissions, &role.CreatedAt, &role.UpdatedAt); err != nil {
+			return nil, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return res, nil
+}
+
+// CreatePermission создает разрешение тенанта. Код не может совпадать со встроенным разрешением.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - code: код разрешения, уникальный среди встроенных и разрешений тенанта, например "reports:read"
+//   - description: описание разрешения
+//
+// Возвращает:
+//   - указатель на структуру XPermission с данными созданного разрешения
+//   - ошибку, если разрешение с таким кодом уже существует или произошла ошибка базы данных
+func (r *AuthRepo) CreatePermission(ctx context.Context, tenant_id string, code string, description string) (*XPermission, error) {
+	const q = `
+		INSERT INTO "Permission"
+		(
+			tenant_id
+			, code
+			, description
+		)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM "Permission"
+			WHERE True
+				AND tenant_id IS NULL
+				AND code = $2
+		)
+		RETURNING
+			id
+			, code
+			, description
+			, tenant_id IS NULL
+			, platform
+			, created_at;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XPermission
+	err = conn.QueryRow(ctx, q, tenant_id, code, description).Scan(&res.ID, &res.Code, &res.Description, &res.BuiltIn, &res.Platform, &res.CreatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "23505") {
+			return nil, &core.ErrCreatePermission{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return &res, nil
+}
+
+// GetPermissions возвращает встроенные разрешения и разрешения тенанта.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//
+// Возвращает:
+//   - список структур XPermission, отсортированный по коду
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) GetPermissions(ctx context.Context, tenant_id string) ([]XPermission, error) {
+	const q = `
+		SELECT
+			id
+			, code
+			, description
+			, tenant_id IS NULL
+			, platform
+			, created_at
+		FROM "Permission"
+		WHERE tenant_id IS NULL OR tenant_id = $1
+		ORDER BY code;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, q, tenant_id)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res := []XPermission{}
+	for rows.Next() {
+		var perm XPermission
+		if err := rows.Scan(&perm.ID, &perm.Code, &perm.Description, &perm.BuiltIn, &perm.Platform, &perm.CreatedAt); err != nil {
+			return nil, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, perm)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return res, nil
+}
+
+// GrantPermission выдает роли тенанта встроенное разрешение или разрешение тенанта по его коду.
+// Роли платформы и разрешения платформы через API не меняются. Повторная выдача не считается ошибкой.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта роли
+//   - role_id: идентификатор роли
+//   - code: код разрешения
+//
+// Возвращает:
+//   - true, если разрешение выдано или уже было у роли
+//   - ошибку, если роль или разрешение не найдены в тенанте или произошла ошибка базы данных
+func (r *AuthRepo) GrantPermission(ctx context.Context, tenant_id string, role_id string, code string) (bool, error) {
+	const q = `
+		WITH role AS (
+			SELECT id
+			FROM "Role"
+			WHERE True
+				AND tenant_id = $1
+				AND id = $2
+				AND NOT platform
+		), perm AS (
+			SELECT id
+			FROM "Permission"
+			WHERE True
+				AND (tenant_id IS NULL OR tenant_id = $1)
+				AND code = $3
+				AND NOT platform
+		), ins AS (
+			INSERT INTO "RolePermission" (role_id, permission_id)
+			SELECT role.id, perm.id FROM role, perm
+			ON CONFLICT DO NOTHING
+		)
+		SELECT
+			EXISTS (SELECT 1 FROM role)
+			, EXISTS (SELECT 1 FROM perm);
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var role_found, perm_found bool
+	err = conn.QueryRow(ctx, q, tenant_id, role_id, code).Scan(&role_found, &perm_found)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			return false, &core.ErrRoleNotFound{ErrMessage: err}
+		}
+
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	if !role_found {
+		return false, &core.ErrRoleNotFound{ErrMessage: role_id}
+	}
+	if !perm_found {
+		return false, &core.ErrPermissionNotFound{ErrMessage: code}
+	}
+
+	return true, nil
+}
+
+// RevokePermission отзывает у роли тенанта разрешение по его коду. Роли платформы через API не меняются.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта роли
+//   - role_id: идентификатор роли
+//   - code: код разрешения
+//
+// Возвращает:
+//   - true, если разрешение было отозвано
+//   - ошибку, если у роли нет такого разрешения или произошла ошибка базы данных
+func (r *AuthRepo) RevokePermission(ctx context.Context, tenant_id string, role_id string, code string) (bool, error) {
+	const q = `
+		DELETE FROM "RolePermission" rp
+		USING "Permission" p, "Role" r
+		WHERE True
+			AND rp.permission_id = p.id
+			AND rp.role_id = r.id
+			AND r.tenant_id = $1
+			AND NOT r.platform
+			AND rp.role_id = $2
+			AND p.code = $3
+			AND (p.tenant_id IS NULL OR p.tenant_id = $1);
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, q, tenant_id, role_id, code)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			return false, &core.ErrRoleNotFound{ErrMessage: err}
+		}
+
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	if tag.RowsAffected() == 0 {
+		return false, &core.ErrPermissionNotFound{ErrMessage: pgx.ErrNoRows}
+	}
+
+	return true, nil
+}
+
+// AssignRole назначает аккаунту роль того же тенанта. Роли платформы через API не назначаются.
+// Повторное назначение не считается ошибкой.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта аккаунта
+//   - account_id: идентификатор аккаунта
+//   - role_id: идентификатор роли
+//
+// Возвращает:
+//   - true, если роль назначена или уже была назначена
+//   - ошибку, если аккаунт или роль не найдены или произошла ошибка базы данных
+func (r *AuthRepo) AssignRole(ctx context.Context, tenant_id string, account_id string, role_id string) (bool, error) {
+	const q = `
+		WITH acc AS (
+			SELECT id
+			FROM "Account"
+			WHERE True
+				AND tenant_id = $1
+				AND id = $2
+		), role AS (
+			SELECT id
+			FROM "Role"
+			WHERE True
+				AND tenant_id = $1
+				AND id = $3
+				AND NOT platform
+		), ins AS (
+			INSERT INTO "AccountRole" (account_id, role_id)
+			SELECT acc.id, role.id FROM acc, role
+			ON CONFLICT DO NOTHING
+		)
+		SELECT
+			EXISTS (SELECT 1 FROM acc)
+			, EXISTS (SELECT 1 FROM role);
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var acc_found, role_found bool
+	err = conn.QueryRow(ctx, q, tenant_id, account_id, role_id).Scan(&acc_found, &role_found)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			return false, &core.ErrRoleNotFound{ErrMessage: err}
+		}
+
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	if !acc_found {
+		return false, &core.ErrAccountNotFound{ErrMessage: account_id}
+	}
+	if !role_found {
+		return false, &core.ErrRoleNotFound{ErrMessage: role_id}
+	}
+
+	return true, nil
+}
+
+// UnassignRole снимает роль с аккаунта тенанта. Роли платформы через API не снимаются.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта аккаунта
+//   - account_id: идентификатор аккаунта
+//   - role_id: идентификатор роли
+//
+// Возвращает:
+//   - true, если роль была снята
+//   - ошибку, если роль не была назначена аккаунту или произошла ошибка базы данных
+func (r *AuthRepo) UnassignRole(ctx context.Context, tenant_id string, account_id string, role_id string) (bool, error) {
+	const q = `
+		DELETE FROM "AccountRole" ar
+		USING "Account" a, "Role" r
+		WHERE True
+			AND a.id = ar.account_id
+			AND r.id = ar.role_id
+			AND a.tenant_id = $1
+			AND r.tenant_id = $1
+			AND NOT r.platform
+			AND ar.account_id = $2
+			AND ar.role_id = $3;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, q, tenant_id, account_id, role_id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			return false, &core.ErrRoleNotFound{ErrMessage: err}
+		}
+
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	if tag.RowsAffected() == 0 {
+		return false, &core.ErrRoleNotFound{ErrMessage: pgx.ErrNoRows}
+	}
+
+	return true, nil
+}
+
+// GetAccountAccess возвращает имена ролей аккаунта тенанта и объединение кодов их разрешений.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта аккаунта
+//   - account_id: идентификатор аккаунта
+//
+// Возвращает:
+//   - указатель на структуру XAccountAccess; у аккаунта без ролей списки пустые
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) GetAccountAccess(ctx context.Context, tenant_id string, account_id string) (*XAccountAccess, error) {
+	const q = `
+		SELECT
+			COALESCE(array_agg(DISTINCT r.name) FILTER (WHERE r.name IS NOT NULL), '{}') AS roles
+			, COALESCE(array_agg(DISTINCT p.code) FILTER (WHERE p.code IS NOT NULL), '{}') AS permissions
+		FROM "AccountRole" ar
+		JOIN "Account" a ON a.id = ar.account_id
+		JOIN "Role" r ON r.id = ar.role_id AND r.tenant_id = a.tenant_id
+		LEFT JOIN "RolePermission" rp ON rp.role_id = r.id
+		LEFT JOIN "Permission" p ON p.id = rp.permission_id
+		WHERE True
+			AND a.tenant_id = $1
+			AND ar.account_id = $2;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	res := XAccountAccess{AccountID: account_id}
+	err = conn.QueryRow(ctx, q, tenant_id, account_id).Scan(&res.Roles, &res.Permissions)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			return nil, &core.ErrAccountNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return &res, nil
+}
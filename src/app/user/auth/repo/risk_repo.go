@@ -0,0 +1,193 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/jackc/pgx/v5"
+)
+
+type IRiskRepo interface {
+	RecordLoginEvent(ctx context.Context, ev *XLoginEvent) error
+	GetRiskHistory(ctx context.Context, tenant_id string, account_id string, ip_address string, device string, country string, asn uint, spray_since time.Time) (*XRiskHistory, error)
+	CountLoginFailures(ctx context.Context, tenant_id string, email_canonical string, ip_address string, since time.Time) (int, error)
+}
+
+// RecordLoginEvent записывает попытку входа или рефреша в журнал входов, по которому оценивается риск.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - ev: событие без id и created_at
+//
+// Возвращает:
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) RecordLoginEvent(ctx context.Context, ev *XLoginEvent) error {
+	const q = `
+		INSERT INTO "LoginEvent"
+		(
+			tenant_id
+			, account_id
+			, email_canonical
+			, kind
+			, outcome
+			, ip_address
+			, device
+			, country
+			, asn
+			, latitude
+			, longitude
+			, score
+			, decision
+			, signals
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14);
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	signals := ev.Signals
+	if signals == nil {
+		signals = []string{}
+	}
+	_, err = conn.Exec(ctx, q, ev.TenantID, ev.AccountID, ev.EmailCanonical, ev.Kind, ev.Outcome, ev.IpAddress, ev.Device,
+		ev.Country, ev.ASN, ev.Latitude, ev.Longitude, ev.Score, ev.Decision, signals)
+	if err != nil {
+		return &core.ErrPGRepo{ErrMessage: err}
+	}
+	return nil
+}
+
+// GetRiskHistory собирает из журнала входов данные для оценки риска: знакомы ли устройство, страна и сеть,
+// где был прошлый успешный вход и сколько разных email недавно не смогли войти с этого IP.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - account_id: идентификатор аккаунта
+//   - ip_address: IP адрес текущей попытки
+//   - device: семейство браузера и ОС текущей попытки
+//   - country: страна текущей попытки, пустая строка - неизвестна
+//   - asn: автономная система текущей попытки, 0 - неизвестна
+//   - spray_since: начало окна подсчета неудачных входов с IP
+//
+// Возвращает:
+//   - указатель на структуру XRiskHistory
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) GetRiskHistory(ctx context.Context, tenant_id string, account_id string, ip_address string, device string, country string, asn uint, spray_since time.Time) (*XRiskHistory, error) {
+	const qKnown = `
+		SELECT
+			COUNT(*)
+			, COALESCE(bool_or(device = $3), FALSE)
+			, COALESCE(bool_or(country = $4), FALSE)
+			, COALESCE(bool_or(asn = $5), FALSE)
+		FROM "LoginEvent"
+		WHERE True
+			AND tenant_id = $1
+			AND account_id = $2
+			AND outcome = 'success';
+	`
+	const qLast = `
+		SELECT
+			created_at
+			, latitude
+			, longitude
+		FROM "LoginEvent"
+		WHERE True
+			AND tenant_id = $1
+			AND account_id = $2
+			AND outcome = 'success'
+		ORDER BY created_at DESC
+		LIMIT 1;
+	`
+	const qSpray = `
+		SELECT COUNT(DISTINCT email_canonical)
+		FROM "LoginEvent"
+		WHERE True
+			AND tenant_id = $1
+			AND ip_address = $2
+			AND kind = 'login'
+			AND outcome = 'failure'
+			AND created_at > $3;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XRiskHistory
+	err = conn.QueryRow(ctx, qKnown, tenant_id, account_id, device, country, asn).Scan(&res.Logins, &res.KnownDevice, &res.KnownCountry, &res.KnownASN)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	var last XLoginEvent
+	err = conn.QueryRow(ctx, qLast, tenant_id, account_id).Scan(&last.CreatedAt, &last.Latitude, &last.Longitude)
+	switch {
+	case err == nil:
+		res.Last = &last
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	if err := conn.QueryRow(ctx, qSpray, tenant_id, ip_address, spray_since.UTC()).Scan(&res.SprayAccounts); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// CountLoginFailures считает неудачные входы по паролю с email или с IP адреса с начала окна. Неудачи аккаунта,
+// после которых был успешный вход, не учитываются.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - email_canonical: каноническая форма email попытки
+//   - ip_address: IP адрес попытки
+//   - since: начало окна
+//
+// Возвращает:
+//   - наибольшее из чисел неудач по email и по IP
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) CountLoginFailures(ctx context.Context, tenant_id string, email_canonical string, ip_address string, since time.Time) (int, error) {
+	const q = `
+		SELECT
+			COUNT(*) FILTER (WHERE f.email_canonical = $2)
+			, COUNT(*) FILTER (WHERE f.ip_address = $3)
+		FROM "LoginEvent" f
+		WHERE True
+			AND f.tenant_id = $1
+			AND f.kind = 'login'
+			AND f.outcome = 'failure'
+			AND f.created_at > $4
+			AND (f.email_canonical = $2 OR f.ip_address = $3)
+			AND NOT EXISTS (
+				SELECT 1
+				FROM "LoginEvent" s
+				WHERE True
+					AND s.tenant_id = f.tenant_id
+					AND s.account_id = f.account_id
+					AND s.outcome = 'success'
+					AND s.created_at > f.created_at
+			);
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var by_email, by_ip int
+	if err := conn.QueryRow(ctx, q, tenant_id, email_canonical, ip_address, since.UTC()).Scan(&by_email, &by_ip); err != nil {
+		return 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return max(by_email, by_ip), nil
+}
@@ -0,0 +1,692 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type ITenantRepo interface {
+	GetTenant(ctx context.Context, id_or_slug string) (*XTenant, error)
+	GetTenantForHost(ctx context.Context, host string) (*XTenant, error)
+	CreateTenant(ctx context.Context, slug string, name string, host *string) (*XTenant, error)
+	CreateOrganization(ctx context.Context, tenant_id string, name string, owner_id string) (*XOrganization, error)
+	GetOrganizationsForAccount(ctx context.Context, tenant_id string, account_id string) ([]XOrganization, error)
+	GetOrganizationMember(ctx context.Context, tenant_id string, org_id string, account_id string) (*XOrgMember, error)
+	GetOrganizationMembers(ctx context.Context, tenant_id string, org_id string) ([]XOrgMember, error)
+	SetMemberRole(ctx context.Context, tenant_id string, org_id string, account_id string, role string) (*XOrgMember, error)
+	RemoveMember(ctx context.Context, tenant_id string, org_id string, account_id string) (bool, error)
+	CreateInvite(ctx context.Context, tenant_id string, org_id string, email string, role string, code string, invited_by string, ttl time.Duration) (*XOrgInvite, error)
+	GetInvite(ctx context.Context, tenant_id string, id string) (*XOrgInvite, error)
+	AcceptInvite(ctx context.Context, tenant_id string, id string, account_id string) (*XOrgMember, error)
+}
+
+// GetTenant извлекает тенант по идентификатору или slug.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - id_or_slug: идентификатор (UUID) или slug тенанта
+//
+// Возвращает:
+//   - указатель на структуру XTenant с данными тенанта
+//   - ошибку, если тенант не найден или произошла ошибка базы данных
+func (r *AuthRepo) GetTenant(ctx context.Context, id_or_slug string) (*XTenant, error) {
+	const q = `
+		SELECT
+			id
+			, slug
+			, name
+			, host
+			, created_at
+			, updated_at
+		FROM "Tenant"
+		WHERE id::text = $1 OR slug = $1
+		LIMIT 1;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XTenant
+	err = conn.QueryRow(ctx, q, id_or_slug).Scan(&res.ID, &res.Slug, &res.Name, &res.Host, &res.CreatedAt, &res.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &core.ErrTenantNotFound{ErrMessage: id_or_slug}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// GetTenantForHost извлекает тенант, привязанный к хосту запроса.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - host: хост запроса без порта
+//
+// Возвращает:
+//   - указатель на структуру XTenant с данными тенанта
+//   - ошибку, если тенант не найден или произошла ошибка базы данных
+func (r *AuthRepo) GetTenantForHost(ctx context.Context, host string) (*XTenant, error) {
+	const q = `
+		SELECT
+			id
+			, slug
+			, name
+			, host
+			, created_at
+			, updated_at
+		FROM "Tenant"
+		WHERE host = $1
+		LIMIT 1;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XTenant
+	err = conn.QueryRow(ctx, q, host).Scan(&res.ID, &res.Slug, &res.Name, &res.Host, &res.CreatedAt, &res.UpdatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &core.ErrTenantNotFound{ErrMessage: host}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// CreateTenant создает новый тенант вместе с его ролями admin и user.
+// Роль admin получает все встроенные разрешения, кроме разрешений операторов платформы.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - slug: уникальный код тенанта
+//   - name: название тенанта
+//   - host: хост тенанта или nil, если тенант определяется только по заголовку
+//
+// Возвращает:
+//   - указатель на структуру XTenant с данными созданного тенанта
+//   - ошибку, если slug или хост уже заняты или произошла ошибка базы данных
+func (r *AuthRepo) CreateTenant(ctx context.Context, slug string, name string, host *string) (*XTenant, error) {
+	const q = `
+		INSERT INTO "Tenant"
+		(
+			slug
+			, name
+			, host
+		)
+		VALUES ($1, $2, $3)
+		RETURNING
+			id
+			, slug
+			, name
+			, host
+			, created_at
+			, updated_at;
+	`
+	const qRoles = `
+		INSERT INTO "Role"
+		(
+			tenant_id
+			, name
+			, description
+		)
+		VALUES
+			($1, 'admin', 'Администратор тенанта')
+			, ($1, 'user', 'Пользователь');
+	`
+	const qGrant = `
+		INSERT INTO "RolePermission" (role_id, permission_id)
+		SELECT r.id, p.id
+		FROM "Role" r
+		CROSS JOIN "Permission" p
+		WHERE True
+			AND r.tenant_id = $1
+			AND r.name = 'admin'
+			AND p.tenant_id IS NULL
+			AND NOT p.platform;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer tx.Rollback(ctx)
+
+	var res XTenant
+	err = tx.QueryRow(ctx, q, slug, name, host).Scan(&res.ID, &res.Slug, &res.Name, &res.Host, &res.CreatedAt, &res.UpdatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, &core.ErrCreateTenant{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	if _, err := tx.Exec(ctx, qRoles, res.ID); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	if _, err := tx.Exec(ctx, qGrant, res.ID); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// CreateOrganization создает организацию в тенанте и делает создателя ее владельцем.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - name: название организации
+//   - owner_id: идентификатор аккаунта создателя
+//
+// Возвращает:
+//   - указатель на структуру XOrganization с ролью owner
+//   - ошибку, если аккаунт не найден в тенанте или произошла ошибка базы данных
+func (r *AuthRepo) CreateOrganization(ctx context.Context, tenant_id string, name string, owner_id string) (*XOrganization, error) {
+	const q = `
+		WITH acc AS (
+			SELECT id
+			FROM "Account"
+			WHERE True
+				AND tenant_id = $1
+				AND id = $3
+		), org AS (
+			INSERT INTO "Organization" (tenant_id, name)
+			SELECT $1, $2 FROM acc
+			RETURNING id, tenant_id, name, created_at, updated_at
+		), member AS (
+			INSERT INTO "OrganizationMember" (org_id, account_id, role)
+			SELECT org.id, acc.id, 'owner' FROM org, acc
+		)
+		SELECT id, tenant_id, name, created_at, updated_at
+		FROM org;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	res := XOrganization{Role: "owner"}
+	err = conn.QueryRow(ctx, q, tenant_id, name, owner_id).Scan(&res.ID, &res.TenantID, &res.Name, &res.CreatedAt, &res.UpdatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrAccountNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// GetOrganizationsForAccount возвращает организации тенанта, в которых состоит аккаунт, вместе с его ролью.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - account_id: идентификатор аккаунта
+//
+// Возвращает:
+//   - список структур XOrganization, отсортированный по названию
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) GetOrganizationsForAccount(ctx context.Context, tenant_id string, account_id string) ([]XOrganization, error) {
+	const q = `
+		SELECT
+			o.id
+			, o.tenant_id
+			, o.name
+			, m.role
+			, o.created_at
+			, o.updated_at
+		FROM "OrganizationMember" m
+		JOIN "Organization" o ON o.id = m.org_id
+		WHERE True
+			AND o.tenant_id = $1
+			AND m.account_id = $2
+		ORDER BY o.name, o.id;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, q, tenant_id, account_id)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res := []XOrganization{}
+	for rows.Next() {
+		var org XOrganization
+		if err := rows.Scan(&org.ID, &org.TenantID, &org.Name, &org.Role, &org.CreatedAt, &org.UpdatedAt); err != nil {
+			return nil, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return res, nil
+}
+
+// GetOrganizationMember извлекает участника организации тенанта.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - org_id: идентификатор организации
+//   - account_id: идентификатор аккаунта участника
+//
+// Возвращает:
+//   - указатель на структуру XOrgMember
+//   - ошибку, если участник не найден или произошла ошибка базы данных
+func (r *AuthRepo) GetOrganizationMember(ctx context.Context, tenant_id string, org_id string, account_id string) (*XOrgMember, error) {
+	const q = `
+		SELECT
+			m.org_id
+			, m.account_id
+			, a.email
+			, m.role
+			, m.created_at
+			, m.updated_at
+		FROM "OrganizationMember" m
+		JOIN "Organization" o ON o.id = m.org_id
+		JOIN "Account" a ON a.id = m.account_id
+		WHERE True
+			AND o.tenant_id = $1
+			AND m.org_id = $2
+			AND m.account_id = $3
+		LIMIT 1;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XOrgMember
+	err = conn.QueryRow(ctx, q, tenant_id, org_id, account_id).Scan(&res.OrgID, &res.AccountID, &res.Email, &res.Role, &res.CreatedAt, &res.UpdatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrMemberNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// GetOrganizationMembers возвращает всех участников организации тенанта.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - org_id: идентификатор организации
+//
+// Возвращает:
+//   - список структур XOrgMember, отсортированный по дате вступления
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) GetOrganizationMembers(ctx context.Context, tenant_id string, org_id string) ([]XOrgMember, error) {
+	const q = `
+		SELECT
+			m.org_id
+			, m.account_id
+			, a.email
+			, m.role
+			, m.created_at
+			, m.updated_at
+		FROM "OrganizationMember" m
+		JOIN "Organization" o ON o.id = m.org_id
+		JOIN "Account" a ON a.id = m.account_id
+		WHERE True
+			AND o.tenant_id = $1
+			AND m.org_id = $2
+		ORDER BY m.created_at, m.account_id;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, q, tenant_id, org_id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			return nil, &core.ErrOrganizationNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res := []XOrgMember{}
+	for rows.Next() {
+		var m XOrgMember
+		if err := rows.Scan(&m.OrgID, &m.AccountID, &m.Email, &m.Role, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return res, nil
+}
+
+// SetMemberRole меняет роль участника организации тенанта.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - org_id: идентификатор организации
+//   - account_id: идентификатор аккаунта участника
+//   - role: новая роль (owner, admin или member)
+//
+// Возвращает:
+//   - указатель на структуру XOrgMember с обновленной ролью
+//   - ошибку, если участник не найден или произошла ошибка базы данных
+func (r *AuthRepo) SetMemberRole(ctx context.Context, tenant_id string, org_id string, account_id string, role string) (*XOrgMember, error) {
+	const q = `
+		UPDATE "OrganizationMember" m
+		SET role = $4,
+		updated_at = NOW()
+		FROM "Organization" o, "Account" a
+		WHERE True
+			AND o.id = m.org_id
+			AND a.id = m.account_id
+			AND o.tenant_id = $1
+			AND m.org_id = $2
+			AND m.account_id = $3
+		RETURNING
+			m.org_id
+			, m.account_id
+			, a.email
+			, m.role
+			, m.created_at
+			, m.updated_at;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XOrgMember
+	err = conn.QueryRow(ctx, q, tenant_id, org_id, account_id, role).Scan(&res.OrgID, &res.AccountID, &res.Email, &res.Role, &res.CreatedAt, &res.UpdatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrMemberNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// RemoveMember исключает участника из организации тенанта.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - org_id: идентификатор организации
+//   - account_id: идентификатор аккаунта участника
+//
+// Возвращает:
+//   - true, если участник был исключен
+//   - ошибку, если участник не найден или произошла ошибка базы данных
+func (r *AuthRepo) RemoveMember(ctx context.Context, tenant_id string, org_id string, account_id string) (bool, error) {
+	const q = `
+		DELETE FROM "OrganizationMember" m
+		USING "Organization" o
+		WHERE True
+			AND o.id = m.org_id
+			AND o.tenant_id = $1
+			AND m.org_id = $2
+			AND m.account_id = $3;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, q, tenant_id, org_id, account_id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			return false, &core.ErrMemberNotFound{ErrMessage: err}
+		}
+
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	if tag.RowsAffected() == 0 {
+		return false, &core.ErrMemberNotFound{ErrMessage: account_id}
+	}
+
+	return true, nil
+}
+
+// CreateInvite создает приглашение в организацию тенанта, действующее ttl.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - org_id: идентификатор организации
+//   - email: email приглашенного
+//   - role: роль, которую получит приглашенный (admin или member)
+//   - code: код приглашения
+//   - invited_by: идентификатор пригласившего аккаунта
+//   - ttl: срок действия приглашения
+//
+// Возвращает:
+//   - указатель на структуру XOrgInvite с данными приглашения
+//   - ошибку, если организация не найдена или произошла ошибка базы данных
+func (r *AuthRepo) CreateInvite(ctx context.Context, tenant_id string, org_id string, email string, role string, code string, invited_by string, ttl time.Duration) (*XOrgInvite, error) {
+	const q = `
+		INSERT INTO "OrganizationInvite"
+		(
+			org_id
+			, email
+			, role
+			, code
+			, invited_by
+			, expires_at
+		)
+		SELECT o.id, $3, $4, $5, $6::uuid, NOW() + make_interval(secs => $7)
+		FROM "Organization" o
+		WHERE True
+			AND o.tenant_id = $1
+			AND o.id = $2
+		RETURNING
+			id
+			, org_id
+			, email
+			, role
+			, code
+			, invited_by
+			, expires_at
+			, accepted_at
+			, created_at;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XOrgInvite
+	err = conn.QueryRow(ctx, q, tenant_id, org_id, email, role, code, invited_by, ttl.Seconds()).Scan(&res.ID, &res.OrgID, &res.Email, &res.Role, &res.Code, &res.InvitedBy, &res.ExpiresAt, &res.AcceptedAt, &res.CreatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrOrganizationNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// GetInvite извлекает действующее (непринятое и неистекшее) приглашение в организацию тенанта.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор приглашения
+//
+// Возвращает:
+//   - указатель на структуру XOrgInvite с данными приглашения
+//   - ошибку, если приглашение не найдено, принято, истекло или произошла ошибка базы данных
+func (r *AuthRepo) GetInvite(ctx context.Context, tenant_id string, id string) (*XOrgInvite, error) {
+	const q = `
+		SELECT
+			i.id
+			, i.org_id
+			, i.email
+			, i.role
+			, i.code
+			, i.invited_by
+			, i.expires_at
+			, i.accepted_at
+			, i.created_at
+		FROM "OrganizationInvite" i
+		JOIN "Organization" o ON o.id = i.org_id
+		WHERE True
+			AND o.tenant_id = $1
+			AND i.id = $2
+			AND i.accepted_at IS NULL
+			AND i.expires_at > NOW()
+		LIMIT 1;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XOrgInvite
+	err = conn.QueryRow(ctx, q, tenant_id, id).Scan(&res.ID, &res.OrgID, &res.Email, &res.Role, &res.Code, &res.InvitedBy, &res.ExpiresAt, &res.AcceptedAt, &res.CreatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrInviteNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
+
+// AcceptInvite помечает приглашение принятым и добавляет аккаунт в организацию.
+// Если аккаунт уже состоит в организации, его роль не понижается до роли из приглашения.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор приглашения
+//   - account_id: идентификатор аккаунта, принимающего приглашение
+//
+// Возвращает:
+//   - указатель на структуру XOrgMember с данными участника
+//   - ошибку, если приглашение уже принято, истекло или произошла ошибка базы данных
+func (r *AuthRepo) AcceptInvite(ctx context.Context, tenant_id string, id string, account_id string) (*XOrgMember, error) {
+	const q = `
+		WITH inv AS (
+			UPDATE "OrganizationInvite" i
+			SET accepted_at = NOW()
+			FROM "Organization" o
+			WHERE True
+				AND o.id = i.org_id
+				AND o.tenant_id = $1
+				AND i.id = $2
+				AND i.accepted_at IS NULL
+				AND i.expires_at > NOW()
+			RETURNING i.org_id, i.role
+		), member AS (
+			INSERT INTO "OrganizationMember" (org_id, account_id, role)
+			SELECT inv.org_id, a.id, inv.role
+			FROM inv
+			JOIN "Account" a ON True
+				AND a.tenant_id = $1
+				AND a.id = $3
+			ON CONFLICT (org_id, account_id) DO UPDATE
+			SET updated_at = NOW()
+			RETURNING org_id, account_id, role, created_at, updated_at
+		)
+		SELECT
+			m.org_id
+			, m.account_id
+			, a.email
+			, m.role
+			, m.created_at
+			, m.updated_at
+		FROM member m
+		JOIN "Account" a ON a.id = m.account_id;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var res XOrgMember
+	err = conn.QueryRow(ctx, q, tenant_id, id, account_id).Scan(&res.OrgID, &res.AccountID, &res.Email, &res.Role, &res.CreatedAt, &res.UpdatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrInviteNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return &res, nil
+}
@@ -0,0 +1,517 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/jobs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type IWebhookRepo interface {
+	CreateWebhook(ctx context.Context, tenant_id string, url string, events []string, secret string, description string) (*XWebhook, error)
+	GetWebhooks(ctx context.Context, tenant_id string) ([]XWebhook, error)
+	UpdateWebhook(ctx context.Context, tenant_id string, id string, url string, events []string, description string, enabled bool) (*XWebhook, error)
+	DeleteWebhook(ctx context.Context, tenant_id string, id string) (bool, error)
+	SearchWebhookDeliveries(ctx context.Context, tenant_id string, webhook_id string, status string, limit int, offset int) ([]XWebhookDelivery, int, error)
+	CreateWebhookDeliveries(ctx context.Context, tenant_id string, event_id string, event string, payload []byte, job func(delivery_id string) jobs.NewJob) (int, error)
+	GetWebhookDelivery(ctx context.Context, tenant_id string, id string) (*XWebhookDelivery, *XWebhook, error)
+	RecordWebhookAttempt(ctx context.Context, tenant_id string, id string, attempt *XWebhookAttempt, disable_after int) (bool, error)
+}
+
+const webhookColumns = `
+	id
+	, tenant_id
+	, url
+	, events
+	, secret
+	, description
+	, enabled
+	, consecutive_failures
+	, disabled_at
+	, disabled_reason
+	, created_at
+	, updated_at
+`
+
+const webhookDeliveryColumns = `
+	id
+	, tenant_id
+	, webhook_id
+	, event_id
+	, event
+	, payload
+	, status
+	, attempts
+	, response_status
+	, response_body
+	, last_error
+	, duration_ms
+	, delivered_at
+	, created_at
+	, updated_at
+`
+
+// CreateWebhook создает подписку тенанта на события.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - url: адрес получателя
+//   - events: типы событий, * - все
+//   - secret: ключ HMAC подписи
+//   - description: описание подписки
+//
+// Возвращает:
+//   - указатель на структуру XWebhook с данными подписки
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) CreateWebhook(ctx context.Context, tenant_id string, url string, events []string, secret string, description string) (*XWebhook, error) {
+	const q = `
+		INSERT INTO "Webhook"
+		(
+			tenant_id
+			, url
+			, events
+			, secret
+			, description
+		)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING` + webhookColumns + `;`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	res, err := scanWebhook(conn.QueryRow(ctx, q, tenant_id, url, events, secret, description))
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return res, nil
+}
+
+// GetWebhooks возвращает подписки тенанта.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//
+// Возвращает:
+//   - список структур XWebhook, отсортированный по дате создания
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) GetWebhooks(ctx context.Context, tenant_id string) ([]XWebhook, error) {
+	const q = `
+		SELECT` + webhookColumns + `
+		FROM "Webhook"
+		WHERE tenant_id = $1
+		ORDER BY created_at, id;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(ctx, q, tenant_id)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res := []XWebhook{}
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, *hook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return res, nil
+}
+
+// UpdateWebhook меняет адрес, фильтр событий, описание и включенность подписки.
+// Включение сбрасывает счетчик неудач и причину отключения.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор подписки
+//   - url: адрес получателя
+//   - events: типы событий, * - все
+//   - description: описание подписки
+//   - enabled: включена ли подписка
+//
+// Возвращает:
+//   - указатель на структуру XWebhook с обновленными данными
+//   - ошибку, если подписка не найдена или произошла ошибка базы данных
+func (r *AuthRepo) UpdateWebhook(ctx context.Context, tenant_id string, id string, url string, events []string, description string, enabled bool) (*XWebhook, error) {
+	const q = `
+		UPDATE "Webhook"
+		SET url = $3,
+		events = $4,
+		description = $5,
+		consecutive_failures = CASE WHEN $6 THEN 0 ELSE consecutive_failures END,
+		disabled_at = CASE WHEN $6 THEN NULL WHEN enabled THEN NOW() ELSE disabled_at END,
+		disabled_reason = CASE WHEN $6 THEN NULL WHEN enabled THEN 'отключена вручную' ELSE disabled_reason END,
+		enabled = $6,
+		updated_at = NOW()
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2
+		RETURNING` + webhookColumns + `;`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	res, err := scanWebhook(conn.QueryRow(ctx, q, tenant_id, id, url, events, description, enabled))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+			return nil, &core.ErrWebhookNotFound{ErrMessage: err}
+		}
+
+		return nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return res, nil
+}
+
+// DeleteWebhook удаляет подписку вместе с журналом ее доставок.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор подписки
+//
+// Возвращает:
+//   - true, если подписка удалена
+//   - ошибку, если подписка не найдена или произошла ошибка базы данных
+func (r *AuthRepo) DeleteWebhook(ctx context.Context, tenant_id string, id string) (bool, error) {
+	const q = `
+		DELETE FROM "Webhook"
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	tag, err := conn.Exec(ctx, q, tenant_id, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			return false, &core.ErrWebhookNotFound{ErrMessage: err}
+		}
+
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	if tag.RowsAffected() == 0 {
+		return false, &core.ErrWebhookNotFound{ErrMessage: pgx.ErrNoRows}
+	}
+
+	return true, nil
+}
+
+// SearchWebhookDeliveries ищет доставки подписки по статусу.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - webhook_id: идентификатор подписки
+//   - status: статус доставки; пустой - любой
+//   - limit: размер страницы
+//   - offset: смещение
+//
+// Возвращает:
+//   - страницу доставок, отсортированных от новых к старым
+//   - общее количество доставок, подходящих под фильтр
+//   - ошибку, если подписка не найдена или произошла ошибка базы данных
+func (r *AuthRepo) SearchWebhookDeliveries(ctx context.Context, tenant_id string, webhook_id string, status string, limit int, offset int) ([]XWebhookDelivery, int, error) {
+	const where = `
+		WHERE True
+			AND tenant_id = $1
+			AND webhook_id = $2
+			AND ($3 = '' OR status = $3)
+	`
+	const qCount = `
+		SELECT COUNT(*)
+		FROM "WebhookDelivery"
+	` + where
+	const q = `
+		SELECT` + webhookDeliveryColumns + `
+		FROM "WebhookDelivery"
+	` + where + `
+		ORDER BY created_at DESC, id
+		LIMIT $4 OFFSET $5;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	var total int
+	err = conn.QueryRow(ctx, qCount, tenant_id, webhook_id, status).Scan(&total)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "22P02" {
+			return nil, 0, &core.ErrWebhookNotFound{ErrMessage: err}
+		}
+
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	rows, err := conn.Query(ctx, q, tenant_id, webhook_id, status, limit, offset)
+	if err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer rows.Close()
+
+	res := []XWebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+		}
+		res = append(res, *delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return res, total, nil
+}
+
+// CreateWebhookDeliveries создает доставки события для всех включенных подписок тенанта, фильтр которых
+// подходит под тип события, и в той же транзакции ставит задачу доставки для каждой из них.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - event_id: идентификатор события, общий для всех доставок
+//   - event: тип события
+//   - payload: тело запроса
+//   - job: строит задачу доставки по идентификатору доставки
+//
+// Возвращает:
+//   - количество созданных доставок
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) CreateWebhookDeliveries(ctx context.Context, tenant_id string, event_id string, event string, payload []byte, job func(delivery_id string) jobs.NewJob) (int, error) {
+	const q = `
+		INSERT INTO "WebhookDelivery"
+		(
+			tenant_id
+			, webhook_id
+			, event_id
+			, event
+			, payload
+		)
+		SELECT w.tenant_id, w.id, $2, $3, $4
+		FROM "Webhook" w
+		WHERE True
+			AND w.tenant_id = $1
+			AND w.enabled
+			AND ($3 = ANY(w.events) OR '*' = ANY(w.events))
+		RETURNING id;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, q, tenant_id, event_id, event, payload)
+	if err != nil {
+		return 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	enqueue := make([]jobs.NewJob, 0, len(ids))
+	for _, id := range ids {
+		enqueue = append(enqueue, job(id))
+	}
+	if err := enqueueJobs(ctx, tx, enqueue); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	return len(ids), nil
+}
+
+// GetWebhookDelivery возвращает доставку вместе с ее подпиской.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор доставки
+//
+// Возвращает:
+//   - указатель на структуру XWebhookDelivery
+//   - указатель на структуру XWebhook подписки
+//   - ошибку, если доставка не найдена (например, подписка удалена) или произошла ошибка базы данных
+func (r *AuthRepo) GetWebhookDelivery(ctx context.Context, tenant_id string, id string) (*XWebhookDelivery, *XWebhook, error) {
+	const qDelivery = `
+		SELECT` + webhookDeliveryColumns + `
+		FROM "WebhookDelivery"
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2;
+	`
+	const qWebhook = `
+		SELECT` + webhookColumns + `
+		FROM "Webhook"
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return nil, nil, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	delivery, err := scanWebhookDelivery(conn.QueryRow(ctx, qDelivery, tenant_id, id))
+	if err == nil {
+		var hook *XWebhook
+		hook, err = scanWebhook(conn.QueryRow(ctx, qWebhook, tenant_id, delivery.WebhookID))
+		if err == nil {
+			return delivery, hook, nil
+		}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+		return nil, nil, &core.ErrWebhookNotFound{ErrMessage: err}
+	}
+	return nil, nil, &core.ErrPGRepo{ErrMessage: err}
+}
+
+// RecordWebhookAttempt записывает результат попытки доставки и обновляет счетчик неудач подписки.
+// Если неудач подряд стало disable_after, подписка отключается.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - tenant_id: идентификатор тенанта
+//   - id: идентификатор доставки
+//   - attempt: результат попытки
+//   - disable_after: количество неудач подряд, после которого подписка отключается; 0 - не отключать
+//
+// Возвращает:
+//   - true, если подписка отключена этой попыткой
+//   - ошибку, если произошла ошибка базы данных
+func (r *AuthRepo) RecordWebhookAttempt(ctx context.Context, tenant_id string, id string, attempt *XWebhookAttempt, disable_after int) (bool, error) {
+	const qDelivery = `
+		UPDATE "WebhookDelivery"
+		SET status = $3,
+		attempts = attempts + 1,
+		response_status = $4,
+		response_body = $5,
+		last_error = $6,
+		duration_ms = $7,
+		delivered_at = CASE WHEN $3 = 'success' THEN NOW() END,
+		updated_at = NOW()
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2
+		RETURNING webhook_id;
+	`
+	const qWebhook = `
+		UPDATE "Webhook"
+		SET consecutive_failures = CASE WHEN $3 THEN 0 ELSE consecutive_failures + 1 END,
+		enabled = enabled AND ($3 OR $4 <= 0 OR consecutive_failures + 1 < $4),
+		disabled_at = CASE WHEN enabled AND NOT $3 AND $4 > 0 AND consecutive_failures + 1 >= $4 THEN NOW() ELSE disabled_at END,
+		disabled_reason = CASE WHEN enabled AND NOT $3 AND $4 > 0 AND consecutive_failures + 1 >= $4 THEN $5 ELSE disabled_reason END,
+		updated_at = NOW()
+		WHERE True
+			AND tenant_id = $1
+			AND id = $2
+		RETURNING NOT $3 AND $4 > 0 AND consecutive_failures = $4;
+	`
+
+	conn, err := r.pgRepo.Acquire(ctx)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	defer tx.Rollback(ctx)
+
+	var webhook_id string
+	err = tx.QueryRow(ctx, qDelivery, tenant_id, id, attempt.Status, attempt.ResponseStatus, attempt.ResponseBody, attempt.Error, attempt.DurationMs).Scan(&webhook_id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, &core.ErrWebhookNotFound{ErrMessage: err}
+		}
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	var disabled bool
+	reason := "отключена после неудачных доставок подряд"
+	if attempt.Error != nil {
+		reason += ": " + *attempt.Error
+	}
+	err = tx.QueryRow(ctx, qWebhook, tenant_id, webhook_id, attempt.Status == "success", disable_after, reason).Scan(&disabled)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, &core.ErrPGRepo{ErrMessage: err}
+	}
+	return disabled, nil
+}
+
+// scanWebhook читает подписку из строки с колонками webhookColumns.
+func scanWebhook(row pgx.Row) (*XWebhook, error) {
+	var res XWebhook
+	err := row.Scan(&res.ID, &res.TenantID, &res.URL, &res.Events, &res.Secret, &res.Description, &res.Enabled, &res.ConsecutiveFailures, &res.DisabledAt, &res.DisabledReason, &res.CreatedAt, &res.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// scanWebhookDelivery читает доставку из строки с колонками webhookDeliveryColumns.
+func scanWebhookDelivery(row pgx.Row) (*XWebhookDelivery, error) {
+	var res XWebhookDelivery
+	err := row.Scan(&res.ID, &res.TenantID, &res.WebhookID, &res.EventID, &res.Event, &res.Payload, &res.Status, &res.Attempts, &res.ResponseStatus, &res.ResponseBody, &res.LastError, &res.DurationMs, &res.DeliveredAt, &res.CreatedAt, &res.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
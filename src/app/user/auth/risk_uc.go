@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/geoip"
+	"github.com/MedodsTechTask/app/mail"
+	"github.com/MedodsTechTask/app/risk"
+	"github.com/MedodsTechTask/app/session"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+)
+
+// Виды попыток в журнале входов
+const (
+	LoginKindLogin   = "login"
+	LoginKindRefresh = "refresh"
+)
+
+// Исходы попыток в журнале входов
+const (
+	LoginOutcomeSuccess = "success"
+	LoginOutcomeFailure = "failure"
+	LoginOutcomeBlocked = "blocked"
+)
+
+// assessLogin оценивает риск успешной проверки учетных данных, записывает попытку в журнал входов и выполняет решение:
+// block запрещает вход, notify отправляет владельцу уведомление. Порог дополнительной проверки
+// приводит к одному из этих решений по настройке step_up_action.
+// Ошибка журнала не должна запрещать вход, поэтому она только логируется и оценка пропускается.
+//
+// Параметры:
+//   - ctx: контекст запроса, из него берутся тенант, IP и User-Agent
+//   - account_id: идентификатор аккаунта
+//   - account_email: email аккаунта для уведомления, пустая строка - будет получен из базы при необходимости
+//   - kind: вид попытки, LoginKindLogin или LoginKindRefresh
+//
+// Возвращает:
+//   - результат оценки, nil - оценка не выполнялась
+//   - указатель на структуру ZError, если вход запрещен
+func (s *AuthUseCase) assessLogin(ctx context.Context, account_id string, account_email string, kind string) (*risk.Assessment, *core.ZError) {
+	if s.conf(ctx).risk == nil {
+		return nil, nil
+	}
+	tenant_id := core.TenantFromContext(ctx)
+	client := core.ClientFromContext(ctx)
+	cfg := s.conf(ctx).risk.Config()
+	location := s.conf(ctx).risk.Locate(client.IP)
+	device := deviceKey(client.UserAgent)
+
+	history, err := s.repo.GetRiskHistory(ctx, tenant_id, account_id, client.IP, device, location.Country, location.ASN, time.Now().Add(-cfg.SprayWindow))
+	if err != nil {
+		log.Printf("risk history of %s: %s", account_id, err)
+		return nil, nil
+	}
+	h := risk.History{
+		Logins:        history.Logins,
+		KnownDevice:   history.KnownDevice,
+		KnownCountry:  history.KnownCountry,
+		KnownASN:      history.KnownASN,
+		SprayAccounts: history.SprayAccounts,
+	}
+	if history.Last != nil {
+		h.Last = &risk.Previous{Time: history.Last.CreatedAt, Latitude: history.Last.Latitude, Longitude: history.Last.Longitude}
+	}
+	// Перебор паролей по многим аккаунтам виден только на входах по паролю
+	if kind != LoginKindLogin {
+		h.SprayAccounts = 0
+	}
+	assessment := s.conf(ctx).risk.Assess(risk.Attempt{Time: time.Now(), Location: location, Device: device}, h)
+
+	outcome := LoginOutcomeSuccess
+	if assessment.Decision == risk.DecisionBlock {
+		outcome = LoginOutcomeBlocked
+	}
+	err = s.repo.RecordLoginEvent(ctx, &repo.XLoginEvent{
+		TenantID:  tenant_id,
+		AccountID: &account_id,
+		Kind:      kind,
+		Outcome:   outcome,
+		IpAddress: client.IP,
+		Device:    device,
+		Country:   location.Country,
+		ASN:       location.ASN,
+		Latitude:  location.Latitude,
+		Longitude: location.Longitude,
+		Score:     assessment.Score,
+		Decision:  assessment.Decision,
+		Signals:   assessment.Signals,
+	})
+	if err != nil {
+		log.Printf("login event of %s: %s", account_id, err)
+	}
+
+	switch assessment.Decision {
+	case risk.DecisionBlock:
+		return &assessment, &core.ZError{
+			Code:      403,
+			Where:     "UseCase",
+			Message:   "Вход запрещен: подозрительная активность",
+			Exception: assessment,
+		}
+	case risk.DecisionNotify:
+		if account_email == "" {
+			acc, err := s.repo.GetAccount(ctx, tenant_id, account_id)
+			if err != nil {
+				log.Printf("risk notice of %s: %s", account_id, err)
+				return &assessment, nil
+			}
+			account_email = acc.Email
+		}
+		s.notifySecurity(ctx, account_email, mail.NoticeSuspiciousLogin)
+	}
+	return &assessment, nil
+}
+
+// recordLoginFailure записывает неудачный вход по паролю в журнал входов. По таким записям с одного IP
+// определяется перебор паролей по многим аккаунтам, а по email и IP - когда на входе нужна CAPTCHA.
+//
+// Параметры:
+//   - ctx: контекст запроса, из него берутся тенант, IP и User-Agent
+//   - account_id: идентификатор аккаунта, пустая строка - email не найден
+//   - email_canonical: каноническая форма email попытки
+func (s *AuthUseCase) recordLoginFailure(ctx context.Context, account_id string, email_canonical string) {
+	client := core.ClientFromContext(ctx)
+	var location geoip.Location
+	if s.conf(ctx).risk != nil {
+		location = s.conf(ctx).risk.Locate(client.IP)
+	}
+	ev := &repo.XLoginEvent{
+		TenantID:       core.TenantFromContext(ctx),
+		EmailCanonical: email_canonical,
+		Kind:           LoginKindLogin,
+		Outcome:        LoginOutcomeFailure,
+		IpAddress:      client.IP,
+		Device:         deviceKey(client.UserAgent),
+		Country:        location.Country,
+		ASN:            location.ASN,
+		Latitude:       location.Latitude,
+		Longitude:      location.Longitude,
+	}
+	if account_id != "" {
+		ev.AccountID = &account_id
+	}
+	if err := s.repo.RecordLoginEvent(ctx, ev); err != nil {
+		log.Printf("login event in tenant %s from %s: %s", ev.TenantID, client.IP, err)
+	}
+}
+
+// riskDetails переводит оценку риска в детали записи аудита.
+func riskDetails(details map[string]string, assessment *risk.Assessment) map[string]string {
+	if assessment == nil {
+		return details
+	}
+	if details == nil {
+		details = map[string]string{}
+	}
+	details["risk_score"] = strconv.Itoa(assessment.Score)
+	details["risk_decision"] = assessment.Decision
+	if len(assessment.Signals) > 0 {
+		details["risk_signals"] = strings.Join(assessment.Signals, ",")
+	}
+	if assessment.StepUp {
+		details["risk_step_up"] = "true"
+	}
+	return details
+}
+
+// deviceKey - ключ устройства для журнала входов: семейство браузера и ОС без версий, чтобы обновление
+// браузера не считалось новым устройством.
+func deviceKey(user_agent string) string {
+	ua := session.ParseUserAgent(user_agent)
+	if ua.Family == "" && ua.OS == "" {
+		return ""
+	}
+	return ua.Family + "/" + ua.OS
+}
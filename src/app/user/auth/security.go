@@ -1,49 +1,116 @@
 package auth
 
 import (
+	"context"
+	"crypto/elliptic"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"math/big"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/metrics"
+	"github.com/MedodsTechTask/app/password"
+	"github.com/MedodsTechTask/app/user/auth/configs"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// CreatePasswordHash генерирует хеш пароля с использованием соли.
+// tracer - спаны сценариев аутентификации, хеширования паролей и JWT
+var tracer = otel.Tracer("github.com/MedodsTechTask/app/user/auth")
+
+// CreatePasswordHash хеширует пароль текущим алгоритмом менеджера паролей (по умолчанию argon2id).
+// Соль генерируется автоматически и хранится внутри хеша в формате PHC.
 //
 // Параметры:
+//   - ctx: контекст запроса, в нем начинается спан хеширования
+//   - passwords: менеджер паролей
 //   - pwd: пароль для хеширования
-//   - salt: соль для хеширования (если пустая строка, генерируется автоматически)
 //
 // Возвращает:
-//   - хеш пароля
-//   - использованную соль
+//   - хеш пароля в формате PHC
 //   - ошибку (если возникла)
-func CreatePasswordHash(pwd string, salt string) (string, string, error) {
+func CreatePasswordHash(ctx context.Context, passwords *password.Manager, pwd string) (string, error) {
 	if pwd == "" {
-		return "", "", &core.ErrPasswordEmpty{ErrMessage: nil}
+		return "", &core.ErrPasswordEmpty{ErrMessage: nil}
 	}
 
-	if salt == "" {
-		saltBytes := make([]byte, 16)
-		if _, err := rand.Read(saltBytes); err != nil {
-			return "", "", &core.ErrGenerationSalt{ErrMessage: err}
-		}
-		salt = hex.EncodeToString(saltBytes)
+	_, span := tracer.Start(ctx, "password.hash")
+	defer span.End()
+	defer observe(metrics.PasswordHashDuration.WithLabelValues("hash"), time.Now())
+	hash, err := passwords.Hash(pwd)
+	if err != nil {
+		recordError(span, err)
+		return "", &core.ErrGenerationHash{ErrMessage: err}
 	}
+	return hash, nil
+}
 
-	hash := sha256.New()
-	if _, err := hash.Write([]byte(pwd + salt)); err != nil {
-		return "", "", &core.ErrGenerationHash{ErrMessage: err}
+// VerifyPasswordHash сверяет пароль с сохраненным хешем. Понимает хеши в формате PHC (argon2id, bcrypt)
+// и устаревшие SHA-256 хеши с солью в отдельной колонке.
+//
+// Параметры:
+//   - ctx: контекст запроса, в нем начинается спан проверки
+//   - passwords: менеджер паролей
+//   - pwd: пароль
+//   - hash: сохраненный хеш
+//   - salt: соль из колонки salt, нужна только для устаревших хешей
+//
+// Возвращает:
+//   - true, если пароль верный
+//   - true, если хеш нужно пересчитать через CreatePasswordHash
+//   - ошибку (если возникла)
+func VerifyPasswordHash(ctx context.Context, passwords *password.Manager, pwd string, hash string, salt string) (bool, bool, error) {
+	if pwd == "" {
+		return false, false, &core.ErrPasswordEmpty{ErrMessage: nil}
+	}
+
+	_, span := tracer.Start(ctx, "password.verify")
+	defer span.End()
+	defer observe(metrics.PasswordHashDuration.WithLabelValues("verify"), time.Now())
+	ok, rehash, err := passwords.Verify(pwd, hash, salt)
+	if err != nil {
+		recordError(span, err)
+		return false, false, &core.ErrGenerationHash{ErrMessage: err}
 	}
-	hashSum := hash.Sum(nil)
-	hashHex := hex.EncodeToString(hashSum)
+	return ok, rehash, nil
+}
 
-	return hashHex, salt, nil
+// CompareCodes сравнивает код подтверждения из запроса с сохраненным за постоянное время.
+//
+// Параметры:
+//   - actual: код из запроса
+//   - expected: сохраненный код
+//
+// Возвращает:
+//   - true, если коды совпадают
+func CompareCodes(actual string, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(expected)) == 1
+}
+
+// CreateDecoyID генерирует случайный UUID v4 для ответов, которые не должны выдавать отсутствие записи.
+//
+// Возвращает:
+//   - UUID в текстовом виде
+func CreateDecoyID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	buf[6] = buf[6]&0x0f | 0x40
+	buf[8] = buf[8]&0x3f | 0x80
+	h := hex.EncodeToString(buf)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
 }
 
 // CreateConfirmCode генерирует случайный код подтверждения.
@@ -73,20 +140,27 @@ func CreateConfirmCode() (string, error) {
 // CreateJWT генерирует JWT токен с заданной полезной нагрузкой и подписывает его с использованием приватного ключа.
 //
 // Параметры:
+//   - ctx: контекст запроса, в нем начинается спан подписи
 //   - payload: карта с данными, которые должны быть включены в токен
 //   - private_key: строка, содержащая приватный ключ для подписи токена
+//   - algorithm: алгоритм подписи (configs.JWTRS512, configs.JWTES256, configs.JWTEdDSA), тип ключа должен ему соответствовать
+//   - issuer: значение claim iss
+//   - ttl: время жизни токена, задает exp, exp_at и exp_in; полезная нагрузка может их переопределить
 //
 // Возвращает:
 //   - сгенерированный и подписанный JWT токен
 //   - ошибку (если возникла)
-func CreateJWT(payload map[string]interface{}, private_key string) (string, error) {
-	delta := 24 * 60 * 60
+func CreateJWT(ctx context.Context, payload map[string]interface{}, private_key string, algorithm string, issuer string, ttl time.Duration) (string, error) {
+	_, span := tracer.Start(ctx, "jwt.sign", trace.WithAttributes(attribute.String("jwt.algorithm", algorithm)))
+	defer span.End()
+
+	delta := int(ttl.Seconds())
 	now := time.Now().UTC()
-	exp := now.Add(time.Duration(delta) * time.Second)
+	exp := now.Add(ttl)
 
 	claims := jwt.MapClaims{
 		"iat":    now.Unix(),
-		"iss":    "MedodsTechTask",
+		"iss":    issuer,
 		"exp":    exp.Unix(),
 		"exp_at": exp.Format(time.RFC3339),
 		"exp_in": delta,
@@ -96,15 +170,18 @@ func CreateJWT(payload map[string]interface{}, private_key string) (string, erro
 		claims[k] = v
 	}
 
-	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(private_key))
+	method, key, err := parseSigningKey(algorithm, private_key)
 	if err != nil {
-		return "", &core.ErrParsePrivateKey{ErrMessage: err}
+		recordError(span, err)
+		return "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS512, claims)
+	defer observe(metrics.JWTDuration.WithLabelValues("sign", algorithm), time.Now())
+	token := jwt.NewWithClaims(method, claims)
 
 	signedToken, err := token.SignedString(key)
 	if err != nil {
+		recordError(span, err)
 		return "", &core.ErrSignedJwt{ErrMessage: err}
 	}
 
@@ -112,27 +189,40 @@ func CreateJWT(payload map[string]interface{}, private_key string) (string, erro
 }
 
 // DecodeJWT декодирует JWT токен и извлекает из него полезную нагрузку с использованием публичного ключа.
+// Принимается только алгоритм, к которому привязан ключ: токен с другим alg в заголовке (none, HS256 с публичным
+// ключом в качестве секрета, другой RSA или ECDSA вариант) отклоняется до проверки подписи. Токен без iss
+// или с другим iss отклоняется.
 //
 // Параметры:
+//   - ctx: контекст запроса, в нем начинается спан проверки
 //   - token_string: строка, содержащая JWT токен для декодирования
 //   - public_key: строка, содержащая публичный ключ для проверки подписи токена
+//   - algorithm: алгоритм подписи, к которому привязан ключ
+//   - issuer: ожидаемое значение claim iss
 //
 // Возвращает:
 //   - карту с полезной нагрузкой (claims) из токена
 //   - ошибку (если возникла)
-func DecodeJWT(token_string string, public_key string) (map[string]interface{}, error) {
-	key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(strings.TrimSpace(public_key)))
+func DecodeJWT(ctx context.Context, token_string string, public_key string, algorithm string, issuer string) (map[string]interface{}, error) {
+	_, span := tracer.Start(ctx, "jwt.verify", trace.WithAttributes(attribute.String("jwt.algorithm", algorithm)))
+	defer span.End()
+
+	method, key, err := parseVerifyingKey(algorithm, public_key)
 	if err != nil {
-		return nil, &core.ErrParsePublicKey{ErrMessage: err}
+		recordError(span, err)
+		return nil, err
 	}
 
+	defer observe(metrics.JWTDuration.WithLabelValues("verify", algorithm), time.Now())
 	token, err := jwt.Parse(token_string, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, &core.ErrUnExpectedSign{ErrMessage: err}
+		if token.Method != method {
+			return nil, &core.ErrUnExpectedSign{ErrMessage: token.Header["alg"]}
 		}
 		return key, nil
-	})
+	}, jwt.WithValidMethods([]string{method.Alg()}), jwt.WithIssuer(issuer))
 	if err != nil {
+		// Просроченный или чужой токен - ответ клиенту, а не сбой, поэтому статус спана не меняется
+		span.SetAttributes(attribute.String("jwt.error", err.Error()))
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return nil, &core.ErrJwtExpired{ErrMessage: err}
 		}
@@ -145,3 +235,201 @@ func DecodeJWT(token_string string, public_key string) (map[string]interface{},
 
 	return nil, &core.ErrInvalidJwtPayload{ErrMessage: err}
 }
+
+// observe записывает в гистограмму время, прошедшее с start; вызывается через defer.
+func observe(h prometheus.Observer, start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// recordError записывает ошибку в спан и помечает его ошибочным.
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// endSpan завершает спан сценария; код ответа пишется в атрибут, ошибочным спан помечается только для 5xx,
+// отказы клиенту (неверный пароль, просроченный токен) сбоями не считаются.
+func endSpan(span trace.Span, zerr *core.ZError) {
+	if zerr != nil {
+		span.SetAttributes(attribute.Int("auth.error.code", zerr.Code))
+		if zerr.Code >= 500 {
+			span.SetStatus(codes.Error, zerr.Message)
+		}
+	}
+	span.End()
+}
+
+// parseSigningKey разбирает приватный ключ в PEM и проверяет, что его тип соответствует алгоритму.
+//
+// Параметры:
+//   - algorithm: алгоритм подписи
+//   - private_key: приватный ключ в PEM
+//
+// Возвращает:
+//   - метод подписи
+//   - ключ для jwt.Token.SignedString
+//   - ошибку (если возникла)
+func parseSigningKey(algorithm string, private_key string) (jwt.SigningMethod, any, error) {
+	pem := []byte(strings.TrimSpace(private_key))
+	switch algorithm {
+	case configs.JWTRS512:
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, nil, &core.ErrParsePrivateKey{ErrMessage: err}
+		}
+		return jwt.SigningMethodRS512, key, nil
+	case configs.JWTES256:
+		key, err := jwt.ParseECPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, nil, &core.ErrParsePrivateKey{ErrMessage: err}
+		}
+		if key.Curve != elliptic.P256() {
+			return nil, nil, &core.ErrParsePrivateKey{ErrMessage: fmt.Errorf("ES256 requires a P-256 key, got %s", key.Curve.Params().Name)}
+		}
+		return jwt.SigningMethodES256, key, nil
+	case configs.JWTEdDSA:
+		key, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, nil, &core.ErrParsePrivateKey{ErrMessage: err}
+		}
+		return jwt.SigningMethodEdDSA, key, nil
+	}
+	return nil, nil, &core.ErrUnExpectedSign{ErrMessage: fmt.Errorf("unknown jwt algorithm %q", algorithm)}
+}
+
+// parseVerifyingKey разбирает публичный ключ в PEM и проверяет, что его тип соответствует алгоритму.
+//
+// Параметры:
+//   - algorithm: алгоритм подписи
+//   - public_key: публичный ключ в PEM
+//
+// Возвращает:
+//   - метод подписи, которым должен быть подписан токен
+//   - ключ для проверки подписи
+//   - ошибку (если возникла)
+func parseVerifyingKey(algorithm string, public_key string) (jwt.SigningMethod, any, error) {
+	pem := []byte(strings.TrimSpace(public_key))
+	switch algorithm {
+	case configs.JWTRS512:
+		key, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, nil, &core.ErrParsePublicKey{ErrMessage: err}
+		}
+		return jwt.SigningMethodRS512, key, nil
+	case configs.JWTES256:
+		key, err := jwt.ParseECPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, nil, &core.ErrParsePublicKey{ErrMessage: err}
+		}
+		if key.Curve != elliptic.P256() {
+			return nil, nil, &core.ErrParsePublicKey{ErrMessage: fmt.Errorf("ES256 requires a P-256 key, got %s", key.Curve.Params().Name)}
+		}
+		return jwt.SigningMethodES256, key, nil
+	case configs.JWTEdDSA:
+		key, err := jwt.ParseEdPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, nil, &core.ErrParsePublicKey{ErrMessage: err}
+		}
+		return jwt.SigningMethodEdDSA, key, nil
+	}
+	return nil, nil, &core.ErrUnExpectedSign{ErrMessage: fmt.Errorf("unknown jwt algorithm %q", algorithm)}
+}
+
+const (
+	// refreshLookupSize - размер идентификатора поиска в начале refresh токена, байт
+	refreshLookupSize = 12
+	// refreshSecretSize - размер секретной части refresh токена, байт
+	refreshSecretSize = 32
+)
+
+// CreateRefreshToken генерирует непрозрачный refresh токен: base64url от идентификатора поиска и
+// случайного секрета. В базе хранятся только идентификатор поиска и хеш токена.
+//
+// Возвращает:
+//   - refresh токен для клиента
+//   - идентификатор поиска токена в базе
+//   - хеш токена для хранения
+//   - ошибку (если возникла)
+func CreateRefreshToken() (string, string, string, error) {
+	buf := make([]byte, refreshLookupSize+refreshSecretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hex.EncodeToString(buf[:refreshLookupSize]), HashRefreshToken(token), nil
+}
+
+// ParseRefreshToken извлекает идентификатор поиска из непрозрачного refresh токена и считает его хеш.
+//
+// Параметры:
+//   - token: refresh токен от клиента
+//
+// Возвращает:
+//   - идентификатор поиска токена в базе
+//   - хеш токена для сравнения с сохраненным
+//   - false, если токен не в формате непрозрачного refresh токена
+func ParseRefreshToken(token string) (string, string, bool) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) != refreshLookupSize+refreshSecretSize {
+		return "", "", false
+	}
+	return hex.EncodeToString(buf[:refreshLookupSize]), HashRefreshToken(token), true
+}
+
+// HashRefreshToken считает хеш refresh токена для хранения. Токен содержит 256 бит случайных данных,
+// поэтому медленный хеш не нужен: SHA-256 достаточно, чтобы утечка базы не давала действующих сессий.
+// Refresh токены в формате JWT, выпущенные до перехода на непрозрачные токены, хранятся с этим же хешем
+// и в качестве идентификатора поиска.
+//
+// Параметры:
+//   - token: refresh токен
+//
+// Возвращает:
+//   - hex SHA-256 от токена
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CompareRefreshTokenHash сравнивает хеши refresh токенов за постоянное время.
+//
+// Параметры:
+//   - stored: хеш из базы
+//   - actual: хеш предъявленного токена
+//
+// Возвращает:
+//   - true, если хеши совпадают
+func CompareRefreshTokenHash(stored string, actual string) bool {
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(actual)) == 1
+}
+
+// CreateWebhookSecret генерирует ключ подписи для подписки на события.
+//
+// Возвращает:
+//   - ключ вида whsec_<32 случайных байта в base64url>
+//   - ошибку (если возникла)
+func CreateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// SignWebhook подписывает тело запроса с событием. Подписывается строка "<timestamp>.<body>", поэтому
+// получатель, сверив подпись и отклонив устаревший timestamp, защищен от подмены и повтора запроса.
+//
+// Параметры:
+//   - secret: ключ подписи подписки
+//   - timestamp: время отправки в секундах Unix, передается в заголовке Webhook-Timestamp
+//   - body: тело запроса
+//
+// Возвращает:
+//   - подпись вида v1=<hex HMAC-SHA256> для заголовка Webhook-Signature
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,186 @@
+package share
+
+import (
+	"time"
+)
+
+type QAccountSearch struct {
+	EmailPrefix string     `form:"email_prefix" example:"user@"`
+	CreatedFrom *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00" example:"2024-02-01T00:00:00Z"`
+	CreatedTo   *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00" example:"2024-03-01T00:00:00Z"`
+	Status      string     `form:"status" example:"active"`
+	Limit       int        `form:"limit" example:"50"`
+	Offset      int        `form:"offset" example:"0"`
+}
+
+type QSignupSearch struct {
+	EmailPrefix string `form:"email_prefix" example:"user@"`
+	Limit       int    `form:"limit" example:"50"`
+	Offset      int    `form:"offset" example:"0"`
+}
+
+type QAccountStatus struct {
+	Status string `json:"status" example:"blocked"`
+}
+
+type ZAdminAccount struct {
+	ID        string     `json:"id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Email     string     `json:"email" example:"user@example.com"`
+	Status    string     `json:"status" example:"active"`
+	CreatedAt time.Time  `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+	UpdatedAt *time.Time `json:"updated_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type ZAccountPage struct {
+	Items  []ZAdminAccount `json:"items"`
+	Total  int             `json:"total" example:"1"`
+	Limit  int             `json:"limit" example:"50"`
+	Offset int             `json:"offset" example:"0"`
+}
+
+type ZSession struct {
+	ID        string    `json:"id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	UserAgent string    `json:"user_agent" example:"Mozilla/5.0"`
+	IpAddress string    `json:"ip_address" example:"127.0.0.1"`
+	ExpiresAt time.Time `json:"expires_at" example:"2024-02-18 05:37:40.483836"`
+	CreatedAt time.Time `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type ZAccountDetail struct {
+	ZAdminAccount
+	Roles       []string   `json:"roles" example:"admin"`
+	Permissions []string   `json:"permissions" example:"rbac:manage"`
+	Sessions    []ZSession `json:"sessions"`
+}
+
+type ZAdminSignup struct {
+	ID        string     `json:"id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Email     string     `json:"email" example:"user@example.com"`
+	CreatedAt time.Time  `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+	UpdatedAt *time.Time `json:"updated_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type ZSignupPage struct {
+	Items  []ZAdminSignup `json:"items"`
+	Total  int            `json:"total" example:"1"`
+	Limit  int            `json:"limit" example:"50"`
+	Offset int            `json:"offset" example:"0"`
+}
+
+type QImpersonate struct {
+	Reason string `json:"reason" example:"Тикет поддержки #1234: не отображается заказ"`
+}
+
+type ZImpersonationToken struct {
+	ImpersonationID string    `json:"impersonation_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	AccessToken     string    `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType       string    `json:"token_type" example:"bearer"`
+	ExpiresAt       time.Time `json:"expires_at" example:"2024-02-13 05:52:40.483836"`
+}
+
+type QImpersonationSearch struct {
+	AccountID string `form:"account_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	AdminID   string `form:"admin_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Limit     int    `form:"limit" example:"50"`
+	Offset    int    `form:"offset" example:"0"`
+}
+
+type ZImpersonation struct {
+	ID        string     `json:"id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	AdminID   string     `json:"admin_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	AccountID string     `json:"account_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Reason    string     `json:"reason" example:"Тикет поддержки #1234: не отображается заказ"`
+	ExpiresAt time.Time  `json:"expires_at" example:"2024-02-13 05:52:40.483836"`
+	EndedAt   *time.Time `json:"ended_at" example:"2024-02-13 05:45:40.483836"`
+	EndedBy   *string    `json:"ended_by" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	CreatedAt time.Time  `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type ZImpersonationPage struct {
+	Items  []ZImpersonation `json:"items"`
+	Total  int              `json:"total" example:"1"`
+	Limit  int              `json:"limit" example:"50"`
+	Offset int              `json:"offset" example:"0"`
+}
+
+type QAuditSearch struct {
+	AccountID string     `form:"account_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	ActorID   string     `form:"actor_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Event     string     `form:"event" example:"login"`
+	Outcome   string     `form:"outcome" example:"failure"`
+	From      *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00" example:"2024-02-01T00:00:00Z"`
+	To        *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" example:"2024-03-01T00:00:00Z"`
+	Limit     int        `form:"limit" example:"50"`
+	Offset    int        `form:"offset" example:"0"`
+}
+
+type ZAuditRecord struct {
+	Seq       int64             `json:"seq" example:"42"`
+	Event     string            `json:"event" example:"login"`
+	Outcome   string            `json:"outcome" example:"failure"`
+	AccountID *string           `json:"account_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	ActorID   *string           `json:"actor_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Email     *string           `json:"email" example:"user@example.com"`
+	IpAddress *string           `json:"ip_address" example:"127.0.0.1"`
+	UserAgent *string           `json:"user_agent" example:"Mozilla/5.0"`
+	Details   map[string]string `json:"details"`
+	PrevHash  string            `json:"prev_hash" example:"0000000000000000000000000000000000000000000000000000000000000000"`
+	Hash      string            `json:"hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	CreatedAt time.Time         `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type ZAuditPage struct {
+	Items  []ZAuditRecord `json:"items"`
+	Total  int            `json:"total" example:"1"`
+	Limit  int            `json:"limit" example:"50"`
+	Offset int            `json:"offset" example:"0"`
+}
+
+type ZAuditVerify struct {
+	Ok          bool   `json:"ok" example:"true"`
+	Checked     int    `json:"checked" example:"1024"`
+	BrokenAtSeq *int64 `json:"broken_at_seq" example:"42"`
+	LastHash    string `json:"last_hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+type QJobSearch struct {
+	Kind   string `form:"kind" example:"mail.send"`
+	Status string `form:"status" example:"dead"`
+	Limit  int    `form:"limit" example:"50"`
+	Offset int    `form:"offset" example:"0"`
+}
+
+type ZJob struct {
+	ID          int64      `json:"id" example:"42"`
+	Kind        string     `json:"kind" example:"mail.send"`
+	Status      string     `json:"status" example:"dead"`
+	Attempts    int        `json:"attempts" example:"8"`
+	MaxAttempts int        `json:"max_attempts" example:"8"`
+	RunAt       time.Time  `json:"run_at" example:"2024-02-13 05:37:40.483836"`
+	LockedBy    *string    `json:"locked_by" example:"app-1-3f2a"`
+	LastError   *string    `json:"last_error" example:"dial tcp: connection refused"`
+	FinishedAt  *time.Time `json:"finished_at" example:"2024-02-13 05:37:40.483836"`
+	CreatedAt   time.Time  `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+	UpdatedAt   time.Time  `json:"updated_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type ZJobPage struct {
+	Items  []ZJob `json:"items"`
+	Total  int    `json:"total" example:"1"`
+	Limit  int    `json:"limit" example:"50"`
+	Offset int    `json:"offset" example:"0"`
+}
+
+type ZJobStat struct {
+	Kind   string `json:"kind" example:"mail.send"`
+	Status string `json:"status" example:"pending"`
+	Count  int    `json:"count" example:"3"`
+}
+
+type ZConfigVersion struct {
+	Version        int64     `json:"version" example:"3"`                                                             // номер загрузки, начиная с 1 при запуске
+	Hash           string    `json:"hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"` // SHA-256 конфигурации без секретов
+	Env            string    `json:"env" example:"production"`
+	LoadedAt       time.Time `json:"loaded_at" example:"2024-02-13 05:37:40.483836"`
+	PendingRestart []string  `json:"pending_restart" example:"db"` // измененные разделы, которые применятся после перезапуска
+}
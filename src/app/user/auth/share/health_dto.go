@@ -0,0 +1,6 @@
+package share
+
+type ZHealth struct {
+	Status string            `json:"status" example:"ok"`            // ok, degraded (/healthz) или unavailable (/readyz)
+	Checks map[string]string `json:"checks" example:"db:ok,keys:ok"` // результат каждой проверки: ok или причина отказа
+}
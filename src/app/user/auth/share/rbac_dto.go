@@ -0,0 +1,48 @@
+package share
+
+import (
+	"time"
+)
+
+type QRole struct {
+	Name        string `json:"name" example:"support"`
+	Description string `json:"description" example:"Сотрудник поддержки"`
+}
+
+type QPermission struct {
+	Code        string `json:"code" example:"accounts:read"`
+	Description string `json:"description" example:"Просмотр аккаунтов"`
+}
+
+type QGrantPermission struct {
+	Code string `json:"code" example:"accounts:read"`
+}
+
+type QAssignRole struct {
+	RoleID string `json:"role_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+}
+
+type ZRole struct {
+	ID          string     `json:"id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Name        string     `json:"name" example:"support"`
+	Description string     `json:"description" example:"Сотрудник поддержки"`
+	Platform    bool       `json:"platform" example:"false"`
+	Permissions []string   `json:"permissions" example:"accounts:read"`
+	CreatedAt   time.Time  `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+	UpdatedAt   *time.Time `json:"updated_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type ZPermission struct {
+	ID          string    `json:"id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Code        string    `json:"code" example:"accounts:read"`
+	Description string    `json:"description" example:"Просмотр аккаунтов"`
+	BuiltIn     bool      `json:"built_in" example:"true"`
+	Platform    bool      `json:"platform" example:"false"`
+	CreatedAt   time.Time `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type ZAccountAccess struct {
+	AccountID   string   `json:"account_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Roles       []string `json:"roles" example:"admin"`
+	Permissions []string `json:"permissions" example:"rbac:manage"`
+}
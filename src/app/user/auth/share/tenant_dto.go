@@ -0,0 +1,63 @@
+package share
+
+import (
+	"time"
+)
+
+type QTenant struct {
+	Slug string  `json:"slug" example:"shop"`
+	Name string  `json:"name" example:"Интернет-магазин"`
+	Host *string `json:"host" example:"shop.example.com"`
+}
+
+type ZTenant struct {
+	ID        string     `json:"id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Slug      string     `json:"slug" example:"shop"`
+	Name      string     `json:"name" example:"Интернет-магазин"`
+	Host      *string    `json:"host" example:"shop.example.com"`
+	CreatedAt time.Time  `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+	UpdatedAt *time.Time `json:"updated_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type QOrganization struct {
+	Name string `json:"name" example:"ООО Ромашка"`
+}
+
+type ZOrganization struct {
+	ID        string     `json:"id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Name      string     `json:"name" example:"ООО Ромашка"`
+	Role      string     `json:"role" example:"owner"`
+	CreatedAt time.Time  `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+	UpdatedAt *time.Time `json:"updated_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type ZOrgMember struct {
+	OrgID     string     `json:"org_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	AccountID string     `json:"account_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Email     string     `json:"email" example:"test@test.ru"`
+	Role      string     `json:"role" example:"member"`
+	CreatedAt time.Time  `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+	UpdatedAt *time.Time `json:"updated_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type QMemberRole struct {
+	Role string `json:"role" example:"admin"`
+}
+
+type QInvite struct {
+	Email string `json:"email" example:"test@test.ru"`
+	Role  string `json:"role" example:"member"`
+}
+
+type ZInvite struct {
+	ID        string    `json:"id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	OrgID     string    `json:"org_id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	Email     string    `json:"email" example:"test@test.ru"`
+	Role      string    `json:"role" example:"member"`
+	Code      string    `json:"code,omitempty" example:"123456"` // Добавлено для local debug
+	ExpiresAt time.Time `json:"expires_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type QInviteAccept struct {
+	Code string `json:"code" example:"123456"`
+}
@@ -0,0 +1,66 @@
+package share
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type QWebhook struct {
+	URL         string   `json:"url" example:"https://example.com/hooks/auth"`
+	Events      []string `json:"events" example:"user.created,user.login"`
+	Description string   `json:"description" example:"CRM"`
+}
+
+type QWebhookUpdate struct {
+	URL         string   `json:"url" example:"https://example.com/hooks/auth"`
+	Events      []string `json:"events" example:"*"`
+	Description string   `json:"description" example:"CRM"`
+	Enabled     bool     `json:"enabled" example:"true"`
+}
+
+type QWebhookDeliverySearch struct {
+	Status string `form:"status" example:"failed"`
+	Limit  int    `form:"limit" example:"50"`
+	Offset int    `form:"offset" example:"0"`
+}
+
+type ZWebhook struct {
+	ID                  string     `json:"id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	URL                 string     `json:"url" example:"https://example.com/hooks/auth"`
+	Events              []string   `json:"events" example:"user.created,user.login"`
+	Description         string     `json:"description" example:"CRM"`
+	Enabled             bool       `json:"enabled" example:"true"`
+	ConsecutiveFailures int        `json:"consecutive_failures" example:"0"`
+	DisabledAt          *time.Time `json:"disabled_at" example:"2024-02-13 05:37:40.483836"`
+	DisabledReason      *string    `json:"disabled_reason" example:"отключена после неудачных доставок подряд: HTTP 500"`
+	CreatedAt           time.Time  `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+	UpdatedAt           time.Time  `json:"updated_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type ZWebhookSecret struct {
+	ZWebhook
+	Secret string `json:"secret" example:"whsec_3q2-7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"`
+}
+
+type ZWebhookDelivery struct {
+	ID             string          `json:"id" example:"592af5b5-4f60-4ddd-b080-be674c86eda8"`
+	EventID        string          `json:"event_id" example:"0b6c2a8e-5e0f-4b1c-9d55-3f1b2c7a9e10"`
+	Event          string          `json:"event" example:"user.login"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status" example:"success"`
+	Attempts       int             `json:"attempts" example:"1"`
+	ResponseStatus *int            `json:"response_status" example:"200"`
+	ResponseBody   *string         `json:"response_body" example:"ok"`
+	LastError      *string         `json:"last_error" example:"HTTP 500"`
+	DurationMs     *int            `json:"duration_ms" example:"120"`
+	DeliveredAt    *time.Time      `json:"delivered_at" example:"2024-02-13 05:37:40.483836"`
+	CreatedAt      time.Time       `json:"created_at" example:"2024-02-13 05:37:40.483836"`
+	UpdatedAt      time.Time       `json:"updated_at" example:"2024-02-13 05:37:40.483836"`
+}
+
+type ZWebhookDeliveryPage struct {
+	Items  []ZWebhookDelivery `json:"items"`
+	Total  int                `json:"total" example:"1"`
+	Limit  int                `json:"limit" example:"50"`
+	Offset int                `json:"offset" example:"0"`
+}
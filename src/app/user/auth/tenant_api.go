@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MedodsTechTask/app/core"
+	share "github.com/MedodsTechTask/app/user/auth/share"
+)
+
+func (h *API) setupTenantRoutes(r *gin.RouterGroup) {
+	r.GET(core.UserAuthOrgs, h.Authenticate(), h.getOrganizations)
+	r.POST(core.UserAuthOrgs, h.Authenticate(), h.createOrganization)
+	r.GET(core.UserAuthOrgMembers, h.Authenticate(), h.getOrganizationMembers)
+	r.PUT(core.UserAuthOrgMember, h.Authenticate(), h.setMemberRole)
+	r.DELETE(core.UserAuthOrgMember, h.Authenticate(), h.removeMember)
+	r.POST(core.UserAuthOrgInvites, h.Authenticate(), h.createInvite)
+	r.POST(core.UserAuthInviteAccept, h.Authenticate(), h.acceptInvite)
+}
+
+// @Summary Организации аккаунта
+// @Description Возвращает организации текущего тенанта, в которых состоит аккаунт, вместе с его ролью
+// @Tags Organizations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} share.ZOrganization
+// @Failure 401 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/orgs [get]
+func (h *API) getOrganizations(c *gin.Context) {
+	res, err := h.uc.GetOrganizations(c.Request.Context(), accountID(c))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Создание организации
+// @Description Создает организацию в текущем тенанте, создатель становится ее владельцем. Роль попадет в токен при следующем рефреше
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body share.QOrganization true "Данные организации"
+// @Success 200 {object} share.ZOrganization
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/orgs [post]
+func (h *API) createOrganization(c *gin.Context) {
+	var req share.QOrganization
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.CreateOrganization(c.Request.Context(), accountID(c), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Участники организации
+// @Description Возвращает участников организации. Доступно любому участнику
+// @Tags Organizations
+// @Produce json
+// @Security BearerAuth
+// @Param org_id path string true "ID организации"
+// @Success 200 {array} share.ZOrgMember
+// @Failure 401 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/orgs/{org_id}/members [get]
+func (h *API) getOrganizationMembers(c *gin.Context) {
+	res, err := h.uc.GetOrganizationMembers(c.Request.Context(), accountID(c), c.Param("org_id"))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Смена роли участника
+// @Description Владелец меняет любые роли, кроме своей; администратор - только admin/member у не-владельцев
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param org_id path string true "ID организации"
+// @Param account_id path string true "ID аккаунта участника"
+// @Param request body share.QMemberRole true "Новая роль"
+// @Success 200 {object} share.ZOrgMember
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/orgs/{org_id}/members/{account_id} [put]
+func (h *API) setMemberRole(c *gin.Context) {
+	var req share.QMemberRole
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.SetMemberRole(c.Request.Context(), accountID(c), c.Param("org_id"), c.Param("account_id"), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Исключение участника
+// @Description Исключает участника из организации или выход из нее. Владельца исключить нельзя
+// @Tags Organizations
+// @Produce json
+// @Security BearerAuth
+// @Param org_id path string true "ID организации"
+// @Param account_id path string true "ID аккаунта участника"
+// @Success 200 {object} share.ZOk
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/orgs/{org_id}/members/{account_id} [delete]
+func (h *API) removeMember(c *gin.Context) {
+	res, err := h.uc.RemoveMember(c.Request.Context(), accountID(c), c.Param("org_id"), c.Param("account_id"))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Приглашение в организацию
+// @Description Создает приглашение на email с ролью admin или member, действующее InviteTTL (по умолчанию 7 дней). Доступно владельцу и администраторам
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param org_id path string true "ID организации"
+// @Param request body share.QInvite true "Данные приглашения"
+// @Success 200 {object} share.ZInvite
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/orgs/{org_id}/invites [post]
+func (h *API) createInvite(c *gin.Context) {
+	var req share.QInvite
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.CreateInvite(c.Request.Context(), accountID(c), c.Param("org_id"), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Принятие приглашения
+// @Description Добавляет текущий аккаунт в организацию. Email аккаунта должен совпадать с email приглашения
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param invite_id path string true "ID приглашения"
+// @Param request body share.QInviteAccept true "Код приглашения"
+// @Success 200 {object} share.ZOrgMember
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /user/auth/invites/{invite_id}/accept [post]
+func (h *API) acceptInvite(c *gin.Context) {
+	var req share.QInviteAccept
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.AcceptInvite(c.Request.Context(), accountID(c), c.Param("invite_id"), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Создание тенанта
+// @Description Создает тенант со slug и необязательным хостом. Доступно только из тенанта по умолчанию. Требует разрешение tenants:manage
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body share.QTenant true "Данные тенанта"
+// @Success 200 {object} share.ZTenant
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 409 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/tenants [post]
+func (h *API) createTenant(c *gin.Context) {
+	var req share.QTenant
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.CreateTenant(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// accountID возвращает идентификатор аккаунта из проверенного access токена.
+func accountID(c *gin.Context) string {
+	claims, _ := Claims(c)
+	account_id, _ := claims["sub"].(string)
+	return account_id
+}
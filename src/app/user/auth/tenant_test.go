@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/MedodsTechTask/app/core"
+)
+
+func TestAuthorizeTenant(t *testing.T) {
+	const other_tenant = "5f0c8a1e-3b7d-4c2a-9e6f-1d2c3b4a5e6f"
+	uc := testUseCase(t, nil)
+	h := NewAPI(uc)
+
+	tests := []struct {
+		name   string
+		tid    interface{}
+		tenant string
+		code   int
+	}{
+		{"same tenant", other_tenant, other_tenant, http.StatusNoContent},
+		{"default tenant without tid", nil, core.DefaultTenantID, http.StatusNoContent},
+		{"foreign tid", other_tenant, core.DefaultTenantID, http.StatusUnauthorized},
+		{"default tid in other tenant", core.DefaultTenantID, other_tenant, http.StatusUnauthorized},
+		// Токен без tid относится к тенанту по умолчанию
+		{"no tid in other tenant", nil, other_tenant, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := map[string]interface{}{"type": "access", "sub": "acc", "permissions": []string{core.PermRBACManage}}
+			if tt.tid != nil {
+				payload["tid"] = tt.tid
+			}
+			token := testToken(t, uc, payload)
+
+			_, zerr := uc.Authorize(core.WithTenant(context.Background(), tt.tenant), token)
+			if tt.code == http.StatusNoContent && zerr != nil {
+				t.Fatalf("Authorize: %+v", zerr)
+			}
+			if tt.code != http.StatusNoContent && (zerr == nil || zerr.Code != tt.code) {
+				t.Fatalf("Authorize error = %+v, want code %d", zerr, tt.code)
+			}
+
+			// Разрешение из токена другого тенанта не дает доступа
+			if code := serveWith(h.RequirePermission(core.PermRBACManage), tt.tenant, token); code != tt.code {
+				t.Errorf("RequirePermission status = %d, want %d", code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,467 @@
+package auth
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/email"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+const (
+	// OrgRoleOwner - владелец организации, единственный, кто может назначать и снимать владельцев
+	OrgRoleOwner = "owner"
+	// OrgRoleAdmin - администратор организации, управляет участниками и приглашениями
+	OrgRoleAdmin = "admin"
+	// OrgRoleMember - рядовой участник организации
+	OrgRoleMember = "member"
+)
+
+var tenantSlugRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ResolveTenant определяет тенант запроса. Порядок: заголовок X-Tenant-ID (slug или ID),
+// затем хост запроса, затем тенант по умолчанию из конфигурации.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - key: значение заголовка X-Tenant-ID, может быть пустым
+//   - host: хост запроса без порта
+//
+// Возвращает:
+//   - идентификатор тенанта
+//   - указатель на структуру ZError с кодом 404, если тенант из заголовка не найден
+func (s *AuthUseCase) ResolveTenant(ctx context.Context, key string, host string) (string, *core.ZError) {
+	if key != "" {
+		return s.lookupTenant("key:"+key, func() (*repo.XTenant, error) {
+			return s.repo.GetTenant(ctx, key)
+		})
+	}
+
+	if host != "" {
+		tenant_id, zerr := s.lookupTenant("host:"+host, func() (*repo.XTenant, error) {
+			return s.repo.GetTenantForHost(ctx, host)
+		})
+		if zerr == nil || zerr.Code != 404 {
+			return tenant_id, zerr
+		}
+	}
+
+	if s.conf(ctx).cfg.DefaultTenant == "" {
+		return core.DefaultTenantID, nil
+	}
+	return s.lookupTenant("key:"+s.conf(ctx).cfg.DefaultTenant, func() (*repo.XTenant, error) {
+		return s.repo.GetTenant(ctx, s.conf(ctx).cfg.DefaultTenant)
+	})
+}
+
+// CreateTenant создает новый тенант. Создавать тенанты могут только аккаунты тенанта по умолчанию,
+// чтобы администратор одного продукта не мог заводить другие.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - req: структура со slug, названием и необязательным хостом тенанта
+//
+// Возвращает:
+//   - указатель на структуру ZTenant с данными созданного тенанта
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) CreateTenant(ctx context.Context, req *share.QTenant) (_ *share.ZTenant, zerr *core.ZError) {
+	defer func() { s.audit(ctx, AuditAdminTenantCreate, zerr, "", "", map[string]string{"slug": req.Slug}) }()
+
+	if core.TenantFromContext(ctx) != core.DefaultTenantID {
+		return nil, &core.ZError{
+			Code:      403,
+			Where:     "UseCase",
+			Message:   "Создавать тенанты можно только из тенанта по умолчанию",
+			Exception: nil,
+		}
+	}
+
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if !tenantSlugRe.MatchString(slug) {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Slug тенанта должен состоять из латинских букв, цифр и дефиса (до 63 символов)",
+			Exception: req.Slug,
+		}
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Название тенанта не может быть пустым",
+			Exception: nil,
+		}
+	}
+	var host *string
+	if req.Host != nil && strings.TrimSpace(*req.Host) != "" {
+		h := strings.ToLower(strings.TrimSpace(*req.Host))
+		host = &h
+	}
+
+	xres, err := s.repo.CreateTenant(ctx, slug, name, host)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	return &share.ZTenant{
+		ID:        xres.ID,
+		Slug:      xres.Slug,
+		Name:      xres.Name,
+		Host:      xres.Host,
+		CreatedAt: xres.CreatedAt,
+		UpdatedAt: xres.UpdatedAt,
+	}, nil
+}
+
+// CreateOrganization создает организацию в тенанте запроса и делает создателя ее владельцем.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - account_id: идентификатор аккаунта создателя из access токена
+//   - req: структура с названием организации
+//
+// Возвращает:
+//   - указатель на структуру ZOrganization с ролью owner
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) CreateOrganization(ctx context.Context, account_id string, req *share.QOrganization) (*share.ZOrganization, *core.ZError) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Название организации не может быть пустым",
+			Exception: nil,
+		}
+	}
+
+	xres, err := s.repo.CreateOrganization(ctx, core.TenantFromContext(ctx), name, account_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return toZOrganization(xres), nil
+}
+
+// GetOrganizations возвращает организации тенанта запроса, в которых состоит аккаунт.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - account_id: идентификатор аккаунта из access токена
+//
+// Возвращает:
+//   - список структур ZOrganization с ролью аккаунта в каждой организации
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка базы данных
+func (s *AuthUseCase) GetOrganizations(ctx context.Context, account_id string) ([]share.ZOrganization, *core.ZError) {
+	xres, err := s.repo.GetOrganizationsForAccount(ctx, core.TenantFromContext(ctx), account_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	res := make([]share.ZOrganization, 0, len(xres))
+	for i := range xres {
+		res = append(res, *toZOrganization(&xres[i]))
+	}
+	return res, nil
+}
+
+// GetOrganizationMembers возвращает участников организации. Доступно любому участнику организации.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - actor_id: идентификатор аккаунта из access токена
+//   - org_id: идентификатор организации
+//
+// Возвращает:
+//   - список структур ZOrgMember
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) GetOrganizationMembers(ctx context.Context, actor_id string, org_id string) ([]share.ZOrgMember, *core.ZError) {
+	tenant_id := core.TenantFromContext(ctx)
+
+	if _, zerr := s.orgActor(ctx, tenant_id, org_id, actor_id); zerr != nil {
+		return nil, zerr
+	}
+
+	xres, err := s.repo.GetOrganizationMembers(ctx, tenant_id, org_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	res := make([]share.ZOrgMember, 0, len(xres))
+	for i := range xres {
+		res = append(res, *toZOrgMember(&xres[i]))
+	}
+	return res, nil
+}
+
+// SetMemberRole меняет роль участника организации. Владелец может менять любые роли, кроме своей,
+// администратор - только между admin и member у участников, не являющихся владельцами.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - actor_id: идентификатор аккаунта из access токена
+//   - org_id: идентификатор организации
+//   - account_id: идентификатор аккаунта участника
+//   - req: структура с новой ролью
+//
+// Возвращает:
+//   - указатель на структуру ZOrgMember с обновленной ролью
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) SetMemberRole(ctx context.Context, actor_id string, org_id string, account_id string, req *share.QMemberRole) (*share.ZOrgMember, *core.ZError) {
+	if req.Role != OrgRoleOwner && req.Role != OrgRoleAdmin && req.Role != OrgRoleMember {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Неизвестная роль в организации",
+			Exception: req.Role,
+		}
+	}
+
+	tenant_id := core.TenantFromContext(ctx)
+
+	actor, zerr := s.orgActor(ctx, tenant_id, org_id, actor_id)
+	if zerr != nil {
+		return nil, zerr
+	}
+	target, err := s.repo.GetOrganizationMember(ctx, tenant_id, org_id, account_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	switch {
+	case actor.AccountID == target.AccountID:
+		return nil, orgForbidden("Нельзя изменить собственную роль")
+	case actor.Role == OrgRoleOwner:
+	case actor.Role == OrgRoleAdmin && target.Role != OrgRoleOwner && req.Role != OrgRoleOwner:
+	default:
+		return nil, orgForbidden("Недостаточно прав в организации")
+	}
+
+	xres, err := s.repo.SetMemberRole(ctx, tenant_id, org_id, account_id, req.Role)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return toZOrgMember(xres), nil
+}
+
+// RemoveMember исключает участника из организации. Участник может выйти сам, владельца исключить нельзя.
+// Владелец исключает любого участника, администратор - только рядовых участников.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - actor_id: идентификатор аккаунта из access токена
+//   - org_id: идентификатор организации
+//   - account_id: идентификатор аккаунта участника
+//
+// Возвращает:
+//   - указатель на структуру ZOk, если участник исключен
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) RemoveMember(ctx context.Context, actor_id string, org_id string, account_id string) (*share.ZOk, *core.ZError) {
+	tenant_id := core.TenantFromContext(ctx)
+
+	actor, zerr := s.orgActor(ctx, tenant_id, org_id, actor_id)
+	if zerr != nil {
+		return nil, zerr
+	}
+	target, err := s.repo.GetOrganizationMember(ctx, tenant_id, org_id, account_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	switch {
+	case target.Role == OrgRoleOwner:
+		return nil, orgForbidden("Владельца нельзя исключить из организации")
+	case actor.AccountID == target.AccountID:
+	case actor.Role == OrgRoleOwner:
+	case actor.Role == OrgRoleAdmin && target.Role == OrgRoleMember:
+	default:
+		return nil, orgForbidden("Недостаточно прав в организации")
+	}
+
+	if _, err := s.repo.RemoveMember(ctx, tenant_id, org_id, account_id); err != nil {
+		return nil, repoError(err)
+	}
+	return &share.ZOk{Ok: true}, nil
+}
+
+// CreateInvite приглашает email в организацию. Доступно владельцу и администраторам организации.
+// Шаблона письма с приглашением пока нет, поэтому код приглашения возвращается в ответе только в окружениях local и test.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - actor_id: идентификатор аккаунта из access токена
+//   - org_id: идентификатор организации
+//   - req: структура с email приглашенного и ролью (admin или member, по умолчанию member)
+//
+// Возвращает:
+//   - указатель на структуру ZInvite с данными приглашения
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) CreateInvite(ctx context.Context, actor_id string, org_id string, req *share.QInvite) (*share.ZInvite, *core.ZError) {
+	role := req.Role
+	if role == "" {
+		role = OrgRoleMember
+	}
+	if role != OrgRoleAdmin && role != OrgRoleMember {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Приглашать можно только с ролью admin или member",
+			Exception: req.Role,
+		}
+	}
+	addr, err := email.Parse(req.Email)
+	if err != nil {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Неверная почта",
+			Exception: err.Error(),
+		}
+	}
+
+	tenant_id := core.TenantFromContext(ctx)
+
+	actor, zerr := s.orgActor(ctx, tenant_id, org_id, actor_id)
+	if zerr != nil {
+		return nil, zerr
+	}
+	if actor.Role != OrgRoleOwner && actor.Role != OrgRoleAdmin {
+		return nil, orgForbidden("Недостаточно прав в организации")
+	}
+
+	code, err := CreateConfirmCode()
+	if err != nil {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Ошибка генерации кода подтверждения",
+			Exception: nil,
+		}
+	}
+
+	xres, err := s.repo.CreateInvite(ctx, tenant_id, org_id, addr.String(), role, code, actor.AccountID, s.conf(ctx).cfg.InviteTTL)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	res := &share.ZInvite{
+		ID:        xres.ID,
+		OrgID:     xres.OrgID,
+		Email:     xres.Email,
+		Role:      xres.Role,
+		Code:      xres.Code,
+		ExpiresAt: xres.ExpiresAt,
+	}
+	if !s.conf(ctx).cfg.ReturnCodes() {
+		res.Code = ""
+	}
+	return res, nil
+}
+
+// AcceptInvite принимает приглашение в организацию. Приглашение может принять только аккаунт тенанта
+// с тем же email, на который оно выписано. Новая роль попадет в токен при следующем рефреше.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - account_id: идентификатор аккаунта из access токена
+//   - invite_id: идентификатор приглашения
+//   - req: структура с кодом приглашения
+//
+// Возвращает:
+//   - указатель на структуру ZOrgMember с данными участника
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) AcceptInvite(ctx context.Context, account_id string, invite_id string, req *share.QInviteAccept) (*share.ZOrgMember, *core.ZError) {
+	tenant_id := core.TenantFromContext(ctx)
+
+	invite, err := s.repo.GetInvite(ctx, tenant_id, invite_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	if !CompareCodes(req.Code, invite.Code) {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Неверный код приглашения",
+			Exception: nil,
+		}
+	}
+
+	acc, err := s.repo.GetAccount(ctx, tenant_id, account_id)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	if email.Canonical(acc.Email) != email.Canonical(invite.Email) {
+		return nil, orgForbidden("Приглашение выписано на другой email")
+	}
+
+	xres, err := s.repo.AcceptInvite(ctx, tenant_id, invite.ID, acc.ID)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	return toZOrgMember(xres), nil
+}
+
+// ----------- Tools -----------
+
+// lookupTenant возвращает идентификатор тенанта из кеша или загружает его функцией load и кеширует.
+func (s *AuthUseCase) lookupTenant(cache_key string, load func() (*repo.XTenant, error)) (string, *core.ZError) {
+	if tenant_id, ok := s.tenants.Load(cache_key); ok {
+		return tenant_id.(string), nil
+	}
+
+	tenant, err := load()
+	if err != nil {
+		return "", repoError(err)
+	}
+	s.tenants.Store(cache_key, tenant.ID)
+	return tenant.ID, nil
+}
+
+// orgActor возвращает членство аккаунта, выполняющего действие, в организации.
+// Если аккаунт не состоит в организации, возвращает 404, не раскрывая существование организации.
+func (s *AuthUseCase) orgActor(ctx context.Context, tenant_id string, org_id string, actor_id string) (*repo.XOrgMember, *core.ZError) {
+	actor, err := s.repo.GetOrganizationMember(ctx, tenant_id, org_id, actor_id)
+	if err != nil {
+		if _, ok := err.(*core.ErrMemberNotFound); ok {
+			return nil, repoError(&core.ErrOrganizationNotFound{ErrMessage: org_id})
+		}
+		return nil, repoError(err)
+	}
+	return actor, nil
+}
+
+// orgForbidden возвращает ZError с кодом 403 для действий в организации.
+func orgForbidden(message string) *core.ZError {
+	return &core.ZError{
+		Code:      403,
+		Where:     "UseCase",
+		Message:   message,
+		Exception: nil,
+	}
+}
+
+// toZOrganization переводит организацию из слоя репозитория в DTO.
+func toZOrganization(x *repo.XOrganization) *share.ZOrganization {
+	return &share.ZOrganization{
+		ID:        x.ID,
+		Name:      x.Name,
+		Role:      x.Role,
+		CreatedAt: x.CreatedAt,
+		UpdatedAt: x.UpdatedAt,
+	}
+}
+
+// toZOrgMember переводит участника организации из слоя репозитория в DTO.
+func toZOrgMember(x *repo.XOrgMember) *share.ZOrgMember {
+	return &share.ZOrgMember{
+		OrgID:     x.OrgID,
+		AccountID: x.AccountID,
+		Email:     x.Email,
+		Role:      x.Role,
+		CreatedAt: x.CreatedAt,
+		UpdatedAt: x.UpdatedAt,
+	}
+}
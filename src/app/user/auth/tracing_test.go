@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/user/auth/configs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spansOnce sync.Once
+	spans     *tracetest.SpanRecorder
+)
+
+// recordSpans подключает запись спанов к глобальному TracerProvider. Трейсер пакета получен до подключения
+// и переключается на провайдер только при первой установке, поэтому провайдер общий для всех тестов.
+func recordSpans() *tracetest.SpanRecorder {
+	spansOnce.Do(func() {
+		spans = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	})
+	return spans
+}
+
+// lastSpan возвращает последний завершенный спан с именем name
+func lastSpan(t *testing.T, recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	ended := recorder.Ended()
+	for i := len(ended) - 1; i >= 0; i-- {
+		if ended[i].Name() == name {
+			return ended[i]
+		}
+	}
+	t.Fatalf("no %s span", name)
+	return nil
+}
+
+// spanAttr возвращает значение атрибута спана
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestJWTSpans(t *testing.T) {
+	recorder := recordSpans()
+	ctx := context.Background()
+	private_key, public_key := testKeys(t, configs.JWTEdDSA)
+
+	token, err := CreateJWT(ctx, nil, private_key, configs.JWTEdDSA, testIssuer, time.Minute)
+	if err != nil {
+		t.Fatalf("CreateJWT: %s", err)
+	}
+	sign := lastSpan(t, recorder, "jwt.sign")
+	if v, _ := spanAttr(sign, "jwt.algorithm"); v.AsString() != configs.JWTEdDSA {
+		t.Errorf("jwt.sign algorithm = %q, want %s", v.AsString(), configs.JWTEdDSA)
+	}
+	if sign.Status().Code != codes.Unset {
+		t.Errorf("jwt.sign status = %v, want unset", sign.Status())
+	}
+
+	// Ключ другого алгоритма - ошибка подписи
+	if _, err := CreateJWT(ctx, nil, private_key, configs.JWTES256, testIssuer, time.Minute); err == nil {
+		t.Fatal("CreateJWT with EdDSA key as ES256 = nil error")
+	}
+	if span := lastSpan(t, recorder, "jwt.sign"); span.Status().Code != codes.Error {
+		t.Errorf("failed jwt.sign status = %v, want error", span.Status())
+	}
+
+	if _, err := DecodeJWT(ctx, token, public_key, configs.JWTEdDSA, testIssuer); err != nil {
+		t.Fatalf("DecodeJWT: %s", err)
+	}
+	if span := lastSpan(t, recorder, "jwt.verify"); span.Status().Code != codes.Unset {
+		t.Errorf("jwt.verify status = %v, want unset", span.Status())
+	}
+	if _, err := DecodeJWT(ctx, token+"x", public_key, configs.JWTEdDSA, testIssuer); err == nil {
+		t.Fatal("DecodeJWT of a broken token = nil error")
+	}
+	if _, ok := spanAttr(lastSpan(t, recorder, "jwt.verify"), "jwt.error"); !ok {
+		t.Error("failed jwt.verify has no jwt.error attribute")
+	}
+}
+
+func TestEndSpan(t *testing.T) {
+	recorder := recordSpans()
+	tracer := otel.Tracer("test")
+
+	tests := []struct {
+		name   string
+		zerr   *core.ZError
+		status codes.Code
+	}{
+		{"ok", nil, codes.Unset},
+		// Отказы клиенту не считаются сбоями сервиса
+		{"client error", &core.ZError{Code: 401, Message: "Неверный токен"}, codes.Unset},
+		{"server error", &core.ZError{Code: 500, Message: "Ошибка базы данных"}, codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, span := tracer.Start(context.Background(), "scenario "+tt.name)
+			endSpan(span, tt.zerr)
+
+			ended := lastSpan(t, recorder, "scenario "+tt.name)
+			if ended.Status().Code != tt.status {
+				t.Errorf("status = %v, want %v", ended.Status().Code, tt.status)
+			}
+			code, ok := spanAttr(ended, "auth.error.code")
+			if tt.zerr == nil && ok {
+				t.Errorf("auth.error.code = %v, want none", code)
+			}
+			if tt.zerr != nil && code.AsInt64() != int64(tt.zerr.Code) {
+				t.Errorf("auth.error.code = %v, want %d", code, tt.zerr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	share "github.com/MedodsTechTask/app/user/auth/share"
+)
+
+// @Summary Подписки на события
+// @Description Подписки тенанта на HTTP уведомления, ключи подписи не возвращаются. Требует разрешение webhooks:read
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} share.ZWebhook
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/webhooks [get]
+func (h *API) getWebhooks(c *gin.Context) {
+	res, err := h.uc.GetWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Создание подписки на события
+// @Description Создает подписку и возвращает ключ подписи, ключ показывается только один раз. События: user.signup, user.created, user.login, user.login_failed, user.password_reset или * для всех. Запрос к получателю подписывается заголовком Webhook-Signature: v1=hex(HMAC-SHA256(secret, "<Webhook-Timestamp>.<body>")). Доставка идет только на публичные адреса, кроме сетей из webhook_allowed_networks. Требует разрешение webhooks:manage
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body share.QWebhook true "Адрес, события и описание"
+// @Success 200 {object} share.ZWebhookSecret
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/webhooks [post]
+func (h *API) createWebhook(c *gin.Context) {
+	var req share.QWebhook
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.CreateWebhook(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Изменение подписки на события
+// @Description Меняет адрес, события, описание и включенность подписки. Включение подписки, отключенной после неудачных доставок, сбрасывает счетчик неудач. Требует разрешение webhooks:manage
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param webhook_id path string true "ID подписки"
+// @Param request body share.QWebhookUpdate true "Новые параметры подписки"
+// @Success 200 {object} share.ZWebhook
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/webhooks/{webhook_id} [put]
+func (h *API) updateWebhook(c *gin.Context) {
+	var req share.QWebhookUpdate
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.UpdateWebhook(c.Request.Context(), c.Param("webhook_id"), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Удаление подписки на события
+// @Description Удаляет подписку вместе с журналом доставок. Требует разрешение webhooks:manage
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param webhook_id path string true "ID подписки"
+// @Success 200 {object} share.ZOk
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/webhooks/{webhook_id} [delete]
+func (h *API) deleteWebhook(c *gin.Context) {
+	res, err := h.uc.DeleteWebhook(c.Request.Context(), c.Param("webhook_id"))
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Журнал доставки событий
+// @Description Доставки событий подписки с результатом последней попытки. Требует разрешение webhooks:read
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param webhook_id path string true "ID подписки"
+// @Param status query string false "Статус доставки" Enums(pending, success, retrying, failed)
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 200)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} share.ZWebhookDeliveryPage
+// @Failure 400 {object} core.ZError
+// @Failure 401 {object} core.ZError
+// @Failure 403 {object} core.ZError
+// @Failure 404 {object} core.ZError
+// @Failure 500 {object} core.ZError
+// @Router /admin/webhooks/{webhook_id}/deliveries [get]
+func (h *API) searchWebhookDeliveries(c *gin.Context) {
+	var req share.QWebhookDeliverySearch
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	res, err := h.uc.SearchWebhookDeliveries(c.Request.Context(), c.Param("webhook_id"), &req)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/egress"
+	"github.com/MedodsTechTask/app/jobs"
+	"github.com/MedodsTechTask/app/user/auth/configs"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+)
+
+// webhookRepo - одна подписка и одна доставка; счетчик неудач ведется как в RecordWebhookAttempt
+type webhookRepo struct {
+	repo.IAuthRepo
+	hook     *repo.XWebhook
+	delivery *repo.XWebhookDelivery
+	attempts []string
+}
+
+func (r *webhookRepo) GetWebhookDelivery(ctx context.Context, tenant_id string, id string) (*repo.XWebhookDelivery, *repo.XWebhook, error) {
+	if r.delivery.ID != id || r.delivery.TenantID != tenant_id {
+		return nil, nil, &core.ErrWebhookNotFound{ErrMessage: id}
+	}
+	hook := *r.hook
+	return r.delivery, &hook, nil
+}
+
+func (r *webhookRepo) RecordWebhookAttempt(ctx context.Context, tenant_id string, id string, attempt *repo.XWebhookAttempt, disable_after int) (bool, error) {
+	r.attempts = append(r.attempts, attempt.Status)
+	if attempt.Status == WebhookDeliverySuccess {
+		r.hook.ConsecutiveFailures = 0
+		return false, nil
+	}
+	r.hook.ConsecutiveFailures++
+	if r.hook.Enabled && disable_after > 0 && r.hook.ConsecutiveFailures >= disable_after {
+		r.hook.Enabled = false
+		return true, nil
+	}
+	return false, nil
+}
+
+func TestValidateWebhook(t *testing.T) {
+	guard, err := egress.NewGuard(nil)
+	if err != nil {
+		t.Fatalf("NewGuard: %s", err)
+	}
+	tests := []struct {
+		name   string
+		url    string
+		events []string
+		want   []string
+		code   int
+	}{
+		{"public url", "https://hooks.example.com/auth", []string{WebhookUserLogin, WebhookUserLogin, WebhookAllEvents}, []string{WebhookUserLogin, WebhookAllEvents}, 0},
+		{"public ip", "http://93.184.216.34:8080/hook", []string{WebhookUserSignup}, []string{WebhookUserSignup}, 0},
+		{"relative url", "/hook", []string{WebhookUserLogin}, nil, 400},
+		{"ftp", "ftp://hooks.example.com/", []string{WebhookUserLogin}, nil, 400},
+		{"metadata address", "http://169.254.169.254/latest/meta-data/", []string{WebhookUserLogin}, nil, 400},
+		{"loopback", "http://127.0.0.1:8080/hook", []string{WebhookUserLogin}, nil, 400},
+		{"ipv6 loopback", "http://[::1]/hook", []string{WebhookUserLogin}, nil, 400},
+		{"private", "https://10.0.0.5/hook", []string{WebhookUserLogin}, nil, 400},
+		{"no events", "https://hooks.example.com/auth", nil, nil, 400},
+		{"unknown event", "https://hooks.example.com/auth", []string{"user.deleted"}, nil, 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, zerr := validateWebhook(guard, tt.url, tt.events)
+			if tt.code != 0 {
+				if zerr == nil || zerr.Code != tt.code {
+					t.Errorf("validateWebhook(%q) error = %+v, want code %d", tt.url, zerr, tt.code)
+				}
+				return
+			}
+			if zerr != nil {
+				t.Fatalf("validateWebhook(%q): %+v", tt.url, zerr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("validateWebhook(%q) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+
+	local, _ := egress.NewGuard([]string{"127.0.0.1"})
+	if _, zerr := validateWebhook(local, "http://127.0.0.1:8080/hook", []string{WebhookUserLogin}); zerr != nil {
+		t.Errorf("validateWebhook with allowed network: %+v", zerr)
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	// Эталон посчитан независимо: HMAC-SHA256("whsec_test", "1700000000." + body)
+	const want = "v1=116440e011bfbe4105aaeee6a6e9d4202342c08fd79cf75b953b8c9b8435ac47"
+	if got := SignWebhook("whsec_test", 1700000000, []byte(`{"event":"user.login"}`)); got != want {
+		t.Errorf("SignWebhook = %s, want %s", got, want)
+	}
+	if SignWebhook("whsec_other", 1700000000, []byte(`{"event":"user.login"}`)) == want {
+		t.Error("SignWebhook ignores the secret")
+	}
+	if SignWebhook("whsec_test", 1700000001, []byte(`{"event":"user.login"}`)) == want {
+		t.Error("SignWebhook ignores the timestamp")
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	const secret = "whsec_test"
+	status := http.StatusInternalServerError
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get("Webhook-Timestamp"), 10, 64)
+		if err != nil || r.Header.Get("Webhook-Signature") != SignWebhook(secret, timestamp, body) {
+			t.Errorf("bad signature %q for timestamp %q", r.Header.Get("Webhook-Signature"), r.Header.Get("Webhook-Timestamp"))
+		}
+		if r.Header.Get("Webhook-Id") != "evt_1" || r.Header.Get("Webhook-Event") != WebhookUserLogin {
+			t.Errorf("headers = %v", r.Header)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	guard, _ := egress.NewGuard([]string{"127.0.0.0/8"})
+	fake := &webhookRepo{
+		hook: &repo.XWebhook{ID: "hook", TenantID: core.DefaultTenantID, URL: srv.URL, Secret: secret, Enabled: true},
+		delivery: &repo.XWebhookDelivery{
+			ID:        "delivery",
+			TenantID:  core.DefaultTenantID,
+			WebhookID: "hook",
+			EventID:   "evt_1",
+			Event:     WebhookUserLogin,
+			Payload:   []byte(`{"event":"user.login"}`),
+		},
+	}
+	uc := &AuthUseCase{repo: fake}
+	uc.live.Store(&settings{
+		cfg:      &configs.Config{WebhookTimeout: time.Second, WebhookDisableAfter: 3},
+		webhooks: guard.Client(),
+	})
+	payload, _ := json.Marshal(webhookJobPayload{DeliveryID: "delivery"})
+	job := &jobs.Job{TenantID: core.DefaultTenantID, Kind: "webhook.deliver", Payload: payload, MaxAttempts: 10}
+	ctx := context.Background()
+
+	// Успех сбрасывает счетчик неудач подряд
+	status = http.StatusOK
+	job.Attempts = 1
+	if err := uc.handleWebhookJob(ctx, job); err != nil {
+		t.Fatalf("delivery to healthy receiver: %s", err)
+	}
+
+	status = http.StatusInternalServerError
+	for i := 1; i <= 3; i++ {
+		job.Attempts = i
+		err := uc.handleWebhookJob(ctx, job)
+		if err == nil {
+			t.Fatalf("attempt %d: nil error, want failure", i)
+		}
+		// Третья неудача подряд отключает подписку, и повторы прекращаются
+		if jobs.IsPermanent(err) != (i == 3) {
+			t.Errorf("attempt %d: permanent = %v, want %v", i, jobs.IsPermanent(err), i == 3)
+		}
+	}
+	if fake.hook.Enabled {
+		t.Error("webhook is still enabled after 3 failures")
+	}
+
+	// Доставки отключенной подписки не отправляются
+	err := uc.handleWebhookJob(ctx, job)
+	if !jobs.IsPermanent(err) {
+		t.Errorf("delivery to disabled webhook = %v, want permanent error", err)
+	}
+	if requests != 4 {
+		t.Errorf("requests = %d, want 4", requests)
+	}
+	want := []string{WebhookDeliverySuccess, WebhookDeliveryRetrying, WebhookDeliveryRetrying, WebhookDeliveryRetrying, WebhookDeliveryFailed}
+	if !slices.Equal(fake.attempts, want) {
+		t.Errorf("attempts = %v, want %v", fake.attempts, want)
+	}
+}
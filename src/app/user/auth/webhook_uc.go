@@ -0,0 +1,468 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/MedodsTechTask/app/core"
+	"github.com/MedodsTechTask/app/egress"
+	"github.com/MedodsTechTask/app/jobs"
+	"github.com/MedodsTechTask/app/user/auth/repo"
+	"github.com/MedodsTechTask/app/user/auth/share"
+)
+
+// События, на которые можно подписаться
+const (
+	// WebhookUserSignup - начата регистрация, на email отправлен код
+	WebhookUserSignup = "user.signup"
+	// WebhookUserCreated - регистрация подтверждена, аккаунт создан
+	WebhookUserCreated = "user.created"
+	// WebhookUserLogin - успешный вход
+	WebhookUserLogin = "user.login"
+	// WebhookUserLoginFailed - неудачный вход в существующий аккаунт
+	WebhookUserLoginFailed = "user.login_failed"
+	// WebhookUserPasswordReset - пароль изменен по коду сброса
+	WebhookUserPasswordReset = "user.password_reset"
+)
+
+// WebhookAllEvents - фильтр подписки на все события
+const WebhookAllEvents = "*"
+
+// JobWebhookDeliver - тип фоновой задачи доставки события по подписке
+const JobWebhookDeliver = "webhook.deliver"
+
+const (
+	// WebhookDeliveryPending - доставка ждет первой попытки
+	WebhookDeliveryPending = "pending"
+	// WebhookDeliverySuccess - получатель ответил 2xx
+	WebhookDeliverySuccess = "success"
+	// WebhookDeliveryRetrying - попытка не удалась, будет повтор
+	WebhookDeliveryRetrying = "retrying"
+	// WebhookDeliveryFailed - попытки исчерпаны или подписка отключена
+	WebhookDeliveryFailed = "failed"
+)
+
+// webhookResponseLimit - сколько байт ответа получателя сохраняется в журнале доставки
+const webhookResponseLimit = 1024
+
+var webhookEvents = []string{WebhookUserSignup, WebhookUserCreated, WebhookUserLogin, WebhookUserLoginFailed, WebhookUserPasswordReset}
+
+// webhookBody - тело запроса к получателю
+type webhookBody struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	TenantID  string    `json:"tenant_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// webhookJobPayload - данные задачи доставки
+type webhookJobPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// GetWebhooks возвращает подписки тенанта запроса. Ключ подписи не возвращается.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//
+// Возвращает:
+//   - список подписок
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка базы данных
+func (s *AuthUseCase) GetWebhooks(ctx context.Context) ([]share.ZWebhook, *core.ZError) {
+	xres, err := s.repo.GetWebhooks(ctx, core.TenantFromContext(ctx))
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	res := make([]share.ZWebhook, 0, len(xres))
+	for i := range xres {
+		res = append(res, toZWebhook(&xres[i]))
+	}
+	return res, nil
+}
+
+// CreateWebhook создает подписку тенанта запроса и генерирует для нее ключ подписи.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - req: адрес, фильтр событий и описание
+//
+// Возвращает:
+//   - указатель на структуру ZWebhookSecret с подпиской и ключом; ключ показывается только при создании
+//   - указатель на структуру ZError с кодом 400, если адрес или фильтр некорректны
+func (s *AuthUseCase) CreateWebhook(ctx context.Context, req *share.QWebhook) (res *share.ZWebhookSecret, zerr *core.ZError) {
+	defer func() {
+		webhook_id := ""
+		if res != nil {
+			webhook_id = res.ID
+		}
+		s.audit(ctx, AuditAdminWebhookCreate, zerr, "", "", map[string]string{"webhook_id": webhook_id, "url": req.URL})
+	}()
+
+	events, zerr := validateWebhook(s.conf(ctx).egress, req.URL, req.Events)
+	if zerr != nil {
+		return nil, zerr
+	}
+	secret, err := CreateWebhookSecret()
+	if err != nil {
+		return nil, &core.ZError{
+			Code:      500,
+			Where:     "UseCase/Security",
+			Message:   "Ошибка генерации ключа подписи",
+			Exception: err.Error(),
+		}
+	}
+
+	xres, err := s.repo.CreateWebhook(ctx, core.TenantFromContext(ctx), req.URL, events, secret, req.Description)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	return &share.ZWebhookSecret{
+		ZWebhook: toZWebhook(xres),
+		Secret:   xres.Secret,
+	}, nil
+}
+
+// UpdateWebhook меняет подписку тенанта запроса. Включение отключенной подписки сбрасывает счетчик неудач.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - webhook_id: идентификатор подписки
+//   - req: адрес, фильтр событий, описание и включенность
+//
+// Возвращает:
+//   - указатель на структуру ZWebhook с обновленной подпиской
+//   - указатель на структуру ZError с кодом 400 при некорректных данных или 404, если подписка не найдена
+func (s *AuthUseCase) UpdateWebhook(ctx context.Context, webhook_id string, req *share.QWebhookUpdate) (_ *share.ZWebhook, zerr *core.ZError) {
+	defer func() {
+		s.audit(ctx, AuditAdminWebhookUpdate, zerr, "", "", map[string]string{
+			"webhook_id": webhook_id,
+			"url":        req.URL,
+			"enabled":    strconv.FormatBool(req.Enabled),
+		})
+	}()
+
+	events, zerr := validateWebhook(s.conf(ctx).egress, req.URL, req.Events)
+	if zerr != nil {
+		return nil, zerr
+	}
+
+	xres, err := s.repo.UpdateWebhook(ctx, core.TenantFromContext(ctx), webhook_id, req.URL, events, req.Description, req.Enabled)
+	if err != nil {
+		return nil, repoError(err)
+	}
+	res := toZWebhook(xres)
+	return &res, nil
+}
+
+// DeleteWebhook удаляет подписку тенанта запроса вместе с журналом доставок.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - webhook_id: идентификатор подписки
+//
+// Возвращает:
+//   - указатель на структуру ZOk
+//   - указатель на структуру ZError с кодом 404, если подписка не найдена
+func (s *AuthUseCase) DeleteWebhook(ctx context.Context, webhook_id string) (_ *share.ZOk, zerr *core.ZError) {
+	defer func() {
+		s.audit(ctx, AuditAdminWebhookDelete, zerr, "", "", map[string]string{"webhook_id": webhook_id})
+	}()
+
+	if _, err := s.repo.DeleteWebhook(ctx, core.TenantFromContext(ctx), webhook_id); err != nil {
+		return nil, repoError(err)
+	}
+	return &share.ZOk{Ok: true}, nil
+}
+
+// SearchWebhookDeliveries возвращает журнал доставки событий подписки.
+//
+// Параметры:
+//   - ctx: контекст выполнения для управления временем жизни операции
+//   - webhook_id: идентификатор подписки
+//   - req: фильтр по статусу и параметры страницы
+//
+// Возвращает:
+//   - указатель на структуру ZWebhookDeliveryPage со страницей доставок и общим количеством
+//   - указатель на структуру ZError с описанием ошибки, если произошла ошибка на любом этапе
+func (s *AuthUseCase) SearchWebhookDeliveries(ctx context.Context, webhook_id string, req *share.QWebhookDeliverySearch) (*share.ZWebhookDeliveryPage, *core.ZError) {
+	switch req.Status {
+	case "", WebhookDeliveryPending, WebhookDeliverySuccess, WebhookDeliveryRetrying, WebhookDeliveryFailed:
+	default:
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Неизвестный статус доставки",
+			Exception: req.Status,
+		}
+	}
+	limit, offset, zerr := normalizePage(req.Limit, req.Offset)
+	if zerr != nil {
+		return nil, zerr
+	}
+
+	xres, total, err := s.repo.SearchWebhookDeliveries(ctx, core.TenantFromContext(ctx), webhook_id, req.Status, limit, offset)
+	if err != nil {
+		return nil, repoError(err)
+	}
+
+	items := make([]share.ZWebhookDelivery, 0, len(xres))
+	for i := range xres {
+		items = append(items, toZWebhookDelivery(&xres[i]))
+	}
+	return &share.ZWebhookDeliveryPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// emitWebhook создает доставки события для подписок тенанта запроса и ставит их в очередь.
+// Уведомление не должно срывать основное действие, поэтому ошибка только логируется.
+//
+// Параметры:
+//   - ctx: контекст запроса
+//   - event: тип события, одна из констант Webhook*
+//   - data: данные события
+func (s *AuthUseCase) emitWebhook(ctx context.Context, event string, data any) {
+	tenant_id := core.TenantFromContext(ctx)
+	event_id, err := newEventID()
+	if err != nil {
+		log.Printf("webhook %s: %s", event, err)
+		return
+	}
+	body, err := json.Marshal(webhookBody{
+		ID:        event_id,
+		Type:      event,
+		TenantID:  tenant_id,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("webhook %s: %s", event, err)
+		return
+	}
+
+	_, err = s.repo.CreateWebhookDeliveries(ctx, tenant_id, event_id, event, body, func(delivery_id string) jobs.NewJob {
+		return jobs.NewJob{
+			TenantID:    tenant_id,
+			Kind:        JobWebhookDeliver,
+			Payload:     webhookJobPayload{DeliveryID: delivery_id},
+			MaxAttempts: s.conf(ctx).cfg.WebhookMaxAttempts,
+		}
+	})
+	if err != nil {
+		log.Printf("webhook %s: %s", event, err)
+	}
+}
+
+// handleWebhookJob выполняет одну попытку доставки и записывает ее результат. Ошибка возвращает задачу
+// в очередь с задержкой; доставки отключенной или удаленной подписки больше не повторяются.
+func (s *AuthUseCase) handleWebhookJob(ctx context.Context, job *jobs.Job) error {
+	var payload webhookJobPayload
+	if err := job.Decode(&payload); err != nil {
+		return err
+	}
+
+	delivery, hook, err := s.repo.GetWebhookDelivery(ctx, job.TenantID, payload.DeliveryID)
+	if err != nil {
+		var not_found *core.ErrWebhookNotFound
+		if errors.As(err, &not_found) {
+			return nil
+		}
+		return err
+	}
+
+	var attempt *repo.XWebhookAttempt
+	if hook.Enabled {
+		attempt = s.sendWebhook(ctx, hook, delivery)
+	} else {
+		msg := "подписка отключена"
+		attempt = &repo.XWebhookAttempt{Status: WebhookDeliveryFailed, Error: &msg}
+	}
+	if attempt.Status == WebhookDeliveryRetrying && job.Attempts >= job.MaxAttempts {
+		attempt.Status = WebhookDeliveryFailed
+	}
+
+	disabled, err := s.repo.RecordWebhookAttempt(ctx, job.TenantID, delivery.ID, attempt, s.conf(ctx).cfg.WebhookDisableAfter)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		log.Printf("webhook %s disabled after %d failed deliveries", hook.ID, s.conf(ctx).cfg.WebhookDisableAfter)
+	}
+
+	switch {
+	case attempt.Status == WebhookDeliverySuccess:
+		return nil
+	case disabled || !hook.Enabled:
+		return jobs.Permanent(errors.New(*attempt.Error))
+	default:
+		return errors.New(*attempt.Error)
+	}
+}
+
+// sendWebhook отправляет подписанный запрос получателю. Перенаправления не выполняются: ответ 3xx считается неудачей.
+// Адрес, к которому разрешилось имя получателя, проверяется при подключении, см. egress.Guard.
+func (s *AuthUseCase) sendWebhook(ctx context.Context, hook *repo.XWebhook, delivery *repo.XWebhookDelivery) *repo.XWebhookAttempt {
+	fail := func(started time.Time, status *int, body *string, err string) *repo.XWebhookAttempt {
+		return &repo.XWebhookAttempt{
+			Status:         WebhookDeliveryRetrying,
+			ResponseStatus: status,
+			ResponseBody:   body,
+			Error:          &err,
+			DurationMs:     int(time.Since(started).Milliseconds()),
+		}
+	}
+
+	if s.conf(ctx).cfg.WebhookTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.conf(ctx).cfg.WebhookTimeout)
+		defer cancel()
+	}
+
+	started := time.Now()
+	timestamp := started.Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fail(started, nil, nil, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MedodsAuth-Webhook/1.0")
+	req.Header.Set("Webhook-Id", delivery.EventID)
+	req.Header.Set("Webhook-Delivery", delivery.ID)
+	req.Header.Set("Webhook-Event", delivery.Event)
+	req.Header.Set("Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("Webhook-Signature", SignWebhook(hook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.conf(ctx).webhooks.Do(req)
+	if err != nil {
+		return fail(started, nil, nil, err.Error())
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	body := string(raw)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fail(started, &resp.StatusCode, &body, fmt.Sprintf("HTTP %d", resp.StatusCode))
+	}
+
+	return &repo.XWebhookAttempt{
+		Status:         WebhookDeliverySuccess,
+		ResponseStatus: &resp.StatusCode,
+		ResponseBody:   &body,
+		DurationMs:     int(time.Since(started).Milliseconds()),
+	}
+}
+
+// ----------- Tools -----------
+
+// validateWebhook проверяет адрес и фильтр подписки. Адрес-IP из внутренней сети отклоняется сразу,
+// имя хоста проверяется при каждой доставке.
+//
+// Возвращает:
+//   - фильтр без повторов
+//   - указатель на структуру ZError с кодом 400, если адрес не http(s), указывает во внутреннюю сеть или событие неизвестно
+func validateWebhook(guard *egress.Guard, raw_url string, events []string) ([]string, *core.ZError) {
+	u, err := url.Parse(raw_url)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Адрес подписки должен быть абсолютным http(s) URL",
+			Exception: raw_url,
+		}
+	}
+	if err := guard.CheckHost(u.Hostname()); err != nil {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Адрес подписки указывает во внутреннюю сеть",
+			Exception: err.Error(),
+		}
+	}
+	if len(events) == 0 {
+		return nil, &core.ZError{
+			Code:      400,
+			Where:     "UseCase",
+			Message:   "Не указаны события подписки",
+			Exception: nil,
+		}
+	}
+
+	res := make([]string, 0, len(events))
+	for _, event := range events {
+		if event != WebhookAllEvents && !slices.Contains(webhookEvents, event) {
+			return nil, &core.ZError{
+				Code:      400,
+				Where:     "UseCase",
+				Message:   "Неизвестное событие подписки",
+				Exception: event,
+			}
+		}
+		if !slices.Contains(res, event) {
+			res = append(res, event)
+		}
+	}
+	return res, nil
+}
+
+// newEventID генерирует идентификатор события в формате UUID v4.
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// toZWebhook переводит подписку из слоя репозитория в DTO без ключа подписи.
+func toZWebhook(x *repo.XWebhook) share.ZWebhook {
+	return share.ZWebhook{
+		ID:                  x.ID,
+		URL:                 x.URL,
+		Events:              x.Events,
+		Description:         x.Description,
+		Enabled:             x.Enabled,
+		ConsecutiveFailures: x.ConsecutiveFailures,
+		DisabledAt:          x.DisabledAt,
+		DisabledReason:      x.DisabledReason,
+		CreatedAt:           x.CreatedAt,
+		UpdatedAt:           x.UpdatedAt,
+	}
+}
+
+// toZWebhookDelivery переводит доставку из слоя репозитория в DTO.
+func toZWebhookDelivery(x *repo.XWebhookDelivery) share.ZWebhookDelivery {
+	return share.ZWebhookDelivery{
+		ID:             x.ID,
+		EventID:        x.EventID,
+		Event:          x.Event,
+		Payload:        x.Payload,
+		Status:         x.Status,
+		Attempts:       x.Attempts,
+		ResponseStatus: x.ResponseStatus,
+		ResponseBody:   x.ResponseBody,
+		LastError:      x.LastError,
+		DurationMs:     x.DurationMs,
+		DeliveredAt:    x.DeliveredAt,
+		CreatedAt:      x.CreatedAt,
+		UpdatedAt:      x.UpdatedAt,
+	}
+}